@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/law-makers/viewport-cli/pkg/api"
+	"github.com/law-makers/viewport-cli/pkg/results"
+)
+
+// warnMissingA11yTrees lists the devices the server returned no --a11y tree
+// for, as servers that predate the option ignore it
+func warnMissingA11yTrees(results []api.ViewportResult) {
+	var missing []string
+	for _, result := range results {
+		if result.A11yTree == nil {
+			missing = append(missing, result.Device)
+		}
+	}
+	if len(missing) > 0 {
+		fmt.Printf("⚠️  Warning: No accessibility tree returned for %s (not supported by the server?)\n", strings.Join(missing, ", "))
+	}
+}
+
+// countA11yNodes returns the number of nodes in a saved accessibility tree
+func countA11yNodes(node results.A11yNode) int {
+	n := 1
+	for _, child := range node.Children {
+		n += countA11yNodes(child)
+	}
+	return n
+}
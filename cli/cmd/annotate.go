@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strings"
+
+	"github.com/law-makers/viewport-cli/pkg/api"
+	"github.com/muesli/termenv"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// annotationBorder is the outline width of an issue's box, in pixels
+const annotationBorder = 3
+
+// annotatedFileName names the annotated copy of a screenshot, e.g. mobile.png -> mobile-annotated.png
+func annotatedFileName(name string) string {
+	return strings.TrimSuffix(name, ".png") + "-annotated.png"
+}
+
+// annotateScreenshot draws a box and label for each issue with a bounding box
+// onto a copy of the PNG. Boxes are in captured-page pixels and are multiplied
+// by scale for screenshots that were downscaled on save. ok is false when no
+// issue has a box.
+func annotateScreenshot(data []byte, issues []api.DetectedIssue, scale float64) (annotated []byte, ok bool, err error) {
+	var boxed []api.DetectedIssue
+	for _, issue := range issues {
+		if issue.BoundingBox != nil && issue.BoundingBox.Width > 0 && issue.BoundingBox.Height > 0 {
+			boxed = append(boxed, issue)
+		}
+	}
+	if len(boxed) == 0 {
+		return nil, false, nil
+	}
+
+	src, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to decode screenshot: %w", err)
+	}
+	img := image.NewRGBA(src.Bounds())
+	draw.Draw(img, img.Bounds(), src, src.Bounds().Min, draw.Src)
+
+	face := basicfont.Face7x13
+	for _, issue := range boxed {
+		b := issue.BoundingBox
+		rect := image.Rect(
+			int(float64(b.X)*scale), int(float64(b.Y)*scale),
+			int(float64(b.X+b.Width)*scale), int(float64(b.Y+b.Height)*scale),
+		).Add(img.Bounds().Min).Intersect(img.Bounds())
+		if rect.Empty() {
+			continue
+		}
+		fill := &image.Uniform{severityRGBA(issue.Severity)}
+
+		// Outline
+		for _, edge := range []image.Rectangle{
+			image.Rect(rect.Min.X, rect.Min.Y, rect.Max.X, rect.Min.Y+annotationBorder),
+			image.Rect(rect.Min.X, rect.Max.Y-annotationBorder, rect.Max.X, rect.Max.Y),
+			image.Rect(rect.Min.X, rect.Min.Y, rect.Min.X+annotationBorder, rect.Max.Y),
+			image.Rect(rect.Max.X-annotationBorder, rect.Min.Y, rect.Max.X, rect.Max.Y),
+		} {
+			draw.Draw(img, edge.Intersect(rect), fill, image.Point{}, draw.Src)
+		}
+
+		// Label on a filled tab above the box, or inside it at the top edge
+		label := fmt.Sprintf("[%s] %s", issue.Severity, issue.Type)
+		width := font.MeasureString(face, label).Ceil() + 6
+		height := face.Metrics().Height.Ceil() + 4
+		top := rect.Min.Y - height
+		if top < img.Bounds().Min.Y {
+			top = rect.Min.Y
+		}
+		tab := image.Rect(rect.Min.X, top, rect.Min.X+width, top+height).Intersect(img.Bounds())
+		draw.Draw(img, tab, fill, image.Point{}, draw.Src)
+
+		drawer := &font.Drawer{
+			Dst:  img,
+			Src:  image.White,
+			Face: face,
+			Dot:  fixed.P(tab.Min.X+3, tab.Min.Y+2+face.Metrics().Ascent.Ceil()),
+		}
+		drawer.DrawString(label)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, false, fmt.Errorf("failed to encode annotated screenshot: %w", err)
+	}
+	return buf.Bytes(), true, nil
+}
+
+// severityRGBA converts a severity's configured terminal color to RGBA
+func severityRGBA(severity string) color.RGBA {
+	c, ok := severityColors[strings.ToLower(severity)]
+	if !ok {
+		return color.RGBA{R: 0xd0, G: 0x30, B: 0x30, A: 0xff}
+	}
+
+	var tc termenv.Color
+	if strings.HasPrefix(c, "#") {
+		tc = termenv.RGBColor(c)
+	} else {
+		var n int
+		fmt.Sscanf(c, "%d", &n)
+		tc = termenv.ANSI256Color(n)
+	}
+	r, g, b := termenv.ConvertToRGB(tc).RGB255()
+	return color.RGBA{R: r, G: g, B: b, A: 0xff}
+}
@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/law-makers/viewport-cli/pkg/api"
+	"github.com/law-makers/viewport-cli/pkg/results"
+)
+
+// mergeScan folds a re-scan into a saved scan: re-scanned devices replace
+// their saved entries in place, devices new to the scan are appended, and the
+// devices that weren't re-scanned are kept and returned separately, as their
+// files have to be carried over. The merged scan keeps the saved scan's ID,
+// label and tags and takes everything else from the re-scan.
+func mergeScan(saved, rescan *api.ScanResponse) (merged *api.ScanResponse, kept []api.ViewportResult) {
+	fresh := make(map[string]int, len(rescan.Results))
+	for i, result := range rescan.Results {
+		fresh[result.Device] = i
+	}
+
+	m := *rescan
+	m.ScanID = saved.ScanID
+	m.Label = saved.Label
+	m.Tags = saved.Tags
+	if m.GlobalAnalysis == "" {
+		m.GlobalAnalysis = saved.GlobalAnalysis
+	}
+	m.Results = nil
+
+	replaced := make(map[string]bool, len(rescan.Results))
+	for _, result := range saved.Results {
+		i, ok := fresh[result.Device]
+		if !ok {
+			m.Results = append(m.Results, result)
+			kept = append(kept, result)
+			continue
+		}
+		if !replaced[result.Device] {
+			replaced[result.Device] = true
+			m.Results = append(m.Results, rescan.Results[i])
+		}
+	}
+	for _, result := range rescan.Results {
+		if !replaced[result.Device] {
+			replaced[result.Device] = true
+			m.Results = append(m.Results, result)
+		}
+	}
+
+	m.Status = mergedStatus(m.Results, saved.Status, rescan.Status)
+	return &m, kept
+}
+
+// mergedStatus derives the status of a merged scan from all of its results,
+// so a re-scan that fixed the empty screenshots clears PARTIAL
+func mergedStatus(merged []api.ViewportResult, saved, rescan string) string {
+	empty := emptyScreenshotDevices(&api.ScanResponse{Results: merged})
+	switch {
+	case len(empty) == len(merged):
+		return "EMPTY"
+	case len(empty) > 0:
+		return "PARTIAL"
+	case rescan != "EMPTY" && rescan != "PARTIAL":
+		return rescan
+	case saved != "EMPTY" && saved != "PARTIAL":
+		return saved
+	default:
+		return "complete"
+	}
+}
+
+// resultFiles lists the files saved for a result
+func resultFiles(result api.ViewportResult) []string {
+	var names []string
+	if result.ScreenshotFile != "" {
+		names = append(names, result.ScreenshotFile)
+	}
+	if result.AnnotatedFile != "" {
+		names = append(names, result.AnnotatedFile)
+	}
+	if result.Reference != nil && result.Reference.DiffFile != "" {
+		names = append(names, result.Reference.DiffFile)
+	}
+	for _, shot := range result.ScrollScreenshots {
+		if shot.ScreenshotFile != "" {
+			names = append(names, shot.ScreenshotFile)
+		}
+	}
+	for _, capture := range result.ZoomCaptures {
+		if capture.ScreenshotFile != "" {
+			names = append(names, capture.ScreenshotFile)
+		}
+	}
+	return names
+}
+
+// appendResults merges resp into the saved scan scanID (--append-results)
+// and saves the merged scan in its place, replacing the whole scan directory
+// at once. It returns the merged scan.
+func appendResults(store results.Store, scanID string, resp *api.ScanResponse) (*api.ScanResponse, error) {
+	data, err := store.ReadFile(scanID, results.MetadataFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scan %s: %w", scanID, err)
+	}
+	var saved api.ScanResponse
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("failed to parse metadata of scan %s: %w", scanID, err)
+	}
+
+	// Encoding names the re-scanned screenshots and fills in their file fields
+	fresh, err := encodeScan(resp)
+	if err != nil {
+		return nil, err
+	}
+	merged, kept := mergeScan(&saved, resp)
+
+	files := fresh.Files
+	for _, result := range kept {
+		for _, name := range resultFiles(result) {
+			if _, clash := files[name]; clash {
+				return nil, fmt.Errorf("re-scanned and kept viewports both save %s; include {device} in --screenshot-name", name)
+			}
+			data, err := store.ReadFile(scanID, name)
+			if err != nil {
+				fmt.Printf("⚠️  Warning: %s of %s is missing from scan %s, not carried over\n", name, result.Device, scanID)
+				continue
+			}
+			files[name] = data
+		}
+	}
+
+	metadata, err := json.MarshalIndent(merged, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+	if err := store.SaveScan(&results.ScanFiles{ScanID: merged.ScanID, Metadata: metadata, Files: files}); err != nil {
+		return nil, err
+	}
+
+	devices := make([]string, len(resp.Results))
+	for i, result := range resp.Results {
+		devices[i] = result.Device
+	}
+	fmt.Printf("🔁 Merged %s into scan %s (%d viewports kept)\n", strings.Join(devices, ", "), merged.ScanID, len(kept))
+	return merged, nil
+}
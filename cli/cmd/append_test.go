@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/law-makers/viewport-cli/pkg/api"
+	"github.com/law-makers/viewport-cli/pkg/results"
+)
+
+// screenshotResult is a viewport result whose screenshot is data
+func screenshotResult(device string, data []byte) api.ViewportResult {
+	return api.ViewportResult{Device: device, ScreenshotBase64: base64.StdEncoding.EncodeToString(data)}
+}
+
+func devicesOf(results []api.ViewportResult) []string {
+	devices := make([]string, len(results))
+	for i, result := range results {
+		devices[i] = result.Device
+	}
+	return devices
+}
+
+func TestMergeScan(t *testing.T) {
+	saved := &api.ScanResponse{
+		ScanID: "scan-1",
+		Status: "PARTIAL",
+		Label:  "before fix",
+		Tags:   []string{"nightly"},
+		Results: []api.ViewportResult{
+			screenshotResult("mobile", []byte("old mobile")),
+			{Device: "tablet"},
+			screenshotResult("desktop", []byte("old desktop")),
+		},
+	}
+	rescan := &api.ScanResponse{
+		ScanID: "scan-2",
+		Status: "completed",
+		Results: []api.ViewportResult{
+			screenshotResult("wide", []byte("new wide")),
+			screenshotResult("tablet", []byte("new tablet")),
+		},
+	}
+
+	merged, kept := mergeScan(saved, rescan)
+
+	// Re-scanned devices replace theirs in place, new ones go last
+	if got, want := devicesOf(merged.Results), []string{"mobile", "tablet", "desktop", "wide"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("merged devices = %q, want %q", got, want)
+	}
+	if merged.Results[1].ScreenshotBase64 != rescan.Results[1].ScreenshotBase64 {
+		t.Error("tablet kept its saved, empty result instead of the re-scanned one")
+	}
+	if got, want := devicesOf(kept), []string{"mobile", "desktop"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("kept devices = %q, want %q", got, want)
+	}
+	if merged.ScanID != "scan-1" || merged.Label != "before fix" || !reflect.DeepEqual(merged.Tags, []string{"nightly"}) {
+		t.Errorf("merged scan is %s labelled %q tagged %q, want the saved scan's ID, label and tags", merged.ScanID, merged.Label, merged.Tags)
+	}
+	// The re-scan filled in the empty tablet screenshot
+	if merged.Status != "completed" {
+		t.Errorf("status = %q, want completed", merged.Status)
+	}
+}
+
+func TestMergeScanDropsDuplicateDevices(t *testing.T) {
+	saved := &api.ScanResponse{ScanID: "scan-1", Results: []api.ViewportResult{{Device: "mobile"}, {Device: "mobile"}}}
+	rescan := &api.ScanResponse{Results: []api.ViewportResult{screenshotResult("mobile", []byte("new"))}}
+
+	merged, kept := mergeScan(saved, rescan)
+	if len(merged.Results) != 1 || len(kept) != 0 {
+		t.Errorf("merged %d results and kept %d, want 1 and 0", len(merged.Results), len(kept))
+	}
+}
+
+func TestMergedStatus(t *testing.T) {
+	full := screenshotResult("mobile", []byte("png"))
+	empty := api.ViewportResult{Device: "tablet"}
+	tests := []struct {
+		name          string
+		results       []api.ViewportResult
+		saved, rescan string
+		want          string
+	}{
+		{"all empty", []api.ViewportResult{empty}, "completed", "completed", "EMPTY"},
+		{"some empty", []api.ViewportResult{full, empty}, "completed", "completed", "PARTIAL"},
+		{"fixed by the re-scan", []api.ViewportResult{full}, "PARTIAL", "completed", "completed"},
+		{"re-scan status", []api.ViewportResult{full}, "completed", "degraded", "degraded"},
+		{"saved status", []api.ViewportResult{full}, "degraded", "PARTIAL", "degraded"},
+		{"neither usable", []api.ViewportResult{full}, "EMPTY", "PARTIAL", "complete"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mergedStatus(tt.results, tt.saved, tt.rescan); got != tt.want {
+				t.Errorf("mergedStatus = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// saveTestScan saves scan to store with files
+func saveTestScan(t *testing.T, store results.Store, scan *api.ScanResponse, files map[string][]byte) {
+	t.Helper()
+	metadata, err := json.Marshal(scan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SaveScan(&results.ScanFiles{ScanID: scan.ScanID, Metadata: metadata, Files: files}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAppendResults(t *testing.T) {
+	store := results.NewFSStore(t.TempDir())
+	mobile := screenshotResult("mobile", []byte("old mobile"))
+	mobile.ScreenshotFile = "mobile.png"
+	tablet := screenshotResult("tablet", []byte("old tablet"))
+	tablet.ScreenshotFile = "tablet.png"
+	// desktop's screenshot went missing from the saved scan
+	desktop := screenshotResult("desktop", []byte("old desktop"))
+	desktop.ScreenshotFile = "desktop.png"
+	saveTestScan(t, store, &api.ScanResponse{ScanID: "scan-1", Status: "completed", Results: []api.ViewportResult{mobile, tablet, desktop}},
+		map[string][]byte{"mobile.png": []byte("old mobile"), "tablet.png": []byte("old tablet")})
+
+	rescan := &api.ScanResponse{ScanID: "scan-2", Status: "completed", Results: []api.ViewportResult{
+		screenshotResult("tablet", []byte("new tablet")),
+		screenshotResult("wide", []byte("new wide")),
+	}}
+	merged, err := appendResults(store, "scan-1", rescan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := devicesOf(merged.Results), []string{"mobile", "tablet", "desktop", "wide"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("merged devices = %q, want %q", got, want)
+	}
+
+	files := map[string]string{
+		"mobile.png": "old mobile",
+		"tablet.png": "new tablet",
+		"wide.png":   "new wide",
+	}
+	for name, want := range files {
+		got, err := store.ReadFile("scan-1", name)
+		if err != nil {
+			t.Errorf("%s not saved: %v", name, err)
+			continue
+		}
+		if !bytes.Equal(got, []byte(want)) {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+	if _, err := store.ReadFile("scan-1", "desktop.png"); err == nil {
+		t.Error("desktop.png appeared, but it was missing from the saved scan")
+	}
+
+	data, err := store.ReadFile("scan-1", results.MetadataFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved api.ScanResponse
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+	if saved.ScanID != "scan-1" || !reflect.DeepEqual(devicesOf(saved.Results), devicesOf(merged.Results)) {
+		t.Errorf("metadata has scan %s with %q, want the merged scan", saved.ScanID, devicesOf(saved.Results))
+	}
+	if _, err := store.ReadFile("scan-2", results.MetadataFile); err == nil {
+		t.Error("the re-scan was also saved as a scan of its own")
+	}
+}
+
+func TestAppendResultsNameClash(t *testing.T) {
+	defer func(name string) { screenshotName = name }(screenshotName)
+	screenshotName = "{index}.png"
+
+	store := results.NewFSStore(t.TempDir())
+	mobile := screenshotResult("mobile", []byte("old mobile"))
+	mobile.ScreenshotFile = "01.png"
+	saveTestScan(t, store, &api.ScanResponse{ScanID: "scan-1", Results: []api.ViewportResult{mobile}},
+		map[string][]byte{"01.png": []byte("old mobile")})
+
+	// The re-scanned tablet is also named 01.png
+	rescan := &api.ScanResponse{Results: []api.ViewportResult{screenshotResult("tablet", []byte("new tablet"))}}
+	_, err := appendResults(store, "scan-1", rescan)
+	if err == nil || !strings.Contains(err.Error(), "01.png") {
+		t.Fatalf("appendResults = %v, want an error naming the clashing file", err)
+	}
+	got, err := store.ReadFile("scan-1", "01.png")
+	if err != nil || !bytes.Equal(got, []byte("old mobile")) {
+		t.Errorf("saved scan changed after a failed merge: %q, %v", got, err)
+	}
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/law-makers/viewport-cli/pkg/api"
+	"github.com/law-makers/viewport-cli/pkg/compare"
+)
+
+// checkBaseline compares a finished scan of target with the target's
+// --baseline-auto baseline, failing on issues the baseline doesn't have. A
+// target with no baseline yet, or --update-baseline, makes resp its baseline.
+func (s *scanSession) checkBaseline(target string, resp *api.ScanResponse) error {
+	// Parallel batch workers share the index
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	base, entry, err := s.baselines.Get(target)
+	if err != nil {
+		fmt.Printf("⚠️  Warning: Failed to read baseline, not comparing: %v\n\n", err)
+		return nil
+	}
+	if base == nil {
+		if _, err := s.baselines.Put(target, resp); err != nil {
+			fmt.Printf("⚠️  Warning: Failed to save baseline: %v\n\n", err)
+			return nil
+		}
+		fmt.Printf("📌 No baseline for %s yet; this scan is now its baseline\n\n", target)
+		return nil
+	}
+
+	byDevice := make(map[string]api.ViewportResult, len(base.Results))
+	for _, r := range base.Results {
+		byDevice[r.Device] = r
+	}
+
+	fmt.Printf("📌 Baseline: scan %s from %s\n", entry.ScanID, entry.Promoted.Local().Format("2006-01-02 15:04"))
+	newIssues, fixed := 0, 0
+	for _, result := range resp.Results {
+		baseResult, ok := byDevice[result.Device]
+		if !ok {
+			fmt.Printf("  • %s: not in the baseline, not compared\n", result.Device)
+			continue
+		}
+		onlyBase, onlyScan, _ := compare.DiffIssues(baseResult.Issues, result.Issues)
+		newIssues += len(onlyScan)
+		fixed += len(onlyBase)
+		if len(onlyBase) == 0 && len(onlyScan) == 0 {
+			fmt.Printf("  • %s: no change\n", result.Device)
+			continue
+		}
+		fmt.Printf("  • %s: %d new, %d fixed\n", result.Device, len(onlyScan), len(onlyBase))
+		for _, issue := range onlyScan {
+			fmt.Printf("      new   %s %s: %s\n", renderSeverity(issue.Severity), issue.Type, issue.Description)
+		}
+		for _, issue := range onlyBase {
+			fmt.Printf("      fixed %s %s: %s\n", renderSeverity(issue.Severity), issue.Type, issue.Description)
+		}
+	}
+
+	if updateBaseline {
+		if _, err := s.baselines.Put(target, resp); err != nil {
+			return fmt.Errorf("failed to update baseline: %w", err)
+		}
+		fmt.Printf("📌 Baseline of %s updated to scan %s\n\n", target, resp.ScanID)
+		return nil
+	}
+	if newIssues == 0 {
+		if fixed > 0 {
+			fmt.Printf("ℹ️  %d baseline issues fixed (--update-baseline records that)\n", fixed)
+		}
+		fmt.Println()
+		return nil
+	}
+
+	fmt.Printf("%s\n\n", lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("1")).Render(
+		fmt.Sprintf("❌ %d issues not in the baseline (accept them with --update-baseline)", newIssues)))
+	return withExitCode(exitRegression, fmt.Errorf("%d issues not in the baseline", newIssues))
+}
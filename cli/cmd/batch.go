@@ -0,0 +1,365 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/law-makers/viewport-cli/pkg/api"
+)
+
+// batchResult records the outcome of one target in a batch scan
+type batchResult struct {
+	Target   string
+	ScanID   string
+	Issues   int
+	Duration time.Duration
+	Err      error
+	// Dir is the --output-dir-per-target directory the scan was saved in
+	Dir string
+}
+
+// errCancelledByFailFast is the error of a target --fail-fast cancelled
+// while it was running, after another target failed
+var errCancelledByFailFast = errors.New("cancelled by --fail-fast")
+
+// loadTargetsFile reads target URLs, one per line, ignoring blank lines and # comments
+func loadTargetsFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open targets file: %w", err)
+	}
+	defer f.Close()
+
+	var targets []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		targets = append(targets, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read targets file: %w", err)
+	}
+
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("targets file %s contains no targets", path)
+	}
+	return targets, nil
+}
+
+// runBatch scans every target in turn against the shared screenshot server
+func (s *scanSession) runBatch(ctx context.Context, targets []string) error {
+	if concurrency != 1 && len(targets) > 1 {
+		concurrency = s.serverConcurrency(ctx)
+	}
+	if concurrency > 1 && len(targets) > 1 {
+		return s.runParallelBatch(ctx, targets)
+	}
+
+	startTime := time.Now()
+	batch := make([]batchResult, 0, len(targets))
+
+	for i, target := range targets {
+		if ctx.Err() != nil {
+			break
+		}
+
+		fmt.Printf("%s %s\n",
+			lipgloss.NewStyle().Bold(true).Render(fmt.Sprintf("[%d/%d]", i+1, len(targets))),
+			lipgloss.NewStyle().Foreground(lipgloss.Color("4")).Render(target))
+
+		targetStart := time.Now()
+		resp, err := s.scanTarget(ctx, target)
+		result := batchResult{Target: target, Duration: time.Since(targetStart), Err: err}
+		if resp != nil {
+			result.ScanID = resp.ScanID
+			for _, r := range resp.Results {
+				result.Issues += len(r.Issues)
+			}
+		}
+		if err == nil {
+			result.Dir = s.targetDirs[target]
+		}
+		batch = append(batch, result)
+
+		if s.summary != nil {
+			s.summary.emit(target, resp, err, result.Duration)
+		}
+		if s.jsonl != nil {
+			if err := s.jsonl.emit(result, resp); err != nil {
+				return fmt.Errorf("failed to write JSON Lines output: %w", err)
+			}
+		}
+		if err != nil && failFast {
+			fmt.Printf("\n%s\n", failFastNotice(len(targets)-len(batch)))
+			break
+		}
+	}
+
+	return printBatchSummary(batch, len(targets), time.Since(startTime))
+}
+
+// serverConcurrency resolves --concurrency against the number of pages the
+// screenshot server advertises it captures at once: 0 takes that number, and
+// a higher explicit value is kept with a warning, since the extra targets
+// only queue on the server. Without an advertised capacity, 0 scans one
+// target at a time.
+func (s *scanSession) serverConcurrency(ctx context.Context) int {
+	capacity := 0
+	if client, ok := s.client.(*api.Client); ok {
+		if info, err := client.Info(ctx); err == nil {
+			capacity = info.MaxConcurrentCaptures
+		}
+	}
+
+	switch {
+	case concurrency == 0 && capacity > 0:
+		if verbose {
+			fmt.Printf("ℹ️  The screenshot server captures %d pages at once, scanning as many targets at a time\n", capacity)
+		}
+		return min(capacity, maxConcurrency)
+	case concurrency == 0:
+		fmt.Println("ℹ️  The screenshot server doesn't say how many pages it captures at once, scanning one target at a time")
+		return 1
+	case capacity > 0 && concurrency > capacity:
+		fmt.Printf("⚠️  Warning: --concurrency %d is more than the %d pages the screenshot server captures at once; the extra targets will queue on the server\n\n", concurrency, capacity)
+	}
+	return concurrency
+}
+
+// runParallelBatch scans up to --concurrency targets at once. Each target's
+// own output is dropped (or sent to stderr with --verbose) since it would
+// interleave; instead a live table shows every target's state on a
+// terminal, or a line is logged per finished target. Workers only send
+// updates; this goroutine alone records results and writes JSON Lines and
+// summary output.
+func (s *scanSession) runParallelBatch(ctx context.Context, targets []string) error {
+	startTime := time.Now()
+
+	// With --fail-fast, the first failure cancels every other target's scan
+	ctx, stop := context.WithCancel(ctx)
+	defer stop()
+	stopped := false
+
+	out := os.Stdout
+	live := isTerminal(out) && !verbose && !ciMode && !runningInCI()
+	if verbose {
+		os.Stdout = os.Stderr
+	} else {
+		devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", os.DevNull, err)
+		}
+		defer devNull.Close()
+		os.Stdout = devNull
+	}
+	defer func() { os.Stdout = out }()
+
+	var progress batchProgress = &lineProgress{w: out, total: len(targets)}
+	if live {
+		progress = newLiveProgress(out, targets)
+	} else {
+		fmt.Fprintf(out, "📦 Scanning %d targets, %d at a time\n", len(targets), concurrency)
+	}
+
+	jobs := make(chan int)
+	updates := make(chan batchUpdate)
+	go func() {
+		defer close(jobs)
+		for i := range targets {
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for w := 0; w < min(concurrency, len(targets)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if ctx.Err() != nil {
+					return
+				}
+				targetStart := time.Now()
+				updates <- batchUpdate{Index: i, State: batchRunning, Started: targetStart}
+
+				resp, err := s.scanTarget(ctx, targets[i])
+				result := batchResult{Target: targets[i], Duration: time.Since(targetStart), Err: err}
+				state := batchDone
+				if err != nil {
+					state = batchFailed
+				}
+				if resp != nil {
+					result.ScanID = resp.ScanID
+					result.Issues = totalIssues(resp.Results)
+				}
+				if err == nil {
+					result.Dir = s.targetDirs[targets[i]]
+				}
+				updates <- batchUpdate{Index: i, State: state, Result: result, Resp: resp}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(updates)
+	}()
+
+	finished := make([]*batchResult, len(targets))
+	var writeErr error
+	for u := range updates {
+		if stopped && u.State == batchFailed && errors.Is(u.Result.Err, context.Canceled) {
+			// Not a failure of its own, so it counts as skipped
+			u.Result.Err = errCancelledByFailFast
+			progress.update(u)
+			continue
+		}
+		progress.update(u)
+		if u.State != batchDone && u.State != batchFailed {
+			continue
+		}
+		result := u.Result
+		finished[u.Index] = &result
+		if result.Err != nil && failFast && !stopped {
+			stopped = true
+			stop()
+		}
+
+		if s.summary != nil {
+			s.summary.emit(result.Target, u.Resp, result.Err, result.Duration)
+		}
+		if s.jsonl != nil && writeErr == nil {
+			writeErr = s.jsonl.emit(result, u.Resp)
+		}
+	}
+	progress.finish()
+	os.Stdout = out
+
+	if writeErr != nil {
+		return fmt.Errorf("failed to write JSON Lines output: %w", writeErr)
+	}
+
+	// Report in target order, whatever order the scans finished in
+	batch := make([]batchResult, 0, len(targets))
+	for _, result := range finished {
+		if result != nil {
+			batch = append(batch, *result)
+		}
+	}
+	fmt.Println()
+	if stopped {
+		skipped := len(targets) - len(batch)
+		fmt.Printf("%s\n\n", failFastNotice(skipped))
+	}
+	if live {
+		// The table already lists every target
+		fmt.Printf("%s\n", lipgloss.NewStyle().Bold(true).Render("📦 Batch Summary"))
+		fmt.Printf("Duration: %.2fs\n\n", time.Since(startTime).Seconds())
+		printTargetDirs(batch)
+		return printBatchTotals(batch, len(targets))
+	}
+	return printBatchSummary(batch, len(targets), time.Since(startTime))
+}
+
+// failFastNotice says that --fail-fast stopped the batch with skipped targets left
+func failFastNotice(skipped int) string {
+	return lipgloss.NewStyle().Foreground(lipgloss.Color("1")).Render(
+		fmt.Sprintf("⛔ Stopped at the first failed target (--fail-fast), %d skipped", skipped))
+}
+
+// printBatchSummary displays the per-target outcomes and returns an error if any target failed
+func printBatchSummary(batch []batchResult, total int, elapsed time.Duration) error {
+	fmt.Printf("%s\n", lipgloss.NewStyle().Bold(true).Render("📦 Batch Summary"))
+	fmt.Printf("Duration: %.2fs\n\n", elapsed.Seconds())
+
+	for _, r := range batch {
+		status := lipgloss.NewStyle().Foreground(lipgloss.Color("2")).Render("✅")
+		detail := fmt.Sprintf("%d issues", r.Issues)
+		if r.Err != nil {
+			status = lipgloss.NewStyle().Foreground(lipgloss.Color("1")).Render("❌")
+			detail = r.Err.Error()
+		}
+		fmt.Printf("  %s %s %s%s\n", status, r.Target,
+			lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render("("+detail+")"), targetDirSuffix(r))
+	}
+	fmt.Println()
+	return printBatchTotals(batch, total)
+}
+
+// targetDirSuffix points at the --output-dir-per-target directory a target
+// was saved in, e.g. " → viewport-results/example.com_about/"
+func targetDirSuffix(r batchResult) string {
+	if r.Dir == "" {
+		return ""
+	}
+	return " → " + filepath.Join(output, r.Dir) + string(filepath.Separator)
+}
+
+// printTargetDirs lists where each target was saved with
+// --output-dir-per-target, for the live table that doesn't show it
+func printTargetDirs(batch []batchResult) {
+	shown := false
+	for _, r := range batch {
+		if r.Dir != "" {
+			fmt.Printf("  📁 %s%s\n", r.Target, targetDirSuffix(r))
+			shown = true
+		}
+	}
+	if shown {
+		fmt.Println()
+	}
+}
+
+// printBatchTotals prints how many targets were scanned, failed and skipped,
+// and returns an error if any target failed or was skipped
+func printBatchTotals(batch []batchResult, total int) error {
+	failed := 0
+	for _, r := range batch {
+		if r.Err != nil {
+			failed++
+		}
+	}
+
+	skipped := total - len(batch)
+	fmt.Printf("Scanned: %d | Failed: %d", len(batch), failed)
+	if skipped > 0 {
+		fmt.Printf(" | Skipped: %d", skipped)
+	}
+	fmt.Printf("\n\n")
+
+	if failed > 0 || skipped > 0 {
+		// Report a gate's own code (--fail-on-empty-viewport, --baseline-auto,
+		// --max-duration) when it is the only reason for failing
+		code := 0
+		for _, r := range batch {
+			if r.Err == nil {
+				continue
+			}
+			if rc := exitCodeFor(r.Err); code == 0 && (rc == exitEmptyViewport || rc == exitRegression || rc == exitTooSlow) {
+				code = rc
+			} else if rc != code {
+				code = exitScanFailed
+			}
+		}
+		// Targets --fail-fast skipped don't hide why it stopped
+		if skipped > 0 && (!failFast || failed == 0) {
+			code = exitScanFailed
+		}
+		return withExitCode(code, fmt.Errorf("batch scan failed: %d of %d targets did not complete", failed+skipped, total))
+	}
+	return nil
+}
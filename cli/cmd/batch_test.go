@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/law-makers/viewport-cli/pkg/api"
+	"github.com/law-makers/viewport-cli/pkg/results"
+)
+
+// batchScanner fails targets ending in /fail, holds those ending in /slow
+// until their scan is cancelled, and completes the rest. It records the
+// targets it was asked to scan.
+type batchScanner struct {
+	mu        sync.Mutex
+	started   []string
+	cancelled []string
+}
+
+func (b *batchScanner) Scan(ctx context.Context, req *api.ScanRequest) (*api.ScanResponse, error) {
+	b.mu.Lock()
+	b.started = append(b.started, req.TargetURL)
+	b.mu.Unlock()
+
+	switch {
+	case strings.HasSuffix(req.TargetURL, "/fail"):
+		return nil, errors.New("page crashed the browser")
+	case strings.HasSuffix(req.TargetURL, "/slow"):
+		select {
+		case <-ctx.Done():
+			b.mu.Lock()
+			b.cancelled = append(b.cancelled, req.TargetURL)
+			b.mu.Unlock()
+			return nil, ctx.Err()
+		case <-time.After(10 * time.Second):
+		}
+	}
+	id := req.TargetURL[strings.LastIndex(req.TargetURL, "/")+1:]
+	return &api.ScanResponse{ScanID: "scan-" + id, Status: "completed", Results: []api.ViewportResult{
+		{Device: "mobile", ScreenshotBase64: "iVBORw0KGgo="},
+	}}, nil
+}
+
+// newBatchSession returns a session scanning with scanner, and the base URL
+// of a target server answering every path
+func newBatchSession(t *testing.T, scanner api.Scanner) (*scanSession, string) {
+	t.Helper()
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	t.Cleanup(target.Close)
+	return &scanSession{client: scanner, store: results.NewFSStore(t.TempDir()), transport: &http.Transport{}}, target.URL
+}
+
+// setBatchFlags sets --fail-fast and --concurrency for a test
+func setBatchFlags(t *testing.T, fast bool, workers int) {
+	t.Helper()
+	oldFast, oldWorkers := failFast, concurrency
+	t.Cleanup(func() { failFast, concurrency = oldFast, oldWorkers })
+	failFast, concurrency = fast, workers
+}
+
+func TestFailFastSkipsQueuedTargets(t *testing.T) {
+	setBatchFlags(t, true, 1)
+	scanner := &batchScanner{}
+	s, base := newBatchSession(t, scanner)
+	targets := []string{base + "/first", base + "/fail", base + "/queued1", base + "/queued2"}
+
+	err := s.runBatch(context.Background(), targets)
+	if code := exitCodeFor(err); code != exitScanFailed {
+		t.Errorf("batch = %v (exit %d), want exit %d", err, code, exitScanFailed)
+	}
+	if err == nil || !strings.Contains(err.Error(), "3 of 4 targets did not complete") {
+		t.Errorf("batch error = %v, want the failed and skipped targets counted", err)
+	}
+	if want := targets[:2]; strings.Join(scanner.started, " ") != strings.Join(want, " ") {
+		t.Errorf("scanned %q, want only %q", scanner.started, want)
+	}
+}
+
+func TestWithoutFailFastScansEveryTarget(t *testing.T) {
+	setBatchFlags(t, false, 1)
+	scanner := &batchScanner{}
+	s, base := newBatchSession(t, scanner)
+	targets := []string{base + "/fail", base + "/second", base + "/third"}
+
+	err := s.runBatch(context.Background(), targets)
+	if err == nil || !strings.Contains(err.Error(), "1 of 3 targets did not complete") {
+		t.Errorf("batch error = %v, want only the failed target counted", err)
+	}
+	if len(scanner.started) != len(targets) {
+		t.Errorf("scanned %q, want every target", scanner.started)
+	}
+}
+
+func TestFailFastCancelsParallelTargets(t *testing.T) {
+	setBatchFlags(t, true, 2)
+	scanner := &batchScanner{}
+	s, base := newBatchSession(t, scanner)
+	targets := []string{base + "/slow", base + "/fail"}
+	for i := 0; i < 6; i++ {
+		targets = append(targets, base+"/slow")
+	}
+
+	start := time.Now()
+	err := s.runBatch(context.Background(), targets)
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("batch took %s, so the in-flight scan wasn't cancelled", elapsed)
+	}
+	if code := exitCodeFor(err); code != exitScanFailed {
+		t.Errorf("batch = %v (exit %d), want exit %d", err, code, exitScanFailed)
+	}
+	if err == nil || !strings.Contains(err.Error(), "8 of 8 targets did not complete") {
+		t.Errorf("batch error = %v, want the failure and every cancelled or queued target counted", err)
+	}
+
+	scanner.mu.Lock()
+	defer scanner.mu.Unlock()
+	if len(scanner.cancelled) == 0 || len(scanner.cancelled) != len(scanner.started)-1 {
+		t.Errorf("%d scans started and %d were cancelled, want all but the failed one cancelled", len(scanner.started), len(scanner.cancelled))
+	}
+	// A worker may take one more target before it sees the cancellation
+	if len(scanner.started) > 3 {
+		t.Errorf("%d of %d targets started, want the queued ones skipped", len(scanner.started), len(targets))
+	}
+}
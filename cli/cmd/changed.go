@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/law-makers/viewport-cli/pkg/git"
+)
+
+// routeRule maps changed files matching a glob to the URLs they affect
+type routeRule struct {
+	glob    string
+	pattern *regexp.Regexp
+	urls    []string
+}
+
+// loadRouteMap reads a route map: one rule per line, a file glob followed by
+// the URLs to scan when a matching file changes. Globs are relative to the
+// repository root; "*" stays within a path segment and "**" spans segments.
+// URLs starting with "/" are resolved against base.
+func loadRouteMap(path, base string) ([]routeRule, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open route map: %w", err)
+	}
+	defer f.Close()
+
+	var rules []routeRule
+	scanner := bufio.NewScanner(f)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("%s:%d: expected a file glob followed by one or more URLs", path, lineNum)
+		}
+
+		rule := routeRule{glob: fields[0], pattern: globPattern(fields[0])}
+		for _, u := range fields[1:] {
+			if strings.HasPrefix(u, "/") {
+				u = strings.TrimSuffix(base, "/") + u
+			}
+			rule.urls = append(rule.urls, u)
+		}
+		rules = append(rules, rule)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read route map: %w", err)
+	}
+
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("route map %s contains no rules", path)
+	}
+	return rules, nil
+}
+
+// globPattern compiles a file glob into an anchored regular expression
+func globPattern(glob string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; {
+		case c == '*' && i+1 < len(glob) && glob[i+1] == '*':
+			i++
+			if i+1 < len(glob) && glob[i+1] == '/' {
+				// "**/" also matches no directories at all
+				i++
+				b.WriteString("(.*/)?")
+			} else {
+				b.WriteString(".*")
+			}
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+// changedTargets returns the URLs mapped from files changed since baseRef,
+// in route map order without duplicates. If files changed but no rule
+// matches, every URL in the map is returned so nothing goes unscanned.
+func changedTargets(routeMapPath, baseRef, base string) ([]string, error) {
+	rules, err := loadRouteMap(routeMapPath, base)
+	if err != nil {
+		return nil, err
+	}
+
+	changed, err := git.ChangedFiles(baseRef)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list changed files: %w", err)
+	}
+	if len(changed) == 0 {
+		return nil, nil
+	}
+
+	var targets []string
+	seen := make(map[string]bool)
+	add := func(urls []string) {
+		for _, u := range urls {
+			if !seen[u] {
+				seen[u] = true
+				targets = append(targets, u)
+			}
+		}
+	}
+
+	for _, rule := range rules {
+		for _, file := range changed {
+			if rule.pattern.MatchString(file) {
+				add(rule.urls)
+				break
+			}
+		}
+	}
+
+	if len(targets) == 0 {
+		fmt.Printf("⚠️  Warning: None of the %d changed files match %s, scanning every mapped URL\n", len(changed), routeMapPath)
+		for _, rule := range rules {
+			add(rule.urls)
+		}
+	} else if verbose {
+		fmt.Printf("ℹ️  %d changed files since %s map to %d URLs\n", len(changed), baseRef, len(targets))
+	}
+	return targets, nil
+}
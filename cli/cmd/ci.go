@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+	"github.com/spf13/cobra"
+)
+
+// ciEnvVars are set by common CI providers
+var ciEnvVars = []string{"CI", "GITHUB_ACTIONS", "GITLAB_CI", "CIRCLECI", "BUILDKITE", "TF_BUILD", "JENKINS_URL"}
+
+// runningInCI reports whether the environment looks like a CI job
+func runningInCI() bool {
+	for _, name := range ciEnvVars {
+		value := strings.ToLower(os.Getenv(name))
+		if value != "" && value != "false" && value != "0" {
+			return true
+		}
+	}
+	return false
+}
+
+// applyCIDefaults turns on the --ci settings for every flag not given
+// explicitly: --no-display, --no-color and --output-format jsonl (unless
+// comparing or printing --summary-only). Interactive mode is skipped in CI with or without --ci.
+func applyCIDefaults(cmd *cobra.Command) {
+	if !ciMode {
+		return
+	}
+
+	if !cmd.Flags().Changed("no-display") {
+		noDisplay = true
+	}
+	if !cmd.Flags().Changed("no-color") {
+		noColor = true
+		lipgloss.SetColorProfile(termenv.Ascii)
+	}
+	if !cmd.Flags().Changed("output-format") && compareToURL == "" && !summaryOnly {
+		outputFormat = "jsonl"
+	}
+}
@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestCIDefaultsKeepServerAutoStart(t *testing.T) {
+	defer func(ci, display, color bool, format string) {
+		ciMode, noDisplay, noColor, outputFormat = ci, display, color, format
+	}(ciMode, noDisplay, noColor, outputFormat)
+	defer lipgloss.SetColorProfile(lipgloss.ColorProfile())
+
+	ciMode = true
+	applyCIDefaults(scanCmd)
+	if !noDisplay {
+		t.Error("--ci left the results display on")
+	}
+	if !autoStartServer() {
+		t.Error("--ci turned off auto-starting the screenshot server")
+	}
+
+	defer func(prev bool) { noAutoStart = prev }(noAutoStart)
+	noAutoStart = true
+	if autoStartServer() {
+		t.Error("the server is auto-started with --no-auto-start")
+	}
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"runtime/debug"
+	"sync"
+)
+
+// cleanups holds the teardown of child processes (screenshot server, tunnel)
+// that must run however the CLI exits: normally, on a panic, or on a second
+// interrupt while the first is still being handled
+var cleanups struct {
+	mu    sync.Mutex
+	next  int
+	funcs map[int]func()
+	order []int
+}
+
+// registerCleanup adds fn to the cleanups run before an abnormal exit and
+// returns a function that removes it again, once the caller has cleaned up
+// itself. fn may run concurrently with the caller, so it must be safe to call
+// twice (like server.Manager.Stop).
+func registerCleanup(fn func()) (unregister func()) {
+	cleanups.mu.Lock()
+	defer cleanups.mu.Unlock()
+	if cleanups.funcs == nil {
+		cleanups.funcs = make(map[int]func())
+	}
+	id := cleanups.next
+	cleanups.next++
+	cleanups.funcs[id] = fn
+	cleanups.order = append(cleanups.order, id)
+
+	return func() {
+		cleanups.mu.Lock()
+		defer cleanups.mu.Unlock()
+		delete(cleanups.funcs, id)
+	}
+}
+
+// runCleanups runs every registered cleanup, newest first, and clears the
+// registry. A panicking cleanup doesn't stop the others.
+func runCleanups() {
+	cleanups.mu.Lock()
+	var pending []func()
+	for i := len(cleanups.order) - 1; i >= 0; i-- {
+		if fn, ok := cleanups.funcs[cleanups.order[i]]; ok {
+			pending = append(pending, fn)
+		}
+	}
+	cleanups.funcs = nil
+	cleanups.order = nil
+	cleanups.mu.Unlock()
+
+	for _, fn := range pending {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					fmt.Fprintf(os.Stderr, "⚠️  Cleanup failed: %v\n", r)
+				}
+			}()
+			fn()
+		}()
+	}
+}
+
+// exitOnPanic is deferred by Execute: it stops child processes before a
+// panic takes the process down, then reports the panic and exits
+func exitOnPanic() {
+	r := recover()
+	if r == nil {
+		return
+	}
+	runCleanups()
+	fmt.Fprintf(os.Stderr, "panic: %v\n\n%s", r, debug.Stack())
+	os.Exit(exitError)
+}
@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRunCleanups(t *testing.T) {
+	var ran []string
+	registerCleanup(func() { ran = append(ran, "server") })
+	unregister := registerCleanup(func() { ran = append(ran, "done already") })
+	registerCleanup(func() { panic("broken cleanup") })
+	registerCleanup(func() { ran = append(ran, "tunnel") })
+	unregister()
+
+	runCleanups()
+	// Newest first, skipping the unregistered one and surviving the panic
+	if want := []string{"tunnel", "server"}; !reflect.DeepEqual(ran, want) {
+		t.Errorf("cleanups ran %q, want %q", ran, want)
+	}
+
+	runCleanups()
+	if len(ran) != 2 {
+		t.Errorf("cleanups ran again after the registry was cleared: %q", ran)
+	}
+}
@@ -0,0 +1,121 @@
+//go:build !windows
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/law-makers/viewport-cli/pkg/server"
+)
+
+// testRoleEnv makes the test binary act as a helper process instead of
+// running the tests: "server" is a fake viewport-server, "panic" a scan that
+// starts one and then panics
+const testRoleEnv = "VIEWPORT_TEST_ROLE"
+
+func TestMain(m *testing.M) {
+	switch os.Getenv(testRoleEnv) {
+	case "server":
+		runFakeServer()
+		return
+	case "panic":
+		runPanickingScan()
+		return
+	}
+	os.Exit(m.Run())
+}
+
+// runFakeServer answers health checks on --port until it is killed, after
+// writing its PID to VIEWPORT_TEST_PIDFILE
+func runFakeServer() {
+	port := os.Args[len(os.Args)-1]
+	if err := os.WriteFile(os.Getenv("VIEWPORT_TEST_PIDFILE"), []byte(strconv.Itoa(os.Getpid())), 0644); err != nil {
+		os.Exit(3)
+	}
+	http.ListenAndServe("127.0.0.1:"+port, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status":"ok"}`)
+	}))
+	os.Exit(4)
+}
+
+// runPanickingScan starts the screenshot server and registers its teardown
+// the way runScan does, then panics with exitOnPanic deferred as in Execute
+func runPanickingScan() {
+	defer exitOnPanic()
+	port, _ := strconv.Atoi(os.Getenv("VIEWPORT_TEST_PORT"))
+	// The server inherits this process's environment
+	os.Setenv(testRoleEnv, "server")
+	manager := server.NewManager(port)
+	if err := manager.Start(context.Background(), false); err != nil {
+		fmt.Fprintln(os.Stderr, "start:", err)
+		os.Exit(5)
+	}
+	defer manager.Stop()
+	defer registerCleanup(func() { manager.Stop() })()
+	panic("injected panic")
+}
+
+func TestExitOnPanicStopsServer(t *testing.T) {
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The fake viewport-server is this test binary, found on PATH
+	bin := t.TempDir()
+	if err := os.Symlink(exe, filepath.Join(bin, "viewport-server")); err != nil {
+		t.Fatal(err)
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+	pidFile := filepath.Join(t.TempDir(), "server.pid")
+
+	cmd := exec.Command(exe)
+	cmd.Env = append(os.Environ(),
+		testRoleEnv+"=panic",
+		"VIEWPORT_TEST_PORT="+strconv.Itoa(port),
+		"VIEWPORT_TEST_PIDFILE="+pidFile,
+		"PATH="+bin+string(os.PathListSeparator)+os.Getenv("PATH"),
+	)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != exitError {
+		t.Fatalf("panicking scan exited with %v, want exit code %d\n%s", err, exitError, stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "panic: injected panic") {
+		t.Errorf("stderr doesn't report the panic:\n%s", stderr.String())
+	}
+
+	data, err := os.ReadFile(pidFile)
+	if err != nil {
+		t.Fatalf("fake server never started: %v", err)
+	}
+	pid, _ := strconv.Atoi(string(data))
+	// Stop waits for the server, so it is gone, not a zombie, by the time the CLI exits
+	if err := syscall.Kill(pid, 0); !errors.Is(err, syscall.ESRCH) {
+		syscall.Kill(pid, syscall.SIGKILL)
+		t.Fatalf("server PID %d still exists after the panic (kill: %v)", pid, err)
+	}
+	if conn, err := net.DialTimeout("tcp", l.Addr().String(), time.Second); err == nil {
+		conn.Close()
+		t.Error("server port still accepts connections after the panic")
+	}
+}
@@ -0,0 +1,230 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/law-makers/viewport-cli/pkg/api"
+	"github.com/law-makers/viewport-cli/pkg/compare"
+)
+
+// comparisonReport is saved as comparison.json alongside the pixel diffs
+type comparisonReport struct {
+	Timestamp        string             `json:"timestamp"`
+	PrimaryURL       string             `json:"primaryUrl"`
+	PrimaryScanID    string             `json:"primaryScanId"`
+	ComparisonURL    string             `json:"comparisonUrl"`
+	ComparisonScanID string             `json:"comparisonScanId"`
+	Devices          []deviceComparison `json:"devices"`
+	// OnlyPrimaryDevices and OnlyComparisonDevices were scanned on one side only
+	OnlyPrimaryDevices    []string `json:"onlyPrimaryDevices,omitempty"`
+	OnlyComparisonDevices []string `json:"onlyComparisonDevices,omitempty"`
+}
+
+// deviceComparison holds the differences found for a single viewport
+type deviceComparison struct {
+	Device               string              `json:"device"`
+	PrimaryDimensions    api.Dimensions      `json:"primaryDimensions"`
+	ComparisonDimensions api.Dimensions      `json:"comparisonDimensions"`
+	OnlyPrimary          []api.DetectedIssue `json:"onlyPrimary"`
+	OnlyComparison       []api.DetectedIssue `json:"onlyComparison"`
+	Common               []api.DetectedIssue `json:"common"`
+	PixelDiffPercent     *float64            `json:"pixelDiffPercent,omitempty"`
+	DiffImage            string              `json:"diffImage,omitempty"`
+	// StyleChanges are the --capture-style values that differ between A and B
+	StyleChanges []compare.StyleChange `json:"styleChanges,omitempty"`
+	// A11yChanges are the --a11y tree nodes added, removed or moved between A and B
+	A11yChanges []compare.A11yChange `json:"a11yChanges,omitempty"`
+
+	diffPNG []byte
+}
+
+// runComparison scans the primary and comparison URLs with identical settings and reports the differences
+func (s *scanSession) runComparison(ctx context.Context, primary, comparison string) error {
+	fmt.Printf("%s %s\n", lipgloss.NewStyle().Bold(true).Render("[A]"), lipgloss.NewStyle().Foreground(lipgloss.Color("4")).Render(primary))
+	respA, err := s.scanTarget(ctx, primary)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s %s\n", lipgloss.NewStyle().Bold(true).Render("[B]"), lipgloss.NewStyle().Foreground(lipgloss.Color("4")).Render(comparison))
+	respB, err := s.scanTarget(ctx, comparison)
+	if err != nil {
+		return err
+	}
+
+	report := buildComparison(respA, respB, pixelDiff)
+	report.PrimaryURL = primary
+	report.ComparisonURL = comparison
+	printComparison(report)
+
+	if noSave {
+		return nil
+	}
+
+	dir, err := saveComparison(report, output)
+	if err != nil {
+		fmt.Printf("⚠️  Warning: Failed to save comparison: %v\n\n", err)
+		return nil
+	}
+	fmt.Printf("💾 Comparison saved to %s/\n\n", dir)
+	return nil
+}
+
+// buildComparison pairs results by device and diffs their issues and, optionally, pixels
+func buildComparison(a, b *api.ScanResponse, withPixels bool) *comparisonReport {
+	report := &comparisonReport{
+		Timestamp:        time.Now().Format(time.RFC3339),
+		PrimaryScanID:    a.ScanID,
+		ComparisonScanID: b.ScanID,
+	}
+
+	byDevice := make(map[string]api.ViewportResult, len(b.Results))
+	for _, r := range b.Results {
+		byDevice[r.Device] = r
+	}
+
+	inA := make(map[string]bool, len(a.Results))
+	for _, ra := range a.Results {
+		inA[ra.Device] = true
+		rb, ok := byDevice[ra.Device]
+		if !ok {
+			report.OnlyPrimaryDevices = append(report.OnlyPrimaryDevices, ra.Device)
+			continue
+		}
+
+		dc := deviceComparison{Device: ra.Device, PrimaryDimensions: ra.Dimensions, ComparisonDimensions: rb.Dimensions}
+		dc.OnlyPrimary, dc.OnlyComparison, dc.Common = compare.DiffIssues(ra.Issues, rb.Issues)
+		dc.StyleChanges = compare.DiffStyles(ra.Styles, rb.Styles)
+		dc.A11yChanges = compare.DiffA11yTree(ra.A11yTree, rb.A11yTree)
+
+		if withPixels {
+			if percent, diffPNG, err := pixelDiffScreenshots(ra.ScreenshotBase64, rb.ScreenshotBase64); err != nil {
+				fmt.Printf("⚠️  Warning: Pixel diff failed for %s: %v\n", ra.Device, err)
+			} else {
+				dc.PixelDiffPercent = &percent
+				dc.diffPNG = diffPNG
+			}
+		}
+
+		report.Devices = append(report.Devices, dc)
+	}
+	for _, rb := range b.Results {
+		if !inA[rb.Device] {
+			report.OnlyComparisonDevices = append(report.OnlyComparisonDevices, rb.Device)
+		}
+	}
+
+	return report
+}
+
+// pixelDiffScreenshots diffs two base64 PNG screenshots, returning the changed percentage and a diff PNG
+func pixelDiffScreenshots(a, b string) (float64, []byte, error) {
+	dataA, err := base64.StdEncoding.DecodeString(a)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to decode screenshot: %w", err)
+	}
+	dataB, err := base64.StdEncoding.DecodeString(b)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to decode screenshot: %w", err)
+	}
+
+	percent, diff, err := compare.PixelDiff(dataA, dataB)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, diff); err != nil {
+		return 0, nil, fmt.Errorf("failed to encode diff image: %w", err)
+	}
+	return percent, buf.Bytes(), nil
+}
+
+// printComparison renders the side-by-side issue diff
+func printComparison(report *comparisonReport) {
+	fmt.Printf("%s\n", lipgloss.NewStyle().Bold(true).Render("🔀 Comparison"))
+	fmt.Printf("A: %s\n", report.PrimaryURL)
+	fmt.Printf("B: %s\n\n", report.ComparisonURL)
+
+	fmt.Println("┌──────────┬────────┬────────┬────────┬────────────┐")
+	fmt.Println("│ Device   │ Only A │ Only B │ Common │ Pixel Diff │")
+	fmt.Println("├──────────┼────────┼────────┼────────┼────────────┤")
+	for _, dc := range report.Devices {
+		pixels := "-"
+		if dc.PixelDiffPercent != nil {
+			pixels = fmt.Sprintf("%.2f%%", *dc.PixelDiffPercent)
+		}
+		fmt.Printf("│ %-8s │ %6d │ %6d │ %6d │ %10s │\n",
+			dc.Device, len(dc.OnlyPrimary), len(dc.OnlyComparison), len(dc.Common), pixels)
+	}
+	fmt.Println("└──────────┴────────┴────────┴────────┴────────────┘")
+	if len(report.OnlyPrimaryDevices) > 0 {
+		fmt.Printf("Only in A, not compared: %s\n", strings.Join(report.OnlyPrimaryDevices, ", "))
+	}
+	if len(report.OnlyComparisonDevices) > 0 {
+		fmt.Printf("Only in B, not compared: %s\n", strings.Join(report.OnlyComparisonDevices, ", "))
+	}
+
+	for _, dc := range report.Devices {
+		if len(dc.OnlyPrimary) == 0 && len(dc.OnlyComparison) == 0 && len(dc.StyleChanges) == 0 && len(dc.A11yChanges) == 0 {
+			continue
+		}
+		fmt.Printf("\n%s\n", lipgloss.NewStyle().Foreground(lipgloss.Color("4")).Render(dc.Device))
+		for _, issue := range dc.OnlyPrimary {
+			fmt.Printf("  A only %s %s: %s\n", renderSeverity(issue.Severity), issue.Type, issue.Description)
+		}
+		for _, issue := range dc.OnlyComparison {
+			fmt.Printf("  B only %s %s: %s\n", renderSeverity(issue.Severity), issue.Type, issue.Description)
+		}
+		for _, change := range dc.StyleChanges {
+			if change.Property == "" {
+				fmt.Printf("  🎨 %s: %s → %s\n", change.Selector, change.Before, change.After)
+				continue
+			}
+			fmt.Printf("  🎨 %s %s: %s → %s\n", change.Selector, change.Property, change.Before, change.After)
+		}
+		for _, change := range dc.A11yChanges {
+			fmt.Printf("  ♿ %-7s %s\n", change.Kind, change)
+		}
+	}
+	fmt.Println()
+}
+
+// saveComparison writes comparison.json and any pixel diff images, returning the directory used
+func saveComparison(report *comparisonReport, outputDir string) (string, error) {
+	dir := filepath.Join(outputDir, fmt.Sprintf("compare-%s-vs-%s", report.PrimaryScanID, report.ComparisonScanID))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	for i := range report.Devices {
+		dc := &report.Devices[i]
+		if dc.diffPNG == nil {
+			continue
+		}
+		dc.DiffImage = fmt.Sprintf("diff-%s.png", dc.Device)
+		if err := os.WriteFile(filepath.Join(dir, dc.DiffImage), dc.diffPNG, 0644); err != nil {
+			return "", fmt.Errorf("failed to write diff image: %w", err)
+		}
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal comparison: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "comparison.json"), data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write comparison: %w", err)
+	}
+
+	return dir, nil
+}
@@ -1,14 +1,13 @@
 package cmd
 
 import (
-	"bufio"
 	"fmt"
 	"os"
-	"strconv"
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/law-makers/viewport-cli/pkg/config"
+	"github.com/law-makers/viewport-cli/pkg/prompt"
 	"github.com/law-makers/viewport-cli/pkg/results"
 	"github.com/spf13/cobra"
 )
@@ -24,8 +23,8 @@ var configInitCmd = &cobra.Command{
 	Short: "Initialize .viewport.yaml configuration file",
 	Long: `Create a new .viewport.yaml configuration file with default settings.
 	
-This will create a config file in your home directory (.config/viewport-cli/.viewport.yaml)
-or in the current directory if home directory is not accessible.`,
+This will create a config file in your home directory (.config/viewport-cli/.viewport.yaml),
+in --work-dir if one is given, or in the current directory if home directory is not accessible.`,
 	RunE: runConfigInit,
 }
 
@@ -49,14 +48,23 @@ var resultsListCmd = &cobra.Command{
 	RunE: runResultsList,
 }
 
+// listTags limits results list to scans with all of these tags
+var listTags []string
+
+// listVerbose makes results list explain scans it skipped
+var listVerbose bool
+
 func init() {
 	configCmd.AddCommand(configInitCmd)
 	configCmd.AddCommand(configShowCmd)
 	resultsCmd.AddCommand(resultsListCmd)
+
+	resultsListCmd.Flags().StringArrayVar(&listTags, "tag", nil, "Only list scans with this tag (repeatable, all must match)")
+	resultsListCmd.Flags().BoolVar(&listVerbose, "verbose", false, "Also list scans skipped because their metadata can't be read, and why")
 }
 
 func runConfigInit(cmd *cobra.Command, args []string) error {
-	reader := bufio.NewReader(os.Stdin)
+	p := prompt.NewPrompter(os.Stdin, os.Stdout)
 
 	// 1. Check if config already exists
 	configPath, err := config.GetConfigPath()
@@ -66,7 +74,7 @@ func runConfigInit(cmd *cobra.Command, args []string) error {
 
 	if _, err := os.Stat(configPath); err == nil {
 		fmt.Printf("Configuration file already exists at %s\n", configPath)
-		if !promptBool(reader, "Do you want to overwrite it?", false) {
+		if !p.Bool("Do you want to overwrite it?", false) {
 			fmt.Println("Operation cancelled.")
 			return nil
 		}
@@ -83,7 +91,7 @@ func runConfigInit(cmd *cobra.Command, args []string) error {
 	// 3. Questions
 	// API Configuration
 	fmt.Println(lipgloss.NewStyle().Foreground(lipgloss.Color("4")).Render("📡 API Configuration"))
-	cfg.API.URL = promptString(reader, "API Endpoint", cfg.API.URL)
+	cfg.API.URL = p.String("API Endpoint", cfg.API.URL)
 	fmt.Println()
 
 	// Scan Configuration
@@ -91,18 +99,18 @@ func runConfigInit(cmd *cobra.Command, args []string) error {
 	
 	// Handle Viewports (CSV)
 	defaultViewports := strings.Join(cfg.Scan.Viewports, ",")
-	viewportsInput := promptString(reader, "Default Viewports (comma separated)", defaultViewports)
-	cfg.Scan.Viewports = parseCSV(viewportsInput)
+	viewportsInput := p.String("Default Viewports (comma separated)", defaultViewports)
+	cfg.Scan.Viewports = prompt.ParseCSV(viewportsInput)
 
-	cfg.Scan.Output = promptString(reader, "Output Directory", cfg.Scan.Output)
+	cfg.Scan.Output = p.String("Output Directory", cfg.Scan.Output)
 	
 	// Handle Timeout (Int)
-	cfg.Scan.Timeout = promptInt(reader, "Timeout (seconds)", cfg.Scan.Timeout)
+	cfg.Scan.Timeout = p.Int("Timeout (seconds)", cfg.Scan.Timeout)
 	fmt.Println()
 
 	// Display Settings
 	fmt.Println(lipgloss.NewStyle().Foreground(lipgloss.Color("4")).Render("🎨 Display Settings"))
-	cfg.Display.Verbose = promptBool(reader, "Enable Verbose Logging?", cfg.Display.Verbose)
+	cfg.Display.Verbose = p.Bool("Enable Verbose Logging?", cfg.Display.Verbose)
 	fmt.Println()
 
 	// 4. Save Config
@@ -117,75 +125,6 @@ func runConfigInit(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-// --- Helper Functions ---
-
-// promptString asks a question and returns the string. Returns default if empty.
-func promptString(r *bufio.Reader, label string, def string) string {
-	inputStr := fmt.Sprintf("%s [%s]: ", label, lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render(def))
-	fmt.Print(inputStr)
-
-	input, _ := r.ReadString('\n')
-	input = strings.TrimSpace(input)
-
-	if input == "" {
-		return def
-	}
-	return input
-}
-
-// promptBool asks a yes/no question.
-func promptBool(r *bufio.Reader, label string, def bool) bool {
-	// Visual indicator of capital letter shows default (e.g., [Y/n] or [y/N])
-	options := "[Y/n]"
-	if !def {
-		options = "[y/N]"
-	}
-
-	fmt.Printf("%s %s: ", label, lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render(options))
-
-	input, _ := r.ReadString('\n')
-	input = strings.TrimSpace(strings.ToLower(input))
-
-	if input == "" {
-		return def
-	}
-
-	return input == "y" || input == "yes"
-}
-
-// promptInt asks for an integer.
-func promptInt(r *bufio.Reader, label string, def int) int {
-	for {
-		inputStr := fmt.Sprintf("%s [%s]: ", label, lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render(strconv.Itoa(def)))
-		fmt.Print(inputStr)
-
-		input, _ := r.ReadString('\n')
-		input = strings.TrimSpace(input)
-
-		if input == "" {
-			return def
-		}
-
-		val, err := strconv.Atoi(input)
-		if err == nil {
-			return val
-		}
-		fmt.Println(lipgloss.NewStyle().Foreground(lipgloss.Color("1")).Render("Please enter a valid number."))
-	}
-}
-
-func parseCSV(input string) []string {
-	parts := strings.Split(input, ",")
-	var result []string
-	for _, p := range parts {
-		trimmed := strings.TrimSpace(p)
-		if trimmed != "" {
-			result = append(result, trimmed)
-		}
-	}
-	return result
-}
-
 func runConfigShow(cmd *cobra.Command, args []string) error {
 	fmt.Printf("\n%s\n\n", lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("6")).Render("⚙️  Current Configuration"))
 
@@ -201,6 +140,12 @@ func runConfigShow(cmd *cobra.Command, args []string) error {
 	// Display API configuration
 	fmt.Println(lipgloss.NewStyle().Foreground(lipgloss.Color("4")).Render("📡 API Configuration"))
 	fmt.Printf("  • Endpoint: %s\n", lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render(cfg.API.URL))
+	if cfg.API.CACert != "" {
+		fmt.Printf("  • CA Certificate: %s\n", cfg.API.CACert)
+	}
+	if cfg.API.ClientCert != "" {
+		fmt.Printf("  • Client Certificate: %s (key %s)\n", cfg.API.ClientCert, cfg.API.ClientKey)
+	}
 	fmt.Println()
 
 	// Display scan configuration
@@ -208,6 +153,44 @@ func runConfigShow(cmd *cobra.Command, args []string) error {
 	fmt.Printf("  • Viewports: %v\n", cfg.Scan.Viewports)
 	fmt.Printf("  • Output: %s\n", cfg.Scan.Output)
 	fmt.Printf("  • Timeout: %ds\n", cfg.Scan.Timeout)
+	fmt.Printf("  • Warmup: %v\n", cfg.Scan.Warmup)
+	if cfg.Scan.UserAgent != "" {
+		fmt.Printf("  • User-Agent: %s\n", cfg.Scan.UserAgent)
+	}
+	if cfg.Scan.RouteMap != "" {
+		fmt.Printf("  • Route Map: %s\n", cfg.Scan.RouteMap)
+	}
+	for _, route := range cfg.Scan.Routes {
+		var opts []string
+		if route.FullPage != nil {
+			opts = append(opts, fmt.Sprintf("full_page=%v", *route.FullPage))
+		}
+		if route.Selector != "" {
+			opts = append(opts, "selector="+route.Selector)
+		}
+		fmt.Printf("  • Route %s: %s\n", route.Match, strings.Join(opts, ", "))
+	}
+	if len(cfg.Scan.SeverityMap) > 0 {
+		fmt.Printf("  • Severity Map: %s\n", config.FormatValue(cfg.Scan.SeverityMap))
+	}
+	if cfg.Scan.DeviceListFile != "" {
+		fmt.Printf("  • Device List: %s\n", cfg.Scan.DeviceListFile)
+	}
+	fmt.Println()
+
+	// Display results storage
+	fmt.Println(lipgloss.NewStyle().Foreground(lipgloss.Color("4")).Render("🗄️  Results Storage"))
+	fmt.Printf("  • Backend: %s\n", cfg.Results.Backend)
+	if cfg.Results.Backend == "s3" {
+		fmt.Printf("  • Bucket: %s\n", cfg.Results.S3.Bucket)
+		fmt.Printf("  • Prefix: %s\n", cfg.Results.S3.Prefix)
+		if cfg.Results.S3.Region != "" {
+			fmt.Printf("  • Region: %s\n", cfg.Results.S3.Region)
+		}
+		if cfg.Results.S3.Endpoint != "" {
+			fmt.Printf("  • Endpoint: %s\n", cfg.Results.S3.Endpoint)
+		}
+	}
 	fmt.Println()
 
 	// Display display settings
@@ -215,6 +198,11 @@ func runConfigShow(cmd *cobra.Command, args []string) error {
 	fmt.Printf("  • Verbose: %v\n", cfg.Display.Verbose)
 	fmt.Printf("  • Colors: %v\n", !cfg.Display.NoColor)
 	fmt.Printf("  • Tables: %v\n", !cfg.Display.NoTable)
+	fmt.Printf("  • Severity Colors:")
+	for _, severity := range []string{"critical", "high", "medium", "low"} {
+		fmt.Printf(" %s", renderSeverity(severity))
+	}
+	fmt.Println()
 	fmt.Println()
 
 	// Show where config is loaded from
@@ -227,37 +215,56 @@ func runConfigShow(cmd *cobra.Command, args []string) error {
 		fmt.Printf("%s Using default configuration (no config file found)\n\n",
 			lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render("📄"))
 	}
+	if cfg.StrictConfig {
+		fmt.Printf("%s Strict config: unknown keys are rejected\n\n",
+			lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render("🔒"))
+	}
 
 	return nil
 }
 
 func runResultsList(cmd *cobra.Command, args []string) error {
-	// Load config to get output directory
-	cfg, err := config.LoadConfig("")
+	tags, err := results.NormalizeTags(listTags)
+	if err != nil {
+		return withExitCode(exitConfigError, err)
+	}
+
+	store, err := configuredResultsStore()
 	if err != nil {
-		cfg = &config.Config{}
-		cfg.Scan.Output = "./viewport-results"
+		return err
 	}
 
 	// Get scan list
-	scans, err := results.ListScans(cfg.Scan.Output)
+	scans, err := store.ListScans()
 	if err != nil {
 		return fmt.Errorf("failed to list scans: %w", err)
 	}
+	if len(tags) > 0 {
+		scans = results.FilterByTags(scans, tags)
+	}
 
 	// Display header
 	fmt.Printf("\n%s\n\n", lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("6")).Render("📋 Previous Scans"))
 
 	if len(scans) == 0 {
-		fmt.Printf("%s No scans found in %s\n\n",
-			lipgloss.NewStyle().Foreground(lipgloss.Color("3")).Render("ℹ️ "),
-			cfg.Scan.Output)
+		if len(tags) > 0 {
+			fmt.Printf("%s No scans tagged %s in %s\n\n",
+				lipgloss.NewStyle().Foreground(lipgloss.Color("3")).Render("ℹ️ "),
+				strings.Join(tags, ", "), store.Location())
+		} else {
+			fmt.Printf("%s No scans found in %s\n\n",
+				lipgloss.NewStyle().Foreground(lipgloss.Color("3")).Render("ℹ️ "),
+				store.Location())
+		}
+		if listVerbose {
+			printSkippedScans(store)
+		}
 		return nil
 	}
 
 	// Display scans table
 	fmt.Println("┌────┬──────────────────────────────────┬──────────────────────┬──────────────┬────────┐")
-	fmt.Println("│    │ Scan ID                          │ Timestamp            │ Viewports    │ Issues │")
+	fmt.Println("│    │ Scan ID / Label                  │ Timestamp            │ Viewports    │ Issues │")
 	fmt.Println("├────┼──────────────────────────────────┼──────────────────────┼──────────────┼────────┤")
 
 	for _, scan := range scans {
@@ -276,9 +283,18 @@ func runResultsList(cmd *cobra.Command, args []string) error {
 			statusIcon = "⚠️"
 		}
 
-		fmt.Printf("│ %s │ %-28s │ %-20s │ %-12s │ %6d │\n",
+		// Prefer the human-friendly label when one is set
+		name := scan.ScanID
+		if scan.Label != "" {
+			name = scan.Label
+		}
+		if len(name) > 32 {
+			name = name[:29] + "..."
+		}
+
+		fmt.Printf("│ %s │ %-32s │ %-20s │ %-12s │ %6d │\n",
 			statusIcon,
-			scan.ScanID[:32],
+			name,
 			timeStr,
 			viewportsStr,
 			scan.IssueCount,
@@ -298,11 +314,37 @@ func runResultsList(cmd *cobra.Command, args []string) error {
 		len(scans),
 		totalIssues)
 
-	fmt.Printf("%s View details: viewport-cli results show <scan-id>\n",
+	fmt.Printf("%s View details: viewport-cli results show <scan-id|label>\n",
 		lipgloss.NewStyle().Foreground(lipgloss.Color("3")).Render("💡"))
-	fmt.Printf("%s Results dir: %s\n\n",
+	fmt.Printf("%s Results: %s\n\n",
 		lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render("📁"),
-		cfg.Scan.Output)
+		store.Location())
 
+	if listVerbose {
+		printSkippedScans(store)
+	}
 	return nil
 }
+
+// printSkippedScans lists the scans left out of a listing because their
+// metadata couldn't be read or parsed, with the reason for each
+func printSkippedScans(store results.Store) {
+	skipper, ok := store.(results.Skipper)
+	if !ok {
+		return
+	}
+	skipped, err := skipper.SkippedScans()
+	if err != nil {
+		fmt.Printf("⚠️  Warning: failed to check for skipped scans: %v\n\n", err)
+		return
+	}
+	if len(skipped) == 0 {
+		return
+	}
+
+	fmt.Printf("⚠️  Warning: skipped %d scan(s) with invalid metadata:\n", len(skipped))
+	for _, scan := range skipped {
+		fmt.Printf("   %s: %s\n", scan.ScanID, scan.Reason)
+	}
+	fmt.Println()
+}
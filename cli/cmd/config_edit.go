@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/law-makers/viewport-cli/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+var configEditCmd = &cobra.Command{
+	Use:   "edit",
+	Short: "Open the configuration file in your editor",
+	Long: `Open the configuration file in $VISUAL or $EDITOR, creating it with defaults if it doesn't exist.
+
+The file is validated after the editor exits. When no editor is available or the
+terminal is not interactive, the config file path is printed instead.`,
+	RunE: runConfigEdit,
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Validate the configuration file",
+	Long:  `Load the configuration and report any invalid settings.`,
+	RunE:  runConfigValidate,
+}
+
+var configUnsetCmd = &cobra.Command{
+	Use:   "unset <key>",
+	Short: "Remove a key from the configuration file so its default applies",
+	Long: `Remove a key such as scan.timeout from the configuration file so the built-in
+default applies again. Whole sections (e.g. results.s3) can be removed too.
+Other keys, their order and comments are left as they are.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runConfigUnset,
+}
+
+func init() {
+	configCmd.AddCommand(configEditCmd)
+	configCmd.AddCommand(configValidateCmd)
+	configCmd.AddCommand(configUnsetCmd)
+}
+
+func runConfigEdit(cmd *cobra.Command, args []string) error {
+	configPath, err := config.GetConfigPath()
+	if err != nil {
+		return fmt.Errorf("failed to determine config path: %w", err)
+	}
+
+	// Create the file with defaults so there is something to edit
+	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+		if err := config.SaveConfig(config.DefaultConfig(), configPath); err != nil {
+			return fmt.Errorf("failed to create config: %w", err)
+		}
+		fmt.Printf("Created default configuration at %s\n", configPath)
+	}
+
+	editor := findEditor()
+	if editor == nil || !isTerminal(os.Stdin) {
+		fmt.Printf("%s Configuration file: %s\n",
+			lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render("📄"),
+			configPath)
+		return nil
+	}
+
+	c := exec.Command(editor[0], append(editor[1:], configPath)...)
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	if err := c.Run(); err != nil {
+		return fmt.Errorf("editor exited with an error: %w", err)
+	}
+
+	return validateConfigFile(configPath)
+}
+
+func runConfigValidate(cmd *cobra.Command, args []string) error {
+	configPath, _ := config.GetConfigPath()
+	if _, err := os.Stat(configPath); err != nil {
+		// Validate whatever LoadConfig would pick up (current directory, defaults)
+		configPath = ""
+	}
+	return validateConfigFile(configPath)
+}
+
+func runConfigUnset(cmd *cobra.Command, args []string) error {
+	key := strings.ToLower(args[0])
+	def, ok := config.DefaultValue(key)
+	if !ok {
+		return withExitCode(exitConfigError, fmt.Errorf("unknown config key %q", args[0]))
+	}
+
+	configPath, err := config.GetConfigPath()
+	if err != nil {
+		return fmt.Errorf("failed to determine config path: %w", err)
+	}
+	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+		fmt.Printf("ℹ️  No configuration file, %s is already the default: %s\n", key, config.FormatValue(def))
+		return nil
+	}
+
+	removed, err := config.UnsetKey(configPath, key)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		fmt.Printf("ℹ️  %s is not set in %s, the default applies: %s\n", key, configPath, config.FormatValue(def))
+		return nil
+	}
+
+	fmt.Printf("%s Reverted %s to its default: %s\n",
+		lipgloss.NewStyle().Foreground(lipgloss.Color("2")).Render("✅"),
+		lipgloss.NewStyle().Bold(true).Render(key),
+		config.FormatValue(def))
+	return nil
+}
+
+// validateConfigFile loads the config at path (or the default search path when empty) and reports problems
+func validateConfigFile(path string) error {
+	cfg, err := config.LoadConfig(path)
+	if err == nil {
+		err = config.Validate(cfg)
+	}
+
+	if err != nil {
+		fmt.Printf("%s Configuration is invalid:\n", lipgloss.NewStyle().Foreground(lipgloss.Color("1")).Render("❌"))
+		for _, line := range strings.Split(err.Error(), "\n") {
+			fmt.Printf("  • %s\n", line)
+		}
+		fmt.Println()
+		return withExitCode(exitConfigError, fmt.Errorf("invalid configuration"))
+	}
+
+	fmt.Printf("%s Configuration is valid\n", lipgloss.NewStyle().Foreground(lipgloss.Color("2")).Render("✅"))
+	return nil
+}
+
+// findEditor returns the editor command to use, or nil if none is available
+func findEditor() []string {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if fields := strings.Fields(os.Getenv(env)); len(fields) > 0 {
+			return fields
+		}
+	}
+
+	fallback := "vi"
+	if runtime.GOOS == "windows" {
+		fallback = "notepad"
+	}
+	if _, err := exec.LookPath(fallback); err == nil {
+		return []string{fallback}
+	}
+	return nil
+}
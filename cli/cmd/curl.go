@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/law-makers/viewport-cli/pkg/api"
+)
+
+// printCurlCommands prints the curl equivalent of each target's scan request
+// without contacting the screenshot server
+func (s *scanSession) printCurlCommands(targets []string) error {
+	client := api.NewClient(apiURL).SetUserAgent(cliUserAgent())
+	if noCompression {
+		client.SetCompression(false)
+	}
+	if _, err := client.SetTLSFiles(s.tlsFiles); err != nil {
+		return withExitCode(exitConfigError, err)
+	}
+
+	for i, target := range targets {
+		req := s.newScanRequest(target)
+		s.applyPresetTarget(req, target)
+		if !unsafePrintSecrets {
+			req.Options.Headers = redactHeaders(req.Options.Headers)
+		}
+
+		command, err := client.CurlCommand(req)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Println(command)
+	}
+	return nil
+}
+
+// redactHeaders returns a copy of headers with sensitive values replaced
+func redactHeaders(headers map[string]string) map[string]string {
+	if headers == nil {
+		return nil
+	}
+	redacted := make(map[string]string, len(headers))
+	for name, value := range headers {
+		if sensitiveHeaders[name] {
+			value = "[REDACTED]"
+		}
+		redacted[name] = value
+	}
+	return redacted
+}
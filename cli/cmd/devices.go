@@ -0,0 +1,270 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/law-makers/viewport-cli/pkg/api"
+	"github.com/law-makers/viewport-cli/pkg/config"
+	"github.com/law-makers/viewport-cli/pkg/results"
+	"github.com/spf13/cobra"
+	"go.yaml.in/yaml/v3"
+)
+
+var devicesCmd = &cobra.Command{
+	Use:   "devices",
+	Short: "Inspect the devices scans can emulate",
+	Long:  `Inspect the device profiles that --viewports names resolve against.`,
+}
+
+var devicesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the resolved device matrix",
+	Long: `List the built-in devices together with the profiles of the device list file
+(--device-list-file or scan.device_list_file in config), as scans resolve them.`,
+	Args: cobra.NoArgs,
+	RunE: runDevicesList,
+}
+
+func init() {
+	devicesCmd.AddCommand(devicesListCmd)
+
+	devicesListCmd.Flags().StringVar(&deviceListFile, "device-list-file", "", "YAML file of device profiles to merge with the built-ins (or scan.device_list_file in config)")
+}
+
+// builtinDevices mirror the screenshot server's built-in viewports
+var builtinDevices = []api.DeviceProfile{
+	{Name: "mobile", Width: 375, Height: 667},
+	{Name: "tablet", Width: 768, Height: 1024},
+	{Name: "desktop", Width: 1920, Height: 1080},
+}
+
+// maxDeviceDimension bounds device widths and heights, in CSS pixels
+const maxDeviceDimension = 10000
+
+// deviceName is the form viewport names take
+var deviceName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// deviceEntry is one profile of a device list file
+type deviceEntry struct {
+	Name              string  `yaml:"name"`
+	Width             int     `yaml:"width"`
+	Height            int     `yaml:"height"`
+	DeviceScaleFactor float64 `yaml:"device_scale_factor"`
+	UserAgent         string  `yaml:"user_agent"`
+	Mobile            bool    `yaml:"mobile"`
+}
+
+// deviceMatrix is the set of devices a scan can resolve viewports against
+type deviceMatrix struct {
+	profiles []api.DeviceProfile
+	custom   map[string]bool // Names defined by the device list file
+}
+
+// lookup returns the profile named name
+func (m *deviceMatrix) lookup(name string) (api.DeviceProfile, bool) {
+	for _, profile := range m.profiles {
+		if profile.Name == name {
+			return profile, true
+		}
+	}
+	return api.DeviceProfile{}, false
+}
+
+// names lists every device in the matrix
+func (m *deviceMatrix) names() []string {
+	names := make([]string, len(m.profiles))
+	for i, profile := range m.profiles {
+		names[i] = profile.Name
+	}
+	return names
+}
+
+// loadDeviceList reads a device list file: a "devices" list of profiles with
+// a name, width and height, and optionally device_scale_factor, user_agent
+// and mobile. Every profile is checked and all problems reported together.
+func loadDeviceList(path string) ([]api.DeviceProfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read device list: %w", err)
+	}
+
+	var file struct {
+		Devices []deviceEntry `yaml:"devices"`
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("failed to parse device list %s: %w", path, err)
+	}
+	if len(file.Devices) == 0 {
+		return nil, fmt.Errorf("device list %s defines no devices", path)
+	}
+
+	var errs []error
+	seen := make(map[string]bool)
+	profiles := make([]api.DeviceProfile, 0, len(file.Devices))
+	for i, d := range file.Devices {
+		where := fmt.Sprintf("%s: devices[%d]", path, i)
+		if d.Name != "" {
+			where = fmt.Sprintf("%s: device %q", path, d.Name)
+		}
+		switch {
+		case d.Name == "":
+			errs = append(errs, fmt.Errorf("%s needs a name", where))
+		case !deviceName.MatchString(d.Name):
+			errs = append(errs, fmt.Errorf("%s: names must be lowercase letters, digits, '-' and '_'", where))
+		case seen[d.Name]:
+			errs = append(errs, fmt.Errorf("%s is defined more than once", where))
+		}
+		seen[d.Name] = true
+		if d.Width <= 0 || d.Width > maxDeviceDimension || d.Height <= 0 || d.Height > maxDeviceDimension {
+			errs = append(errs, fmt.Errorf("%s: width and height must be between 1 and %d, got %d×%d", where, maxDeviceDimension, d.Width, d.Height))
+		}
+		if d.DeviceScaleFactor < 0 || d.DeviceScaleFactor > 10 {
+			errs = append(errs, fmt.Errorf("%s: device_scale_factor must be between 0 (default) and 10, got %g", where, d.DeviceScaleFactor))
+		}
+		profiles = append(profiles, api.DeviceProfile(d))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return profiles, nil
+}
+
+// resolveDeviceMatrix merges the built-in devices with the profiles of the
+// device list file at path, if any. A profile named like a built-in replaces it.
+func resolveDeviceMatrix(path string) (*deviceMatrix, error) {
+	m := &deviceMatrix{profiles: append([]api.DeviceProfile(nil), builtinDevices...), custom: make(map[string]bool)}
+	if path == "" {
+		return m, nil
+	}
+
+	profiles, err := loadDeviceList(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, profile := range profiles {
+		m.custom[profile.Name] = true
+		replaced := false
+		for i := range m.profiles {
+			if m.profiles[i].Name == profile.Name {
+				m.profiles[i] = profile
+				replaced = true
+			}
+		}
+		if !replaced {
+			m.profiles = append(m.profiles, profile)
+		}
+	}
+	return m, nil
+}
+
+// viewportsFromScan returns the viewports of a saved scan (--viewports-from),
+// in its order, and adds each one to m at the dimensions it was captured at,
+// so the new scan uses the same breakpoints even where a device list or the
+// built-ins size the device differently. Profile fields a scan doesn't record
+// (pixel ratio, user agent, touch) are taken from m's profile of the same name.
+func viewportsFromScan(scan *results.ScanMetadata, m *deviceMatrix) ([]string, error) {
+	if len(scan.Results) == 0 {
+		return nil, fmt.Errorf("scan %s has no viewports to reuse", scan.ScanID)
+	}
+
+	var names []string
+	for _, result := range scan.Results {
+		name := strings.ToLower(result.Device)
+		if containsString(names, name) {
+			continue
+		}
+		names = append(names, name)
+
+		profile, known := m.lookup(name)
+		width, height := result.Dimensions.Width, result.Dimensions.Height
+		if width <= 0 || height <= 0 {
+			if !known {
+				return nil, fmt.Errorf("scan %s doesn't record the size of viewport %q, and it isn't a known device", scan.ScanID, name)
+			}
+			continue
+		}
+		if known && profile.Width == width && profile.Height == height {
+			continue
+		}
+
+		profile.Name, profile.Width, profile.Height = name, width, height
+		m.custom[name] = true
+		if known {
+			for i := range m.profiles {
+				if m.profiles[i].Name == name {
+					m.profiles[i] = profile
+				}
+			}
+		} else {
+			m.profiles = append(m.profiles, profile)
+		}
+	}
+	return names, nil
+}
+
+// configuredDeviceList returns --device-list-file, or scan.device_list_file from config
+func configuredDeviceList(cfg *config.Config) string {
+	if deviceListFile == "" && cfg != nil {
+		return cfg.Scan.DeviceListFile
+	}
+	return deviceListFile
+}
+
+// deviceProfiles checks that every viewport is in the matrix and returns the
+// profiles the server needs to be sent: those from the device list file
+func deviceProfiles(m *deviceMatrix, viewports []string) ([]api.DeviceProfile, error) {
+	var profiles []api.DeviceProfile
+	for _, name := range viewports {
+		profile, ok := m.lookup(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown viewport %q (available: %s)", name, strings.Join(m.names(), ", "))
+		}
+		if m.custom[name] {
+			profiles = append(profiles, profile)
+		}
+	}
+	return profiles, nil
+}
+
+func runDevicesList(cmd *cobra.Command, args []string) error {
+	cfg, _ := config.LoadConfig("")
+	matrix, err := resolveDeviceMatrix(configuredDeviceList(cfg))
+	if err != nil {
+		return withExitCode(exitConfigError, err)
+	}
+
+	fmt.Printf("\n%s\n\n", lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("6")).Render("📱 Devices"))
+	rows := [][]string{{"Name", "Size", "DPR", "Mobile", "Source", "User-Agent"}}
+	for _, profile := range matrix.profiles {
+		dpr := "1"
+		if profile.DeviceScaleFactor > 0 {
+			dpr = fmt.Sprintf("%g", profile.DeviceScaleFactor)
+		}
+		mobile := "-"
+		if profile.Mobile {
+			mobile = "yes"
+		}
+		source := "built-in"
+		if matrix.custom[profile.Name] {
+			source = "device list"
+		}
+		userAgent := profile.UserAgent
+		if userAgent == "" {
+			userAgent = "(browser default)"
+		} else if len(userAgent) > 50 {
+			userAgent = userAgent[:47] + "..."
+		}
+		rows = append(rows, []string{profile.Name, fmt.Sprintf("%d×%d", profile.Width, profile.Height), dpr, mobile, source, userAgent})
+	}
+	printPlainMatrix(rows)
+	fmt.Println()
+	return nil
+}
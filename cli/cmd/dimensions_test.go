@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/law-makers/viewport-cli/pkg/api"
+	"github.com/law-makers/viewport-cli/pkg/results"
+)
+
+// scannerFunc adapts a function to api.Scanner
+type scannerFunc func(ctx context.Context, req *api.ScanRequest) (*api.ScanResponse, error)
+
+func (f scannerFunc) Scan(ctx context.Context, req *api.ScanRequest) (*api.ScanResponse, error) {
+	return f(ctx, req)
+}
+
+// savedPNGs lists the PNG files under dir
+func savedPNGs(t *testing.T, dir string) []string {
+	t.Helper()
+	var pngs []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if strings.HasSuffix(path, ".png") {
+			pngs = append(pngs, path)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pngs
+}
+
+func TestDimensionsOnlyScanSavesNoPNGs(t *testing.T) {
+	defer func(v bool) { dimensionsOnly = v }(dimensionsOnly)
+	dimensionsOnly = true
+
+	var sent *api.ScanRequest
+	// A server that predates the option sends screenshots anyway
+	client := scannerFunc(func(ctx context.Context, req *api.ScanRequest) (*api.ScanResponse, error) {
+		sent = req
+		return &api.ScanResponse{ScanID: "scan-1", Status: "completed", Results: []api.ViewportResult{
+			{Device: "mobile", ScreenshotBase64: "iVBORw0KGgo=", Dimensions: api.Dimensions{Width: 375, Height: 2400},
+				Issues: []api.DetectedIssue{{Severity: "high", Type: "horizontal-scroll"}}},
+			{Device: "desktop", ScreenshotBase64: "iVBORw0KGgo=", Dimensions: api.Dimensions{Width: 1440, Height: 1800}},
+		}}, nil
+	})
+	// The CLI checks the target for redirects itself
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer target.Close()
+
+	dir := t.TempDir()
+	s := &scanSession{client: client, store: results.NewFSStore(dir), transport: &http.Transport{}}
+
+	resp, err := s.scanTarget(context.Background(), target.URL)
+	if err != nil {
+		t.Fatalf("scan failed on screenshot-free results: %v", err)
+	}
+	if sent == nil || !sent.Options.DimensionsOnly {
+		t.Error("request doesn't ask the server to leave out screenshots")
+	}
+	if !resp.DimensionsOnly {
+		t.Error("response isn't marked as a dimensions-only scan")
+	}
+	if pngs := savedPNGs(t, dir); len(pngs) > 0 {
+		t.Errorf("dimensions-only scan wrote %q", pngs)
+	}
+
+	data, err := s.store.ReadFile("scan-1", results.MetadataFile)
+	if err != nil {
+		t.Fatalf("metadata not saved: %v", err)
+	}
+	var saved api.ScanResponse
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+	if len(saved.Results) != 2 || saved.Results[0].Dimensions.Width != 375 || len(saved.Results[0].Issues) != 1 {
+		t.Errorf("metadata lost the dimensions or issues: %+v", saved.Results)
+	}
+	for _, result := range saved.Results {
+		if result.ScreenshotBase64 != "" || result.ScreenshotFile != "" {
+			t.Errorf("%s metadata still refers to a screenshot", result.Device)
+		}
+	}
+}
+
+func TestEncodeScanDimensionsOnly(t *testing.T) {
+	resp := &api.ScanResponse{ScanID: "scan-1", DimensionsOnly: true, Results: []api.ViewportResult{{Device: "mobile"}}}
+	scan, err := encodeScan(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scan.Files) != 0 {
+		t.Errorf("encoded files %v, want none", scan.Files)
+	}
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+
+	"github.com/law-makers/viewport-cli/pkg/api"
+	"golang.org/x/image/draw"
+)
+
+// fitWithin returns the largest size with the same aspect ratio as width×height
+// that fits within maxWidth×maxHeight. A zero limit means no limit on that side.
+func fitWithin(width, height, maxWidth, maxHeight int) (int, int) {
+	scale := 1.0
+	if maxWidth > 0 && width > maxWidth {
+		scale = float64(maxWidth) / float64(width)
+	}
+	if maxHeight > 0 && height > maxHeight {
+		if s := float64(maxHeight) / float64(height); s < scale {
+			scale = s
+		}
+	}
+	if scale == 1.0 {
+		return width, height
+	}
+
+	w := int(float64(width)*scale + 0.5)
+	h := int(float64(height)*scale + 0.5)
+	if w < 1 {
+		w = 1
+	}
+	if h < 1 {
+		h = 1
+	}
+	return w, h
+}
+
+// downscaleScreenshot shrinks a PNG screenshot to fit the size limits. It
+// returns the data to write along with the original and saved sizes; data is
+// returned unchanged when the image already fits.
+func downscaleScreenshot(data []byte, maxWidth, maxHeight int) ([]byte, api.Dimensions, api.Dimensions, error) {
+	src, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, api.Dimensions{}, api.Dimensions{}, fmt.Errorf("failed to decode screenshot image: %w", err)
+	}
+
+	bounds := src.Bounds()
+	original := api.Dimensions{Width: bounds.Dx(), Height: bounds.Dy()}
+	w, h := fitWithin(original.Width, original.Height, maxWidth, maxHeight)
+	if w == original.Width && h == original.Height {
+		return data, original, original, nil
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Src, nil)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, dst); err != nil {
+		return nil, api.Dimensions{}, api.Dimensions{}, fmt.Errorf("failed to encode screenshot image: %w", err)
+	}
+	return buf.Bytes(), original, api.Dimensions{Width: w, Height: h}, nil
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"errors"
+)
+
+// Process exit codes, so CI can tell failure classes apart
+const (
+	exitOK            = 0   // Scan completed
+	exitError         = 1   // Generic or unexpected error
+	exitScanFailed    = 2   // Scan failed or returned empty screenshots
+	exitThreshold     = 3   // Reserved for an issue threshold gate; nothing returns it yet
+	exitConfigError   = 4   // Invalid configuration, flags or input files
+	exitStartup       = 5   // Screenshot server or tunnel could not be started
+	exitEmptyViewport = 6   // Some viewports returned empty screenshots (--fail-on-empty-viewport)
+	exitRegression    = 7   // Issues not in the target's baseline (--baseline-auto)
+	exitTooSlow       = 8   // A capture took longer than --max-duration
+	exitInterrupted   = 130 // Interrupted a second time while shutting down
+)
+
+// codedError attaches a process exit code to an error
+type codedError struct {
+	code int
+	err  error
+}
+
+func (e *codedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *codedError) Unwrap() error {
+	return e.err
+}
+
+// withExitCode wraps err so Execute exits with code. A nil err stays nil.
+func withExitCode(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &codedError{code: code, err: err}
+}
+
+// exitCodeFor returns the exit code for an error returned by a command
+func exitCodeFor(err error) int {
+	if err == nil {
+		return exitOK
+	}
+	var e *codedError
+	if errors.As(err, &e) {
+		return e.code
+	}
+	return exitError
+}
@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// frameworkManifest describes where a framework's build lists its routes
+type frameworkManifest struct {
+	name string // Display name
+	path string // Manifest file, relative to the project directory
+	// hint says how to produce the manifest when it is missing
+	hint string
+	// parse returns the manifest's static page paths, and the dynamic
+	// routes (e.g. "/blog/[slug]") that have no URL of their own
+	parse func(data []byte) (pages, dynamic []string, err error)
+}
+
+// frameworkManifests are the frameworks --framework reads routes from
+var frameworkManifests = map[string]frameworkManifest{
+	"next": {
+		name:  "Next.js",
+		path:  filepath.Join(".next", "routes-manifest.json"),
+		hint:  "run `next build` in this directory first",
+		parse: parseNextRoutes,
+	},
+}
+
+// unsupportedFrameworks explains frameworks --framework can't read routes from
+var unsupportedFrameworks = map[string]string{
+	"cra": "Create React App has no route manifest: its routes exist only in client-side code, so list its URLs in a --targets-file",
+}
+
+// frameworkNames lists the supported --framework values
+func frameworkNames() []string {
+	names := make([]string, 0, len(frameworkManifests))
+	for name := range frameworkManifests {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// frameworkTargets lists the pages of the project in dir, from the route
+// manifest of framework, as URLs under base. It also returns the dynamic
+// routes that were left out.
+func frameworkTargets(framework, dir, base string) ([]string, []string, error) {
+	manifest, ok := frameworkManifests[framework]
+	if !ok {
+		if reason, known := unsupportedFrameworks[framework]; known {
+			return nil, nil, fmt.Errorf("--framework %s is not supported: %s", framework, reason)
+		}
+		return nil, nil, fmt.Errorf("unknown --framework %q (supported: %s)", framework, strings.Join(frameworkNames(), ", "))
+	}
+
+	path := filepath.Join(dir, manifest.path)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil, fmt.Errorf("no %s route manifest at %s: %s", manifest.name, path, manifest.hint)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read %s route manifest: %w", manifest.name, err)
+	}
+
+	pages, dynamic, err := manifest.parse(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if len(pages) == 0 {
+		if len(dynamic) > 0 {
+			return nil, nil, fmt.Errorf("%s lists only dynamic routes (%s); list their URLs in a --targets-file", path, strings.Join(dynamic, ", "))
+		}
+		return nil, nil, fmt.Errorf("%s lists no pages: %s", path, manifest.hint)
+	}
+
+	targets := make([]string, len(pages))
+	for i, page := range pages {
+		targets[i] = strings.TrimSuffix(base, "/") + page
+	}
+	return targets, dynamic, nil
+}
+
+// nextRoutesManifest is the part of Next.js's routes-manifest.json that lists pages
+type nextRoutesManifest struct {
+	BasePath      string      `json:"basePath"`
+	StaticRoutes  []nextRoute `json:"staticRoutes"`
+	DynamicRoutes []nextRoute `json:"dynamicRoutes"`
+}
+
+type nextRoute struct {
+	Page string `json:"page"`
+}
+
+// parseNextRoutes reads a Next.js routes-manifest.json. Pages under the
+// basePath are returned with it; internal pages ("/_app", "/_not-found"),
+// error pages and API routes aren't pages to scan.
+func parseNextRoutes(data []byte) ([]string, []string, error) {
+	var manifest nextRoutesManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, nil, err
+	}
+
+	scannable := func(page string) bool {
+		return strings.HasPrefix(page, "/") &&
+			!strings.HasPrefix(page, "/_") &&
+			page != "/api" && !strings.HasPrefix(page, "/api/") &&
+			page != "/404" && page != "/500"
+	}
+	withBase := func(page string) string {
+		if manifest.BasePath == "" {
+			return page
+		}
+		if page == "/" {
+			return manifest.BasePath
+		}
+		return manifest.BasePath + page
+	}
+
+	var pages, dynamic []string
+	for _, route := range manifest.StaticRoutes {
+		if scannable(route.Page) && !containsString(pages, withBase(route.Page)) {
+			pages = append(pages, withBase(route.Page))
+		}
+	}
+	for _, route := range manifest.DynamicRoutes {
+		if scannable(route.Page) {
+			dynamic = append(dynamic, withBase(route.Page))
+		}
+	}
+	return pages, dynamic, nil
+}
@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// sensitiveHeaders are redacted whenever headers are printed
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+	"X-Api-Key":           true,
+}
+
+// hostnameLabel is one dot-separated label of a DNS hostname
+var hostnameLabel = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?$`)
+
+// validateHostHeader checks that value is a plausible Host header: a DNS
+// hostname, optionally followed by :port
+func validateHostHeader(value string) error {
+	host := value
+	if h, port, err := net.SplitHostPort(value); err == nil {
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			return fmt.Errorf("%q has an invalid port", value)
+		}
+		host = h
+	}
+	host = strings.TrimSuffix(host, ".")
+	if host == "" || len(host) > 253 {
+		return fmt.Errorf("%q is not a valid hostname", value)
+	}
+	for _, label := range strings.Split(host, ".") {
+		if !hostnameLabel.MatchString(label) {
+			return fmt.Errorf("%q is not a valid hostname", value)
+		}
+	}
+	return nil
+}
+
+// hostname strips any port from a Host header value
+func hostname(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		return h
+	}
+	return host
+}
+
+// loadHeadersFile reads a JSON object of header names to string values
+func loadHeadersFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read headers file: %w", err)
+	}
+
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("headers file %s must contain a JSON object: %w", path, err)
+	}
+
+	headers := make(map[string]string, len(raw))
+	for name, value := range raw {
+		str, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("headers file %s: value for %q must be a string", path, name)
+		}
+		headers[http.CanonicalHeaderKey(strings.TrimSpace(name))] = str
+	}
+
+	return headers, nil
+}
+
+// parseHeaderFlags parses repeated "Name: Value" flags
+func parseHeaderFlags(values []string) (map[string]string, error) {
+	headers := make(map[string]string, len(values))
+	for _, v := range values {
+		name, value, ok := strings.Cut(v, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid --header %q (expected \"Name: Value\")", v)
+		}
+		headers[http.CanonicalHeaderKey(name)] = strings.TrimSpace(value)
+	}
+	return headers, nil
+}
+
+// resolveHeaders merges the headers file with --header flags, flags taking precedence
+func resolveHeaders(file string, flags []string) (map[string]string, error) {
+	headers := make(map[string]string)
+
+	if file != "" {
+		fromFile, err := loadHeadersFile(file)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range fromFile {
+			headers[k] = v
+		}
+	}
+
+	fromFlags, err := parseHeaderFlags(flags)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range fromFlags {
+		headers[k] = v
+	}
+
+	if len(headers) == 0 {
+		return nil, nil
+	}
+	return headers, nil
+}
+
+// formatHeaders renders headers for diagnostics with sensitive values redacted
+func formatHeaders(headers map[string]string) string {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		value := headers[name]
+		if sensitiveHeaders[name] {
+			value = "[REDACTED]"
+		}
+		parts = append(parts, fmt.Sprintf("%s: %s", name, value))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,244 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/law-makers/viewport-cli/pkg/api"
+	"github.com/law-makers/viewport-cli/pkg/compare"
+)
+
+// groupedIssue is a detected issue together with every device it was reported on
+type groupedIssue struct {
+	Issue   api.DetectedIssue
+	Devices []string
+}
+
+// dedupeIssues groups identical issues (by type and description) across viewports,
+// preserving the order in which they were first reported
+func dedupeIssues(results []api.ViewportResult) []groupedIssue {
+	var groups []groupedIssue
+	index := make(map[string]int)
+
+	for _, result := range results {
+		for _, issue := range result.Issues {
+			key := compare.IssueKey(issue)
+			if i, ok := index[key]; ok {
+				if !containsString(groups[i].Devices, result.Device) {
+					groups[i].Devices = append(groups[i].Devices, result.Device)
+				}
+				continue
+			}
+			index[key] = len(groups)
+			groups = append(groups, groupedIssue{Issue: issue, Devices: []string{result.Device}})
+		}
+	}
+
+	return groups
+}
+
+// httpErrorIssueType is the issue type --error-as-issue reports error pages under
+const httpErrorIssueType = "http-error"
+
+// addHTTPErrorIssues adds a critical issue to each result whose page came back
+// with an HTTP error status, returning the affected devices
+func addHTTPErrorIssues(results []api.ViewportResult) []string {
+	var devices []string
+	for i := range results {
+		result := &results[i]
+		if result.HTTPStatus < 400 {
+			continue
+		}
+		result.Issues = append(result.Issues, api.DetectedIssue{
+			Severity:    "critical",
+			Type:        httpErrorIssueType,
+			Description: fmt.Sprintf("Page returned HTTP %s; the screenshot shows an error page", httpStatusText(result.HTTPStatus)),
+			Suggestion:  "Check that the route exists and the server can render it",
+		})
+		devices = append(devices, result.Device)
+	}
+	return devices
+}
+
+// httpStatusText formats a status code with its reason phrase, e.g. "404 Not Found"
+func httpStatusText(code int) string {
+	if text := http.StatusText(code); text != "" {
+		return fmt.Sprintf("%d %s", code, text)
+	}
+	return strconv.Itoa(code)
+}
+
+// printHTTPStatus shows the HTTP status behind each capture: once if every
+// viewport got the same status, otherwise per device
+func printHTTPStatus(results []api.ViewportResult) {
+	var parts []string
+	first, same := 0, true
+	for _, result := range results {
+		if result.HTTPStatus == 0 {
+			same = false
+			continue
+		}
+		if first == 0 {
+			first = result.HTTPStatus
+		} else if result.HTTPStatus != first {
+			same = false
+		}
+		parts = append(parts, result.Device+" "+renderHTTPStatus(result.HTTPStatus))
+	}
+	if len(parts) == 0 {
+		return
+	}
+	if same {
+		fmt.Printf("HTTP status: %s\n", renderHTTPStatus(first))
+		return
+	}
+	fmt.Printf("HTTP status: %s\n", strings.Join(parts, ", "))
+}
+
+// renderHTTPStatus formats a status code, in red for errors
+func renderHTTPStatus(code int) string {
+	if code >= 400 {
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("1")).Render(httpStatusText(code))
+	}
+	return httpStatusText(code)
+}
+
+// totalIssues counts the issues across all viewports
+func totalIssues(results []api.ViewportResult) int {
+	total := 0
+	for _, result := range results {
+		total += len(result.Issues)
+	}
+	return total
+}
+
+// printDedupedIssues renders each unique issue once with the devices it affects
+func printDedupedIssues(results []api.ViewportResult) {
+	groups := dedupeIssues(results)
+	if len(groups) == 0 {
+		return
+	}
+
+	fmt.Printf("\nUnique Issues: %d (%d across all viewports)\n", len(groups), totalIssues(results))
+	for _, g := range groups {
+		fmt.Printf("  • %s %s: %s %s\n",
+			renderSeverity(g.Issue.Severity),
+			g.Issue.Type,
+			g.Issue.Description,
+			lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render("("+strings.Join(g.Devices, ", ")+")"),
+		)
+	}
+}
+
+// printFailedResources lists failed resource loads captured for each viewport
+func printFailedResources(results []api.ViewportResult) {
+	total := 0
+	for _, result := range results {
+		total += len(result.FailedResources)
+	}
+
+	if total == 0 {
+		fmt.Printf("\nFailed Resources: none\n")
+		return
+	}
+
+	fmt.Printf("\nFailed Resources: %d\n", total)
+	for _, result := range results {
+		if len(result.FailedResources) == 0 {
+			continue
+		}
+		fmt.Printf("  %s\n", lipgloss.NewStyle().Bold(true).Render(result.Device))
+		for _, entry := range result.FailedResources {
+			reason := entry.Error
+			if entry.Status > 0 {
+				reason = fmt.Sprintf("HTTP %d", entry.Status)
+			}
+			kind := ""
+			if entry.ResourceType != "" {
+				kind = entry.ResourceType + " "
+			}
+			fmt.Printf("    • %s%s %s\n", kind, entry.URL,
+				lipgloss.NewStyle().Foreground(lipgloss.Color("1")).Render("("+reason+")"))
+		}
+	}
+}
+
+// containsString reports whether list contains s
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// mixedContentIssueType is the issue type --check-mixed-content reports insecure resources under
+const mixedContentIssueType = "mixed-content"
+
+// addMixedContentIssues adds an issue to each result for every insecure
+// resource its page requested: high when the browser blocked it, as the page
+// is then missing it, medium when it loaded over plain http
+func addMixedContentIssues(results []api.ViewportResult) {
+	for i := range results {
+		result := &results[i]
+		for _, entry := range result.MixedContent {
+			kind := entry.ResourceType
+			if kind == "" {
+				kind = "resource"
+			}
+			issue := api.DetectedIssue{
+				Severity:    "medium",
+				Type:        mixedContentIssueType,
+				Description: fmt.Sprintf("Insecure %s %s loaded over http on an https page", kind, entry.URL),
+				Suggestion:  "Serve the resource over https, or reference it with an https:// or relative URL",
+			}
+			if entry.Blocked {
+				issue.Severity = "high"
+				issue.Description = fmt.Sprintf("Insecure %s %s was blocked by the browser on an https page", kind, entry.URL)
+			}
+			result.Issues = append(result.Issues, issue)
+		}
+	}
+}
+
+// printMixedContent lists the insecure resources found for each viewport of
+// pageURL, which only https pages can have
+func printMixedContent(results []api.ViewportResult, pageURL string) {
+	if !strings.HasPrefix(pageURL, "https://") {
+		fmt.Printf("\nMixed Content: not checked (%s is not an https page)\n", pageURL)
+		return
+	}
+
+	total := 0
+	for _, result := range results {
+		total += len(result.MixedContent)
+	}
+	if total == 0 {
+		fmt.Printf("\nMixed Content: none\n")
+		return
+	}
+
+	fmt.Printf("\nMixed Content: %d\n", total)
+	for _, result := range results {
+		if len(result.MixedContent) == 0 {
+			continue
+		}
+		fmt.Printf("  %s\n", lipgloss.NewStyle().Bold(true).Render(result.Device))
+		for _, entry := range result.MixedContent {
+			kind := ""
+			if entry.ResourceType != "" {
+				kind = entry.ResourceType + " "
+			}
+			status := "loaded"
+			if entry.Blocked {
+				status = "blocked"
+			}
+			fmt.Printf("    • %s%s %s\n", kind, entry.URL,
+				lipgloss.NewStyle().Foreground(lipgloss.Color("1")).Render("("+status+")"))
+		}
+	}
+}
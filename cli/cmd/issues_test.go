@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/law-makers/viewport-cli/pkg/api"
+)
+
+func TestDedupeIssues(t *testing.T) {
+	scroll := api.DetectedIssue{Severity: "high", Type: "horizontal-scroll", Description: "Page scrolls horizontally"}
+	overlap := api.DetectedIssue{Severity: "medium", Type: "overlap", Description: "Header overlaps the menu"}
+	results := []api.ViewportResult{
+		{Device: "mobile", Issues: []api.DetectedIssue{scroll, overlap, scroll}},
+		{Device: "tablet", Issues: []api.DetectedIssue{
+			// Same issue, reported with different case and spacing
+			{Severity: "high", Type: "Horizontal-Scroll", Description: "  page scrolls horizontally "},
+		}},
+		{Device: "desktop"},
+		{Device: "wide", Issues: []api.DetectedIssue{
+			overlap,
+			{Severity: "low", Type: "overlap", Description: "Footer overlaps the menu"},
+		}},
+	}
+
+	got := dedupeIssues(results)
+	want := []groupedIssue{
+		{Issue: scroll, Devices: []string{"mobile", "tablet"}},
+		{Issue: overlap, Devices: []string{"mobile", "wide"}},
+		{Issue: api.DetectedIssue{Severity: "low", Type: "overlap", Description: "Footer overlaps the menu"}, Devices: []string{"wide"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("dedupeIssues =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestDedupeIssuesNone(t *testing.T) {
+	if got := dedupeIssues([]api.ViewportResult{{Device: "mobile"}}); got != nil {
+		t.Errorf("dedupeIssues = %+v, want none", got)
+	}
+}
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+
+	"github.com/law-makers/viewport-cli/pkg/api"
+)
+
+// jsonlLine is one line of --output-format jsonl, describing a finished target
+type jsonlLine struct {
+	Target          string          `json:"target"`
+	ScanID          string          `json:"scanId,omitempty"`
+	Status          string          `json:"status,omitempty"`
+	IssueCount      int             `json:"issueCount"`
+	DurationSeconds float64         `json:"durationSeconds"`
+	Viewports       []jsonlViewport `json:"viewports,omitempty"`
+	Error           string          `json:"error,omitempty"`
+}
+
+// jsonlViewport summarizes one viewport without the screenshot payload
+type jsonlViewport struct {
+	Device     string              `json:"device"`
+	Dimensions api.Dimensions      `json:"dimensions"`
+	Issues     []api.DetectedIssue `json:"issues"`
+}
+
+// issueLine is one line of --output-format ndjson: a detected issue with
+// the scan, target and device it was found on, for log pipelines
+type issueLine struct {
+	ScanID      string `json:"scanId"`
+	Timestamp   string `json:"timestamp,omitempty"`
+	Target      string `json:"target"`
+	Device      string `json:"device"`
+	Severity    string `json:"severity"`
+	Type        string `json:"type"`
+	Description string `json:"description"`
+	Suggestion  string `json:"suggestion,omitempty"`
+}
+
+// jsonlWriter emits one JSON object per line, safe for concurrent use
+type jsonlWriter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	// issues writes a line per issue (ndjson) instead of one per target (jsonl)
+	issues bool
+}
+
+// newJSONLWriter creates a JSON Lines writer on w
+func newJSONLWriter(w io.Writer) *jsonlWriter {
+	return &jsonlWriter{enc: json.NewEncoder(w)}
+}
+
+// newIssueWriter creates a writer of one JSON line per issue on w
+func newIssueWriter(w io.Writer) *jsonlWriter {
+	return &jsonlWriter{enc: json.NewEncoder(w), issues: true}
+}
+
+// emit writes the line for a finished target. Each line is written with a
+// single Write call, so it reaches the consumer as soon as the target is done.
+func (j *jsonlWriter) emit(result batchResult, resp *api.ScanResponse) error {
+	if j.issues {
+		return j.emitIssues(result, resp)
+	}
+	line := jsonlLine{
+		Target:          result.Target,
+		ScanID:          result.ScanID,
+		IssueCount:      result.Issues,
+		DurationSeconds: result.Duration.Seconds(),
+	}
+	if resp != nil {
+		line.Status = resp.Status
+		for _, r := range resp.Results {
+			issues := r.Issues
+			if issues == nil {
+				issues = []api.DetectedIssue{}
+			}
+			line.Viewports = append(line.Viewports, jsonlViewport{
+				Device:     r.Device,
+				Dimensions: r.Dimensions,
+				Issues:     issues,
+			})
+		}
+	}
+	if result.Err != nil {
+		line.Error = result.Err.Error()
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.enc.Encode(line)
+}
+
+// emitIssues writes a line for each issue of a finished target, in viewport
+// order. A target that failed, or found nothing, writes none. Gates such as
+// --max-duration fail a target after its scan completed, so resp may be set
+// even then.
+func (j *jsonlWriter) emitIssues(result batchResult, resp *api.ScanResponse) error {
+	if resp == nil || result.Err != nil {
+		return nil
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for _, r := range resp.Results {
+		for _, issue := range r.Issues {
+			line := issueLine{
+				ScanID:      resp.ScanID,
+				Timestamp:   resp.Timestamp,
+				Target:      result.Target,
+				Device:      r.Device,
+				Severity:    issue.Severity,
+				Type:        issue.Type,
+				Description: issue.Description,
+				Suggestion:  issue.Suggestion,
+			}
+			if err := j.enc.Encode(line); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/law-makers/viewport-cli/pkg/api"
+)
+
+// testScanResponse is a completed scan with issues on two viewports
+func testScanResponse() *api.ScanResponse {
+	return &api.ScanResponse{
+		ScanID:    "scan-1",
+		Status:    "completed",
+		Timestamp: "2026-10-14T08:00:00Z",
+		Results: []api.ViewportResult{
+			{Device: "mobile", Issues: []api.DetectedIssue{
+				{Severity: "high", Type: "horizontal-scroll", Description: "Page scrolls horizontally", Suggestion: "Set max-width: 100%"},
+				{Severity: "low", Type: "small-text", Description: "Text under 12px"},
+			}},
+			{Device: "tablet"},
+			{Device: "desktop", Issues: []api.DetectedIssue{
+				{Severity: "medium", Type: "overlap", Description: "Elements overlap"},
+			}},
+		},
+	}
+}
+
+func TestEmitIssuesWritesOneLinePerIssue(t *testing.T) {
+	var buf bytes.Buffer
+	w := newIssueWriter(&buf)
+	result := batchResult{Target: "https://example.com", ScanID: "scan-1", Issues: 3, Duration: time.Second}
+	if err := w.emit(result, testScanResponse()); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	want := []issueLine{
+		{ScanID: "scan-1", Timestamp: "2026-10-14T08:00:00Z", Target: "https://example.com", Device: "mobile", Severity: "high", Type: "horizontal-scroll", Description: "Page scrolls horizontally", Suggestion: "Set max-width: 100%"},
+		{ScanID: "scan-1", Timestamp: "2026-10-14T08:00:00Z", Target: "https://example.com", Device: "mobile", Severity: "low", Type: "small-text", Description: "Text under 12px"},
+		{ScanID: "scan-1", Timestamp: "2026-10-14T08:00:00Z", Target: "https://example.com", Device: "desktop", Severity: "medium", Type: "overlap", Description: "Elements overlap"},
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines, want %d:\n%s", len(lines), len(want), buf.String())
+	}
+	for i, line := range lines {
+		var got issueLine
+		if err := json.Unmarshal([]byte(line), &got); err != nil {
+			t.Fatalf("line %d is not JSON: %v", i+1, err)
+		}
+		if got != want[i] {
+			t.Errorf("line %d = %+v, want %+v", i+1, got, want[i])
+		}
+	}
+	if strings.Contains(lines[1], "suggestion") {
+		t.Errorf("line 2 has an empty suggestion: %s", lines[1])
+	}
+}
+
+func TestEmitIssuesSkipsFailedTargets(t *testing.T) {
+	tests := []struct {
+		name   string
+		result batchResult
+		resp   *api.ScanResponse
+	}{
+		{"request failed", batchResult{Target: "https://example.com", Err: errors.New("connection refused")}, nil},
+		// Gates fail a target after its scan completed, with the response in hand
+		{"gate failed", batchResult{Target: "https://example.com", ScanID: "scan-1", Err: errors.New("scan took 40s, over --max-duration 30s")}, testScanResponse()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := newIssueWriter(&buf).emit(tt.result, tt.resp); err != nil {
+				t.Fatal(err)
+			}
+			if buf.Len() != 0 {
+				t.Errorf("wrote issue lines for a failed target:\n%s", buf.String())
+			}
+		})
+	}
+}
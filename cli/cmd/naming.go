@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/law-makers/viewport-cli/pkg/api"
+)
+
+// defaultScreenshotName keeps the historical <device>.png layout
+const defaultScreenshotName = "{device}"
+
+// unsafeFileChars matches anything that isn't safe in a file name on every platform
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// screenshotFileNames renders the naming template for every result, making
+// the names filesystem-safe and unique within the scan directory
+func screenshotFileNames(template string, results []api.ViewportResult) []string {
+	if template == "" {
+		template = defaultScreenshotName
+	}
+
+	names := make([]string, len(results))
+	used := make(map[string]bool)
+
+	for i, result := range results {
+		replacer := strings.NewReplacer(
+			"{device}", result.Device,
+			"{width}", strconv.Itoa(result.Dimensions.Width),
+			"{height}", strconv.Itoa(result.Dimensions.Height),
+			"{scheme}", "default",
+			"{index}", fmt.Sprintf("%02d", i+1),
+		)
+
+		base := strings.TrimSuffix(replacer.Replace(template), ".png")
+		base = strings.Trim(unsafeFileChars.ReplaceAllString(base, "-"), "-.")
+		if base == "" {
+			base = fmt.Sprintf("screenshot-%02d", i+1)
+		}
+
+		// Append an index on collision, e.g. two results for the same device
+		name := base + ".png"
+		for n := 2; used[strings.ToLower(name)]; n++ {
+			name = fmt.Sprintf("%s-%d.png", base, n)
+		}
+		used[strings.ToLower(name)] = true
+		names[i] = name
+	}
+
+	return names
+}
+
+// scrollScreenshotName names the capture of a viewport scrolled to offset
+// after its full-page screenshot, e.g. mobile.png -> mobile-at-500.png
+func scrollScreenshotName(name string, offset int) string {
+	return fmt.Sprintf("%s-at-%d.png", strings.TrimSuffix(name, ".png"), offset)
+}
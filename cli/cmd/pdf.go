@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image/png"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/law-makers/viewport-cli/pkg/api"
+)
+
+// pdfMargin is the page margin of --pdf reports, in millimetres
+const pdfMargin = 12.0
+
+// pdfIssueLine is the height reserved per issue below a screenshot, in millimetres
+const pdfIssueLine = 12.0
+
+// pdfReport collects scan results into a PDF with one page per device per URL
+type pdfReport struct {
+	pdf    *fpdf.Fpdf
+	tr     func(string) string // Converts UTF-8 to the core fonts' cp1252
+	pages  int
+	images int
+}
+
+// newPDFReport starts an empty A4 report
+func newPDFReport() *pdfReport {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(pdfMargin, pdfMargin, pdfMargin)
+	pdf.SetAutoPageBreak(true, pdfMargin)
+	pdf.SetTitle("ViewPort-CLI scan report", true)
+	pdf.SetCreator("viewport-cli "+version, true)
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-pdfMargin + 2)
+		pdf.SetFont("Helvetica", "", 8)
+		pdf.SetTextColor(128, 128, 128)
+		pdf.CellFormat(0, 4, fmt.Sprintf("Page %d", pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+	return &pdfReport{pdf: pdf, tr: pdf.UnicodeTranslatorFromDescriptor("")}
+}
+
+// add appends a page for every viewport of a scan
+func (r *pdfReport) add(resp *api.ScanResponse) {
+	target := resp.RequestedURL
+	if target == "" {
+		target = resp.FinalURL
+	}
+	for _, result := range resp.Results {
+		r.addPage(resp, target, result)
+	}
+}
+
+// addPage lays out one viewport: a header with the URL, device and issue
+// count, the screenshot scaled to fit the page, then the issues
+func (r *pdfReport) addPage(resp *api.ScanResponse, target string, result api.ViewportResult) {
+	pdf := r.pdf
+	pdf.AddPage()
+	r.pages++
+
+	pdf.SetFont("Helvetica", "B", 13)
+	pdf.SetTextColor(0, 0, 0)
+	pdf.MultiCell(0, 6, r.tr(target), "", "L", false)
+
+	issues := fmt.Sprintf("%d issues", len(result.Issues))
+	if len(result.Issues) == 1 {
+		issues = "1 issue"
+	} else if resp.AnalysisSkipped && len(result.Issues) == 0 {
+		issues = "analysis skipped"
+	}
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.SetTextColor(96, 96, 96)
+	pdf.MultiCell(0, 5, r.tr(fmt.Sprintf("%s  ·  %d×%d  ·  %s  ·  %s",
+		result.Device, result.Dimensions.Width, result.Dimensions.Height, issues, resp.ScanID)), "", "L", false)
+	pdf.Ln(3)
+
+	if err := r.addScreenshot(result); err != nil {
+		pdf.SetFont("Helvetica", "I", 10)
+		pdf.SetTextColor(192, 0, 0)
+		pdf.MultiCell(0, 5, r.tr(fmt.Sprintf("Screenshot not included: %v", err)), "", "L", false)
+		pdf.Ln(3)
+	}
+
+	for _, issue := range result.Issues {
+		c := severityRGBA(issue.Severity)
+		pdf.SetFont("Helvetica", "B", 10)
+		pdf.SetTextColor(int(c.R), int(c.G), int(c.B))
+		pdf.MultiCell(0, 5, r.tr(fmt.Sprintf("[%s] %s", issue.Severity, issue.Type)), "", "L", false)
+		pdf.SetFont("Helvetica", "", 10)
+		pdf.SetTextColor(0, 0, 0)
+		pdf.MultiCell(0, 5, r.tr(issue.Description), "", "L", false)
+		if issue.Suggestion != "" {
+			pdf.SetTextColor(96, 96, 96)
+			pdf.MultiCell(0, 5, r.tr("Suggestion: "+issue.Suggestion), "", "L", false)
+		}
+		pdf.Ln(2)
+	}
+}
+
+// addScreenshot places the screenshot below the header, scaled down to the
+// page width and to the height left after reserving room for the issues
+func (r *pdfReport) addScreenshot(result api.ViewportResult) error {
+	pdf := r.pdf
+	data, err := base64.StdEncoding.DecodeString(result.ScreenshotBase64)
+	if err != nil {
+		return fmt.Errorf("failed to decode screenshot: %w", err)
+	}
+	if len(data) == 0 {
+		return fmt.Errorf("empty screenshot")
+	}
+	cfg, err := png.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to decode screenshot image: %w", err)
+	}
+	if cfg.Width == 0 || cfg.Height == 0 {
+		return fmt.Errorf("empty screenshot")
+	}
+
+	r.images++
+	name := fmt.Sprintf("screenshot-%d", r.images)
+	opts := fpdf.ImageOptions{ImageType: "PNG"}
+	pdf.RegisterImageOptionsReader(name, opts, bytes.NewReader(data))
+	if err := pdf.Error(); err != nil {
+		// A bad image shouldn't sink the whole report
+		pdf.ClearError()
+		return err
+	}
+
+	pageW, pageH := pdf.GetPageSize()
+	maxW := pageW - 2*pdfMargin
+	top := pdf.GetY()
+	maxH := pageH - pdfMargin - top
+	if n := len(result.Issues); n > 0 {
+		maxH -= min(float64(n)*pdfIssueLine, maxH*0.3)
+	}
+
+	ratio := float64(cfg.Height) / float64(cfg.Width)
+	w, h := maxW, maxW*ratio
+	if h > maxH {
+		h, w = maxH, maxH/ratio
+	}
+	pdf.ImageOptions(name, pdfMargin+(maxW-w)/2, top, w, h, false, opts, 0, "")
+	pdf.SetY(top + h + 4)
+	return nil
+}
+
+// write saves the report, failing if no pages were added
+func (r *pdfReport) write(path string) error {
+	if r.pages == 0 {
+		return fmt.Errorf("no scans completed, nothing to write")
+	}
+	if err := r.pdf.OutputFileAndClose(path); err != nil {
+		return fmt.Errorf("failed to write PDF: %w", err)
+	}
+	return nil
+}
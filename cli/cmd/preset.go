@@ -0,0 +1,411 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/law-makers/viewport-cli/pkg/api"
+	"github.com/spf13/cobra"
+	"go.yaml.in/yaml/v3"
+)
+
+// presetVersion is the preset file format this CLI reads
+const presetVersion = 1
+
+// presetOnlyFlags can't be set by a preset's options: they choose what is
+// scanned, which is the preset's job, or don't make sense in a stored job
+var presetOnlyFlags = map[string]bool{
+	"preset-file":    true,
+	"target":         true,
+	"targets-file":   true,
+	"only-changed":   true,
+	"framework":      true,
+	"selftest":       true,
+	"interactive":    true,
+	"json-schema":    true,
+	"print-curl":     true,
+	"output-stdout":  true,
+	"append-results": true,
+	"compare-to-url": true,
+}
+
+// presetTarget is a target of a preset file with the capture options it
+// overrides
+type presetTarget struct {
+	URL       string
+	Viewports []string // Instead of the scan's viewports
+	FullPage  *bool
+	Selector  string
+	Delay     *int              // Milliseconds, instead of --delay
+	Headers   map[string]string // Merged over the scan's headers
+	devices   []api.DeviceProfile
+}
+
+// scanPreset is a scan job read from a --preset-file
+type scanPreset struct {
+	path    string
+	targets []presetTarget
+}
+
+// urls lists the preset's targets in order
+func (p *scanPreset) urls() []string {
+	urls := make([]string, len(p.targets))
+	for i, t := range p.targets {
+		urls[i] = t.URL
+	}
+	return urls
+}
+
+// resolveDevices checks the per-target viewports against the device matrix,
+// when there is one, and looks up their device list profiles
+func (p *scanPreset) resolveDevices(m *deviceMatrix) error {
+	if m == nil {
+		return nil
+	}
+	for i := range p.targets {
+		t := &p.targets[i]
+		if len(t.Viewports) == 0 {
+			continue
+		}
+		devices, err := deviceProfiles(m, t.Viewports)
+		if err != nil {
+			return fmt.Errorf("%s: target %s: %w", p.path, t.URL, err)
+		}
+		t.devices = devices
+	}
+	return nil
+}
+
+// byURL indexes the targets that override capture options by URL
+func (p *scanPreset) byURL() map[string]presetTarget {
+	targets := make(map[string]presetTarget)
+	for _, t := range p.targets {
+		if t.Viewports != nil || t.FullPage != nil || t.Selector != "" || t.Delay != nil || t.Headers != nil {
+			targets[t.URL] = t
+		}
+	}
+	return targets
+}
+
+// applyPresetTarget overrides req with the preset options of target, which
+// is the URL as listed in the preset (req may be for where it redirects)
+func (s *scanSession) applyPresetTarget(req *api.ScanRequest, target string) {
+	t, ok := s.presetTargets[target]
+	if !ok {
+		return
+	}
+	if t.Viewports != nil {
+		req.Viewports = t.Viewports
+		req.Options.Devices = t.devices
+	}
+	if t.FullPage != nil {
+		req.Options.FullPage = *t.FullPage
+	}
+	if t.Selector != "" {
+		req.Options.Selector = t.Selector
+	}
+	if t.Delay != nil {
+		req.Options.CaptureDelayMs = *t.Delay
+	}
+	if t.Headers != nil {
+		headers := make(map[string]string, len(s.headers)+len(t.Headers))
+		for name, value := range s.headers {
+			headers[name] = value
+		}
+		for name, value := range t.Headers {
+			headers[name] = value
+		}
+		req.Options.Headers = headers
+	}
+}
+
+// presetParser collects the problems of a preset file, each with its line
+type presetParser struct {
+	path  string
+	errs  []error
+	lines []int // Line of each of errs
+}
+
+func (p *presetParser) errorf(node *yaml.Node, format string, args ...any) {
+	p.errs = append(p.errs, fmt.Errorf("%s:%d: %s", p.path, node.Line, fmt.Sprintf(format, args...)))
+	p.lines = append(p.lines, node.Line)
+}
+
+// err joins the problems found, in the order of their lines
+func (p *presetParser) err() error {
+	order := make([]int, len(p.errs))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return p.lines[order[a]] < p.lines[order[b]] })
+	errs := make([]error, len(order))
+	for i, j := range order {
+		errs[i] = p.errs[j]
+	}
+	return errors.Join(errs...)
+}
+
+// loadPresetFile reads a preset file and sets the scan flags of its options
+// that weren't given on the command line. A preset has a "targets" list,
+// each a URL or a mapping of url and optionally viewports, full_page,
+// selector, delay and headers, and "options" keyed by scan flag name. Every
+// entry is checked and all problems reported together.
+func loadPresetFile(cmd *cobra.Command, path string) (*scanPreset, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read preset: %w", err)
+	}
+	var doc yaml.Node
+	if err := yaml.NewDecoder(bytes.NewReader(data)).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse preset %s: %w", path, err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("preset %s must be a mapping with targets and options", path)
+	}
+
+	p := &presetParser{path: path}
+	preset := &scanPreset{path: path}
+	var targets, options *yaml.Node
+	root := doc.Content[0]
+	for _, pair := range p.mapping(root, "") {
+		key, value := pair.key, pair.value
+		switch key.Value {
+		case "version":
+			if v, err := strconv.Atoi(value.Value); err != nil || value.Kind != yaml.ScalarNode || v != presetVersion {
+				p.errorf(value, "unsupported version %q (this CLI reads version %d)", value.Value, presetVersion)
+			}
+		case "targets":
+			targets = value
+		case "options":
+			options = value
+		default:
+			p.errorf(key, "unknown key %q (expected version, targets or options)", key.Value)
+		}
+	}
+
+	if targets == nil {
+		p.errorf(root, "no targets")
+	} else {
+		preset.targets = p.targets(targets)
+	}
+	if options != nil {
+		p.options(cmd, options)
+	}
+	if len(p.errs) > 0 {
+		return nil, p.err()
+	}
+	return preset, nil
+}
+
+// presetPair is a key of a mapping with its value
+type presetPair struct {
+	key, value *yaml.Node
+}
+
+// mapping returns the pairs of a mapping node, reporting duplicate keys and
+// anything that isn't a mapping
+func (p *presetParser) mapping(node *yaml.Node, what string) []presetPair {
+	if node.Kind != yaml.MappingNode {
+		p.errorf(node, "%s must be a mapping", what)
+		return nil
+	}
+	var pairs []presetPair
+	seen := make(map[string]bool)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if seen[key.Value] {
+			p.errorf(key, "duplicate key %q", strings.TrimPrefix(what+"."+key.Value, "."))
+			continue
+		}
+		seen[key.Value] = true
+		pairs = append(pairs, presetPair{key, value})
+	}
+	return pairs
+}
+
+// targets parses the targets list
+func (p *presetParser) targets(node *yaml.Node) []presetTarget {
+	if node.Kind != yaml.SequenceNode {
+		p.errorf(node, "targets must be a list")
+		return nil
+	}
+	if len(node.Content) == 0 {
+		p.errorf(node, "no targets")
+	}
+
+	var targets []presetTarget
+	lines := make(map[string]int)
+	for i, item := range node.Content {
+		where := fmt.Sprintf("targets[%d]", i)
+		t, ok := p.target(item, where)
+		if !ok {
+			continue
+		}
+		if line, dup := lines[t.URL]; dup {
+			p.errorf(item, "%s: %s is already listed on line %d", where, t.URL, line)
+			continue
+		}
+		lines[t.URL] = item.Line
+		targets = append(targets, t)
+	}
+	return targets
+}
+
+// target parses one entry of the targets list
+func (p *presetParser) target(node *yaml.Node, where string) (presetTarget, bool) {
+	var t presetTarget
+	if node.Kind == yaml.ScalarNode {
+		url, err := normalizeTarget(node.Value)
+		if err != nil {
+			p.errorf(node, "%s: %v", where, err)
+			return t, false
+		}
+		t.URL = url
+		return t, true
+	}
+
+	errs := len(p.errs)
+	var urlNode *yaml.Node
+	for _, pair := range p.mapping(node, where) {
+		key, value := pair.key, pair.value
+		field := where + "." + key.Value
+		switch key.Value {
+		case "url":
+			urlNode = value
+		case "viewports":
+			t.Viewports = p.strings(value, field)
+			if value.Kind == yaml.SequenceNode && len(value.Content) == 0 {
+				p.errorf(value, "%s is empty", field)
+			}
+		case "full_page":
+			if fullPage, ok := p.bool(value, field); ok {
+				t.FullPage = &fullPage
+			}
+		case "selector":
+			if value.Kind != yaml.ScalarNode || strings.TrimSpace(value.Value) == "" {
+				p.errorf(value, "%s must be a CSS selector", field)
+				continue
+			}
+			t.Selector = value.Value
+		case "delay":
+			delay, err := strconv.Atoi(value.Value)
+			if value.Kind != yaml.ScalarNode || err != nil || delay < 0 || delay > maxCaptureDelay {
+				p.errorf(value, "%s must be between 0 and %d milliseconds", field, maxCaptureDelay)
+				continue
+			}
+			t.Delay = &delay
+		case "headers":
+			t.Headers = make(map[string]string)
+			for _, pair := range p.mapping(value, field) {
+				name, header := pair.key, pair.value
+				if header.Kind != yaml.ScalarNode {
+					p.errorf(header, "%s.%s must be a string", field, name.Value)
+					continue
+				}
+				t.Headers[name.Value] = header.Value
+			}
+		default:
+			p.errorf(key, "%s: unknown key %q (expected url, viewports, full_page, selector, delay or headers)", where, key.Value)
+		}
+	}
+
+	if urlNode == nil {
+		if node.Kind == yaml.MappingNode {
+			p.errorf(node, "%s needs a url", where)
+		}
+		return t, false
+	}
+	url, err := normalizeTarget(urlNode.Value)
+	if urlNode.Kind != yaml.ScalarNode || err != nil {
+		if err == nil {
+			err = fmt.Errorf("must be a URL")
+		}
+		p.errorf(urlNode, "%s.url: %v", where, err)
+		return t, false
+	}
+	t.URL = url
+	return t, len(p.errs) == errs
+}
+
+// strings parses a list of strings, or one string
+func (p *presetParser) strings(node *yaml.Node, field string) []string {
+	if node.Kind == yaml.ScalarNode {
+		return []string{node.Value}
+	}
+	if node.Kind != yaml.SequenceNode {
+		p.errorf(node, "%s must be a list", field)
+		return nil
+	}
+	values := []string{}
+	for _, item := range node.Content {
+		if item.Kind != yaml.ScalarNode {
+			p.errorf(item, "%s must be a list of strings", field)
+			continue
+		}
+		values = append(values, item.Value)
+	}
+	return values
+}
+
+// bool parses a true or false
+func (p *presetParser) bool(node *yaml.Node, field string) (bool, bool) {
+	var value bool
+	if node.Kind != yaml.ScalarNode || node.Decode(&value) != nil {
+		p.errorf(node, "%s must be true or false", field)
+		return false, false
+	}
+	return value, true
+}
+
+// options sets the scan flags of the options mapping. Flags given on the
+// command line win over the preset.
+func (p *presetParser) options(cmd *cobra.Command, node *yaml.Node) {
+	for _, pair := range p.mapping(node, "options") {
+		key, value := pair.key, pair.value
+		name := key.Value
+		field := "options." + name
+		flag := cmd.Flags().Lookup(name)
+		switch {
+		case flag == nil:
+			p.errorf(key, "%s: unknown scan flag --%s", field, name)
+			continue
+		case presetOnlyFlags[name]:
+			p.errorf(key, "%s: --%s can't be set by a preset", field, name)
+			continue
+		case cmd.InheritedFlags().Lookup(name) != nil:
+			p.errorf(key, "%s: --%s is a global flag; pass it on the command line", field, name)
+			continue
+		case flag.Changed:
+			continue
+		}
+
+		var values []*yaml.Node
+		switch value.Kind {
+		case yaml.ScalarNode:
+			values = []*yaml.Node{value}
+		case yaml.SequenceNode:
+			if !strings.HasSuffix(flag.Value.Type(), "Slice") && !strings.HasSuffix(flag.Value.Type(), "Array") {
+				p.errorf(value, "%s: --%s takes one value, not a list", field, name)
+				continue
+			}
+			values = value.Content
+		default:
+			p.errorf(value, "%s: --%s takes a %s, not a mapping", field, name, flag.Value.Type())
+			continue
+		}
+		for _, item := range values {
+			if item.Kind != yaml.ScalarNode {
+				p.errorf(item, "%s: list items must be values", field)
+				continue
+			}
+			if err := cmd.Flags().Set(name, item.Value); err != nil {
+				p.errorf(item, "%s: invalid value %q for --%s (%s)", field, item.Value, name, flag.Value.Type())
+			}
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// tlsSessionCacheSize is how many TLS sessions the target transport keeps for resumption
+const tlsSessionCacheSize = 64
+
+// dnsCache resolves each host once and dials its cached addresses, so a
+// batch against one host doesn't repeat the lookup for every URL. Failed
+// lookups aren't cached.
+type dnsCache struct {
+	mu     sync.Mutex
+	hosts  map[string][]string
+	dialer net.Dialer
+}
+
+// newDNSCache creates an empty cache
+func newDNSCache() *dnsCache {
+	return &dnsCache{
+		hosts:  make(map[string][]string),
+		dialer: net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second},
+	}
+}
+
+// lookup returns the addresses of host, resolving it on first use
+func (c *dnsCache) lookup(ctx context.Context, host string) ([]string, error) {
+	c.mu.Lock()
+	addrs, ok := c.hosts[host]
+	c.mu.Unlock()
+	if ok {
+		return addrs, nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.hosts[host] = addrs
+	c.mu.Unlock()
+	return addrs, nil
+}
+
+// DialContext dials addr through the cache, trying each address of its host in turn
+func (c *dnsCache) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || net.ParseIP(host) != nil {
+		return c.dialer.DialContext(ctx, network, addr)
+	}
+
+	addrs, err := c.lookup(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	var firstErr error
+	for _, ip := range addrs {
+		conn, err := c.dialer.DialContext(ctx, network, net.JoinHostPort(ip, port))
+		if err == nil {
+			return conn, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return nil, firstErr
+}
+
+// newTargetTransport returns the transport shared by the CLI's own requests
+// to scan targets (the redirect check), so connections and TLS sessions are
+// reused across a batch. A non-empty host is used as the TLS server name, for
+// --host-header. With a cache, host lookups go through it.
+func newTargetTransport(host string, cache *dnsCache) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{ClientSessionCache: tls.NewLRUClientSessionCache(tlsSessionCacheSize)}
+	if host != "" {
+		transport.TLSClientConfig.ServerName = hostname(host)
+	}
+	if cache != nil {
+		transport.DialContext = cache.DialContext
+	}
+	return transport
+}
+
+// targetOrigins returns the distinct scheme://host origins of targets, in order
+func targetOrigins(targets []string) []string {
+	seen := make(map[string]bool)
+	var origins []string
+	for _, target := range targets {
+		u, err := url.Parse(target)
+		if err != nil || u.Host == "" {
+			continue
+		}
+		origin := u.Scheme + "://" + u.Host
+		if !seen[origin] {
+			seen[origin] = true
+			origins = append(origins, origin)
+		}
+	}
+	return origins
+}
+
+// prewarmTargets resolves the host of every target origin and opens a
+// connection to it (completing the TLS handshake for https), leaving the
+// connection idle in s.transport for the first redirect check to reuse.
+// Failures are only reported; the scan will hit them again with full diagnostics.
+func (s *scanSession) prewarmTargets(ctx context.Context, targets []string) {
+	origins := targetOrigins(targets)
+	start := time.Now()
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(origins))
+	client := &http.Client{
+		Transport: s.transport,
+		Timeout:   15 * time.Second,
+		// Only the connection matters, not where the origin sends us
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	for i, origin := range origins {
+		wg.Add(1)
+		go func(i int, origin string) {
+			defer wg.Done()
+			req, err := http.NewRequestWithContext(ctx, http.MethodHead, origin+"/", nil)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			req.Header.Set("User-Agent", cliUserAgent())
+			if hostHeader != "" {
+				req.Host = hostHeader
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}(i, origin)
+	}
+	wg.Wait()
+
+	warmed := 0
+	for i, err := range errs {
+		if err != nil {
+			if verbose {
+				fmt.Printf("ℹ️  Could not pre-warm %s: %v\n", origins[i], err)
+			}
+			continue
+		}
+		warmed++
+	}
+	if verbose {
+		fmt.Printf("ℹ️  Pre-warmed %d of %d hosts in %s\n", warmed, len(origins), time.Since(start).Round(time.Millisecond))
+	}
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// BenchmarkPrewarmSameHost runs the redirect checks of a 50-URL batch on one
+// https host, the part of a scan --prewarm speeds up. Cold dials and
+// handshakes for every URL, as a transport per request would; shared reuses
+// the session transport from the first check on; prewarmed also caches the
+// host lookup and opens the connection before the first check. conns/op
+// counts the TLS connections each batch opened.
+func BenchmarkPrewarmSameHost(b *testing.B) {
+	const urls = 50
+	var conns atomic.Int64
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	srv.StartTLS()
+	defer srv.Close()
+
+	// Reach the server by name so there is a lookup to cache; its certificate is for example.com
+	base := strings.Replace(srv.URL, "127.0.0.1", "localhost", 1)
+	targets := make([]string, urls)
+	for i := range targets {
+		targets[i] = fmt.Sprintf("%s/page-%d", base, i)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(srv.Certificate())
+	transport := func(cache *dnsCache) *http.Transport {
+		t := newTargetTransport("example.com", cache)
+		t.TLSClientConfig.RootCAs = roots
+		return t
+	}
+
+	benchmarks := []struct {
+		name string
+		run  func(ctx context.Context)
+	}{
+		{"cold", func(ctx context.Context) {
+			for _, target := range targets {
+				t := transport(nil)
+				s := &scanSession{transport: t}
+				s.checkRedirects(ctx, target)
+				t.CloseIdleConnections()
+			}
+		}},
+		{"shared", func(ctx context.Context) {
+			s := &scanSession{transport: transport(nil)}
+			for _, target := range targets {
+				s.checkRedirects(ctx, target)
+			}
+			s.transport.CloseIdleConnections()
+		}},
+		{"prewarmed", func(ctx context.Context) {
+			s := &scanSession{transport: transport(newDNSCache())}
+			s.prewarmTargets(ctx, targets)
+			for _, target := range targets {
+				s.checkRedirects(ctx, target)
+			}
+			s.transport.CloseIdleConnections()
+		}},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			conns.Store(0)
+			for i := 0; i < b.N; i++ {
+				bm.run(context.Background())
+			}
+			b.ReportMetric(float64(conns.Load())/float64(b.N), "conns/op")
+		})
+	}
+}
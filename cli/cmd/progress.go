@@ -0,0 +1,277 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/law-makers/viewport-cli/pkg/api"
+)
+
+// batchState is how far a target of a parallel batch has got
+type batchState int
+
+const (
+	batchQueued batchState = iota
+	batchRunning
+	batchDone
+	batchFailed
+)
+
+// batchUpdate reports that one target of a parallel batch changed state.
+// Workers send them over a channel; only the goroutine reading it keeps state.
+type batchUpdate struct {
+	Index   int
+	State   batchState
+	Started time.Time
+	// Result and Resp are set once the target is done or failed
+	Result batchResult
+	Resp   *api.ScanResponse
+}
+
+// batchProgress shows a parallel batch as it runs, one update at a time
+type batchProgress interface {
+	update(u batchUpdate)
+	// finish is called once every worker has stopped
+	finish()
+}
+
+// batchRow is one target's line in the progress table
+type batchRow struct {
+	target  string
+	state   batchState
+	started time.Time
+	result  batchResult
+}
+
+// lineProgress logs a line per finished target, for output that isn't a
+// terminal (or --ci) where a live table can't redraw
+type lineProgress struct {
+	w        io.Writer
+	total    int
+	finished int
+}
+
+func (p *lineProgress) update(u batchUpdate) {
+	if u.State != batchDone && u.State != batchFailed {
+		return
+	}
+	p.finished++
+	fmt.Fprintf(p.w, "[%d/%d] %s\n", p.finished, p.total, rowStatusLine(u.State, u.Result))
+}
+
+func (p *lineProgress) finish() {}
+
+// rowStatusLine describes a finished target, e.g. "✅ https://example.com (3 issues, 2.1s)"
+func rowStatusLine(state batchState, r batchResult) string {
+	if state == batchFailed {
+		reason, _, _ := strings.Cut(r.Err.Error(), "\n")
+		return fmt.Sprintf("❌ %s (%s, %.1fs)", r.Target, reason, r.Duration.Seconds())
+	}
+	return fmt.Sprintf("✅ %s (%d issues, %.1fs)", r.Target, r.Issues, r.Duration.Seconds())
+}
+
+// liveProgress redraws a table of every target in place while the batch
+// runs, and leaves the final table behind
+type liveProgress struct {
+	program *tea.Program
+	out     io.Writer
+	done    chan tea.Model
+}
+
+// newLiveProgress starts drawing the progress table of targets on out
+func newLiveProgress(out io.Writer, targets []string) *liveProgress {
+	model := batchModel{rows: make([]batchRow, len(targets)), start: time.Now()}
+	for i, target := range targets {
+		model.rows[i] = batchRow{target: target}
+	}
+
+	// The scan's own interrupt handling stops the workers, and keys aren't used
+	p := &liveProgress{
+		program: tea.NewProgram(model, tea.WithOutput(out), tea.WithInput(nil), tea.WithoutSignalHandler()),
+		out:     out,
+		done:    make(chan tea.Model, 1),
+	}
+	go func() {
+		final, err := p.program.Run()
+		if err != nil {
+			final = model
+		}
+		p.done <- final
+	}()
+	return p
+}
+
+func (p *liveProgress) update(u batchUpdate) {
+	p.program.Send(u)
+}
+
+func (p *liveProgress) finish() {
+	p.program.Send(batchFinishedMsg{})
+	final := (<-p.done).(batchModel)
+	// The live view is cut to the terminal height; the final table is printed in full
+	fmt.Fprint(p.out, final.table(0))
+}
+
+// batchFinishedMsg tells the progress table that the batch is over
+type batchFinishedMsg struct{}
+
+// batchTickMsg redraws the elapsed times of running targets
+type batchTickMsg struct{}
+
+// batchTick is how often the progress table redraws without updates
+const batchTick = 250 * time.Millisecond
+
+func tickBatch() tea.Cmd {
+	return tea.Tick(batchTick, func(time.Time) tea.Msg { return batchTickMsg{} })
+}
+
+// batchModel is the bubbletea model of the progress table
+type batchModel struct {
+	rows     []batchRow
+	start    time.Time
+	width    int
+	height   int
+	finished bool
+}
+
+func (m batchModel) Init() tea.Cmd {
+	return tickBatch()
+}
+
+func (m batchModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case batchUpdate:
+		row := &m.rows[msg.Index]
+		row.state = msg.State
+		if msg.State == batchRunning {
+			row.started = msg.Started
+		} else {
+			row.result = msg.Result
+		}
+	case batchTickMsg:
+		if !m.finished {
+			return m, tickBatch()
+		}
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+	case batchFinishedMsg:
+		m.finished = true
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+func (m batchModel) View() string {
+	if m.finished {
+		// finish prints the whole table once the program has exited
+		return ""
+	}
+	// Leave room for the header and the line bubbletea keeps for the cursor
+	return m.table(m.height - 3)
+}
+
+// table renders the header and at most limit target rows (0 = all). When
+// rows have to be left out, finished ones go first, then the last queued ones.
+func (m batchModel) table(limit int) string {
+	counts := make(map[batchState]int)
+	for _, row := range m.rows {
+		counts[row.state]++
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %d/%d done", lipgloss.NewStyle().Bold(true).Render("📦 Batch:"),
+		counts[batchDone]+counts[batchFailed], len(m.rows))
+	if counts[batchFailed] > 0 {
+		fmt.Fprintf(&b, ", %s", lipgloss.NewStyle().Foreground(lipgloss.Color("1")).Render(fmt.Sprintf("%d failed", counts[batchFailed])))
+	}
+	fmt.Fprintf(&b, ", %d running, %d queued (%.0fs)\n", counts[batchRunning], counts[batchQueued], time.Since(m.start).Seconds())
+
+	shown := m.visibleRows(limit)
+	for i, row := range m.rows {
+		if shown[i] {
+			b.WriteString(m.renderRow(row))
+			b.WriteString("\n")
+		}
+	}
+	if hidden := len(m.rows) - len(shown); hidden > 0 {
+		b.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render(fmt.Sprintf("  … %d more", hidden)))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// visibleRows picks which rows fit in limit lines, keeping running and
+// failed targets in view, then queued targets in batch order, then the
+// most recently listed done ones
+func (m batchModel) visibleRows(limit int) map[int]bool {
+	shown := make(map[int]bool, len(m.rows))
+	if limit <= 0 || len(m.rows) <= limit {
+		for i := range m.rows {
+			shown[i] = true
+		}
+		return shown
+	}
+
+	// One line goes to the "… more" note
+	limit--
+	pick := func(state batchState, reverse bool) {
+		for n := range m.rows {
+			i := n
+			if reverse {
+				i = len(m.rows) - 1 - n
+			}
+			if len(shown) >= limit {
+				return
+			}
+			if m.rows[i].state == state {
+				shown[i] = true
+			}
+		}
+	}
+	pick(batchRunning, false)
+	pick(batchFailed, false)
+	pick(batchQueued, false)
+	pick(batchDone, true)
+	return shown
+}
+
+// renderRow formats one target's row: state, issues or error, time and URL
+func (m batchModel) renderRow(row batchRow) string {
+	var state, detail string
+	var elapsed time.Duration
+	switch row.state {
+	case batchQueued:
+		state = lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render("·  queued ")
+	case batchRunning:
+		state = lipgloss.NewStyle().Foreground(lipgloss.Color("4")).Render("⏳ running")
+		elapsed = time.Since(row.started)
+	case batchDone:
+		state = lipgloss.NewStyle().Foreground(lipgloss.Color("2")).Render("✅ done   ")
+		detail = fmt.Sprintf("%d issues", row.result.Issues)
+		elapsed = row.result.Duration
+	case batchFailed:
+		state = lipgloss.NewStyle().Foreground(lipgloss.Color("1")).Render("❌ failed ")
+		reason, _, _ := strings.Cut(row.result.Err.Error(), "\n")
+		detail = reason
+		elapsed = row.result.Duration
+	}
+
+	timing := ""
+	if elapsed > 0 {
+		timing = fmt.Sprintf("%.1fs", elapsed.Seconds())
+	}
+	line := fmt.Sprintf("  %s %6s  %s", state, timing, row.target)
+	if detail != "" {
+		line += lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render(" (" + detail + ")")
+	}
+	if m.width > 0 {
+		// A row that wraps would throw off the redraw of the rows below it
+		line = ansi.Truncate(line, m.width, "…")
+	}
+	return line
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// maxRedirects matches the limit used by net/http's default client
+const maxRedirects = 10
+
+// resolveRedirects follows HTTP redirects from target and returns the final
+// URL along with each hop. The target is requested with the scan's headers so
+// authenticated pages resolve the same way the browser will see them; a
+// User-Agent among them takes precedence over userAgent. A non-empty host is
+// sent as the Host header to target's address and any redirect back to it;
+// transport should then use it as the TLS server name (see newTargetTransport).
+// A nil transport selects one for host.
+func resolveRedirects(ctx context.Context, target, userAgent, host string, headers map[string]string, transport http.RoundTripper) (string, []string, error) {
+	if transport == nil {
+		transport = http.DefaultTransport
+		if host != "" {
+			transport = newTargetTransport(host, nil)
+		}
+	}
+
+	var hops []string
+	client := &http.Client{
+		Transport: transport,
+		Timeout:   15 * time.Second,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+			hops = append(hops, req.URL.String())
+			req.Header.Set("User-Agent", userAgent)
+			for name, value := range headers {
+				req.Header.Set(name, value)
+			}
+			if host != "" && req.URL.Host == via[0].URL.Host {
+				req.Host = host
+			}
+			return nil
+		},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", userAgent)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	if host != "" {
+		req.Host = host
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", hops, fmt.Errorf("failed to resolve redirects: %w", err)
+	}
+	defer resp.Body.Close()
+	// Drain a little so the connection can be reused, but don't download whole pages
+	io.CopyN(io.Discard, resp.Body, 4096)
+
+	return resp.Request.URL.String(), hops, nil
+}
+
+// checkRedirects reports whether target redirects. It returns the URL to scan
+// (the final URL unless --no-follow was given) and the final URL, which is
+// empty if it could not be resolved.
+func (s *scanSession) checkRedirects(ctx context.Context, target string) (string, string) {
+	final, hops, err := resolveRedirects(ctx, target, cliUserAgent(), hostHeader, s.headers, s.transport)
+	if err != nil {
+		// The screenshot server may still reach targets this machine can't
+		if verbose {
+			fmt.Printf("ℹ️  Could not check %s for redirects: %v\n", target, err)
+		}
+		return target, ""
+	}
+	if len(hops) == 0 || final == target {
+		return target, final
+	}
+
+	fmt.Printf("↪️  %s redirects to %s", target, final)
+	if len(hops) > 1 {
+		fmt.Printf(" (%d hops)", len(hops))
+	}
+	fmt.Println()
+
+	if noFollow {
+		fmt.Printf("⚠️  Warning: Scanning the original URL (--no-follow); screenshots may show the redirect target\n\n")
+		return target, final
+	}
+	fmt.Printf("   Scanning the final URL instead (use --no-follow to keep the original)\n\n")
+	return final, final
+}
@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	"image/png"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/law-makers/viewport-cli/pkg/api"
+	"github.com/law-makers/viewport-cli/pkg/compare"
+)
+
+// referenceTolerance is how far (0-255) each color channel may drift from the
+// reference and still match, absorbing font antialiasing and resampling blur
+const referenceTolerance = 24
+
+// referenceDiffFileName names the diff overlay against a reference, e.g. mobile.png -> mobile-reference-diff.png
+func referenceDiffFileName(name string) string {
+	return strings.TrimSuffix(name, ".png") + "-reference-diff.png"
+}
+
+// parseReferences parses --reference "<device>=<image.png>" mappings into
+// image paths by device, checking each image exists
+func parseReferences(mappings []string, devices []string) (map[string]string, error) {
+	if len(mappings) == 0 {
+		return nil, nil
+	}
+
+	refs := make(map[string]string, len(mappings))
+	for _, mapping := range mappings {
+		device, path, ok := strings.Cut(mapping, "=")
+		device, path = strings.TrimSpace(device), strings.TrimSpace(path)
+		if !ok || device == "" || path == "" {
+			return nil, fmt.Errorf("invalid --reference %q (expected <device>=<image.png>)", mapping)
+		}
+		if _, dup := refs[device]; dup {
+			return nil, fmt.Errorf("more than one --reference for %s", device)
+		}
+		if _, err := os.Stat(path); err != nil {
+			return nil, fmt.Errorf("invalid --reference for %s: %w", device, err)
+		}
+		refs[device] = path
+	}
+
+	for device := range refs {
+		found := false
+		for _, d := range devices {
+			if d == device {
+				found = true
+				break
+			}
+		}
+		if !found {
+			fmt.Printf("⚠️  Warning: --reference given for %s, which is not being scanned\n", device)
+		}
+	}
+	return refs, nil
+}
+
+// compareReferences pixel-diffs each screenshot with a reference image and
+// records the result on it. The reference is resized to the screenshot's width
+// with its aspect ratio kept, so mockups exported at 2x or another frame width
+// still line up; only the height both images then cover is compared. Failures
+// are reported as warnings and leave the result without a comparison.
+func compareReferences(results []api.ViewportResult, refs map[string]string) {
+	for i := range results {
+		result := &results[i]
+		path, ok := refs[result.Device]
+		if !ok {
+			continue
+		}
+		ref, err := compareReference(result.ScreenshotBase64, path)
+		if err != nil {
+			fmt.Printf("⚠️  Warning: Reference comparison failed for %s: %v\n", result.Device, err)
+			continue
+		}
+		result.Reference = ref
+	}
+}
+
+// compareReference diffs one base64 PNG screenshot against the reference image at path
+func compareReference(screenshotBase64, path string) (*api.ReferenceComparison, error) {
+	data, err := base64.StdEncoding.DecodeString(screenshotBase64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode screenshot: %w", err)
+	}
+	shot, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode screenshot image: %w", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open reference: %w", err)
+	}
+	defer f.Close()
+	refImg, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode reference %s: %w", path, err)
+	}
+
+	shotBounds, refBounds := shot.Bounds(), refImg.Bounds()
+	if shotBounds.Empty() || refBounds.Empty() {
+		return nil, fmt.Errorf("screenshot or reference image is empty")
+	}
+
+	scaled := compare.ScaleToWidth(refImg, shotBounds.Dx())
+	height := min(shotBounds.Dy(), scaled.Bounds().Dy())
+	percent, diff := compare.PixelDiffImages(
+		compare.Crop(shot, shotBounds.Dx(), height),
+		compare.Crop(scaled, shotBounds.Dx(), height),
+		referenceTolerance,
+	)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, diff); err != nil {
+		return nil, fmt.Errorf("failed to encode diff image: %w", err)
+	}
+
+	return &api.ReferenceComparison{
+		Image:          path,
+		Similarity:     100 - percent,
+		Scale:          float64(shotBounds.Dx()) / float64(refBounds.Dx()),
+		ComparedHeight: height,
+		HeightMismatch: shotBounds.Dy() != scaled.Bounds().Dy(),
+		DiffPNG:        buf.Bytes(),
+	}, nil
+}
+
+// printReferences reports the similarity of each screenshot to its reference
+func printReferences(results []api.ViewportResult) {
+	fmt.Printf("\n%s\n", lipgloss.NewStyle().Bold(true).Render("🎨 Design reference:"))
+	for _, result := range results {
+		ref := result.Reference
+		if ref == nil {
+			continue
+		}
+		color := "2"
+		switch {
+		case ref.Similarity < 80:
+			color = "1"
+		case ref.Similarity < 95:
+			color = "3"
+		}
+		line := fmt.Sprintf("  • %s: %s similar to %s", result.Device,
+			lipgloss.NewStyle().Foreground(lipgloss.Color(color)).Render(fmt.Sprintf("%.2f%%", ref.Similarity)), ref.Image)
+		if ref.Scale != 1 {
+			line += fmt.Sprintf(" (scaled %.2fx)", ref.Scale)
+		}
+		if ref.HeightMismatch {
+			line += fmt.Sprintf(", top %dpx compared (heights differ)", ref.ComparedHeight)
+		}
+		fmt.Println(line)
+	}
+}
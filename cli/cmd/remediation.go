@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// remediation is the advice printed for one kind of scan failure
+type remediation struct {
+	problem string // What is wrong, e.g. "Firefox browser binaries not found"
+	// matches reports whether an error message is this kind of failure
+	matches func(errStr string) bool
+	steps   []string // Lines printed under "Solutions:"
+}
+
+// remediations are the failures scan recognizes from their error messages.
+// Only the first match is printed, unless --verbose-errors asks for all.
+var remediations = []remediation{
+	{
+		problem: "Firefox browser binaries not found",
+		matches: func(errStr string) bool {
+			return contains(errStr, "Executable doesn't exist") || contains(errStr, "firefox")
+		},
+		steps: []string{
+			"  1. Install Firefox binaries:",
+			"     npx playwright install firefox",
+			"",
+			"  2. Or install with system dependencies:",
+			"     npx playwright install --with-deps firefox",
+			"",
+			"  3. If you're on Windows and Playwright was already installed,",
+			"     try reinstalling:",
+			"     npm install --force",
+		},
+	},
+	{
+		problem: "System dependencies missing (common in Docker, IDX, or restricted containers)",
+		matches: func(errStr string) bool {
+			return contains(errStr, "missing dependencies") || contains(errStr, "libxcb") ||
+				contains(errStr, "libx11") || contains(errStr, "libgtk")
+		},
+		steps: []string{
+			"  1. Install deps: sudo npx playwright install-deps",
+			"  2. Use xvfb-run wrapper: xvfb-run npx viewport-cli scan --target <url>",
+			"  3. Use in environment with system libraries (Linux desktop, native OS)",
+		},
+	},
+	{
+		problem: "The screenshot server requires a client certificate it trusts (mutual TLS)",
+		matches: func(errStr string) bool {
+			return contains(errStr, "tls: certificate required") || contains(errStr, "tls: bad certificate") ||
+				contains(errStr, "tls: unknown certificate authority")
+		},
+		steps: []string{
+			"  1. Pass the certificate issued for the CLI: --client-cert <cert.pem> --client-key <key.pem>",
+			"  2. Or set api.client_cert and api.client_key in the config file",
+		},
+	},
+	{
+		problem: "The screenshot server's certificate isn't signed by a trusted CA",
+		matches: func(errStr string) bool {
+			return contains(errStr, "x509: certificate signed by unknown authority")
+		},
+		steps: []string{
+			"  1. Trust the CA that signed it: --ca-cert <ca.pem>",
+			"  2. Or set api.ca_cert in the config file",
+		},
+	},
+}
+
+// printRemediation prints the advice for the first remediation matching
+// errStr, if any
+func printRemediation(errStr string) {
+	for _, r := range remediations {
+		if r.matches(errStr) {
+			r.print("")
+			return
+		}
+	}
+}
+
+// print writes the problem and its solutions, with note after the problem
+func (r remediation) print(note string) {
+	fmt.Printf("⚠️  %s%s\n\n", r.problem, note)
+	fmt.Printf("Solutions:\n")
+	for _, step := range r.steps {
+		fmt.Println(step)
+	}
+}
+
+// printVerboseErrors is the --verbose-errors report of a failed scan: every
+// remediation, marking those that match, then extended diagnostics, the raw
+// error and the output of a server this scan started
+func (s *scanSession) printVerboseErrors(target string, err error) {
+	fmt.Printf("Known problems and solutions (--verbose-errors):\n\n")
+	for _, r := range remediations {
+		note := ""
+		if r.matches(err.Error()) {
+			note = " [matches this error]"
+		}
+		r.print(note)
+		fmt.Println()
+	}
+
+	s.printDiagnostics(target)
+	fmt.Printf("  • CLI: viewport-cli %s (%s/%s)\n", version, runtime.GOOS, runtime.GOARCH)
+	switch {
+	case s.serverErr != nil:
+		fmt.Printf("  • Server auto-start: failed: %s\n", strings.ReplaceAll(s.serverErr.Error(), "\n", "\n    "))
+	case s.server != nil && s.server.Spawned():
+		fmt.Printf("  • Server auto-start: started %s\n", s.server.GetURL())
+	case s.server != nil:
+		fmt.Printf("  • Server auto-start: server was already running\n")
+	default:
+		fmt.Printf("  • Server auto-start: off\n")
+	}
+	if s.tlsFiles.CACert != "" || s.tlsFiles.ClientCert != "" {
+		fmt.Printf("  • TLS: CA %q, client certificate %q, key %q\n", s.tlsFiles.CACert, s.tlsFiles.ClientCert, s.tlsFiles.ClientKey)
+	}
+
+	fmt.Printf("\nRaw error:\n")
+	printErrorTree(err, 1)
+
+	if s.server != nil && s.server.Spawned() {
+		if out := s.server.RecentOutput(); out != "" {
+			fmt.Printf("\nRecent server output:\n%s\n", out)
+		} else {
+			fmt.Printf("\nRecent server output: (none)\n")
+		}
+	} else {
+		fmt.Printf("\nRecent server output: not captured (the server wasn't started by this scan; see --server-log)\n")
+	}
+}
+
+// printErrorTree prints err and the errors it wraps, one per line with its type
+func printErrorTree(err error, depth int) {
+	fmt.Printf("%s%T: %q\n", strings.Repeat("  ", depth), err, err.Error())
+	switch wrapped := err.(type) {
+	case interface{ Unwrap() error }:
+		if next := wrapped.Unwrap(); next != nil {
+			printErrorTree(next, depth+1)
+		}
+	case interface{ Unwrap() []error }:
+		for _, next := range wrapped.Unwrap() {
+			printErrorTree(next, depth+1)
+		}
+	}
+}
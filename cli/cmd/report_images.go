@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/law-makers/viewport-cli/pkg/results"
+)
+
+// reportPreviewWidth is the width screenshots over the inline cap are
+// downscaled to before being inlined in a report
+const reportPreviewWidth = 480
+
+// minPreviewWidth is the narrowest preview; images still over the cap at this
+// width are inlined anyway
+const minPreviewWidth = 60
+
+// reportImage is a screenshot shown in a report
+type reportImage struct {
+	Device string
+	Src    template.URL // Inlined PNG, as a data URI
+	Link   string       // Full-resolution PNG written beside the report, if Src is a preview
+	Width  int          // Size of the full-resolution screenshot
+	Height int
+}
+
+// reportImagesDir is the directory full-resolution screenshots are written to
+// beside the report out, e.g. report.html -> report-images
+func reportImagesDir(out string) string {
+	return strings.TrimSuffix(out, filepath.Ext(out)) + "-images"
+}
+
+// addReportImages adds the screenshots of each target's scan to the report.
+// Screenshots over maxBytes are inlined as a downscaled preview linking to
+// the full-resolution PNG, written to reportImagesDir(out); maxBytes 0
+// inlines every screenshot as it is.
+func addReportImages(store results.Store, report *aggregateReport, out string, maxBytes int) error {
+	for i := range report.Targets {
+		target := &report.Targets[i]
+		for _, result := range target.Scan.Results {
+			if result.ScreenshotFile == "" {
+				continue
+			}
+			data, err := store.ReadFile(target.Scan.ScanID, result.ScreenshotFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "⚠️  Warning: skipping %s screenshot of %s: %v\n", result.Device, target.Scan.ScanID, err)
+				continue
+			}
+			image, downscaled, err := newReportImage(data, maxBytes)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "⚠️  Warning: skipping %s screenshot of %s: %v\n", result.Device, target.Scan.ScanID, err)
+				continue
+			}
+			image.Device = result.Device
+			if downscaled {
+				dir := filepath.Join(reportImagesDir(out), target.Scan.ScanID)
+				if err := os.MkdirAll(dir, 0755); err != nil {
+					return fmt.Errorf("failed to create report image directory: %w", err)
+				}
+				name := filepath.Base(result.ScreenshotFile)
+				if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+					return fmt.Errorf("failed to write report image: %w", err)
+				}
+				image.Link = filepath.ToSlash(filepath.Join(filepath.Base(reportImagesDir(out)), target.Scan.ScanID, name))
+			}
+			target.Images = append(target.Images, image)
+		}
+	}
+	return nil
+}
+
+// newReportImage inlines a PNG screenshot, downscaling it until it fits in
+// maxBytes. downscaled reports whether the inlined image is a preview.
+func newReportImage(data []byte, maxBytes int) (image reportImage, downscaled bool, err error) {
+	preview, original, _, err := downscaleScreenshot(data, 0, 0)
+	if err != nil {
+		return reportImage{}, false, err
+	}
+	image = reportImage{Width: original.Width, Height: original.Height}
+
+	if maxBytes > 0 && len(preview) > maxBytes {
+		downscaled = true
+		for width := reportPreviewWidth; ; width /= 2 {
+			if preview, _, _, err = downscaleScreenshot(data, width, 0); err != nil {
+				return reportImage{}, false, err
+			}
+			if len(preview) <= maxBytes || width/2 < minPreviewWidth {
+				break
+			}
+		}
+	}
+	image.Src = template.URL("data:image/png;base64," + base64.StdEncoding.EncodeToString(preview))
+	return image, downscaled, nil
+}
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/law-makers/viewport-cli/pkg/results"
+)
+
+// noisePNG encodes a width×height image of random pixels, which PNG can't
+// compress much
+func noisePNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	rng := rand.New(rand.NewSource(1))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{uint8(rng.Intn(256)), uint8(rng.Intn(256)), uint8(rng.Intn(256)), 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestAddReportImagesCapsLargeScreenshots(t *testing.T) {
+	small := noisePNG(t, 40, 40)
+	large := noisePNG(t, 800, 600)
+	const maxBytes = 64 * 1024
+	if len(small) > maxBytes || len(large) <= maxBytes {
+		t.Fatalf("fixture sizes: small %d, large %d bytes", len(small), len(large))
+	}
+
+	scan := results.ScanMetadata{ScanID: "scan-1", Status: "completed"}
+	scan.Results = []results.Result{
+		{Device: "mobile", ScreenshotFile: "mobile.png"},
+		{Device: "desktop", ScreenshotFile: "desktop.png"},
+	}
+	metadata, err := json.Marshal(scan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := results.NewFSStore(t.TempDir())
+	err = store.SaveScan(&results.ScanFiles{
+		ScanID:   scan.ScanID,
+		Metadata: metadata,
+		Files:    map[string][]byte{"mobile.png": small, "desktop.png": large},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(t.TempDir(), "report.html")
+	report := &aggregateReport{Targets: []aggregateTarget{{URL: "https://example.com", Scan: &scan}}}
+	if err := addReportImages(store, report, out, maxBytes); err != nil {
+		t.Fatal(err)
+	}
+
+	images := report.Targets[0].Images
+	if len(images) != 2 {
+		t.Fatalf("got %d images, want 2", len(images))
+	}
+	mobile, desktop := images[0], images[1]
+
+	if mobile.Link != "" {
+		t.Errorf("small screenshot linked to %q, want it inlined as is", mobile.Link)
+	}
+	if !strings.HasPrefix(string(mobile.Src), "data:image/png;base64,") {
+		t.Errorf("small screenshot src = %.40q, want a data URI", mobile.Src)
+	}
+
+	if desktop.Width != 800 || desktop.Height != 600 {
+		t.Errorf("large screenshot size = %d×%d, want the full resolution 800×600", desktop.Width, desktop.Height)
+	}
+	if len(desktop.Src) > maxBytes*4/3+len("data:image/png;base64,")+4 {
+		t.Errorf("large screenshot inlined as %d bytes, over the %d byte cap", len(desktop.Src), maxBytes)
+	}
+	if desktop.Link != "report-images/scan-1/desktop.png" {
+		t.Errorf("large screenshot link = %q, want report-images/scan-1/desktop.png", desktop.Link)
+	}
+	full, err := os.ReadFile(filepath.Join(filepath.Dir(out), desktop.Link))
+	if err != nil {
+		t.Fatalf("full-resolution screenshot not written: %v", err)
+	}
+	if !bytes.Equal(full, large) {
+		t.Error("full-resolution screenshot differs from the saved one")
+	}
+}
+
+func TestNewReportImageNoCap(t *testing.T) {
+	data := noisePNG(t, 300, 200)
+	image, downscaled, err := newReportImage(data, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if downscaled {
+		t.Error("downscaled with no cap")
+	}
+	if image.Width != 300 || image.Height != 200 {
+		t.Errorf("size = %d×%d, want 300×200", image.Width, image.Height)
+	}
+}
@@ -0,0 +1,467 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/law-makers/viewport-cli/pkg/compare"
+	"github.com/law-makers/viewport-cli/pkg/config"
+	"github.com/law-makers/viewport-cli/pkg/results"
+	"github.com/spf13/cobra"
+)
+
+var resultsShowCmd = &cobra.Command{
+	Use:   "show <scan-id|label>",
+	Short: "Show details of a saved scan",
+	Long:  `Display the viewports and detected issues of a previous scan, looked up by scan ID or label.`,
+	Args:  cobra.ExactArgs(1),
+	RunE:  runResultsShow,
+}
+
+var resultsRenameCmd = &cobra.Command{
+	Use:   "rename <scan-id|label> <label>",
+	Short: "Attach a human-friendly label to a scan",
+	Long: `Attach a label to a saved scan so it can be referred to by name instead of its ID.
+
+Labels may contain letters, digits, '.', '_' and '-', and must be unique across scans.`,
+	Args: cobra.ExactArgs(2),
+	RunE: runResultsRename,
+}
+
+var resultsTagCmd = &cobra.Command{
+	Use:   "tag <scan-id|label> <tag>...",
+	Short: "Tag a saved scan",
+	Long: `Attach one or more tags to a saved scan, e.g. a release or "regression", to find it
+later with "results list --tag" and "results search --tag". A scan can have any number
+of tags, and the same tag can be on many scans.
+
+Tags are trimmed and lowercased, and may contain letters, digits, '.', '_' and '-'.`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: runResultsTag,
+}
+
+var resultsUntagCmd = &cobra.Command{
+	Use:   "untag <scan-id|label> <tag>...",
+	Short: "Remove tags from a saved scan",
+	Long:  `Remove one or more tags from a saved scan. Tags the scan doesn't have are ignored.`,
+	Args:  cobra.MinimumNArgs(2),
+	RunE:  runResultsUntag,
+}
+
+var resultsVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Check saved scans for missing or corrupt files",
+	Long: `Check every saved scan for missing or invalid metadata.json and for screenshots
+referenced by metadata that are missing. Broken scans are hidden from "results list".
+
+With --repair, missing screenshots are restored from metadata and unreadable metadata
+is rebuilt from the screenshots present (issues can't be recovered).`,
+	Args: cobra.NoArgs,
+	RunE: runResultsVerify,
+}
+
+var resultsSearchCmd = &cobra.Command{
+	Use:   "search <query>",
+	Short: "Find saved scans with issues matching a query",
+	Long: `Search the issues of every saved scan for a query, matched case-insensitively against
+issue type, description and suggestion. Matching scans are listed newest first with the
+matching issues.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runResultsSearch,
+}
+
+var resultsReindexCmd = &cobra.Command{
+	Use:   "reindex",
+	Short: "Rebuild the results index",
+	Long: `Rebuild the index that "results list" and other commands use to avoid reading every
+scan's metadata. The index refreshes itself when scans change, so this is only needed
+if it seems out of date, e.g. after editing metadata by hand.`,
+	Args: cobra.NoArgs,
+	RunE: runResultsReindex,
+}
+
+var (
+	repairResults  bool
+	searchSeverity string
+	searchDevice   string
+	searchTags     []string
+)
+
+func init() {
+	resultsCmd.AddCommand(resultsShowCmd)
+	resultsCmd.AddCommand(resultsRenameCmd)
+	resultsCmd.AddCommand(resultsTagCmd)
+	resultsCmd.AddCommand(resultsUntagCmd)
+	resultsCmd.AddCommand(resultsVerifyCmd)
+	resultsCmd.AddCommand(resultsSearchCmd)
+	resultsCmd.AddCommand(resultsReindexCmd)
+
+	resultsVerifyCmd.Flags().BoolVar(&repairResults, "repair", false, "Repair broken scans where possible")
+	resultsSearchCmd.Flags().StringVar(&searchSeverity, "severity", "", "Only match issues of this severity (e.g. high)")
+	resultsSearchCmd.Flags().StringVar(&searchDevice, "device", "", "Only match issues found on this viewport")
+	resultsSearchCmd.Flags().StringArrayVar(&searchTags, "tag", nil, "Only search scans with this tag (repeatable, all must match)")
+}
+
+// openResultsStore opens the results store selected in cfg (defaults if nil).
+// A non-empty outputDir overrides the filesystem backend's directory.
+func openResultsStore(cfg *config.Config, outputDir string) (results.Store, error) {
+	if cfg == nil {
+		cfg = config.DefaultConfig()
+	}
+	if outputDir == "" {
+		outputDir = cfg.Scan.Output
+	}
+	if outputDir == "" {
+		outputDir = "./viewport-results"
+	}
+
+	store, err := results.NewStore(results.StoreOptions{
+		Backend:  cfg.Results.Backend,
+		Dir:      outputDir,
+		Bucket:   cfg.Results.S3.Bucket,
+		Prefix:   cfg.Results.S3.Prefix,
+		Region:   cfg.Results.S3.Region,
+		Endpoint: cfg.Results.S3.Endpoint,
+	})
+	if err != nil {
+		return nil, withExitCode(exitConfigError, fmt.Errorf("failed to open results store: %w", err))
+	}
+	return store, nil
+}
+
+// configuredResultsStore opens the results store from the loaded configuration
+func configuredResultsStore() (results.Store, error) {
+	cfg, err := config.LoadConfig("")
+	var unknownKeys *config.UnknownKeysError
+	if errors.As(err, &unknownKeys) {
+		return nil, withExitCode(exitConfigError, err)
+	}
+	if err != nil {
+		cfg = nil
+	}
+	return openResultsStore(cfg, "")
+}
+
+func runResultsShow(cmd *cobra.Command, args []string) error {
+	store, err := configuredResultsStore()
+	if err != nil {
+		return err
+	}
+
+	scan, err := results.ResolveScan(store, args[0])
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("\n%s\n\n", lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("6")).Render("📋 Scan Details"))
+	fmt.Printf("  • Scan ID: %s\n", lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render(scan.ScanID))
+	if scan.Label != "" {
+		fmt.Printf("  • Label: %s\n", scan.Label)
+	}
+	if len(scan.Tags) > 0 {
+		fmt.Printf("  • Tags: %s\n", strings.Join(scan.Tags, ", "))
+	}
+	if scan.RequestedURL != "" {
+		fmt.Printf("  • Target: %s\n", scan.RequestedURL)
+	}
+	if scan.FinalURL != "" && scan.FinalURL != scan.RequestedURL {
+		fmt.Printf("  • Redirected To: %s\n", scan.FinalURL)
+	}
+	if scan.GitCommit != "" {
+		commit := scan.GitCommit
+		if scan.GitDirty {
+			commit += " (uncommitted changes)"
+		}
+		fmt.Printf("  • Commit: %s\n", commit)
+	}
+	if scan.NetworkProfile != "" || scan.CPUThrottle > 0 {
+		fmt.Printf("  • Throttling: %s\n", describeThrottling(scan.NetworkProfile, scan.CPUThrottle))
+	}
+	if scan.HostHeader != "" {
+		fmt.Printf("  • Host header: %s\n", scan.HostHeader)
+	}
+	if scan.CaptureDelayMs > 0 {
+		fmt.Printf("  • Capture delay: %dms\n", scan.CaptureDelayMs)
+	}
+	if scan.DurationMs > 0 {
+		fmt.Printf("  • Capture duration: %.2fs\n", float64(scan.DurationMs)/1000)
+	}
+	if scan.AnalysisSkipped {
+		fmt.Println("  • Analysis: skipped (screenshot-only scan)")
+	}
+	if scan.DimensionsOnly {
+		fmt.Println("  • Screenshots: none (dimensions-only scan)")
+	}
+	fmt.Printf("  • Timestamp: %s\n", scan.Timestamp)
+	fmt.Printf("  • Status: %s\n", scan.Status)
+	fmt.Println()
+
+	for _, result := range scan.Results {
+		fmt.Printf("%s %s\n",
+			lipgloss.NewStyle().Foreground(lipgloss.Color("4")).Render(strings.ToUpper(result.Device)),
+			lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render(fmt.Sprintf("(%d×%d)", result.Dimensions.Width, result.Dimensions.Height)))
+
+		for _, shot := range result.ScrollScreenshots {
+			fmt.Printf("  📜 Scrolled to %dpx: %s\n", shot.Offset, shot.ScreenshotFile)
+		}
+		for _, capture := range result.ZoomCaptures {
+			fmt.Printf("  🔍 At %d%% zoom: %s (%s)\n", zoomPercent(capture.Zoom), capture.ScreenshotFile, countIssues(len(capture.Issues)))
+		}
+		for _, style := range result.Styles {
+			switch {
+			case style.Error != "":
+				fmt.Printf("  🎨 %s: %s\n", style.Selector, style.Error)
+			case !style.Matched:
+				fmt.Printf("  🎨 %s: %s\n", style.Selector, compare.NoMatch)
+			default:
+				fmt.Printf("  🎨 %s: %s\n", style.Selector, formatStyle(style.Properties))
+			}
+		}
+		if result.A11yTree != nil {
+			fmt.Printf("  ♿ Accessibility tree: %d nodes\n", countA11yNodes(*result.A11yTree))
+		}
+		if len(result.Issues) == 0 {
+			fmt.Println("  ✅ No issues")
+		}
+		for _, issue := range result.Issues {
+			fmt.Printf("  • %s %s: %s\n", renderSeverity(issue.Severity), issue.Type, issue.Description)
+			if issue.Suggestion != "" {
+				fmt.Printf("    💡 %s\n", issue.Suggestion)
+			}
+		}
+		fmt.Println()
+	}
+
+	return nil
+}
+
+func runResultsRename(cmd *cobra.Command, args []string) error {
+	store, err := configuredResultsStore()
+	if err != nil {
+		return err
+	}
+
+	scan, err := results.ResolveScan(store, args[0])
+	if err != nil {
+		return err
+	}
+
+	if err := store.SetLabel(scan.ScanID, args[1]); err != nil {
+		return err
+	}
+
+	fmt.Printf("%s Scan %s labelled %s\n",
+		lipgloss.NewStyle().Foreground(lipgloss.Color("2")).Render("✅"),
+		scan.ScanID,
+		lipgloss.NewStyle().Bold(true).Render(args[1]))
+
+	return nil
+}
+
+func runResultsTag(cmd *cobra.Command, args []string) error {
+	return updateScanTags(args[0], args[1:], true)
+}
+
+func runResultsUntag(cmd *cobra.Command, args []string) error {
+	return updateScanTags(args[0], args[1:], false)
+}
+
+// updateScanTags adds or removes tags on the scan ref names and prints its resulting tags
+func updateScanTags(ref string, tags []string, add bool) error {
+	tags, err := results.NormalizeTags(tags)
+	if err != nil {
+		return withExitCode(exitConfigError, err)
+	}
+
+	store, err := configuredResultsStore()
+	if err != nil {
+		return err
+	}
+	scan, err := results.ResolveScan(store, ref)
+	if err != nil {
+		return err
+	}
+
+	var updated []string
+	if add {
+		updated, err = store.UpdateTags(scan.ScanID, tags, nil)
+	} else {
+		updated, err = store.UpdateTags(scan.ScanID, nil, tags)
+	}
+	if err != nil {
+		return err
+	}
+
+	current := "none"
+	if len(updated) > 0 {
+		current = strings.Join(updated, ", ")
+	}
+	fmt.Printf("%s Scan %s tags: %s\n",
+		lipgloss.NewStyle().Foreground(lipgloss.Color("2")).Render("✅"),
+		scan.ScanID,
+		lipgloss.NewStyle().Bold(true).Render(current))
+	return nil
+}
+
+func runResultsVerify(cmd *cobra.Command, args []string) error {
+	store, err := configuredResultsStore()
+	if err != nil {
+		return err
+	}
+
+	verifier, ok := store.(results.Verifier)
+	if !ok {
+		return fmt.Errorf("results verify is not supported for %s", store.Location())
+	}
+
+	checks, err := verifier.Verify(repairResults)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("\n%s\n\n", lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("6")).Render("🔍 Verifying Scans"))
+
+	healthy, broken, repaired := 0, 0, 0
+	for _, check := range checks {
+		switch {
+		case check.Healthy():
+			healthy++
+			continue
+		case check.Repaired:
+			repaired++
+			fmt.Printf("%s %s\n", lipgloss.NewStyle().Foreground(lipgloss.Color("3")).Render("🔧"), check.ScanID)
+		default:
+			broken++
+			fmt.Printf("%s %s\n", lipgloss.NewStyle().Foreground(lipgloss.Color("1")).Render("❌"), check.ScanID)
+		}
+		for _, problem := range check.Problems {
+			fmt.Printf("  • %s\n", problem)
+		}
+	}
+	if healthy == len(checks) {
+		fmt.Println("  ✅ All scans are healthy")
+	}
+
+	fmt.Printf("\n%s Healthy: %d | Broken: %d | Repaired: %d\n\n",
+		lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render("📊"),
+		healthy, broken, repaired)
+
+	if broken > 0 {
+		if !repairResults {
+			fmt.Printf("%s Run with --repair to fix what can be recovered\n\n",
+				lipgloss.NewStyle().Foreground(lipgloss.Color("3")).Render("💡"))
+		}
+		return fmt.Errorf("%d of %d scans are broken", broken, len(checks))
+	}
+	return nil
+}
+
+func runResultsSearch(cmd *cobra.Command, args []string) error {
+	store, err := configuredResultsStore()
+	if err != nil {
+		return err
+	}
+
+	tags, err := results.NormalizeTags(searchTags)
+	if err != nil {
+		return withExitCode(exitConfigError, err)
+	}
+
+	query := args[0]
+	matches, err := results.SearchIssues(store, results.SearchOptions{
+		Query:    query,
+		Severity: searchSeverity,
+		Device:   searchDevice,
+		Tags:     tags,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to search scans: %w", err)
+	}
+
+	fmt.Printf("\n%s\n\n", lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("6")).Render("🔎 Search Results"))
+
+	if len(matches) == 0 {
+		fmt.Printf("%s No issues matching %q in %s\n\n",
+			lipgloss.NewStyle().Foreground(lipgloss.Color("3")).Render("ℹ️ "),
+			query, store.Location())
+		return nil
+	}
+
+	scanCount, issueCount := 0, 0
+	lastScan := ""
+	for _, match := range matches {
+		if match.Scan.ScanID != lastScan {
+			if lastScan != "" {
+				fmt.Println()
+			}
+			lastScan = match.Scan.ScanID
+			scanCount++
+
+			name := match.Scan.ScanID
+			if match.Scan.Label != "" {
+				name += " (" + match.Scan.Label + ")"
+			}
+			fmt.Printf("%s %s\n", lipgloss.NewStyle().Bold(true).Render(name),
+				lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render(match.Scan.Timestamp))
+		}
+
+		fmt.Printf("  %s\n", lipgloss.NewStyle().Foreground(lipgloss.Color("4")).Render(strings.ToUpper(match.Device)))
+		for _, issue := range match.Issues {
+			issueCount++
+			fmt.Printf("    • %s %s: %s\n", renderSeverity(issue.Severity),
+				highlightMatch(issue.Type, query), highlightMatch(issue.Description, query))
+			if issue.Suggestion != "" {
+				fmt.Printf("      💡 %s\n", highlightMatch(issue.Suggestion, query))
+			}
+		}
+	}
+
+	fmt.Printf("\nFound %d matching issues in %d scans\n\n", issueCount, scanCount)
+	return nil
+}
+
+func runResultsReindex(cmd *cobra.Command, args []string) error {
+	store, err := configuredResultsStore()
+	if err != nil {
+		return err
+	}
+
+	indexer, ok := store.(results.Indexer)
+	if !ok {
+		return fmt.Errorf("results reindex is not supported for %s", store.Location())
+	}
+
+	count, err := indexer.Reindex()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s Indexed %d scans in %s\n",
+		lipgloss.NewStyle().Foreground(lipgloss.Color("2")).Render("✅"),
+		count, store.Location())
+	return nil
+}
+
+// highlightMatch emphasizes each case-insensitive occurrence of query in text
+func highlightMatch(text, query string) string {
+	if query == "" {
+		return text
+	}
+	style := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("3"))
+	lowerText, lowerQuery := strings.ToLower(text), strings.ToLower(query)
+
+	var b strings.Builder
+	for {
+		i := strings.Index(lowerText, lowerQuery)
+		// Lowercasing can change byte lengths outside ASCII; don't highlight then
+		if i < 0 || len(lowerText) != len(text) {
+			b.WriteString(text)
+			return b.String()
+		}
+		b.WriteString(text[:i])
+		b.WriteString(style.Render(text[i : i+len(query)]))
+		text, lowerText = text[i+len(query):], lowerText[i+len(query):]
+	}
+}
@@ -0,0 +1,683 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/law-makers/viewport-cli/pkg/api"
+	"github.com/law-makers/viewport-cli/pkg/compare"
+	"github.com/law-makers/viewport-cli/pkg/git"
+	"github.com/law-makers/viewport-cli/pkg/results"
+	"github.com/spf13/cobra"
+)
+
+var resultsAggregateReportCmd = &cobra.Command{
+	Use:   "aggregate-report [scan|revision...]",
+	Short: "Write one report summarizing the issues of several saved scans",
+	Long: `Write a single HTML or Markdown report of the issues found by several saved scans, e.g.
+the scans of a release. Scans are given by scan ID, label or git revision, as for "results diff",
+or selected from the saved scans with --tag, --since and --until.
+
+Issues are grouped by target URL, then severity. Each target is reported from its newest
+selected scan and compared with the scan of the same target saved before it: counts show the
+trend (▲ more issues, ▼ fewer, = unchanged) and issues the previous scan didn't have are
+marked new.
+
+With --screenshots the HTML report shows each target's screenshots. Screenshots larger
+than --inline-max-kb are inlined as a downscaled preview linking to the full-resolution
+PNG, written next to the report in <report>-images/, so reports of full-page scans
+stay small enough for browsers to open.`,
+	RunE: runResultsAggregateReport,
+}
+
+var (
+	aggregateFormat string
+	aggregateOut    string
+	aggregateTags   []string
+	aggregateSince  string
+	aggregateUntil  string
+	aggregateShots  bool
+	aggregateMaxKB  int
+)
+
+func init() {
+	resultsCmd.AddCommand(resultsAggregateReportCmd)
+
+	resultsAggregateReportCmd.Flags().StringVar(&aggregateFormat, "format", "", "Report format: html or md (default: from the --out extension, else html)")
+	resultsAggregateReportCmd.Flags().StringVar(&aggregateOut, "out", "", "File to write the report to (default: standard output)")
+	resultsAggregateReportCmd.Flags().StringArrayVar(&aggregateTags, "tag", nil, "Report the scans with this tag (repeatable, all must match)")
+	resultsAggregateReportCmd.Flags().StringVar(&aggregateSince, "since", "", "Report the scans taken on or after this date (YYYY-MM-DD or RFC 3339)")
+	resultsAggregateReportCmd.Flags().StringVar(&aggregateUntil, "until", "", "Report the scans taken up to this date, inclusive (YYYY-MM-DD or RFC 3339)")
+	resultsAggregateReportCmd.Flags().BoolVar(&aggregateShots, "screenshots", false, "Show each target's screenshots in the HTML report")
+	resultsAggregateReportCmd.Flags().IntVar(&aggregateMaxKB, "inline-max-kb", 256, "Inline screenshots up to this size in KB; larger ones are inlined downscaled and linked to the full-resolution PNG (0: no cap)")
+}
+
+// unknownTarget groups scans that recorded no URL
+const unknownTarget = "(unknown target)"
+
+// aggregateReport is the issues of several scans, by target
+type aggregateReport struct {
+	Generated  time.Time
+	Scans      int // Scans selected
+	Summary    []severityRow
+	Targets    []aggregateTarget
+	severities []string // Severities found, most severe first
+}
+
+// aggregateTarget is one target's section of the report
+type aggregateTarget struct {
+	URL      string
+	Scan     *results.ScanMetadata // Newest selected scan of the target
+	Previous *results.ScanMetadata // Scan of the target saved before Scan, if any
+	Earlier  int                   // Older selected scans of the target, not reported
+	Rows     []severityRow
+	Groups   []severityGroup
+	Images   []reportImage // Screenshots of Scan, with --screenshots
+	counts   map[string]int
+	prev     map[string]int
+}
+
+// severityRow counts the issues of one severity, with the change since the previous scan
+type severityRow struct {
+	Severity string
+	Count    int
+	Delta    int
+	Compared bool // False when there is no previous scan to compare with
+}
+
+// severityGroup lists the issues of one severity
+type severityGroup struct {
+	Severity string
+	Issues   []aggregateIssue
+}
+
+// aggregateIssue is an issue of a target's scan and the viewport it was found on
+type aggregateIssue struct {
+	results.Issue
+	Device string
+	New    bool // Not found on the same viewport by the previous scan
+}
+
+// Trend renders the change in the row's count, e.g. "▲ +2"
+func (r severityRow) Trend() string {
+	switch {
+	case !r.Compared:
+		return ""
+	case r.Delta > 0:
+		return fmt.Sprintf("▲ +%d", r.Delta)
+	case r.Delta < 0:
+		return fmt.Sprintf("▼ %d", r.Delta)
+	default:
+		return "="
+	}
+}
+
+// totalRow adds up rows, e.g. a target's severities
+func totalRow(rows []severityRow) severityRow {
+	total := severityRow{Severity: "Total"}
+	for _, row := range rows {
+		total.Count += row.Count
+		total.Delta += row.Delta
+		total.Compared = total.Compared || row.Compared
+	}
+	return total
+}
+
+// TrendClass is the HTML class of the row's trend
+func (r severityRow) TrendClass() string {
+	switch {
+	case !r.Compared || r.Delta == 0:
+		return "same"
+	case r.Delta > 0:
+		return "up"
+	default:
+		return "down"
+	}
+}
+
+func runResultsAggregateReport(cmd *cobra.Command, args []string) error {
+	format, err := aggregateReportFormat(aggregateFormat, aggregateOut)
+	if err != nil {
+		return withExitCode(exitConfigError, err)
+	}
+	if aggregateShots && format != "html" {
+		return withExitCode(exitConfigError, fmt.Errorf("--screenshots is only supported for HTML reports"))
+	}
+	if aggregateShots && aggregateOut == "" {
+		return withExitCode(exitConfigError, fmt.Errorf("--screenshots requires --out, to write full-resolution screenshots next to the report"))
+	}
+	if aggregateMaxKB < 0 {
+		return withExitCode(exitConfigError, fmt.Errorf("--inline-max-kb must not be negative, got %d", aggregateMaxKB))
+	}
+	filtered := len(aggregateTags) > 0 || aggregateSince != "" || aggregateUntil != ""
+	if len(args) > 0 && filtered {
+		return withExitCode(exitConfigError, fmt.Errorf("give the scans to report, or select them with --tag, --since and --until, not both"))
+	}
+	if len(args) == 0 && !filtered {
+		return withExitCode(exitConfigError, fmt.Errorf("give the scans to report, or select them with --tag, --since or --until"))
+	}
+
+	store, err := configuredResultsStore()
+	if err != nil {
+		return err
+	}
+	summaries, err := store.ListScans()
+	if err != nil {
+		return fmt.Errorf("failed to list scans: %w", err)
+	}
+
+	var scans []*results.ScanMetadata
+	if len(args) > 0 {
+		seen := make(map[string]bool)
+		for _, ref := range args {
+			scan, err := resolveScanOrRevision(store, strings.TrimSpace(ref))
+			if err != nil {
+				return err
+			}
+			if !seen[scan.ScanID] {
+				seen[scan.ScanID] = true
+				scans = append(scans, scan)
+			}
+		}
+	} else {
+		selected, err := selectAggregateScans(summaries)
+		if err != nil {
+			return withExitCode(exitConfigError, err)
+		}
+		if len(selected) == 0 {
+			return fmt.Errorf("no saved scans match --tag, --since and --until in %s", store.Location())
+		}
+		for _, summary := range selected {
+			scan, err := store.GetScan(summary.ScanID)
+			if err != nil {
+				return fmt.Errorf("failed to read scan %s: %w", summary.ScanID, err)
+			}
+			scans = append(scans, scan)
+		}
+	}
+
+	report := buildAggregateReport(store, summaries, scans)
+	if aggregateShots {
+		if err := addReportImages(store, report, aggregateOut, aggregateMaxKB*1024); err != nil {
+			return err
+		}
+	}
+	var out []byte
+	if format == "md" {
+		out = []byte(renderAggregateMarkdown(report))
+	} else if out, err = renderAggregateHTML(report); err != nil {
+		return err
+	}
+
+	if aggregateOut == "" {
+		_, err := os.Stdout.Write(out)
+		return err
+	}
+	if dir := filepath.Dir(aggregateOut); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create report directory: %w", err)
+		}
+	}
+	if err := os.WriteFile(aggregateOut, out, 0644); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	fmt.Printf("%s Report of %d scans across %d targets written to %s\n",
+		lipgloss.NewStyle().Foreground(lipgloss.Color("2")).Render("✅"),
+		report.Scans, len(report.Targets), aggregateOut)
+	return nil
+}
+
+// aggregateReportFormat resolves --format, falling back to the extension of out
+func aggregateReportFormat(format, out string) (string, error) {
+	switch strings.ToLower(format) {
+	case "html", "md":
+		return strings.ToLower(format), nil
+	case "markdown":
+		return "md", nil
+	case "":
+		switch strings.ToLower(filepath.Ext(out)) {
+		case ".md", ".markdown":
+			return "md", nil
+		}
+		return "html", nil
+	}
+	return "", fmt.Errorf("invalid --format %q (expected html or md)", format)
+}
+
+// selectAggregateScans narrows summaries with --tag, --since and --until
+func selectAggregateScans(summaries []results.ScanSummary) ([]results.ScanSummary, error) {
+	tags, err := results.NormalizeTags(aggregateTags)
+	if err != nil {
+		return nil, err
+	}
+	since, err := parseReportDate("--since", aggregateSince, false)
+	if err != nil {
+		return nil, err
+	}
+	until, err := parseReportDate("--until", aggregateUntil, true)
+	if err != nil {
+		return nil, err
+	}
+	if !since.IsZero() && !until.IsZero() && !until.After(since) {
+		return nil, fmt.Errorf("--until must be after --since")
+	}
+
+	if len(tags) > 0 {
+		summaries = results.FilterByTags(summaries, tags)
+	}
+	// FilterByDateRange excludes both bounds
+	if !since.IsZero() {
+		since = since.Add(-time.Nanosecond)
+	}
+	return results.FilterByDateRange(summaries, since, until), nil
+}
+
+// parseReportDate parses a --since or --until value. A date alone means the
+// start of that day, or with endOfDay the start of the next.
+func parseReportDate(flag, value string, endOfDay bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	day, err := time.ParseInLocation(time.DateOnly, value, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s %q (expected YYYY-MM-DD or RFC 3339)", flag, value)
+	}
+	if endOfDay {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day, nil
+}
+
+// scanTarget is the URL a saved scan is grouped under
+func scanTarget(scan *results.ScanMetadata) string {
+	switch {
+	case scan.RequestedURL != "":
+		return scan.RequestedURL
+	case scan.FinalURL != "":
+		return scan.FinalURL
+	}
+	return unknownTarget
+}
+
+// buildAggregateReport groups scans by target and compares each target's
+// newest scan with the one saved before it. summaries are every saved scan,
+// newest first.
+func buildAggregateReport(store results.Store, summaries []results.ScanSummary, scans []*results.ScanMetadata) *aggregateReport {
+	report := &aggregateReport{Generated: time.Now(), Scans: len(scans)}
+
+	order := make(map[string]int, len(summaries))
+	for i, summary := range summaries {
+		order[summary.ScanID] = i
+	}
+	position := func(scan *results.ScanMetadata) int {
+		if i, ok := order[scan.ScanID]; ok {
+			return i
+		}
+		return len(summaries)
+	}
+	sort.SliceStable(scans, func(i, j int) bool { return position(scans[i]) < position(scans[j]) })
+
+	byURL := make(map[string]*aggregateTarget)
+	var urls []string
+	for _, scan := range scans {
+		url := scanTarget(scan)
+		if target, ok := byURL[url]; ok {
+			target.Earlier++
+			continue
+		}
+		byURL[url] = &aggregateTarget{URL: url, Scan: scan}
+		urls = append(urls, url)
+	}
+	sort.Strings(urls)
+
+	// Saved scans are only read as far back as each target's previous scan
+	cache := make(map[string]*results.ScanMetadata)
+	previous := func(scan *results.ScanMetadata) *results.ScanMetadata {
+		i, ok := order[scan.ScanID]
+		if !ok {
+			return nil
+		}
+		for _, summary := range summaries[i+1:] {
+			older, cached := cache[summary.ScanID]
+			if !cached {
+				older, _ = store.GetScan(summary.ScanID)
+				cache[summary.ScanID] = older
+			}
+			if older != nil && scanTarget(older) == scanTarget(scan) {
+				return older
+			}
+		}
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	for _, url := range urls {
+		target := byURL[url]
+		if url != unknownTarget {
+			target.Previous = previous(target.Scan)
+		}
+		target.counts = countSeverities(target.Scan, seen, &report.severities)
+		if target.Previous != nil {
+			target.prev = countSeverities(target.Previous, seen, &report.severities)
+		}
+		target.Groups = groupIssues(target.Scan, target.Previous)
+		report.Targets = append(report.Targets, *target)
+	}
+	report.severities = orderSeverities(report.severities)
+
+	totals := make(map[string]severityRow)
+	for i := range report.Targets {
+		target := &report.Targets[i]
+		for _, severity := range report.severities {
+			row := severityRow{Severity: severity, Count: target.counts[severity], Compared: target.Previous != nil}
+			if row.Compared {
+				row.Delta = row.Count - target.prev[severity]
+			}
+			if row.Count == 0 && row.Delta == 0 {
+				continue
+			}
+			target.Rows = append(target.Rows, row)
+
+			total := totals[severity]
+			total.Severity = severity
+			total.Count += row.Count
+			total.Delta += row.Delta
+			total.Compared = total.Compared || row.Compared
+			totals[severity] = total
+		}
+		sort.SliceStable(target.Groups, func(a, b int) bool {
+			return severityRank(report.severities, target.Groups[a].Severity) < severityRank(report.severities, target.Groups[b].Severity)
+		})
+	}
+	for _, severity := range report.severities {
+		if total := totals[severity]; total.Count > 0 || total.Delta != 0 {
+			report.Summary = append(report.Summary, total)
+		}
+	}
+	return report
+}
+
+// countSeverities counts a scan's issues by lowercased severity, adding
+// severities not seen before to found
+func countSeverities(scan *results.ScanMetadata, seen map[string]bool, found *[]string) map[string]int {
+	counts := make(map[string]int)
+	for _, result := range scan.Results {
+		for _, issue := range result.Issues {
+			severity := strings.ToLower(issue.Severity)
+			counts[severity]++
+			if !seen[severity] {
+				seen[severity] = true
+				*found = append(*found, severity)
+			}
+		}
+	}
+	return counts
+}
+
+// orderSeverities sorts severities most severe first, with any outside
+// api.Severities after them in name order
+func orderSeverities(severities []string) []string {
+	var ordered, other []string
+	for _, severity := range api.Severities {
+		if containsString(severities, severity) {
+			ordered = append(ordered, severity)
+		}
+	}
+	for _, severity := range severities {
+		if !api.IsSeverity(severity) {
+			other = append(other, severity)
+		}
+	}
+	sort.Strings(other)
+	return append(ordered, other...)
+}
+
+// severityRank is the position of severity in ordered
+func severityRank(ordered []string, severity string) int {
+	for i, s := range ordered {
+		if s == severity {
+			return i
+		}
+	}
+	return len(ordered)
+}
+
+// groupIssues groups the issues of scan by severity, marking those the
+// previous scan didn't find on the same viewport as new
+func groupIssues(scan, previous *results.ScanMetadata) []severityGroup {
+	key := func(device string, issue results.Issue) string {
+		return strings.ToLower(device) + "\x00" + compare.IssueKey(api.DetectedIssue{Type: issue.Type, Description: issue.Description})
+	}
+	before := make(map[string]bool)
+	if previous != nil {
+		for _, result := range previous.Results {
+			for _, issue := range result.Issues {
+				before[key(result.Device, issue)] = true
+			}
+		}
+	}
+
+	var groups []severityGroup
+	index := make(map[string]int)
+	for _, result := range scan.Results {
+		for _, issue := range result.Issues {
+			severity := strings.ToLower(issue.Severity)
+			i, ok := index[severity]
+			if !ok {
+				i = len(groups)
+				index[severity] = i
+				groups = append(groups, severityGroup{Severity: severity})
+			}
+			groups[i].Issues = append(groups[i].Issues, aggregateIssue{
+				Issue:  issue,
+				Device: result.Device,
+				New:    previous != nil && !before[key(result.Device, issue)],
+			})
+		}
+	}
+	return groups
+}
+
+// describeReportScan identifies a scan in the report: ID, label, time and commit
+func describeReportScan(scan *results.ScanMetadata) string {
+	desc := scan.ScanID
+	if scan.Label != "" {
+		desc += " (" + scan.Label + ")"
+	}
+	if t, err := time.Parse(time.RFC3339, scan.Timestamp); err == nil {
+		desc += ", " + t.Local().Format("2006-01-02 15:04")
+	}
+	if scan.GitCommit != "" {
+		desc += ", commit " + git.Short(scan.GitCommit)
+		if scan.GitDirty {
+			desc += " (dirty)"
+		}
+	}
+	return desc
+}
+
+// Notes are remarks about how the target's scan was taken
+func (t aggregateTarget) Notes() []string {
+	var notes []string
+	if strings.EqualFold(t.Scan.Status, "partial") {
+		notes = append(notes, "Partial scan: some viewports failed to capture")
+	}
+	if t.Scan.AnalysisSkipped {
+		notes = append(notes, "Analysis was skipped (screenshot-only scan), so no issues were recorded")
+	}
+	if t.Previous == nil && t.URL != unknownTarget {
+		notes = append(notes, "First scan of this target: no trend")
+	}
+	if t.Earlier > 0 {
+		notes = append(notes, fmt.Sprintf("%d older selected scans of this target are not reported", t.Earlier))
+	}
+	return notes
+}
+
+// ScanDescription describes the target's reported scan
+func (t aggregateTarget) ScanDescription() string {
+	return describeReportScan(t.Scan)
+}
+
+// PreviousDescription describes the scan the target is compared with
+func (t aggregateTarget) PreviousDescription() string {
+	if t.Previous == nil {
+		return ""
+	}
+	return describeReportScan(t.Previous)
+}
+
+// IssueCount is the number of issues of the target's scan
+func (t aggregateTarget) IssueCount() int {
+	n := 0
+	for _, group := range t.Groups {
+		n += len(group.Issues)
+	}
+	return n
+}
+
+// Changed reports whether the target has issues, or had some in its previous scan
+func (t aggregateTarget) Changed() bool {
+	return len(t.Rows) > 0
+}
+
+// markdownEscaper escapes the characters Markdown would format in report text
+var markdownEscaper = strings.NewReplacer(`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "|", `\|`, "<", `\<`, "[", `\[`, "]", `\]`)
+
+// renderAggregateMarkdown renders the report as Markdown
+func renderAggregateMarkdown(report *aggregateReport) string {
+	var b strings.Builder
+	esc := markdownEscaper.Replace
+	severityTable := func(rows []severityRow) {
+		b.WriteString("| Severity | Issues | Trend |\n|---|---:|---|\n")
+		for _, row := range rows {
+			fmt.Fprintf(&b, "| %s | %d | %s |\n", esc(row.Severity), row.Count, row.Trend())
+		}
+		total := totalRow(rows)
+		fmt.Fprintf(&b, "| **%s** | %d | %s |\n\n", total.Severity, total.Count, total.Trend())
+	}
+
+	b.WriteString("# Responsive quality report\n\n")
+	fmt.Fprintf(&b, "Generated %s by viewport-cli %s from %d scans of %d targets.\n\n",
+		report.Generated.Format("2006-01-02 15:04"), esc(version), report.Scans, len(report.Targets))
+	b.WriteString("## Summary\n\n")
+	if len(report.Summary) == 0 {
+		b.WriteString("No issues found in any target.\n\n")
+	} else {
+		b.WriteString("Trends compare each target with its previous scan.\n\n")
+		severityTable(report.Summary)
+	}
+
+	for _, target := range report.Targets {
+		fmt.Fprintf(&b, "## %s\n\n", esc(target.URL))
+		fmt.Fprintf(&b, "- Scan: %s\n", esc(target.ScanDescription()))
+		if target.Previous != nil {
+			fmt.Fprintf(&b, "- Compared with: %s\n", esc(target.PreviousDescription()))
+		}
+		for _, note := range target.Notes() {
+			fmt.Fprintf(&b, "- %s\n", esc(note))
+		}
+		b.WriteString("\n")
+
+		if target.IssueCount() == 0 {
+			b.WriteString("✅ No issues\n\n")
+		}
+		if target.Changed() {
+			severityTable(target.Rows)
+		}
+		for _, group := range target.Groups {
+			fmt.Fprintf(&b, "### %s (%d)\n\n", esc(group.Severity), len(group.Issues))
+			for _, issue := range group.Issues {
+				fmt.Fprintf(&b, "- **%s** %s: %s", esc(issue.Device), esc(issue.Type), esc(issue.Description))
+				if issue.New {
+					b.WriteString(" _(new)_")
+				}
+				b.WriteString("\n")
+				if issue.Suggestion != "" {
+					fmt.Fprintf(&b, "  - 💡 %s\n", esc(issue.Suggestion))
+				}
+			}
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// renderAggregateHTML renders the report as a standalone HTML page
+func renderAggregateHTML(report *aggregateReport) ([]byte, error) {
+	var buf bytes.Buffer
+	data := struct {
+		*aggregateReport
+		Version string
+	}{report, version}
+	if err := aggregateHTML.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to render report: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// aggregateHTML is the template of HTML aggregate reports
+var aggregateHTML = template.Must(template.New("report").Funcs(template.FuncMap{"total": totalRow}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Responsive quality report</title>
+<style>
+  body { font-family: system-ui, sans-serif; margin: 2rem auto; max-width: 60rem; padding: 0 1rem; color: #222; }
+  table { border-collapse: collapse; margin: 0.5rem 0 1rem; }
+  th, td { border: 1px solid #ccc; padding: 0.25rem 0.75rem; text-align: left; }
+  td.count { text-align: right; }
+  tr.total { font-weight: bold; }
+  .up { color: #b00020; } .down { color: #1b7f3b; } .same { color: #666; }
+  .meta { color: #555; } .new { background: #fde7e9; color: #b00020; border-radius: 3px; padding: 0 0.3rem; font-size: 0.8em; }
+  .severity-critical, .severity-high { color: #b00020; } .severity-medium { color: #a15c00; } .severity-low { color: #555; }
+  section { border-top: 1px solid #ddd; margin-top: 2rem; }
+  li { margin: 0.25rem 0; } .suggestion { color: #555; }
+  .screenshots { display: flex; flex-wrap: wrap; gap: 1rem; align-items: flex-start; }
+  figure { margin: 0; } figure img { max-width: 28rem; border: 1px solid #ccc; }
+  figcaption { color: #555; font-size: 0.9em; }
+</style>
+</head>
+<body>
+<h1>Responsive quality report</h1>
+<p class="meta">Generated {{.Generated.Format "2006-01-02 15:04"}} by viewport-cli {{.Version}} from {{.Scans}} scans of {{len .Targets}} targets.</p>
+{{define "severities"}}<table>
+<tr><th>Severity</th><th>Issues</th><th>Trend</th></tr>
+{{range .}}<tr><td class="severity-{{.Severity}}">{{.Severity}}</td><td class="count">{{.Count}}</td><td class="{{.TrendClass}}">{{.Trend}}</td></tr>
+{{end}}{{with total .}}<tr class="total"><td>{{.Severity}}</td><td class="count">{{.Count}}</td><td class="{{.TrendClass}}">{{.Trend}}</td></tr>{{end}}
+</table>{{end}}
+<h2>Summary</h2>
+{{if .Summary}}<p class="meta">Trends compare each target with its previous scan.</p>
+{{template "severities" .Summary}}{{else}}<p>No issues found in any target.</p>{{end}}
+{{range .Targets}}<section>
+<h2>{{.URL}}</h2>
+<ul class="meta">
+<li>Scan: {{.ScanDescription}}</li>
+{{with .PreviousDescription}}<li>Compared with: {{.}}</li>
+{{end}}{{range .Notes}}<li>{{.}}</li>
+{{end}}</ul>
+{{if eq .IssueCount 0}}<p>✅ No issues</p>
+{{end}}{{if .Changed}}{{template "severities" .Rows}}
+{{end}}{{range .Groups}}<h3 class="severity-{{.Severity}}">{{.Severity}} ({{len .Issues}})</h3>
+<ul>
+{{range .Issues}}<li><strong>{{.Device}}</strong> {{.Type}}: {{.Description}}{{if .New}} <span class="new">new</span>{{end}}{{with .Suggestion}}<br><span class="suggestion">💡 {{.}}</span>{{end}}</li>
+{{end}}</ul>
+{{end}}{{with .Images}}<h3>Screenshots</h3>
+<div class="screenshots">
+{{range .}}<figure>{{if .Link}}<a href="{{.Link}}">{{end}}<img src="{{.Src}}" alt="{{.Device}} screenshot">{{if .Link}}</a>{{end}}
+<figcaption>{{.Device}} ({{.Width}}×{{.Height}}){{with .Link}} · <a href="{{.}}">full size</a>{{end}}</figcaption></figure>
+{{end}}</div>
+{{end}}</section>
+{{end}}</body>
+</html>
+`))
@@ -0,0 +1,237 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/law-makers/viewport-cli/pkg/config"
+	"github.com/law-makers/viewport-cli/pkg/results"
+	"github.com/spf13/cobra"
+)
+
+var resultsCompareCmd = &cobra.Command{
+	Use:   "compare --viewport <device> --scans <scan,scan,...>",
+	Short: "Compare one viewport's issues across several saved scans",
+	Long: `Show how the issues of one viewport change across several saved scans, e.g. to follow
+an issue through a series of releases. Each row is an issue type and each column a scan, in
+the order given; cells count the issues of that type, and "n/a" marks scans that didn't
+capture the viewport.
+
+Scans may be given by scan ID, label or git revision, as for "results diff".`,
+	Args: cobra.NoArgs,
+	RunE: runResultsCompare,
+}
+
+var (
+	compareViewport string
+	compareScans    []string
+	compareNoTable  bool
+)
+
+func init() {
+	resultsCmd.AddCommand(resultsCompareCmd)
+
+	resultsCompareCmd.Flags().StringVar(&compareViewport, "viewport", "", "Viewport to compare, e.g. mobile")
+	resultsCompareCmd.Flags().StringSliceVar(&compareScans, "scans", nil, "Scans to compare, oldest first (comma-separated or repeatable)")
+	resultsCompareCmd.Flags().BoolVar(&compareNoTable, "no-table", false, "Print plain aligned columns instead of a table (or display.no_table in config)")
+	resultsCompareCmd.MarkFlagRequired("viewport")
+	resultsCompareCmd.MarkFlagRequired("scans")
+}
+
+// issueMatrix counts one viewport's issues by type, per scan
+type issueMatrix struct {
+	columns []string         // Column headers: label, or scan ID
+	present []bool           // Whether each scan captured the viewport
+	types   []string         // Issue types, sorted
+	counts  []map[string]int // Issue count by type, per scan
+}
+
+// buildIssueMatrix tabulates device's issues across scans
+func buildIssueMatrix(scans []*results.ScanMetadata, device string) issueMatrix {
+	m := issueMatrix{
+		columns: make([]string, len(scans)),
+		present: make([]bool, len(scans)),
+		counts:  make([]map[string]int, len(scans)),
+	}
+	seen := make(map[string]bool)
+	for i, scan := range scans {
+		m.columns[i] = scan.ScanID
+		if scan.Label != "" {
+			m.columns[i] = scan.Label
+		}
+		m.counts[i] = make(map[string]int)
+		for _, result := range scan.Results {
+			if !strings.EqualFold(result.Device, device) {
+				continue
+			}
+			m.present[i] = true
+			for _, issue := range result.Issues {
+				m.counts[i][issue.Type]++
+				if !seen[issue.Type] {
+					seen[issue.Type] = true
+					m.types = append(m.types, issue.Type)
+				}
+			}
+		}
+	}
+	sort.Strings(m.types)
+	return m
+}
+
+// cell renders the count of issueType in scan i
+func (m issueMatrix) cell(i int, issueType string) string {
+	if !m.present[i] {
+		return "n/a"
+	}
+	if n := m.counts[i][issueType]; n > 0 {
+		return strconv.Itoa(n)
+	}
+	return "-"
+}
+
+// total renders the number of issues in scan i
+func (m issueMatrix) total(i int) string {
+	if !m.present[i] {
+		return "n/a"
+	}
+	n := 0
+	for _, count := range m.counts[i] {
+		n += count
+	}
+	return strconv.Itoa(n)
+}
+
+func runResultsCompare(cmd *cobra.Command, args []string) error {
+	if len(compareScans) < 2 {
+		return withExitCode(exitConfigError, fmt.Errorf("--scans needs at least two scans to compare"))
+	}
+
+	store, err := configuredResultsStore()
+	if err != nil {
+		return err
+	}
+	if cfg, err := config.LoadConfig(""); err == nil && cfg.Display.NoTable {
+		compareNoTable = true
+	}
+
+	scans := make([]*results.ScanMetadata, len(compareScans))
+	for i, ref := range compareScans {
+		scans[i], err = resolveScanOrRevision(store, strings.TrimSpace(ref))
+		if err != nil {
+			return err
+		}
+	}
+
+	matrix := buildIssueMatrix(scans, compareViewport)
+	missing := 0
+	for _, present := range matrix.present {
+		if !present {
+			missing++
+		}
+	}
+	if missing == len(scans) {
+		return fmt.Errorf("none of the scans captured the %s viewport", compareViewport)
+	}
+
+	fmt.Printf("\n%s\n\n", lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("6")).Render(
+		fmt.Sprintf("📊 %s issues across %d scans", compareViewport, len(scans))))
+
+	if len(matrix.types) == 0 {
+		fmt.Printf("%s No %s issues in any of the scans\n\n",
+			lipgloss.NewStyle().Foreground(lipgloss.Color("2")).Render("✅"), compareViewport)
+	} else {
+		rows := [][]string{append([]string{"Issue type"}, matrix.columns...)}
+		for _, issueType := range matrix.types {
+			row := []string{issueType}
+			for i := range scans {
+				row = append(row, matrix.cell(i, issueType))
+			}
+			rows = append(rows, row)
+		}
+		totals := []string{"Total"}
+		for i := range scans {
+			totals = append(totals, matrix.total(i))
+		}
+		rows = append(rows, totals)
+
+		if compareNoTable {
+			printPlainMatrix(rows)
+		} else {
+			printMatrixTable(rows)
+		}
+		fmt.Println()
+	}
+
+	// Key each column to the scan it stands for
+	for i, scan := range scans {
+		fmt.Printf("  • %s\n", describeScan(strings.TrimSpace(compareScans[i]), scan))
+	}
+	if missing > 0 {
+		fmt.Printf("\n%s %d of %d scans have no %s result (n/a)\n",
+			lipgloss.NewStyle().Foreground(lipgloss.Color("3")).Render("⚠️ "), missing, len(scans), compareViewport)
+	}
+	fmt.Println()
+	return nil
+}
+
+// matrixWidths returns the width of each column of rows
+func matrixWidths(rows [][]string) []int {
+	widths := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for i, cell := range row {
+			if w := lipgloss.Width(cell); w > widths[i] {
+				widths[i] = w
+			}
+		}
+	}
+	return widths
+}
+
+// printMatrixTable prints rows as a box-drawn table; the first row is the
+// header and the last the totals
+func printMatrixTable(rows [][]string) {
+	widths := matrixWidths(rows)
+	rule := func(left, mid, right string) {
+		parts := make([]string, len(widths))
+		for i, w := range widths {
+			parts[i] = strings.Repeat("─", w+2)
+		}
+		fmt.Println(left + strings.Join(parts, mid) + right)
+	}
+	line := func(row []string) {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			if i == 0 {
+				cells[i] = fmt.Sprintf(" %-*s ", widths[i], cell)
+			} else {
+				cells[i] = fmt.Sprintf(" %*s ", widths[i], cell)
+			}
+		}
+		fmt.Println("│" + strings.Join(cells, "│") + "│")
+	}
+
+	rule("┌", "┬", "┐")
+	line(rows[0])
+	rule("├", "┼", "┤")
+	for _, row := range rows[1 : len(rows)-1] {
+		line(row)
+	}
+	rule("├", "┼", "┤")
+	line(rows[len(rows)-1])
+	rule("└", "┴", "┘")
+}
+
+// printPlainMatrix prints rows as space-aligned columns, for --no-table
+func printPlainMatrix(rows [][]string) {
+	widths := matrixWidths(rows)
+	for _, row := range rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = fmt.Sprintf("%-*s", widths[i], cell)
+		}
+		fmt.Println(strings.TrimRight(strings.Join(cells, "  "), " "))
+	}
+}
@@ -0,0 +1,279 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/law-makers/viewport-cli/pkg/api"
+	"github.com/law-makers/viewport-cli/pkg/compare"
+	"github.com/law-makers/viewport-cli/pkg/git"
+	"github.com/law-makers/viewport-cli/pkg/results"
+	"github.com/spf13/cobra"
+)
+
+var resultsDiffCmd = &cobra.Command{
+	Use:   "diff <scan|revision> <scan|revision>",
+	Short: "Compare the issues of two saved scans",
+	Long: `Compare the issues found by two saved scans, device by device.
+
+Each scan may be given by scan ID, label or git revision. Scans record the commit
+checked out when they ran, so "viewport-cli results diff HEAD~1 HEAD" compares the
+newest scans taken at the previous and current commits.
+
+--format json prints the differences for scripts, e.g. CI gates with rules of
+their own: per device the new and resolved issues and any change of dimensions,
+with totals in "summary". --json-schema prints the schema of that document.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if diffJSONSchema {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(2)(cmd, args)
+	},
+	RunE: runResultsDiff,
+}
+
+var (
+	diffFormat     string
+	diffJSONSchema bool
+)
+
+func init() {
+	resultsCmd.AddCommand(resultsDiffCmd)
+
+	resultsDiffCmd.Flags().StringVar(&diffFormat, "format", "text", "Output format: text, or json for scripts")
+	resultsDiffCmd.Flags().BoolVar(&diffJSONSchema, "json-schema", false, "Print the JSON Schema of --format json output and exit")
+}
+
+// diffDocument is the --format json output of results diff. The old scan is
+// the first argument, the new one the second.
+type diffDocument struct {
+	Old       string      `json:"old"` // How the scan was given and what it resolved to
+	OldScanID string      `json:"oldScanId"`
+	New       string      `json:"new"`
+	NewScanID string      `json:"newScanId"`
+	Summary   diffSummary `json:"summary"`
+	// Devices are those in both scans, then those only in the old scan, then
+	// those only in the new one
+	Devices []diffDevice `json:"devices"`
+}
+
+// diffSummary totals the differences of all devices
+type diffSummary struct {
+	NewIssues       int `json:"newIssues"`
+	ResolvedIssues  int `json:"resolvedIssues"`
+	UnchangedIssues int `json:"unchangedIssues"`
+	// DevicesChanged counts devices in both scans with new or resolved
+	// issues, or a change of dimensions
+	DevicesChanged int `json:"devicesChanged"`
+	DevicesAdded   int `json:"devicesAdded"`
+	DevicesRemoved int `json:"devicesRemoved"`
+}
+
+// Statuses of a diffDevice
+const (
+	diffCompared = "compared" // In both scans
+	diffAdded    = "added"    // Only in the new scan
+	diffRemoved  = "removed"  // Only in the old scan
+)
+
+// diffDevice is the differences found for one device. An added device's
+// issues are all new; a removed device wasn't scanned again, so its issues
+// aren't counted as resolved.
+type diffDevice struct {
+	Device          string              `json:"device"`
+	Status          string              `json:"status"`
+	NewIssues       []api.DetectedIssue `json:"newIssues"`
+	ResolvedIssues  []api.DetectedIssue `json:"resolvedIssues"`
+	UnchangedIssues int                 `json:"unchangedIssues"`
+	// DimensionChange is set when a compared device changed size
+	DimensionChange *dimensionChange      `json:"dimensionChange,omitempty"`
+	StyleChanges    []compare.StyleChange `json:"styleChanges,omitempty"`
+	A11yChanges     []compare.A11yChange  `json:"a11yChanges,omitempty"`
+}
+
+// dimensionChange is a device's size in the old and the new scan
+type dimensionChange struct {
+	Old api.Dimensions `json:"old"`
+	New api.Dimensions `json:"new"`
+}
+
+func runResultsDiff(cmd *cobra.Command, args []string) error {
+	if diffFormat != "text" && diffFormat != "json" {
+		return withExitCode(exitConfigError, fmt.Errorf("invalid --format %q (expected text or json)", diffFormat))
+	}
+	if diffJSONSchema {
+		return printSchema(diffDocument{}, "viewport-cli results diff --format json")
+	}
+
+	store, err := configuredResultsStore()
+	if err != nil {
+		return err
+	}
+
+	a, err := resolveScanOrRevision(store, args[0])
+	if err != nil {
+		return err
+	}
+	b, err := resolveScanOrRevision(store, args[1])
+	if err != nil {
+		return err
+	}
+
+	respA, respB := scanResponseFromMetadata(a), scanResponseFromMetadata(b)
+	report := buildComparison(respA, respB, false)
+	report.PrimaryURL = describeScan(args[0], a)
+	report.ComparisonURL = describeScan(args[1], b)
+
+	if diffFormat == "json" {
+		data, err := json.MarshalIndent(buildDiffDocument(report, respB), "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal diff: %w", err)
+		}
+		fmt.Fprintln(os.Stdout, string(data))
+		return nil
+	}
+
+	fmt.Println()
+	printComparison(report)
+	return nil
+}
+
+// buildDiffDocument converts the comparison of two scans into the --format
+// json document, taking the issues of added devices from the new scan
+func buildDiffDocument(report *comparisonReport, newScan *api.ScanResponse) *diffDocument {
+	doc := &diffDocument{
+		Old:       report.PrimaryURL,
+		OldScanID: report.PrimaryScanID,
+		New:       report.ComparisonURL,
+		NewScanID: report.ComparisonScanID,
+		Devices:   []diffDevice{},
+	}
+
+	for _, dc := range report.Devices {
+		device := diffDevice{
+			Device:          dc.Device,
+			Status:          diffCompared,
+			NewIssues:       dc.OnlyComparison,
+			ResolvedIssues:  dc.OnlyPrimary,
+			UnchangedIssues: len(dc.Common),
+			StyleChanges:    dc.StyleChanges,
+			A11yChanges:     dc.A11yChanges,
+		}
+		if dc.PrimaryDimensions != dc.ComparisonDimensions {
+			device.DimensionChange = &dimensionChange{Old: dc.PrimaryDimensions, New: dc.ComparisonDimensions}
+		}
+		if len(device.NewIssues) > 0 || len(device.ResolvedIssues) > 0 || device.DimensionChange != nil {
+			doc.Summary.DevicesChanged++
+		}
+		doc.Summary.UnchangedIssues += device.UnchangedIssues
+		doc.Devices = append(doc.Devices, device)
+	}
+	for _, name := range report.OnlyPrimaryDevices {
+		doc.Devices = append(doc.Devices, diffDevice{
+			Device:         name,
+			Status:         diffRemoved,
+			NewIssues:      []api.DetectedIssue{},
+			ResolvedIssues: []api.DetectedIssue{},
+		})
+		doc.Summary.DevicesRemoved++
+	}
+	for _, name := range report.OnlyComparisonDevices {
+		device := diffDevice{Device: name, Status: diffAdded, NewIssues: []api.DetectedIssue{}, ResolvedIssues: []api.DetectedIssue{}}
+		for _, result := range newScan.Results {
+			if result.Device == name && len(result.Issues) > 0 {
+				device.NewIssues = result.Issues
+			}
+		}
+		doc.Devices = append(doc.Devices, device)
+		doc.Summary.DevicesAdded++
+	}
+
+	for _, device := range doc.Devices {
+		doc.Summary.NewIssues += len(device.NewIssues)
+		doc.Summary.ResolvedIssues += len(device.ResolvedIssues)
+	}
+	return doc
+}
+
+// resolveScanOrRevision looks a scan up by ID or label, then as a git revision
+// matched against the commit each scan recorded
+func resolveScanOrRevision(store results.Store, ref string) (*results.ScanMetadata, error) {
+	scan, err := results.ResolveScan(store, ref)
+	if err == nil {
+		return scan, nil
+	}
+
+	commit, gitErr := git.ResolveRevision(ref)
+	if gitErr != nil {
+		return nil, fmt.Errorf("no scan found with ID or label %q, and it is not a git revision", ref)
+	}
+	scan, err = results.FindByCommit(store, commit)
+	if err != nil {
+		return nil, fmt.Errorf("no scan found for %s (commit %s)", ref, git.Short(commit))
+	}
+	return scan, nil
+}
+
+// describeScan labels a side of the comparison with how it was asked for and what it resolved to
+func describeScan(ref string, scan *results.ScanMetadata) string {
+	desc := scan.ScanID
+	if scan.GitCommit != "" {
+		desc += " @ " + git.Short(scan.GitCommit)
+		if scan.GitDirty {
+			desc += " (dirty)"
+		}
+	}
+	if scan.RequestedURL != "" {
+		desc += " " + scan.RequestedURL
+	}
+	if ref != scan.ScanID {
+		desc = ref + ": " + desc
+	}
+	return desc
+}
+
+// scanResponseFromMetadata converts a saved scan into the shape the comparison code works on
+func scanResponseFromMetadata(scan *results.ScanMetadata) *api.ScanResponse {
+	resp := &api.ScanResponse{
+		ScanID:       scan.ScanID,
+		Timestamp:    scan.Timestamp,
+		Status:       scan.Status,
+		RequestedURL: scan.RequestedURL,
+		FinalURL:     scan.FinalURL,
+		GitCommit:    scan.GitCommit,
+		GitDirty:     scan.GitDirty,
+	}
+	for _, r := range scan.Results {
+		result := api.ViewportResult{
+			Device:     r.Device,
+			Dimensions: api.Dimensions{Width: r.Dimensions.Width, Height: r.Dimensions.Height},
+		}
+		for _, style := range r.Styles {
+			result.Styles = append(result.Styles, api.ElementStyle(style))
+		}
+		if r.A11yTree != nil {
+			tree := a11yNodeFromMetadata(*r.A11yTree)
+			result.A11yTree = &tree
+		}
+		for _, issue := range r.Issues {
+			result.Issues = append(result.Issues, api.DetectedIssue{
+				Severity:    issue.Severity,
+				Type:        issue.Type,
+				Description: issue.Description,
+				Suggestion:  issue.Suggestion,
+			})
+		}
+		resp.Results = append(resp.Results, result)
+	}
+	return resp
+}
+
+// a11yNodeFromMetadata converts a saved accessibility tree node and its children
+func a11yNodeFromMetadata(node results.A11yNode) api.A11yNode {
+	converted := api.A11yNode{Role: node.Role, Name: node.Name, Level: node.Level}
+	for _, child := range node.Children {
+		converted.Children = append(converted.Children, a11yNodeFromMetadata(child))
+	}
+	return converted
+}
@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/law-makers/viewport-cli/pkg/results"
+)
+
+// oldDiffScan and newDiffScan are saved scans of one page before and after a change:
+// mobile fixed one issue and gained another, tablet grew taller, desktop was
+// dropped and wide added
+const (
+	oldDiffScan = `{
+  "scanId": "scan-old",
+  "status": "completed",
+  "results": [
+    {"device": "mobile", "dimensions": {"width": 375, "height": 2000}, "issues": [
+      {"severity": "high", "type": "horizontal-scroll", "description": "Page scrolls horizontally"},
+      {"severity": "low", "type": "small-text", "description": "Text under 12px"}
+    ]},
+    {"device": "tablet", "dimensions": {"width": 768, "height": 1800}, "issues": []},
+    {"device": "desktop", "dimensions": {"width": 1440, "height": 1600}, "issues": [
+      {"severity": "medium", "type": "overlap", "description": "Elements overlap"}
+    ]}
+  ]
+}`
+	newDiffScan = `{
+  "scanId": "scan-new",
+  "status": "completed",
+  "results": [
+    {"device": "mobile", "dimensions": {"width": 375, "height": 2000}, "issues": [
+      {"severity": "low", "type": "small-text", "description": "Text under 12px"},
+      {"severity": "critical", "type": "text-clipped", "description": "Heading is clipped"}
+    ]},
+    {"device": "tablet", "dimensions": {"width": 768, "height": 2100}, "issues": []},
+    {"device": "wide", "dimensions": {"width": 1920, "height": 1400}, "issues": [
+      {"severity": "medium", "type": "overlap", "description": "Elements overlap"}
+    ]}
+  ]
+}`
+)
+
+// wantDiffJSON is the --format json document for oldDiffScan and newDiffScan
+const wantDiffJSON = `{
+  "old": "scan-old",
+  "oldScanId": "scan-old",
+  "new": "scan-new",
+  "newScanId": "scan-new",
+  "summary": {
+    "newIssues": 2,
+    "resolvedIssues": 1,
+    "unchangedIssues": 1,
+    "devicesChanged": 2,
+    "devicesAdded": 1,
+    "devicesRemoved": 1
+  },
+  "devices": [
+    {
+      "device": "mobile",
+      "status": "compared",
+      "newIssues": [{"severity": "critical", "type": "text-clipped", "description": "Heading is clipped", "suggestion": ""}],
+      "resolvedIssues": [{"severity": "high", "type": "horizontal-scroll", "description": "Page scrolls horizontally", "suggestion": ""}],
+      "unchangedIssues": 1
+    },
+    {
+      "device": "tablet",
+      "status": "compared",
+      "newIssues": [],
+      "resolvedIssues": [],
+      "unchangedIssues": 0,
+      "dimensionChange": {"old": {"width": 768, "height": 1800}, "new": {"width": 768, "height": 2100}}
+    },
+    {
+      "device": "desktop",
+      "status": "removed",
+      "newIssues": [],
+      "resolvedIssues": [],
+      "unchangedIssues": 0
+    },
+    {
+      "device": "wide",
+      "status": "added",
+      "newIssues": [{"severity": "medium", "type": "overlap", "description": "Elements overlap", "suggestion": ""}],
+      "resolvedIssues": [],
+      "unchangedIssues": 0
+    }
+  ]
+}`
+
+// diffJSON builds the results diff --format json output for two saved
+// scans, the way runResultsDiff does
+func diffJSON(t *testing.T, oldScan, newScan string) []byte {
+	t.Helper()
+	store := results.NewFSStore(t.TempDir())
+	var refs []string
+	for _, metadata := range []string{oldScan, newScan} {
+		var scan results.ScanMetadata
+		if err := json.Unmarshal([]byte(metadata), &scan); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.SaveScan(&results.ScanFiles{ScanID: scan.ScanID, Metadata: []byte(metadata)}); err != nil {
+			t.Fatal(err)
+		}
+		refs = append(refs, scan.ScanID)
+	}
+
+	a, err := resolveScanOrRevision(store, refs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := resolveScanOrRevision(store, refs[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	respA, respB := scanResponseFromMetadata(a), scanResponseFromMetadata(b)
+	report := buildComparison(respA, respB, false)
+	report.PrimaryURL = describeScan(refs[0], a)
+	report.ComparisonURL = describeScan(refs[1], b)
+
+	data, err := json.Marshal(buildDiffDocument(report, respB))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// equalJSON reports whether two JSON documents hold the same values
+func equalJSON(t *testing.T, a, b []byte) bool {
+	t.Helper()
+	var va, vb any
+	if err := json.Unmarshal(a, &va); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(b, &vb); err != nil {
+		t.Fatal(err)
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+func TestResultsDiffJSON(t *testing.T) {
+	got := diffJSON(t, oldDiffScan, newDiffScan)
+	if !equalJSON(t, got, []byte(wantDiffJSON)) {
+		t.Errorf("diff document =\n%s\nwant\n%s", got, wantDiffJSON)
+	}
+	checkSchemaJSON(t, diffDocument{}, got)
+}
+
+func TestResultsDiffJSONUnchanged(t *testing.T) {
+	got := diffJSON(t, oldDiffScan, `{"scanId": "scan-again", "status": "completed", "results": [
+    {"device": "mobile", "dimensions": {"width": 375, "height": 2000}, "issues": [
+      {"severity": "high", "type": "horizontal-scroll", "description": "Page scrolls horizontally"},
+      {"severity": "low", "type": "small-text", "description": "text under 12px "}
+    ]}
+  ]}`)
+	checkSchemaJSON(t, diffDocument{}, got)
+
+	var doc diffDocument
+	if err := json.Unmarshal(got, &doc); err != nil {
+		t.Fatal(err)
+	}
+	// Issues match regardless of case and surrounding space
+	want := diffSummary{UnchangedIssues: 2, DevicesRemoved: 2}
+	if doc.Summary != want {
+		t.Errorf("summary = %+v, want %+v", doc.Summary, want)
+	}
+	if len(doc.Devices) != 3 || doc.Devices[0].NewIssues == nil || doc.Devices[0].ResolvedIssues == nil {
+		t.Errorf("devices = %+v, want mobile with empty, not null, issue lists and the two removed devices", doc.Devices)
+	}
+}
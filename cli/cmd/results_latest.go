@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/law-makers/viewport-cli/pkg/results"
+	"github.com/spf13/cobra"
+)
+
+var resultsLatestCmd = &cobra.Command{
+	Use:   "latest",
+	Short: "Show the most recently saved scan",
+	Long: `Show a summary of the most recently saved scan, read from the latest.json pointer that
+every scan updates at the top of the output directory (next to a "latest" symlink to the
+scan's directory, where symlinks are supported).
+
+With --json the pointer is printed as is, for scripts.`,
+	Args: cobra.NoArgs,
+	RunE: runResultsLatest,
+}
+
+var latestJSON bool
+
+func init() {
+	resultsCmd.AddCommand(resultsLatestCmd)
+
+	resultsLatestCmd.Flags().BoolVar(&latestJSON, "json", false, "Print the latest.json pointer as JSON")
+}
+
+func runResultsLatest(cmd *cobra.Command, args []string) error {
+	store, err := configuredResultsStore()
+	if err != nil {
+		return err
+	}
+
+	latest, err := store.Latest()
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("no latest scan recorded in %s; it is written by each scan", store.Location())
+	}
+	if err != nil {
+		return err
+	}
+
+	if latestJSON {
+		data, err := json.MarshalIndent(latest, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode %s: %w", results.LatestFile, err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	// The label may have changed since the pointer was written
+	if scan, err := store.GetScan(latest.ScanID); err == nil {
+		latest.Label = scan.Label
+	}
+
+	location := latest.Path
+	if fsStore, ok := store.(*results.FSStore); ok {
+		location = filepath.Join(fsStore.Location(), latest.Path)
+	}
+
+	fmt.Printf("\n%s\n\n", lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("6")).Render("🕒 Latest Scan"))
+	fmt.Printf("  • Scan ID: %s\n", lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render(latest.ScanID))
+	if latest.Label != "" {
+		fmt.Printf("  • Label: %s\n", latest.Label)
+	}
+	if latest.RequestedURL != "" {
+		fmt.Printf("  • Target: %s\n", latest.RequestedURL)
+	}
+	if latest.GitCommit != "" {
+		fmt.Printf("  • Commit: %s\n", latest.GitCommit)
+	}
+	fmt.Printf("  • Timestamp: %s\n", latest.Timestamp.Format(time.RFC3339))
+	fmt.Printf("  • Status: %s\n", latest.Status)
+	fmt.Printf("  • Viewports: %s\n", strings.Join(latest.Viewports, ", "))
+	fmt.Printf("  • Issues: %d\n", latest.IssueCount)
+	fmt.Printf("  • Location: %s\n", location)
+	fmt.Println()
+	return nil
+}
@@ -0,0 +1,247 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/law-makers/viewport-cli/pkg/api"
+	"github.com/law-makers/viewport-cli/pkg/config"
+	"github.com/law-makers/viewport-cli/pkg/results"
+	"github.com/spf13/cobra"
+)
+
+var resultsMigrateCmd = &cobra.Command{
+	Use:   "migrate --to <dir|s3://bucket/prefix>",
+	Short: "Copy saved scans to another results directory or backend",
+	Long: `Copy every saved scan, with its metadata and screenshots, from one results store to
+another: a directory, or s3://bucket/prefix (the region and endpoint come from
+results.s3 in config). --from defaults to the configured results store.
+
+Scans are copied oldest first and each copy is read back and compared with the
+source. Scans with unreadable metadata or missing screenshots are skipped and
+reported. Scans already copied are left as they are, so an interrupted migration
+can be run again. With --move, each source scan is deleted once its copy checks out.`,
+	Args: cobra.NoArgs,
+	RunE: runResultsMigrate,
+}
+
+var (
+	migrateFrom string
+	migrateTo   string
+	migrateMove bool
+)
+
+func init() {
+	resultsCmd.AddCommand(resultsMigrateCmd)
+
+	resultsMigrateCmd.Flags().StringVar(&migrateFrom, "from", "", "Results directory or s3://bucket/prefix to copy from (default: the configured results store)")
+	resultsMigrateCmd.Flags().StringVar(&migrateTo, "to", "", "Results directory or s3://bucket/prefix to copy to")
+	resultsMigrateCmd.Flags().BoolVar(&migrateMove, "move", false, "Delete each scan from the source once its copy is verified")
+}
+
+// openStoreLocation opens a results directory, or an S3 bucket and prefix
+// given as s3://bucket/prefix with the region and endpoint of cfg
+func openStoreLocation(cfg *config.Config, location string) (results.Store, error) {
+	if cfg == nil {
+		cfg = config.DefaultConfig()
+	}
+	rest, ok := strings.CutPrefix(location, "s3://")
+	if !ok {
+		return results.NewFSStore(location), nil
+	}
+	bucket, prefix, _ := strings.Cut(rest, "/")
+	if bucket == "" {
+		return nil, withExitCode(exitConfigError, fmt.Errorf("invalid results location %q (expected s3://bucket/prefix)", location))
+	}
+	store, err := results.NewS3Store(bucket, prefix, cfg.Results.S3.Region, cfg.Results.S3.Endpoint)
+	if err != nil {
+		return nil, withExitCode(exitConfigError, fmt.Errorf("failed to open results store: %w", err))
+	}
+	return store, nil
+}
+
+func runResultsMigrate(cmd *cobra.Command, args []string) error {
+	if migrateTo == "" {
+		return withExitCode(exitConfigError, fmt.Errorf("--to is required"))
+	}
+	cfg, err := config.LoadConfig("")
+	var unknownKeys *config.UnknownKeysError
+	if errors.As(err, &unknownKeys) {
+		return withExitCode(exitConfigError, err)
+	}
+	if err != nil {
+		cfg = nil
+	}
+
+	var from results.Store
+	if migrateFrom == "" {
+		from, err = openResultsStore(cfg, "")
+	} else {
+		from, err = openStoreLocation(cfg, migrateFrom)
+	}
+	if err != nil {
+		return err
+	}
+	to, err := openStoreLocation(cfg, migrateTo)
+	if err != nil {
+		return err
+	}
+	if filepath.Clean(from.Location()) == filepath.Clean(to.Location()) {
+		return withExitCode(exitConfigError, fmt.Errorf("--from and --to are both %s", to.Location()))
+	}
+
+	scans, err := from.ListScans()
+	if err != nil {
+		return err
+	}
+	var skipped []results.SkippedScan
+	if skipper, ok := from.(results.Skipper); ok {
+		if skipped, err = skipper.SkippedScans(); err != nil {
+			return err
+		}
+	}
+
+	// Labels must stay unique in the destination
+	existing, err := to.ListScans()
+	if err != nil {
+		return err
+	}
+	labels := make(map[string]string, len(existing))
+	for _, scan := range existing {
+		if scan.Label != "" {
+			labels[scan.Label] = scan.ScanID
+		}
+	}
+
+	fmt.Printf("\n%s\n\n", lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("6")).Render("📦 Migrating Scans"))
+	fmt.Printf("From: %s\nTo:   %s\n\n", from.Location(), to.Location())
+
+	migrated, already, failed, removed := 0, 0, 0, 0
+	for _, scan := range skipped {
+		fmt.Printf("%s %s skipped: %s\n", lipgloss.NewStyle().Foreground(lipgloss.Color("3")).Render("⚠️ "), scan.ScanID, scan.Reason)
+	}
+	// Oldest first, so the destination's latest scan is the source's
+	for i := len(scans) - 1; i >= 0; i-- {
+		scan := scans[i]
+		if owner, ok := labels[scan.Label]; ok && scan.Label != "" && owner != scan.ScanID {
+			failed++
+			fmt.Printf("%s %s: label %q is already used by %s in the destination\n",
+				lipgloss.NewStyle().Foreground(lipgloss.Color("1")).Render("❌"), scan.ScanID, scan.Label, owner)
+			continue
+		}
+
+		copied, err := migrateScan(from, to, scan.ScanID)
+		var corrupt *corruptScanError
+		switch {
+		case errors.As(err, &corrupt):
+			skipped = append(skipped, results.SkippedScan{ScanID: scan.ScanID, Reason: corrupt.reason})
+			fmt.Printf("%s %s skipped: %s\n", lipgloss.NewStyle().Foreground(lipgloss.Color("3")).Render("⚠️ "), scan.ScanID, corrupt.reason)
+			continue
+		case err != nil:
+			failed++
+			fmt.Printf("%s %s: %v\n", lipgloss.NewStyle().Foreground(lipgloss.Color("1")).Render("❌"), scan.ScanID, err)
+			continue
+		case copied:
+			migrated++
+			fmt.Printf("%s %s\n", lipgloss.NewStyle().Foreground(lipgloss.Color("2")).Render("✅"), scan.ScanID)
+		default:
+			already++
+			fmt.Printf("%s %s already in the destination\n", lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render("•"), scan.ScanID)
+		}
+		if scan.Label != "" {
+			labels[scan.Label] = scan.ScanID
+		}
+
+		if migrateMove {
+			if err := from.DeleteScan(scan.ScanID); err != nil {
+				failed++
+				fmt.Printf("  %s could not delete the source copy: %v\n", lipgloss.NewStyle().Foreground(lipgloss.Color("1")).Render("❌"), err)
+				continue
+			}
+			removed++
+		}
+	}
+	if len(scans) == 0 && len(skipped) == 0 {
+		fmt.Println("  No saved scans to migrate")
+	}
+
+	fmt.Printf("\n%s Migrated: %d | Already there: %d | Skipped: %d | Failed: %d\n",
+		lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render("📊"),
+		migrated, already, len(skipped), failed)
+	if migrateMove {
+		fmt.Printf("%s Removed from source: %d (skipped and failed scans are kept)\n",
+			lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render("🗑️ "), removed)
+	}
+	fmt.Println()
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d scans failed to migrate", failed, len(scans))
+	}
+	return nil
+}
+
+// corruptScanError is a source scan that can't be migrated as it is
+type corruptScanError struct {
+	reason string
+}
+
+func (e *corruptScanError) Error() string {
+	return e.reason
+}
+
+// migrateScan copies scan scanID with every file its metadata references,
+// then reads the copy back to check it. A scan already in the destination,
+// byte for byte, is only checked; it reports whether anything was copied.
+func migrateScan(from, to results.Store, scanID string) (bool, error) {
+	metadata, err := from.ReadFile(scanID, results.MetadataFile)
+	if err != nil {
+		return false, &corruptScanError{reason: fmt.Sprintf("metadata can't be read: %v", err)}
+	}
+	var scan api.ScanResponse
+	if err := json.Unmarshal(metadata, &scan); err != nil {
+		return false, &corruptScanError{reason: fmt.Sprintf("metadata is invalid: %v", err)}
+	}
+
+	files := make(map[string][]byte)
+	for _, result := range scan.Results {
+		for _, name := range resultFiles(result) {
+			data, err := from.ReadFile(scanID, name)
+			if err != nil {
+				return false, &corruptScanError{reason: fmt.Sprintf("%s of %s is missing", name, result.Device)}
+			}
+			files[name] = data
+		}
+	}
+
+	copied := false
+	if current, err := to.ReadFile(scanID, results.MetadataFile); err != nil {
+		save := &results.ScanFiles{ScanID: scanID, Metadata: metadata, Files: files}
+		if fs, ok := from.(*results.FSStore); ok {
+			save.Dir = fs.TargetDir(scanID)
+		}
+		if err := to.SaveScan(save); err != nil {
+			return false, fmt.Errorf("failed to save copy: %w", err)
+		}
+		copied = true
+	} else if !bytes.Equal(current, metadata) {
+		return false, fmt.Errorf("a different scan with this ID is already in the destination")
+	}
+
+	// The source is only ever removed after this
+	files[results.MetadataFile] = metadata
+	for name, data := range files {
+		saved, err := to.ReadFile(scanID, name)
+		if err != nil {
+			return false, fmt.Errorf("verification failed: %s can't be read back: %w", name, err)
+		}
+		if !bytes.Equal(saved, data) {
+			return false, fmt.Errorf("verification failed: %s differs from the source", name)
+		}
+	}
+	return copied, nil
+}
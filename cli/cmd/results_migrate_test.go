@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/law-makers/viewport-cli/pkg/api"
+	"github.com/law-makers/viewport-cli/pkg/results"
+)
+
+// setMigrateFlags sets --from, --to and --move for a test, with the config
+// search kept away from the user's own config
+func setMigrateFlags(t *testing.T, from, to string, move bool) {
+	t.Helper()
+	oldFrom, oldTo, oldMove := migrateFrom, migrateTo, migrateMove
+	t.Cleanup(func() { migrateFrom, migrateTo, migrateMove = oldFrom, oldTo, oldMove })
+	migrateFrom, migrateTo, migrateMove = from, to, move
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("VIEWPORT_WORK_DIR", "")
+	t.Chdir(t.TempDir())
+}
+
+// migrateTestScan is a scan whose mobile screenshot is mobile.png
+func migrateTestScan(scanID, label string) *api.ScanResponse {
+	mobile := api.ViewportResult{Device: "mobile", ScreenshotFile: "mobile.png"}
+	return &api.ScanResponse{ScanID: scanID, Status: "completed", Label: label, Results: []api.ViewportResult{mobile}}
+}
+
+func TestResultsMigrateMove(t *testing.T) {
+	srcDir, dstDir := t.TempDir(), t.TempDir()
+	src, dst := results.NewFSStore(srcDir), results.NewFSStore(dstDir)
+	shot := map[string][]byte{"mobile.png": []byte("png")}
+
+	// Copied and verified
+	saveTestScan(t, src, migrateTestScan("scan-good", "release"), shot)
+	// Copied by an earlier, interrupted run
+	saveTestScan(t, src, migrateTestScan("scan-copied", ""), shot)
+	saveTestScan(t, dst, migrateTestScan("scan-copied", ""), shot)
+	// Its screenshot is gone
+	saveTestScan(t, src, migrateTestScan("scan-no-shot", ""), nil)
+	// Its label belongs to another scan in the destination
+	saveTestScan(t, src, migrateTestScan("scan-label", "nightly"), shot)
+	saveTestScan(t, dst, migrateTestScan("scan-other", "nightly"), shot)
+	// A different scan already has its ID in the destination
+	saveTestScan(t, src, migrateTestScan("scan-clash", ""), shot)
+	saveTestScan(t, dst, &api.ScanResponse{ScanID: "scan-clash", Status: "failed"}, nil)
+	// Unreadable metadata
+	if err := os.MkdirAll(filepath.Join(srcDir, "scan-corrupt"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(srcDir, "scan-corrupt", results.MetadataFile), []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	setMigrateFlags(t, srcDir, dstDir, true)
+	err := runResultsMigrate(resultsMigrateCmd, nil)
+	if err == nil || !strings.Contains(err.Error(), "2 of 5 scans failed to migrate") {
+		t.Errorf("migrate = %v, want the label and ID clashes counted as failures", err)
+	}
+
+	// Only scans whose copy checked out leave the source
+	for id, wantKept := range map[string]bool{
+		"scan-good":    false,
+		"scan-copied":  false,
+		"scan-no-shot": true,
+		"scan-label":   true,
+		"scan-clash":   true,
+		"scan-corrupt": true,
+	} {
+		_, statErr := os.Stat(filepath.Join(srcDir, id, results.MetadataFile))
+		if kept := statErr == nil; kept != wantKept {
+			t.Errorf("%s kept in the source = %v, want %v", id, kept, wantKept)
+		}
+	}
+
+	for _, id := range []string{"scan-good", "scan-copied"} {
+		data, err := dst.ReadFile(id, "mobile.png")
+		if err != nil || string(data) != "png" {
+			t.Errorf("%s screenshot in the destination = %q, %v, want the source's", id, data, err)
+		}
+	}
+	for _, id := range []string{"scan-no-shot", "scan-label", "scan-corrupt"} {
+		if _, err := dst.GetScan(id); err == nil {
+			t.Errorf("%s was copied, want it left in the source only", id)
+		}
+	}
+	if clash, err := dst.GetScan("scan-clash"); err != nil || clash.Status != "failed" {
+		t.Errorf("destination scan-clash = %+v, %v, want it left as it was", clash, err)
+	}
+}
+
+func TestResultsMigrateWithoutMoveKeepsSource(t *testing.T) {
+	srcDir, dstDir := t.TempDir(), t.TempDir()
+	src := results.NewFSStore(srcDir)
+	saveTestScan(t, src, migrateTestScan("scan-good", ""), map[string][]byte{"mobile.png": []byte("png")})
+
+	setMigrateFlags(t, srcDir, dstDir, false)
+	if err := runResultsMigrate(resultsMigrateCmd, nil); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if _, err := src.GetScan("scan-good"); err != nil {
+		t.Errorf("source scan removed without --move: %v", err)
+	}
+	if _, err := results.NewFSStore(dstDir).GetScan("scan-good"); err != nil {
+		t.Errorf("scan not copied: %v", err)
+	}
+}
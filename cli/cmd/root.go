@@ -4,28 +4,58 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/law-makers/viewport-cli/pkg/config"
 	"github.com/spf13/cobra"
 )
 
+// version is the CLI release, also sent in the default User-Agent
+const version = "1.1.6"
+
+var (
+	noColor      bool
+	workDir      string
+	strictConfig bool
+)
+
 var rootCmd = &cobra.Command{
 	Use:   "viewport-cli",
 	Short: "ViewPort-CLI - Responsive design auditing tool",
 	Long: `A command-line tool for capturing screenshots of websites across multiple device viewports to identify responsive design issues before deployment.`,
-	Version: "1.1.6",
+	Version: version,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		config.SetWorkDir(workDir)
+		config.SetStrict(strictConfig)
+
+		// Errors are reported by the commands that need the config
+		cfg, _ := config.LoadConfig("")
+		applyDisplayConfig(cfg)
+	},
 }
 
 // Execute runs the root command
 func Execute() {
+	defer exitOnPanic()
+
 	err := rootCmd.Execute()
 	if err != nil {
+		// os.Exit skips deferred calls, so stop anything still running first
+		runCleanups()
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitCodeFor(err))
 	}
 }
 
 func init() {
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return withExitCode(exitConfigError, err)
+	})
+	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "Disable colored output")
+	rootCmd.PersistentFlags().BoolVar(&strictConfig, "strict-config", false, "Fail on unknown config keys instead of ignoring them (or strict_config in config)")
+	rootCmd.PersistentFlags().StringVar(&workDir, "work-dir", "", "Directory for config and state files (default ~/.config/viewport-cli, or $VIEWPORT_WORK_DIR)")
+
 	// Add subcommands
 	rootCmd.AddCommand(scanCmd)
 	rootCmd.AddCommand(configCmd)
 	rootCmd.AddCommand(resultsCmd)
+	rootCmd.AddCommand(devicesCmd)
 }
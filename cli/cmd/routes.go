@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+
+	"github.com/law-makers/viewport-cli/pkg/api"
+	"github.com/law-makers/viewport-cli/pkg/config"
+)
+
+// routeOverride is a compiled scan.routes entry
+type routeOverride struct {
+	pattern *regexp.Regexp
+	options config.RouteOptions
+}
+
+// compileRoutes compiles the scan.routes path globs
+func compileRoutes(routes []config.RouteOptions) []routeOverride {
+	compiled := make([]routeOverride, len(routes))
+	for i, route := range routes {
+		compiled[i] = routeOverride{pattern: globPattern(route.Match), options: route}
+	}
+	return compiled
+}
+
+// urlPath returns the path of target for matching against route globs, "/" when it has none
+func urlPath(target string) string {
+	u, err := url.Parse(target)
+	if err != nil || u.Path == "" {
+		return "/"
+	}
+	return u.Path
+}
+
+// matchRoute returns the first route whose glob matches target's path
+func matchRoute(routes []routeOverride, target string) (config.RouteOptions, bool) {
+	path := urlPath(target)
+	for _, route := range routes {
+		if route.pattern.MatchString(path) {
+			return route.options, true
+		}
+	}
+	return config.RouteOptions{}, false
+}
+
+// applyRoute overrides the capture options for target with the first matching route
+func applyRoute(opts *api.ScanOptions, routes []routeOverride, target string) {
+	route, ok := matchRoute(routes, target)
+	if !ok {
+		return
+	}
+	if route.FullPage != nil {
+		opts.FullPage = *route.FullPage
+	}
+	if route.Selector != "" {
+		opts.Selector = route.Selector
+	}
+}
+
+// filterTargets keeps the targets whose path matches an include glob (all
+// targets when there are none) and no exclude glob
+func filterTargets(targets, include, exclude []string) []string {
+	compile := func(globs []string) []*regexp.Regexp {
+		patterns := make([]*regexp.Regexp, len(globs))
+		for i, glob := range globs {
+			patterns[i] = globPattern(glob)
+		}
+		return patterns
+	}
+	matchesAny := func(patterns []*regexp.Regexp, path string) bool {
+		for _, p := range patterns {
+			if p.MatchString(path) {
+				return true
+			}
+		}
+		return false
+	}
+
+	includes, excludes := compile(include), compile(exclude)
+	var kept []string
+	for _, target := range targets {
+		path := urlPath(target)
+		if len(includes) > 0 && !matchesAny(includes, path) {
+			continue
+		}
+		if matchesAny(excludes, path) {
+			continue
+		}
+		kept = append(kept, target)
+	}
+	if skipped := len(targets) - len(kept); skipped > 0 && verbose {
+		fmt.Printf("ℹ️  --include/--exclude skipped %d of %d targets\n", skipped, len(targets))
+	}
+	return kept
+}
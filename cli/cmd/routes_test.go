@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/law-makers/viewport-cli/pkg/api"
+	"github.com/law-makers/viewport-cli/pkg/config"
+)
+
+func TestMatchRoute(t *testing.T) {
+	viewportOnly := false
+	routes := compileRoutes([]config.RouteOptions{
+		{Match: "/", FullPage: &viewportOnly},
+		{Match: "/products/*", Selector: "#product"},
+		{Match: "/docs/**", FullPage: &viewportOnly},
+		{Match: "/blog/**/comments", Selector: ".comments"},
+		{Match: "/page-?.html", Selector: "main"},
+		{Match: "/products/**", Selector: "#listing"},
+	})
+
+	tests := []struct {
+		target string
+		want   string // Match of the route found, "" for none
+	}{
+		{"https://example.com", "/"},
+		{"https://example.com/", "/"},
+		{"https://example.com/products/shoe", "/products/*"},
+		{"https://example.com/products/shoe?color=red#top", "/products/*"},
+		// "*" stays within a segment; the later "**" route catches deeper paths
+		{"https://example.com/products/shoe/reviews", "/products/**"},
+		{"https://example.com/products", ""},
+		{"https://example.com/docs/", "/docs/**"},
+		{"https://example.com/docs/guide/install", "/docs/**"},
+		// "**/" also matches no directories at all
+		{"https://example.com/blog/comments", "/blog/**/comments"},
+		{"https://example.com/blog/2026/10/post/comments", "/blog/**/comments"},
+		{"https://example.com/page-1.html", "/page-?.html"},
+		{"https://example.com/page-10.html", ""},
+		// Glob characters other than * and ? are literal
+		{"https://example.com/page-1xhtml", ""},
+		{"https://example.com/about", ""},
+	}
+	for _, tt := range tests {
+		route, ok := matchRoute(routes, tt.target)
+		if got := route.Match; got != tt.want || ok != (tt.want != "") {
+			t.Errorf("matchRoute(%q) = %q, %v, want %q", tt.target, got, ok, tt.want)
+		}
+	}
+}
+
+func TestApplyRoute(t *testing.T) {
+	viewportOnly := false
+	routes := compileRoutes([]config.RouteOptions{
+		{Match: "/", FullPage: &viewportOnly},
+		{Match: "/products/*", Selector: "#product"},
+	})
+
+	opts := &api.ScanOptions{FullPage: true}
+	applyRoute(opts, routes, "https://example.com/")
+	if opts.FullPage || opts.Selector != "" {
+		t.Errorf("home page options = %+v, want viewport only", opts)
+	}
+
+	opts = &api.ScanOptions{FullPage: true}
+	applyRoute(opts, routes, "https://example.com/products/shoe")
+	if !opts.FullPage || opts.Selector != "#product" {
+		t.Errorf("product options = %+v, want full page clipped to #product", opts)
+	}
+
+	opts = &api.ScanOptions{FullPage: true, Selector: "body"}
+	applyRoute(opts, routes, "https://example.com/about")
+	if !opts.FullPage || opts.Selector != "body" {
+		t.Errorf("unmatched options = %+v, want them unchanged", opts)
+	}
+}
+
+func TestFilterTargets(t *testing.T) {
+	targets := []string{
+		"https://example.com/",
+		"https://example.com/products/shoe",
+		"https://example.com/products/shoe/reviews",
+		"https://example.com/admin/users",
+		"https://example.com/docs/guide",
+	}
+	tests := []struct {
+		name             string
+		include, exclude []string
+		want             []string
+	}{
+		{"no filters", nil, nil, targets},
+		{"include", []string{"/products/**"}, nil, targets[1:3]},
+		{"exclude", nil, []string{"/admin/**"}, []string{targets[0], targets[1], targets[2], targets[4]}},
+		{"exclude wins", []string{"/products/**"}, []string{"/products/*/reviews"}, targets[1:2]},
+		{"several includes", []string{"/", "/docs/*"}, nil, []string{targets[0], targets[4]}},
+		{"nothing matches", []string{"/blog/**"}, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := filterTargets(targets, tt.include, tt.exclude); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("filterTargets = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
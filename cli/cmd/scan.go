@@ -4,18 +4,36 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
+	"os/exec"
 	"os/signal"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"sync"
 	"strings"
 	"syscall"
 	"time"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/law-makers/viewport-cli/pkg/api"
+	"github.com/law-makers/viewport-cli/pkg/baseline"
 	"github.com/law-makers/viewport-cli/pkg/config"
+	"github.com/law-makers/viewport-cli/pkg/git"
+	"github.com/law-makers/viewport-cli/pkg/lock"
+	"github.com/law-makers/viewport-cli/pkg/metrics"
+	"github.com/law-makers/viewport-cli/pkg/prompt"
+	"github.com/law-makers/viewport-cli/pkg/results"
 	"github.com/law-makers/viewport-cli/pkg/server"
+	"github.com/law-makers/viewport-cli/pkg/tracing"
+	"github.com/mattn/go-isatty"
 	"github.com/spf13/cobra"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // Helper function to check if string contains substring
@@ -30,8 +48,93 @@ var (
 	viewports []string
 	output    string
 	apiURL    string
+	caCert    string
+	clientCert string
+	clientKey string
 	noDisplay bool
-	autoStart bool
+	noAutoStart bool
+	serverLog string
+	verbose   bool
+	interactive bool
+	noSave    bool
+	outputDirPerTarget bool
+	metricsFile string
+	dedupeIssuesFlag bool
+	headerFlags []string
+	headersFile string
+	captureNetwork bool
+	checkMixedContent bool
+	noCompression bool
+	serverReadyBody string
+	allowEmpty bool
+	requireAllShots bool
+	targetsFile string
+	presetFile string
+	keepServer bool
+	screenshotName string
+	outputFormat string
+	jsonSchema bool
+	summaryOnly bool
+	warmup bool
+	compareToURL string
+	maxWidth int
+	maxHeight int
+	noFollow bool
+	onlyDevices []string
+	maxRetries int
+	timeoutEscalation float64
+	timeoutRetryMax int
+	breakerThreshold int
+	printCurl bool
+	unsafePrintSecrets bool
+	recordDir string
+	replayDir string
+	failOnEmptyViewport bool
+	maxDuration time.Duration
+	noStream bool
+	pixelDiff bool
+	throttle string
+	cpuThrottle int
+	noLock bool
+	userAgent string
+	scrollAt []int
+	zoomLevels []float64
+	ciMode bool
+	screenshotOnly bool
+	dimensionsOnly bool
+	verboseErrors bool
+	baselineAuto bool
+	updateBaseline bool
+	onlyChanged bool
+	framework string
+	baseRef string
+	routeMap string
+	otelEndpoint string
+	annotate bool
+	referenceFlags []string
+	maxIdleConns int
+	hostHeader string
+	errorAsIssue bool
+	includePaths []string
+	excludePaths []string
+	selftest bool
+	pdfPath string
+	waitFonts bool
+	waitTimeout int
+	captureDelay int
+	concurrency int
+	failFast bool
+	captureRetries int
+	captureStyles []string
+	captureA11y bool
+	viewportsFrom string
+	styleCaptures []api.StyleCapture
+	viewportsPerRequest int
+	outputStdout bool
+	prewarm bool
+	deviceListFile string
+	appendResultsTo string
+	rateLimit float64
 )
 
 var scanCmd = &cobra.Command{
@@ -49,18 +152,180 @@ CLI flags override configuration file settings.`,
 
 func init() {
 	scanCmd.Flags().StringVar(&targetURL, "target", "", "Target URL to scan (e.g., http://localhost:3000)")
+	scanCmd.Flags().StringVar(&targetsFile, "targets-file", "", "File of target URLs to scan in one batch, one per line")
+	scanCmd.Flags().StringVar(&presetFile, "preset-file", "", "YAML scan job of targets, per-target options and scan flags, for reproducible CI runs (flags given on the command line win)")
+	scanCmd.Flags().IntVar(&concurrency, "concurrency", 1, fmt.Sprintf("Scan up to this many batch targets at once, with a live progress table (at most %d; 0 = as many as the screenshot server captures at once)", maxConcurrency))
+	scanCmd.Flags().BoolVar(&failFast, "fail-fast", false, "Stop a batch at the first target that fails, cancelling the targets still running or queued")
+	scanCmd.Flags().BoolVar(&onlyChanged, "only-changed", false, "Only scan URLs mapped from files changed since --base-ref (see --route-map)")
+	scanCmd.Flags().StringVar(&framework, "framework", "", "Scan every page in this framework's route manifest in the current directory, under --target or --port (supported: "+strings.Join(frameworkNames(), ", ")+")")
+	scanCmd.Flags().StringVar(&baseRef, "base-ref", "origin/main", "Git revision --only-changed diffs against (via its merge base with HEAD)")
+	scanCmd.Flags().StringArrayVar(&includePaths, "include", nil, "Only scan targets whose URL path matches this glob, e.g. \"/products/**\" (repeatable)")
+	scanCmd.Flags().StringArrayVar(&excludePaths, "exclude", nil, "Skip targets whose URL path matches this glob (repeatable, wins over --include)")
+	scanCmd.Flags().StringVar(&routeMap, "route-map", "", "File mapping changed-file globs to URLs for --only-changed (default .viewport-routes)")
+	scanCmd.Flags().StringVar(&compareToURL, "compare-to-url", "", "Also scan this URL with identical settings and compare the results per device")
+	scanCmd.Flags().BoolVar(&pixelDiff, "pixel-diff", false, "Include a per-device pixel diff in --compare-to-url reports")
 	scanCmd.Flags().IntVar(&port, "port", 3000, "Local port to scan (used if target not specified)")
 	scanCmd.Flags().IntVar(&serverPort, "server-port", 3001, "Screenshot server port")
 	scanCmd.Flags().StringSliceVar(&viewports, "viewports", nil, "Viewports to test (comma-separated)")
+	scanCmd.Flags().StringVar(&viewportsFrom, "viewports-from", "", "Scan the viewports of a saved scan (ID or label) at the sizes it captured them")
 	scanCmd.Flags().StringVar(&output, "output", "", "Output directory for results")
 	scanCmd.Flags().StringVar(&apiURL, "api", "", "Screenshot server endpoint (overrides --server-port)")
+	scanCmd.Flags().StringVar(&caCert, "ca-cert", "", "PEM file of CA certificates to trust for an https --api server, on top of the system roots")
+	scanCmd.Flags().StringVar(&clientCert, "client-cert", "", "PEM client certificate for --api servers that require mutual TLS (needs --client-key)")
+	scanCmd.Flags().StringVar(&clientKey, "client-key", "", "PEM private key of --client-cert")
+	scanCmd.Flags().BoolVar(&selftest, "selftest", false, "Check the toolchain by scanning a built-in local page, then report pass/fail")
 	scanCmd.Flags().BoolVar(&noDisplay, "no-display", false, "Don't display results, just save")
-	scanCmd.Flags().BoolVar(&autoStart, "no-auto-start", false, "Don't auto-start the screenshot server")
+	scanCmd.Flags().BoolVar(&noAutoStart, "no-auto-start", false, "Don't auto-start the screenshot server")
+	scanCmd.Flags().BoolVar(&keepServer, "keep-server", false, "Leave the screenshot server running after the scan")
+	scanCmd.Flags().BoolVar(&warmup, "warmup", false, "Prime the browser with a throwaway scan when the server was just started")
+	scanCmd.Flags().StringVar(&serverReadyBody, "server-ready-body", "", "JSON fields the server health response must contain to be ready (e.g. '{\"browserReady\":true}')")
+	scanCmd.Flags().StringVar(&serverLog, "server-log", "", "Write the spawned screenshot server's output to this file")
+	scanCmd.Flags().BoolVar(&verbose, "verbose", false, "Show verbose output, including screenshot server logs")
+	scanCmd.Flags().BoolVar(&verboseErrors, "verbose-errors", false, "On failure, print every known remediation, extended diagnostics, the raw error and recent server output")
+	scanCmd.Flags().BoolVar(&allowEmpty, "allow-empty", false, "Warn instead of failing when all screenshots are empty")
+	scanCmd.Flags().BoolVar(&requireAllShots, "require-all-screenshots", false, "Fail if any single viewport returns an empty screenshot")
+	scanCmd.Flags().StringVar(&otelEndpoint, "otel-endpoint", "", "Export OpenTelemetry traces of the scan to this OTLP/HTTP endpoint (e.g. http://localhost:4318)")
+	scanCmd.Flags().StringVar(&metricsFile, "metrics-file", "", "Write Prometheus text-format metrics to this file after the scan")
+	scanCmd.Flags().StringVar(&hostHeader, "host-header", "", "Host header for the target, to scan a virtual host by IP (e.g. --target http://10.0.0.5 --host-header example.com)")
+	scanCmd.Flags().StringArrayVar(&headerFlags, "header", nil, "Custom request header for the target, \"Name: Value\" (repeatable)")
+	scanCmd.Flags().StringVar(&headersFile, "headers-file", "", "JSON file of custom request headers (--header takes precedence)")
+	scanCmd.Flags().BoolVar(&screenshotOnly, "screenshot-only", false, "Only capture screenshots, skipping issue analysis (faster and cheaper)")
+	scanCmd.Flags().BoolVar(&dimensionsOnly, "dimensions-only", false, "Only report dimensions and issues, without transferring or saving screenshots (fast CI checks)")
+	scanCmd.Flags().BoolVar(&captureNetwork, "capture-network", false, "Record failed resource loads (broken images, CSS, scripts) per viewport")
+	scanCmd.Flags().BoolVar(&checkMixedContent, "check-mixed-content", false, "Report http:// resources loaded by an https:// page as mixed-content issues, per viewport")
+	scanCmd.Flags().BoolVar(&dedupeIssuesFlag, "dedupe-issues", false, "Report identical issues once with the list of affected devices")
+	scanCmd.Flags().StringVar(&userAgent, "user-agent", "", "User-Agent for the CLI's own requests to the screenshot server and target (default viewport-cli/<version>)")
+	scanCmd.Flags().BoolVar(&noCompression, "no-compression", false, "Don't request gzip-compressed responses from the screenshot server")
+	scanCmd.Flags().BoolVar(&noStream, "no-stream", false, "Don't stream live capture progress from the screenshot server")
+	scanCmd.Flags().BoolVar(&failOnEmptyViewport, "fail-on-empty-viewport", false, "Save results, then fail if any viewport's screenshot is empty")
+	scanCmd.Flags().DurationVar(&maxDuration, "max-duration", 0, "Save results, then fail if a target's capture took longer than this (e.g. 45s)")
+	scanCmd.Flags().BoolVar(&baselineAuto, "baseline-auto", false, "Compare each target with its baseline in the work dir and fail on new issues; the first scan of a target becomes its baseline")
+	scanCmd.Flags().BoolVar(&updateBaseline, "update-baseline", false, "With --baseline-auto, make this scan the baseline of each target instead of failing on new issues")
+	scanCmd.Flags().StringVar(&recordDir, "record", "", "Save each scan response as a fixture in this directory")
+	scanCmd.Flags().StringVar(&replayDir, "replay", "", "Serve scan responses from fixtures in this directory instead of the screenshot server")
+	scanCmd.Flags().BoolVar(&printCurl, "print-curl", false, "Print equivalent curl commands for the scan requests instead of sending them")
+	scanCmd.Flags().BoolVar(&unsafePrintSecrets, "unsafe-print-secrets", false, "Don't redact auth and cookie headers in --print-curl output")
+	scanCmd.Flags().IntVar(&maxIdleConns, "max-idle-conns", api.DefaultMaxIdleConns, "Idle connections to the screenshot server kept open for reuse across a batch (0 = new connection per request)")
+	scanCmd.Flags().IntVar(&viewportsPerRequest, "viewports-per-request", 0, "Split scans into requests of at most this many viewports (0 = all at once, splitting automatically if the server refuses)")
+	scanCmd.Flags().BoolVar(&prewarm, "prewarm", false, "Resolve each target host once and open its connections before scanning (for large same-host batches)")
+	scanCmd.Flags().Float64Var(&rateLimit, "rate-limit", 0, "Send at most this many requests per second to the screenshot server, honoring 429 Retry-After (0 = no limit)")
+	scanCmd.Flags().IntVar(&maxRetries, "max-retries", 2, "Retries for scan requests that fail to reach the screenshot server")
+	scanCmd.Flags().Float64Var(&timeoutEscalation, "timeout-retry-escalation", 0, "Retry a scan request that times out, up to --max-retries times, with its timeout multiplied by this factor each time (e.g. 1.5, 0 = don't retry timeouts)")
+	scanCmd.Flags().IntVar(&timeoutRetryMax, "timeout-retry-max", 600, "Longest timeout in seconds --timeout-retry-escalation raises a retry to")
+	scanCmd.Flags().IntVar(&breakerThreshold, "breaker-threshold", 3, "Fail remaining scans immediately after this many consecutive unreachable-server errors (0 = never)")
+	scanCmd.Flags().StringVar(&deviceListFile, "device-list-file", "", "YAML file of named device profiles that viewports resolve against, merged with the built-ins")
+	scanCmd.Flags().StringSliceVar(&onlyDevices, "only", nil, "Only scan these viewports out of the selected set (repeatable)")
+	scanCmd.Flags().StringVar(&throttle, "throttle", "", "Emulate a slow connection: "+strings.Join(api.NetworkProfiles, ", "))
+	scanCmd.Flags().IntVar(&cpuThrottle, "cpu-throttle", 0, "Emulate a slower CPU by this factor (e.g. 4 = 4× slower, 0 = off)")
+	scanCmd.Flags().BoolVar(&waitFonts, "wait-fonts", false, "Wait for web fonts to load (document.fonts.ready) before capturing")
+	scanCmd.Flags().IntVar(&waitTimeout, "wait-timeout", 5, "Seconds to wait for --wait-fonts before capturing anyway")
+	scanCmd.Flags().IntVar(&captureDelay, "delay", 0, fmt.Sprintf("Milliseconds to wait after the page loads before capturing, e.g. for entrance animations (at most %d)", maxCaptureDelay))
+	scanCmd.Flags().BoolVar(&captureA11y, "a11y", false, "Capture each viewport's accessibility tree, saved in metadata and compared by results diff")
+	scanCmd.Flags().StringArrayVar(&captureStyles, "capture-style", nil, "Capture computed CSS of the first element matching a selector, \"<selector>:<property>,...\" e.g. '.header:display,width' (repeatable)")
+	scanCmd.Flags().IntVar(&captureRetries, "capture-retries", 2, "Retry a viewport capture that fails or comes back empty up to this many times, waiting longer each time")
+	scanCmd.Flags().IntSliceVar(&scrollAt, "scroll-at", nil, "Also capture each viewport scrolled to these pixel offsets (e.g. 0,500,1000)")
+	scanCmd.Flags().Float64SliceVar(&zoomLevels, "zoom", nil, "Also capture each viewport at these page zoom factors, with issues per zoom (e.g. 1,1.5,2 for 100%, 150% and 200%)")
+	scanCmd.Flags().BoolVar(&errorAsIssue, "error-as-issue", false, "Report a critical issue for viewports whose page returned an HTTP error status (4xx/5xx)")
+	scanCmd.Flags().StringVar(&pdfPath, "pdf", "", "Also write a PDF report with one page per device per URL (screenshot and issues)")
+	scanCmd.Flags().BoolVar(&annotate, "annotate", false, "Also save <device>-annotated.png with issue regions outlined (when the server reports them)")
+	scanCmd.Flags().StringArrayVar(&referenceFlags, "reference", nil, "Compare a device's screenshot to a design image, \"<device>=<image.png>\" (repeatable)")
+	scanCmd.Flags().BoolVar(&noFollow, "no-follow", false, "Scan the target URL as given even if it redirects elsewhere")
+	scanCmd.Flags().IntVar(&maxWidth, "max-width", 0, "Downscale saved screenshots wider than this many pixels (0 = no limit)")
+	scanCmd.Flags().IntVar(&maxHeight, "max-height", 0, "Downscale saved screenshots taller than this many pixels (0 = no limit)")
+	scanCmd.Flags().StringVar(&screenshotName, "screenshot-name", defaultScreenshotName, "Screenshot file name template; tokens: {device} {width} {height} {scheme} {index}")
+	scanCmd.Flags().BoolVar(&outputStdout, "output-stdout", false, "Write the PNG screenshot of the single scanned viewport to stdout (other output goes to stderr)")
+	scanCmd.Flags().StringVar(&outputFormat, "output-format", "text", "Output format: text, jsonl to stream one JSON line per target to stdout, or ndjson for one JSON line per issue and nothing else")
+	scanCmd.Flags().BoolVar(&summaryOnly, "summary-only", false, "Print only one line per scanned target: PASS, FAIL with issue counts by severity, or ERROR")
+	scanCmd.Flags().BoolVar(&jsonSchema, "json-schema", false, "Print the JSON Schema of saved scan metadata (or of --output-format jsonl lines) and exit")
+	scanCmd.Flags().BoolVar(&noLock, "no-lock", false, "Don't lock the output directory against concurrent scans")
+	scanCmd.Flags().BoolVar(&noSave, "no-save", false, "Don't save results to the output directory")
+	scanCmd.Flags().BoolVar(&outputDirPerTarget, "output-dir-per-target", false, "Save each target's scans in its own subdirectory of the output directory, named after its host and path")
+	scanCmd.Flags().StringVar(&appendResultsTo, "append-results", "", "Merge this scan's viewports into an existing saved scan (ID or label) instead of saving a new one")
+	scanCmd.Flags().BoolVar(&ciMode, "ci", false, "Use CI-friendly defaults: --no-display --no-color --output-format jsonl, no prompts")
+	scanCmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "Prompt for scan settings before running")
 }
 
-func runScan(cmd *cobra.Command, args []string) error {
+func runScan(cmd *cobra.Command, args []string) (err error) {
+	session := &scanSession{}
+
+	// A preset sets the flags the command line doesn't, including --ci
+	var preset *scanPreset
+	if presetFile != "" {
+		if cmd.Flags().Changed("target") || targetsFile != "" || onlyChanged || framework != "" || selftest || compareToURL != "" || appendResultsTo != "" || outputStdout || interactive {
+			return withExitCode(exitConfigError, fmt.Errorf("--preset-file lists its own targets and cannot be combined with --target, --targets-file, --only-changed, --framework, --selftest, --compare-to-url, --append-results, --output-stdout or --interactive"))
+		}
+		preset, err = loadPresetFile(cmd, presetFile)
+		if err != nil {
+			return withExitCode(exitConfigError, err)
+		}
+	}
+	applyCIDefaults(cmd)
+
+	// --json-schema documents the output instead of scanning
+	if jsonSchema {
+		return printJSONSchema(outputFormat)
+	}
+
+	// With --output-stdout, stdout carries the PNG bytes only
+	var pngOut *os.File
+	if outputStdout {
+		if outputFormat != "text" {
+			return withExitCode(exitConfigError, fmt.Errorf("--output-stdout cannot be combined with --output-format %s", outputFormat))
+		}
+		pngOut = os.Stdout
+		os.Stdout = os.Stderr
+	}
+
+	switch outputFormat {
+	case "text":
+	case "jsonl":
+		// Keep stdout for JSON lines only; human-readable progress goes to stderr
+		session.jsonl = newJSONLWriter(os.Stdout)
+		os.Stdout = os.Stderr
+	case "ndjson":
+		// Stdout carries issue lines only, for log pipelines; everything
+		// else is dropped unless --verbose sends it to stderr
+		session.jsonl = newIssueWriter(os.Stdout)
+		if verbose {
+			os.Stdout = os.Stderr
+		} else {
+			devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+			if err != nil {
+				return fmt.Errorf("failed to open %s: %w", os.DevNull, err)
+			}
+			defer devNull.Close()
+			os.Stdout = devNull
+		}
+	default:
+		return withExitCode(exitConfigError, fmt.Errorf("invalid --output-format %q (expected text, jsonl or ndjson)", outputFormat))
+	}
+
+	// --summary-only keeps stdout for one line per target and drops everything
+	// else, unless --verbose asks for it on stderr
+	if summaryOnly {
+		if outputFormat != "text" || outputStdout || compareToURL != "" || selftest || interactive || printCurl {
+			return withExitCode(exitConfigError, fmt.Errorf("--summary-only cannot be combined with --output-format %s, --output-stdout, --compare-to-url, --selftest, --interactive or --print-curl", outputFormat))
+		}
+		session.summary = &summaryWriter{w: os.Stdout}
+		if verbose {
+			os.Stdout = os.Stderr
+		} else {
+			devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+			if err != nil {
+				return fmt.Errorf("failed to open %s: %w", os.DevNull, err)
+			}
+			defer devNull.Close()
+			os.Stdout = devNull
+		}
+	}
+
 	// Load configuration
 	cfg, err := config.LoadConfig("")
+	var unknownKeys *config.UnknownKeysError
+	if errors.As(err, &unknownKeys) {
+		// Strict mode: a mistyped key must not silently fall back to defaults
+		return withExitCode(exitConfigError, err)
+	}
 	if err != nil {
 		// Just warn, don't fail - use defaults if config doesn't exist
 		fmt.Printf("%s Warning: Could not load config: %v (using defaults)\n", 
@@ -80,6 +345,18 @@ func runScan(cmd *cobra.Command, args []string) error {
 		viewports = []string{"mobile", "tablet", "desktop"}
 	}
 
+	if cfg != nil && cfg.Display.Verbose {
+		verbose = true
+	}
+
+	if !cmd.Flags().Changed("warmup") && cfg != nil {
+		warmup = cfg.Scan.Warmup
+	}
+
+	if userAgent == "" && cfg != nil {
+		userAgent = cfg.Scan.UserAgent
+	}
+
 	// Determine API URL (--api flag takes precedence over --server-port)
 	if apiURL == "" {
 		if cfg != nil && cfg.API.URL != "" {
@@ -88,173 +365,1023 @@ func runScan(cmd *cobra.Command, args []string) error {
 			apiURL = fmt.Sprintf("http://127.0.0.1:%d", serverPort)
 		}
 	}
+	if (clientCert == "") != (clientKey == "") {
+		return withExitCode(exitConfigError, fmt.Errorf("--client-cert and --client-key must be given together"))
+	}
+	session.tlsFiles = configuredTLSFiles(cfg)
+	if session.tlsFiles != (api.TLSFiles{}) && strings.HasPrefix(apiURL, "http://") {
+		fmt.Println("⚠️  Warning: --ca-cert and --client-cert have no effect with an http:// screenshot server")
+	}
+
+	// --selftest scans a built-in page into a throwaway directory
+	if selftest {
+		if cmd.Flags().Changed("target") || cmd.Flags().Changed("port") || targetsFile != "" || onlyChanged || framework != "" ||
+			compareToURL != "" || recordDir != "" || replayDir != "" || printCurl || outputDirPerTarget {
+			return withExitCode(exitConfigError, fmt.Errorf("--selftest scans its own page and cannot be combined with target, batch, comparison, record/replay or --print-curl options"))
+		}
+		pageURL, stopPage, err := startSelftestServer()
+		if err != nil {
+			return withExitCode(exitStartup, err)
+		}
+		defer stopPage()
+
+		tmpDir, err := os.MkdirTemp("", "viewport-selftest-*")
+		if err != nil {
+			return fmt.Errorf("failed to create self-test output directory: %w", err)
+		}
+		defer os.RemoveAll(tmpDir)
+
+		targetURL, output, noSave, interactive = pageURL, tmpDir, false, false
+	}
+
+	// Run the interactive wizard unless the target was given or stdin is not a terminal
+	if interactive {
+		if cmd.Flags().Changed("target") || cmd.Flags().Changed("port") || targetsFile != "" || framework != "" {
+			fmt.Println("ℹ️  Target provided on the command line, skipping interactive mode")
+		} else if runningInCI() {
+			fmt.Println("ℹ️  CI environment detected, skipping interactive mode")
+		} else if !isTerminal(os.Stdin) {
+			fmt.Println("ℹ️  Standard input is not a terminal, skipping interactive mode")
+		} else {
+			session.openResults = runScanWizard()
+		}
+	}
+
+	// Without a device list or --viewports-from, viewport names are left for
+	// the server to resolve
+	var matrix *deviceMatrix
+	if file := configuredDeviceList(cfg); file != "" || viewportsFrom != "" {
+		matrix, err = resolveDeviceMatrix(file)
+		if err != nil {
+			return withExitCode(exitConfigError, err)
+		}
+	}
+
+	if viewportsFrom != "" {
+		if cmd.Flags().Changed("viewports") {
+			return withExitCode(exitConfigError, fmt.Errorf("--viewports-from cannot be combined with --viewports (use --only to narrow it)"))
+		}
+		store, err := openResultsStore(cfg, output)
+		if err != nil {
+			return err
+		}
+		scan, err := results.ResolveScan(store, viewportsFrom)
+		if err != nil {
+			return withExitCode(exitConfigError, fmt.Errorf("--viewports-from: %w", err))
+		}
+		viewports, err = viewportsFromScan(scan, matrix)
+		if err != nil {
+			return withExitCode(exitConfigError, fmt.Errorf("--viewports-from: %w", err))
+		}
+	}
+
+	if len(onlyDevices) > 0 {
+		viewports, err = restrictViewports(viewports, onlyDevices)
+		if err != nil {
+			return withExitCode(exitConfigError, err)
+		}
+	}
+
+	if matrix != nil {
+		session.devices, err = deviceProfiles(matrix, viewports)
+		if err != nil {
+			return withExitCode(exitConfigError, err)
+		}
+	}
+
+	if appendResultsTo != "" && (targetsFile != "" || onlyChanged || framework != "" || compareToURL != "" || selftest || noSave || outputStdout) {
+		return withExitCode(exitConfigError, fmt.Errorf("--append-results updates one saved scan and cannot be combined with batch, comparison, --selftest, --no-save or --output-stdout"))
+	}
+
+	if outputStdout {
+		if targetsFile != "" || onlyChanged || framework != "" || compareToURL != "" || selftest || printCurl {
+			return withExitCode(exitConfigError, fmt.Errorf("--output-stdout writes one screenshot and cannot be combined with batch, comparison, --selftest or --print-curl options"))
+		}
+		if len(viewports) != 1 {
+			return withExitCode(exitConfigError, fmt.Errorf("--output-stdout needs exactly one viewport, got %d (use --only or --viewports)", len(viewports)))
+		}
+	}
+
+	// A targets file, a preset, --only-changed or --framework turns this into
+	// a batch scan sharing one server
+	var targets []string
+	targetsSource := targetsFile
+	if preset != nil {
+		if err := preset.resolveDevices(matrix); err != nil {
+			return withExitCode(exitConfigError, err)
+		}
+		targets = preset.urls()
+		targetsSource = presetFile
+		session.presetTargets = preset.byURL()
+	} else if framework != "" {
+		if targetsFile != "" || onlyChanged || compareToURL != "" {
+			return withExitCode(exitConfigError, fmt.Errorf("--framework cannot be combined with --targets-file, --only-changed or --compare-to-url"))
+		}
+		// Manifest paths like "/about" are resolved against --target or --port
+		base := targetURL
+		if base == "" {
+			base = fmt.Sprintf("http://localhost:%d", port)
+		}
+
+		var dynamic []string
+		targets, dynamic, err = frameworkTargets(framework, ".", base)
+		if err != nil {
+			return withExitCode(exitConfigError, err)
+		}
+		if len(dynamic) > 0 {
+			fmt.Printf("ℹ️  Skipping %d dynamic routes with no URL of their own: %s (scan them with --targets-file)\n",
+				len(dynamic), strings.Join(dynamic, ", "))
+		}
+		targetsSource = frameworkManifests[framework].name + " route manifest"
+	} else if onlyChanged {
+		if targetsFile != "" || compareToURL != "" {
+			return withExitCode(exitConfigError, fmt.Errorf("--only-changed cannot be combined with --targets-file or --compare-to-url"))
+		}
+		if routeMap == "" && cfg != nil {
+			routeMap = cfg.Scan.RouteMap
+		}
+		if routeMap == "" {
+			routeMap = defaultRouteMap
+		}
+		// Mapped paths like "/about" are resolved against --target or --port
+		base := targetURL
+		if base == "" {
+			base = fmt.Sprintf("http://localhost:%d", port)
+		}
+
+		targets, err = changedTargets(routeMap, baseRef, base)
+		if err != nil {
+			return withExitCode(exitConfigError, err)
+		}
+		if len(targets) == 0 {
+			fmt.Printf("ℹ️  No files changed since %s, nothing to scan\n", baseRef)
+			return nil
+		}
+		targetsSource = "changed files since " + baseRef
+	} else if targetsFile != "" {
+		if cmd.Flags().Changed("target") {
+			return withExitCode(exitConfigError, fmt.Errorf("--target and --targets-file cannot be used together"))
+		}
+		targets, err = loadTargetsFile(targetsFile)
+		if err != nil {
+			return withExitCode(exitConfigError, err)
+		}
+	} else {
+		// If no target specified but port is, construct localhost URL
+		if targetURL == "" && port > 0 {
+			targetURL = fmt.Sprintf("http://localhost:%d", port)
+		}
+
+		if targetURL == "" {
+			return withExitCode(exitConfigError, fmt.Errorf("either --target or --port must be specified"))
+		}
+		targets = []string{targetURL}
+	}
+
+	targets, err = normalizeTargets(targets)
+	if err != nil {
+		return withExitCode(exitConfigError, err)
+	}
+	if compareToURL != "" {
+		if compareToURL, err = normalizeTarget(compareToURL); err != nil {
+			return withExitCode(exitConfigError, fmt.Errorf("invalid --compare-to-url: %w", err))
+		}
+	}
+
+	if len(includePaths) > 0 || len(excludePaths) > 0 {
+		targets = filterTargets(targets, includePaths, excludePaths)
+		if len(targets) == 0 {
+			fmt.Println("ℹ️  No targets match --include/--exclude, nothing to scan")
+			return nil
+		}
+	}
+	if cfg != nil {
+		session.routes = compileRoutes(cfg.Scan.Routes)
+		session.severityMap = cfg.Scan.SeverityMap
+	}
+
+	session.headers, err = resolveHeaders(headersFile, headerFlags)
+	if err != nil {
+		return withExitCode(exitConfigError, err)
+	}
+
+	if hostHeader != "" {
+		if err := validateHostHeader(hostHeader); err != nil {
+			return withExitCode(exitConfigError, fmt.Errorf("invalid --host-header: %w", err))
+		}
+		if _, ok := session.headers["Host"]; ok {
+			return withExitCode(exitConfigError, fmt.Errorf("set the Host header with --host-header, not --header or --headers-file"))
+		}
+	}
+
+	session.references, err = parseReferences(referenceFlags, viewports)
+	if err != nil {
+		return withExitCode(exitConfigError, err)
+	}
+
+	var readyBody map[string]interface{}
+	if serverReadyBody != "" {
+		if err := json.Unmarshal([]byte(serverReadyBody), &readyBody); err != nil {
+			return withExitCode(exitConfigError, fmt.Errorf("invalid --server-ready-body (expected a JSON object): %w", err))
+		}
+	}
+
+	if compareToURL != "" && (targetsFile != "" || outputFormat != "text") {
+		return withExitCode(exitConfigError, fmt.Errorf("--compare-to-url cannot be combined with --targets-file or --output-format jsonl or ndjson"))
+	}
+
+	if recordDir != "" && replayDir != "" {
+		return withExitCode(exitConfigError, fmt.Errorf("--record and --replay cannot be used together"))
+	}
 
-	// If no target specified but port is, construct localhost URL
-	if targetURL == "" && port > 0 {
-		targetURL = fmt.Sprintf("http://localhost:%d", port)
+	if maxRetries < 0 || breakerThreshold < 0 || maxIdleConns < 0 {
+		return withExitCode(exitConfigError, fmt.Errorf("--max-retries, --breaker-threshold and --max-idle-conns must not be negative"))
+	}
+	if timeoutEscalation != 0 && timeoutEscalation <= 1 {
+		return withExitCode(exitConfigError, fmt.Errorf("--timeout-retry-escalation must be a factor above 1 (0 = off)"))
+	}
+	if cmd.Flags().Changed("timeout-retry-max") {
+		if timeoutEscalation == 0 {
+			return withExitCode(exitConfigError, fmt.Errorf("--timeout-retry-max needs --timeout-retry-escalation"))
+		}
+		if time.Duration(timeoutRetryMax)*time.Second < api.DefaultTimeout {
+			return withExitCode(exitConfigError, fmt.Errorf("--timeout-retry-max must be at least the default timeout of %ds", int(api.DefaultTimeout.Seconds())))
+		}
 	}
 
-	if targetURL == "" {
-		return fmt.Errorf("either --target or --port must be specified")
+	if maxWidth < 0 || maxHeight < 0 {
+		return withExitCode(exitConfigError, fmt.Errorf("--max-width and --max-height must not be negative"))
+	}
+
+	if allowEmpty && requireAllShots {
+		return withExitCode(exitConfigError, fmt.Errorf("--allow-empty and --require-all-screenshots cannot be used together"))
+	}
+	for _, offset := range scrollAt {
+		if offset < 0 {
+			return withExitCode(exitConfigError, fmt.Errorf("--scroll-at offsets must not be negative"))
+		}
+	}
+	if err := validateZoomLevels(zoomLevels); err != nil {
+		return withExitCode(exitConfigError, err)
+	}
+	if updateBaseline && !baselineAuto {
+		return withExitCode(exitConfigError, fmt.Errorf("--update-baseline needs --baseline-auto"))
+	}
+	if baselineAuto {
+		if screenshotOnly || dimensionsOnly || compareToURL != "" || appendResultsTo != "" {
+			return withExitCode(exitConfigError, fmt.Errorf("--baseline-auto compares issues, so it cannot be combined with --screenshot-only, --dimensions-only, --compare-to-url or --append-results"))
+		}
+		dir, err := config.GetStatePath("baselines")
+		if err != nil {
+			return withExitCode(exitConfigError, fmt.Errorf("failed to locate baseline directory: %w", err))
+		}
+		session.baselines = baseline.NewStore(dir)
+	}
+	if screenshotOnly && dedupeIssuesFlag {
+		fmt.Println("⚠️  Warning: --dedupe-issues has no effect with --screenshot-only")
+	}
+	if dimensionsOnly {
+		if conflicts := screenshotFlagsSet(); len(conflicts) > 0 {
+			return withExitCode(exitConfigError, fmt.Errorf("--dimensions-only captures no screenshots, so it cannot be combined with %s", strings.Join(conflicts, ", ")))
+		}
+	}
+	if throttle != "" {
+		if err := api.ValidateNetworkProfile(throttle); err != nil {
+			return withExitCode(exitConfigError, fmt.Errorf("invalid --throttle: %w", err))
+		}
+	}
+	if cpuThrottle < 0 || cpuThrottle == 1 {
+		return withExitCode(exitConfigError, fmt.Errorf("--cpu-throttle must be a slowdown factor of 2 or more (0 = off)"))
+	}
+
+	if rateLimit < 0 {
+		return withExitCode(exitConfigError, fmt.Errorf("--rate-limit must be 0 (no limit) or more requests per second"))
+	}
+
+	if viewportsPerRequest < 0 {
+		return withExitCode(exitConfigError, fmt.Errorf("--viewports-per-request must be 0 (no limit) or more"))
+	}
+	session.viewportChunk = viewportsPerRequest
+	if waitTimeout <= 0 {
+		return withExitCode(exitConfigError, fmt.Errorf("--wait-timeout must be at least 1 second"))
+	}
+	if styleCaptures, err = parseStyleCaptures(captureStyles); err != nil {
+		return withExitCode(exitConfigError, err)
+	}
+	if concurrency < 0 || concurrency > maxConcurrency {
+		return withExitCode(exitConfigError, fmt.Errorf("--concurrency must be between 0 (match the server) and %d", maxConcurrency))
+	}
+	if captureDelay < 0 || captureDelay > maxCaptureDelay {
+		return withExitCode(exitConfigError, fmt.Errorf("--delay must be between 0 and %d milliseconds", maxCaptureDelay))
+	}
+	if maxDuration < 0 {
+		return withExitCode(exitConfigError, fmt.Errorf("--max-duration must not be negative"))
+	}
+	if captureRetries < 0 || captureRetries > maxCaptureRetries {
+		return withExitCode(exitConfigError, fmt.Errorf("--capture-retries must be between 0 and %d", maxCaptureRetries))
+	}
+	if cmd.Flags().Changed("wait-timeout") && !waitFonts {
+		fmt.Println("⚠️  Warning: --wait-timeout has no effect without --wait-fonts")
+	}
+	if outputDirPerTarget && noSave {
+		fmt.Println("⚠️  Warning: --output-dir-per-target has no effect with --no-save")
+	}
+
+	if printCurl {
+		if compareToURL != "" {
+			targets = append(targets, compareToURL)
+		}
+		return session.printCurlCommands(targets)
+	}
+
+	if !noSave {
+		if selftest {
+			// Always local, so the throwaway results can be checked and removed
+			session.store = results.NewFSStore(output)
+		} else {
+			session.store, err = openResultsStore(cfg, output)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Fail fast if results could not be saved, before contacting the server.
+		// Comparison reports are always written locally.
+		if _, local := session.store.(*results.FSStore); local || compareToURL != "" {
+			if err := ensureWritableDir(output); err != nil {
+				return err
+			}
+
+			if !noLock {
+				outputLock, err := lock.Acquire(filepath.Join(output, lockFileName), staleLockAge)
+				if err != nil {
+					return fmt.Errorf("another scan is using the output directory: %w (use --no-lock to bypass)", err)
+				}
+				defer outputLock.Release()
+			}
+		}
+
+		if outputDirPerTarget {
+			if _, local := session.store.(*results.FSStore); !local {
+				return withExitCode(exitConfigError, fmt.Errorf("--output-dir-per-target needs the filesystem results backend, not %s", session.store.Location()))
+			}
+			dirTargets := targets
+			if compareToURL != "" {
+				dirTargets = append(dirTargets[:len(dirTargets):len(dirTargets)], compareToURL)
+			}
+			session.targetDirs = targetDirNames(dirTargets)
+		}
+
+		if appendResultsTo != "" {
+			saved, err := results.ResolveScan(session.store, appendResultsTo)
+			if err != nil {
+				return withExitCode(exitConfigError, fmt.Errorf("--append-results: %w", err))
+			}
+			session.appendTo = saved.ScanID
+			if saved.RequestedURL != "" && saved.RequestedURL != targets[0] {
+				fmt.Printf("⚠️  Warning: scan %s was of %s, not %s\n", saved.ScanID, saved.RequestedURL, targets[0])
+			}
+		}
+	}
+
+	// Tag scans with the checked-out commit so results diff can select them by revision
+	if commit, err := git.HeadCommit(); err == nil {
+		session.gitCommit = commit
+		session.gitDirty, _ = git.IsDirty()
 	}
 
 	// Display startup info
 	fmt.Printf("\n%s\n", lipgloss.NewStyle().Bold(true).Render("🎯 ViewPort-CLI Scan"))
-	fmt.Printf("Target: %s\n", lipgloss.NewStyle().Foreground(lipgloss.Color("4")).Render(targetURL))
+	if len(targets) == 1 {
+		fmt.Printf("Target: %s\n", lipgloss.NewStyle().Foreground(lipgloss.Color("4")).Render(targets[0]))
+	} else {
+		fmt.Printf("Targets: %s\n", lipgloss.NewStyle().Foreground(lipgloss.Color("4")).Render(fmt.Sprintf("%d (from %s)", len(targets), targetsSource)))
+	}
 	fmt.Printf("Screenshot Server: %s\n", lipgloss.NewStyle().Foreground(lipgloss.Color("4")).Render(apiURL))
 	fmt.Printf("Output: %s\n\n", lipgloss.NewStyle().Foreground(lipgloss.Color("4")).Render(output))
 
 	// Display which viewports
-	fmt.Printf("Viewports: %v\n\n", viewports)
+	fmt.Printf("Viewports: %v\n", viewports)
+	if viewportsFrom != "" {
+		var sizes []string
+		for _, profile := range session.devices {
+			sizes = append(sizes, fmt.Sprintf("%s %d×%d", profile.Name, profile.Width, profile.Height))
+		}
+		if len(sizes) > 0 {
+			fmt.Printf("Viewport sizes from %s: %s\n", viewportsFrom, strings.Join(sizes, ", "))
+		}
+	}
+	if throttle != "" || cpuThrottle > 0 {
+		fmt.Printf("Throttling: %s\n", describeThrottling(throttle, cpuThrottle))
+	}
+	if hostHeader != "" {
+		fmt.Printf("Host header: %s\n", hostHeader)
+	}
+	if waitFonts {
+		fmt.Printf("Web fonts: waiting up to %ds\n", waitTimeout)
+	}
+	if captureDelay > 0 {
+		fmt.Printf("Capture delay: %dms after load\n", captureDelay)
+	}
+	if rateLimit > 0 {
+		fmt.Printf("Rate limit: %g requests/s\n", rateLimit)
+	}
+	if maxDuration > 0 {
+		fmt.Printf("Duration budget: %s per target\n", maxDuration)
+	}
+	if dimensionsOnly {
+		fmt.Println("Screenshots: skipped (--dimensions-only)")
+	}
+	fmt.Println()
+
+	// Tracing is a no-op unless an endpoint is given
+	shutdownTracing, err := tracing.Setup(otelEndpoint, version)
+	if err != nil {
+		return withExitCode(exitConfigError, err)
+	}
+	defer func() {
+		flushCtx, cancelFlush := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancelFlush()
+		if err := shutdownTracing(flushCtx); err != nil {
+			fmt.Printf("⚠️  Warning: Failed to export traces: %v\n", err)
+		}
+	}()
 
 	// Setup server manager
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	// Handle Ctrl+C gracefully
+	ctx, rootSpan := tracing.Start(ctx, "scan", attribute.Int("viewport.targets", len(targets)))
+	defer func() { tracing.End(rootSpan, err) }()
+
+	// Handle Ctrl+C gracefully; a second one stops the server and exits at once
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
 	go func() {
 		<-sigChan
 		cancel()
+		<-sigChan
+		fmt.Fprintln(os.Stderr, "\nInterrupted again, stopping child processes and exiting")
+		runCleanups()
+		os.Exit(exitInterrupted)
 	}()
 
-	// Auto-start server if needed
+	// Auto-start server if needed. The server is owned here, not by individual
+	// scans, so every target in a batch shares it.
 	var serverManager *server.Manager
-	if !noDisplay {
-		// Extract port from apiURL
-		var sPort int
-		fmt.Sscanf(apiURL, "http://localhost:%d", &sPort)
-		if sPort == 0 {
-			sPort = serverPort
+	if autoStartServer() {
+		serverManager = newServerManager(readyBody)
+		session.server = serverManager
+
+		// A kept server must outlive this process, so don't tie it to ctx
+		serverCtx := ctx
+		if keepServer {
+			serverCtx = context.Background()
 		}
 
-		serverManager = server.NewManager(sPort)
-		if err := serverManager.Start(ctx, true); err != nil {
+		_, startSpan := tracing.Start(ctx, "server.start")
+		startErr := serverManager.Start(serverCtx, true)
+		if errors.Is(startErr, server.ErrNotInstalled) && offerServerInstall(ctx) {
+			startErr = serverManager.Start(serverCtx, true)
+		}
+		tracing.End(startSpan, startErr)
+		if err := startErr; err != nil {
 			// Not fatal - server might already be running or might be on different host
+			session.serverErr = err
 			fmt.Printf("⚠️ Warning: Could not auto-start server: %v\n", err)
 			fmt.Printf("   Continuing anyway - server may already be running\n\n")
 		} else {
-			// Register cleanup
+			// Register cleanup, also for exits that skip deferred calls
 			defer func() {
-				if serverManager != nil {
-					serverManager.Stop()
-				}
+				stopServer(serverManager, err != nil)
 			}()
+			if !keepServer {
+				defer registerCleanup(func() { serverManager.Stop() })()
+			}
 		}
 	}
 
 	// Create API client
-	client := api.NewClient(apiURL)
+	client := api.NewClient(apiURL).
+		SetRetryCount(maxRetries).
+		SetCircuitBreaker(breakerThreshold).
+		SetRateLimit(rateLimit).
+		SetUserAgent(cliUserAgent()).
+		SetTransportOptions(api.TransportOptions{MaxIdleConns: maxIdleConns, DisableKeepAlives: maxIdleConns == 0})
+	if noCompression {
+		client.SetCompression(false)
+	}
+	if verbose {
+		client.OnRetryAfter(func(status int, wait time.Duration) {
+			fmt.Printf("⏳ Screenshot server busy (HTTP %d), retrying in %s\n", status, wait.Round(time.Second))
+		})
+	}
+	if timeouts := scanAttemptTimeouts(); len(timeouts) > 1 {
+		// Each attempt's context sets its timeout
+		client.SetTimeout(timeouts[len(timeouts)-1])
+	}
+	if _, err := client.SetTLSFiles(session.tlsFiles); err != nil {
+		return withExitCode(exitConfigError, err)
+	}
+	session.client = client
+	if recordDir != "" {
+		session.client = api.NewRecorder(client, recordDir)
+	} else if replayDir != "" {
+		session.client = api.NewReplayer(replayDir)
+	}
+
+	// Redirect checks share connections and TLS sessions; --prewarm also caches DNS
+	// and connects to every target host up front
+	var dns *dnsCache
+	if prewarm {
+		dns = newDNSCache()
+	}
+	session.transport = newTargetTransport(hostHeader, dns)
+	defer session.transport.CloseIdleConnections()
+	if prewarm && !selftest {
+		session.prewarmTargets(ctx, targets)
+	}
+
+	// Only a freshly started browser needs priming
+	if warmup && serverManager != nil && serverManager.Spawned() {
+		warmupServer(ctx, client)
+	}
+
+	// The PDF covers whatever was scanned, however the scan ends
+	if pdfPath != "" {
+		session.report = newPDFReport()
+		defer func() {
+			if pdfErr := session.report.write(pdfPath); pdfErr != nil {
+				fmt.Printf("⚠️  Warning: PDF report not written: %v\n", pdfErr)
+				if err == nil {
+					err = pdfErr
+				}
+				return
+			}
+			fmt.Printf("📄 PDF report written to %s (%d pages)\n", pdfPath, session.report.pages)
+		}()
+	}
+
+	// Metrics cover every target scanned, however the scan ends
+	if metricsFile != "" {
+		defer func() {
+			if err := metrics.WriteFile(metricsFile, scanMetrics(session.targetMetrics)); err != nil {
+				fmt.Printf("⚠️  Warning: Failed to write metrics: %v\n", err)
+			}
+		}()
+	}
+
+	if selftest {
+		resp, scanErr := session.scanTarget(ctx, targets[0])
+		err = session.checkSelftest(targets[0], resp, scanErr)
+		return err
+	}
+
+	if targetsFile != "" || preset != nil || onlyChanged || framework != "" || session.jsonl != nil {
+		return session.runBatch(ctx, targets)
+	}
+
+	if compareToURL != "" {
+		return session.runComparison(ctx, targets[0], compareToURL)
+	}
+
+	scanStart := time.Now()
+	resp, err := session.scanTarget(ctx, targets[0])
+	if session.summary != nil {
+		session.summary.emit(targets[0], resp, err, time.Since(scanStart))
+	}
+	if err == nil && pngOut != nil {
+		err = writeScreenshotTo(pngOut, resp)
+	}
+	return err
+}
+
+// writeScreenshotTo writes the PNG of a single-viewport scan to w, for --output-stdout
+func writeScreenshotTo(w io.Writer, resp *api.ScanResponse) error {
+	if len(resp.Results) != 1 {
+		return withExitCode(exitScanFailed, fmt.Errorf("--output-stdout needs exactly one viewport result, the server returned %d", len(resp.Results)))
+	}
+	data, err := base64.StdEncoding.DecodeString(resp.Results[0].ScreenshotBase64)
+	if err != nil {
+		return withExitCode(exitScanFailed, fmt.Errorf("failed to decode %s screenshot: %w", resp.Results[0].Device, err))
+	}
+	if len(data) == 0 {
+		return withExitCode(exitScanFailed, fmt.Errorf("%s screenshot is empty", resp.Results[0].Device))
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("failed to write screenshot to stdout: %w", err)
+	}
+	return nil
+}
+
+// scanSession holds the state shared by every target scanned in one invocation
+type scanSession struct {
+	client      api.Scanner
+	headers     map[string]string
+	openResults bool
+	jsonl       *jsonlWriter
+	summary     *summaryWriter
+	store       results.Store // nil with --no-save
+	gitCommit   string        // Commit checked out in the working directory, if any
+	gitDirty    bool
+	serverErr   error // Why auto-starting the server failed, if it did
+	server      *server.Manager // Auto-start manager, nil when the server isn't managed
+	routes      []routeOverride // scan.routes capture options by URL path
+	references  map[string]string // --reference image path by device
+	report      *pdfReport        // --pdf pages collected so far
+	severityMap map[string]string // scan.severity_map backend severities
+	unmappedSeverities map[string]bool // Unmapped severities already warned about
+	viewportChunk int // Most viewports sent per request (0 = all), lowered when the server refuses more
+	transport   *http.Transport // Shared by the CLI's own requests to targets
+	devices     []api.DeviceProfile // Device list profiles of the scanned viewports
+	appendTo    string // --append-results scan ID the results are merged into
+	savedIDs    map[string]bool // Scan IDs saved so far, so concurrent scans don't overwrite each other
+	targetDirs  map[string]string // --output-dir-per-target directory of each target
+	tlsFiles    api.TLSFiles      // --ca-cert, --client-cert and --client-key, or their config
+	baselines   *baseline.Store   // --baseline-auto baselines, nil without it
+	presetTargets map[string]presetTarget // --preset-file targets with their own capture options
+	targetMetrics []targetMetrics // --metrics-file results of the targets scanned so far
+	// mu guards the fields above that scans change, and saving, for the
+	// workers of a parallel batch (--concurrency)
+	mu sync.Mutex
+}
+
+// cliUserAgent returns the User-Agent for the CLI's own HTTP requests
+func cliUserAgent() string {
+	if userAgent != "" {
+		return userAgent
+	}
+	return api.DefaultUserAgent + "/" + version
+}
+
+// configuredTLSFiles returns the TLS files given as flags, falling back to
+// api.ca_cert and the api.client_cert/api.client_key pair in config
+func configuredTLSFiles(cfg *config.Config) api.TLSFiles {
+	files := api.TLSFiles{CACert: caCert, ClientCert: clientCert, ClientKey: clientKey}
+	if cfg == nil {
+		return files
+	}
+	if files.CACert == "" {
+		files.CACert = cfg.API.CACert
+	}
+	if files.ClientCert == "" && files.ClientKey == "" {
+		files.ClientCert, files.ClientKey = cfg.API.ClientCert, cfg.API.ClientKey
+	}
+	return files
+}
+
+// newServerManager creates a server manager for the configured screenshot server
+func newServerManager(readyBody map[string]interface{}) *server.Manager {
+	// Extract port from apiURL
+	var sPort int
+	fmt.Sscanf(apiURL, "http://localhost:%d", &sPort)
+	if sPort == 0 {
+		sPort = serverPort
+	}
+
+	serverManager := server.NewManager(sPort)
+	serverManager.SetUserAgent(cliUserAgent())
+	if serverLog != "" {
+		serverManager.SetLogFile(serverLog)
+	}
+	if readyBody != nil {
+		serverManager.SetReadyBody(readyBody)
+	}
+	if verbose {
+		serverManager.SetLogEcho(os.Stderr)
+	}
+	// A kept server must outlive this process
+	serverManager.SetStopWithParent(!keepServer)
+	return serverManager
+}
+
+// offerServerInstall asks to install the missing screenshot server and
+// installs it with consent. It only prompts in an interactive terminal, and
+// reports whether the server is now installed.
+func offerServerInstall(ctx context.Context) bool {
+	if ciMode || runningInCI() || !isTerminal(os.Stdin) {
+		return false
+	}
 
-	// Create scan request (viewports are kept as-is, lowercase)
+	// Prompts go to stderr, which is a terminal even when stdout carries jsonl or summary lines
+	install := strings.Join(server.InstallCommand, " ")
+	fmt.Fprintf(os.Stderr, "\n📦 The screenshot server (viewport-server) is not installed.\n")
+	p := prompt.NewPrompter(os.Stdin, os.Stderr)
+	if !p.Bool(fmt.Sprintf("Install it now with '%s'?", install), false) {
+		return false
+	}
+
+	fmt.Fprintf(os.Stderr, "⏳ Running %s...\n", install)
+	if err := server.Install(ctx, os.Stderr); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n\n", err)
+		return false
+	}
+	fmt.Fprintf(os.Stderr, "✅ viewport-server installed\n\n")
+	return true
+}
+
+// warmupServer sends a throwaway scan so the browser's cold start doesn't
+// count against the real scan's timeout. Failures are only reported.
+func warmupServer(ctx context.Context, client *api.Client) {
+	fmt.Println("🔥 Warming up screenshot server...")
+
+	warmupCtx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	req := &api.ScanRequest{
+		TargetURL: "about:blank",
+		Viewports: viewports[:1],
+	}
+	if _, err := client.Scan(warmupCtx, req); err != nil {
+		fmt.Printf("⚠️  Warning: Warmup scan failed: %v\n\n", err)
+		return
+	}
+	fmt.Printf("✅ Screenshot server warmed up\n\n")
+}
+
+// stopServer shuts down the screenshot server started by this run, unless --keep-server was given
+func stopServer(serverManager *server.Manager, failed bool) {
+	if keepServer {
+		fmt.Printf("ℹ️  Screenshot server left running on %s (--keep-server)\n\n", serverManager.GetURL())
+		return
+	}
+
+	if !failed {
+		serverManager.Stop()
+		return
+	}
+
+	// Attempt to kill server on error since we started it
+	fmt.Printf("Cleaning up screenshot server on port %d...\n", serverPort)
+	if err := serverManager.Stop(); err != nil {
+		fmt.Printf("  ⚠️  Error stopping server: %v\n", err)
+	} else {
+		fmt.Printf("  ✅ Server stopped\n")
+	}
+	fmt.Println()
+}
+
+// normalizeSeverities maps the response's issue severities through
+// scan.severity_map, warning once per run about severities it can't place
+func (s *scanSession) normalizeSeverities(resp *api.ScanResponse) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, severity := range api.NormalizeSeverities(resp, s.severityMap) {
+		if s.unmappedSeverities == nil {
+			s.unmappedSeverities = make(map[string]bool)
+		}
+		if s.unmappedSeverities[severity] {
+			continue
+		}
+		s.unmappedSeverities[severity] = true
+		fmt.Printf("⚠️  Warning: unknown issue severity %q from the server; map it to one of %s with scan.severity_map\n",
+			severity, strings.Join(api.Severities, ", "))
+	}
+}
+
+// claimScanID returns id, or the next free "scan-<unix millis>" ID if a scan
+// saved earlier in the session already has it. The server names scans by the
+// millisecond they finish, so scans of a parallel batch can collide. Call
+// with s.mu held.
+func (s *scanSession) claimScanID(id string) string {
+	if s.savedIDs == nil {
+		s.savedIDs = make(map[string]bool)
+	}
+	for s.savedIDs[id] {
+		ms, err := strconv.ParseInt(strings.TrimPrefix(id, "scan-"), 10, 64)
+		if err != nil {
+			break
+		}
+		id = fmt.Sprintf("scan-%d", ms+1)
+	}
+	s.savedIDs[id] = true
+	return id
+}
+
+// newScanRequest builds the scan request sent for target
+func (s *scanSession) newScanRequest(target string) *api.ScanRequest {
+	// Viewports are kept as-is, lowercase
 	req := &api.ScanRequest{
-		TargetURL: targetURL,
+		TargetURL: target,
 		Viewports: viewports,
 		Options: &api.ScanOptions{
-			FullPage: true,
+			FullPage:        true,
+			Headers:         s.headers,
+			CaptureNetwork:  captureNetwork,
+			CaptureMixedContent: checkMixedContent,
+			NetworkProfile:  throttle,
+			CPUThrottle:     cpuThrottle,
+			ScrollPositions: scrollAt,
+			ZoomLevels:      zoomLevels,
+			SkipAnalysis:    screenshotOnly,
+			DimensionsOnly:  dimensionsOnly,
+			HostHeader:      hostHeader,
+			Devices:         s.devices,
+			CaptureRetries:  captureRetries,
+			CaptureStyles:   styleCaptures,
+			CaptureA11yTree: captureA11y,
+			CaptureDelayMs:  captureDelay,
 		},
 	}
+	if waitFonts {
+		req.Options.WaitForFonts = true
+		req.Options.WaitTimeout = waitTimeout * 1000
+	}
+	applyRoute(req.Options, s.routes, target)
+	return req
+}
+
+// autoStartServer reports whether scan starts the screenshot server itself:
+// unless --no-auto-start is given or --replay needs no server
+func autoStartServer() bool {
+	return !noAutoStart && replayDir == ""
+}
+
+// scanTarget scans a single target URL, displays and saves the results
+func (s *scanSession) scanTarget(ctx context.Context, target string) (*api.ScanResponse, error) {
+	ctx, span := tracing.Start(ctx, "scan.target", attribute.String("viewport.target_url", target))
+	resp, err := s.captureTarget(ctx, target)
+	if err == nil && s.baselines != nil {
+		err = s.checkBaseline(target, resp)
+	}
+	tracing.End(span, err)
+	if s.report != nil && resp != nil {
+		s.mu.Lock()
+		s.report.add(resp)
+		s.mu.Unlock()
+	}
+	return resp, err
+}
+
+// captureTarget does the work of scanTarget
+func (s *scanSession) captureTarget(ctx context.Context, target string) (*api.ScanResponse, error) {
+	scanURL, finalURL := s.checkRedirects(ctx, target)
+
+	req := s.newScanRequest(scanURL)
+	s.applyPresetTarget(req, target)
 
 	// Show loading message
 	fmt.Println("📸 Capturing screenshots...")
 	startTime := time.Now()
 
+	// Export metrics however the scan ends
+	var resp *api.ScanResponse
+	var elapsed time.Duration
+	scanSucceeded := false
+	if metricsFile != "" {
+		defer func() {
+			if elapsed == 0 {
+				elapsed = time.Since(startTime)
+			}
+			s.recordMetrics(target, resp, elapsed, scanSucceeded)
+		}()
+	}
+
 	// Send scan request
-	scanCtx, scanCancel := context.WithTimeout(ctx, 180*time.Second)
+	scanCtx, scanCancel := context.WithTimeout(ctx, scanDeadline())
 	defer scanCancel()
 
-	resp, err := client.Scan(scanCtx, req)
+	resp, err := s.scan(scanCtx, req)
+	if errors.Is(err, api.ErrCircuitOpen) {
+		// The server was already diagnosed on the failures that tripped the breaker
+		fmt.Printf("\n%s\n", lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("1")).Render("❌ Scan Skipped"))
+		fmt.Printf("Error: %v\n\n", err)
+		if verboseErrors {
+			s.printVerboseErrors(target, err)
+			fmt.Println()
+		}
+		return nil, withExitCode(exitScanFailed, fmt.Errorf("scan failed: %w", err))
+	}
 	if err != nil {
 		// Enhanced error reporting
 		fmt.Printf("\n%s\n", lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("1")).Render("❌ Scan Failed"))
 		fmt.Printf("Error: %v\n\n", err)
 		
-		// Check for Firefox/Playwright related errors
-		errStr := err.Error()
-		if contains(errStr, "Executable doesn't exist") || contains(errStr, "firefox") {
-			fmt.Printf("⚠️  Firefox browser binaries not found\n\n")
-			fmt.Printf("Solutions:\n")
-			fmt.Printf("  1. Install Firefox binaries:\n")
-			fmt.Printf("     npx playwright install firefox\n\n")
-			fmt.Printf("  2. Or install with system dependencies:\n")
-			fmt.Printf("     npx playwright install --with-deps firefox\n\n")
-			fmt.Printf("  3. If you're on Windows and Playwright was already installed,\n")
-			fmt.Printf("     try reinstalling:\n")
-			fmt.Printf("     npm install --force\n")
-		} else if contains(errStr, "missing dependencies") || contains(errStr, "libxcb") || 
-		   contains(errStr, "libx11") || contains(errStr, "libgtk") {
-			fmt.Printf("⚠️  System dependencies missing (common in Docker, IDX, or restricted containers)\n\n")
-			fmt.Printf("Solutions:\n")
-			fmt.Printf("  1. Install deps: sudo npx playwright install-deps\n")
-			fmt.Printf("  2. Use xvfb-run wrapper: xvfb-run npx viewport-cli scan --target <url>\n")
-			fmt.Printf("  3. Use in environment with system libraries (Linux desktop, native OS)\n")
+		if verboseErrors {
+			s.printVerboseErrors(target, err)
+			fmt.Println()
+		} else {
+			printRemediation(err.Error())
+			fmt.Printf("\n")
+			s.printDiagnostics(target)
+			fmt.Println()
 		}
-		
-		fmt.Printf("\nDiagnostics:\n")
-		fmt.Printf("  • Target URL: %s\n", targetURL)
-		fmt.Printf("  • API Server: %s\n", apiURL)
-		fmt.Printf("  • Viewports: %v\n", viewports)
-		fmt.Printf("  • Output Dir: %s\n", output)
-		
-		// Attempt to kill server on error if we started it
-		if serverManager != nil {
-			fmt.Printf("\nCleaning up screenshot server on port %d...\n", serverPort)
-			if err := serverManager.Stop(); err != nil {
-				fmt.Printf("  ⚠️  Error stopping server: %v\n", err)
-			} else {
-				fmt.Printf("  ✅ Server stopped\n")
-			}
+
+		// An unreachable server we failed to start is a startup problem, not a scan failure
+		if s.serverErr != nil && errors.Is(err, api.ErrRequestFailed) {
+			return nil, withExitCode(exitStartup, fmt.Errorf("scan failed: %w (server auto-start failed: %v)", err, s.serverErr))
+		}
+		return nil, withExitCode(exitScanFailed, fmt.Errorf("scan failed: %w", err))
+	}
+
+	elapsed = time.Since(startTime)
+	resp.RequestedURL = target
+	resp.FinalURL = finalURL
+	resp.GitCommit = s.gitCommit
+	resp.GitDirty = s.gitDirty
+	resp.NetworkProfile = req.Options.NetworkProfile
+	resp.CPUThrottle = req.Options.CPUThrottle
+	resp.AnalysisSkipped = req.Options.SkipAnalysis
+	resp.DimensionsOnly = req.Options.DimensionsOnly
+	if resp.DimensionsOnly {
+		// Servers that predate the option send screenshots anyway
+		for i := range resp.Results {
+			resp.Results[i].ScreenshotBase64 = ""
 		}
-		
-		fmt.Println()
-		return fmt.Errorf("scan failed")
 	}
+	resp.HostHeader = req.Options.HostHeader
+	resp.CaptureDelayMs = req.Options.CaptureDelayMs
+	resp.DurationMs = elapsed.Milliseconds()
+	s.normalizeSeverities(resp)
 
-	elapsed := time.Since(startTime)
+	if err := api.CheckCompatibility(resp.APIVersion); err != nil && (verbose || resp.APIVersion != 0) {
+		fmt.Printf("⚠️  Warning: %v\n", err)
+	}
 
 	// Validate that we actually got screenshots with data
-	hasValidScreenshots := false
-	for _, result := range resp.Results {
-		if len(result.ScreenshotBase64) > 0 {
-			hasValidScreenshots = true
-			break
-		}
+	emptyDevices := emptyScreenshotDevices(resp)
+	allEmpty := len(emptyDevices) == len(resp.Results)
+	failEmpty, emptyStatus := emptyScreenshotPolicy(len(emptyDevices), len(resp.Results), allowEmpty, requireAllShots)
+
+	if emptyStatus != "" {
+		// Empty screenshots were tolerated - record that in the saved status
+		fmt.Printf("\n⚠️  Warning: Empty screenshots for: %s\n", strings.Join(emptyDevices, ", "))
+		resp.Status = emptyStatus
 	}
-	
-	if !hasValidScreenshots {
+
+	if failEmpty {
 		// Enhanced error reporting for empty screenshots
 		fmt.Printf("\n%s\n", lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("1")).Render("❌ Scan Failed"))
-		fmt.Printf("Error: All screenshots are empty - browser may not have captured anything\n\n")
-		fmt.Printf("Diagnostics:\n")
-		fmt.Printf("  • Target URL: %s\n", targetURL)
-		fmt.Printf("  • API Server: %s\n", apiURL)
-		fmt.Printf("  • Viewports: %v\n", viewports)
-		fmt.Printf("  • Output Dir: %s\n", output)
+		if allEmpty {
+			fmt.Printf("Error: All screenshots are empty - browser may not have captured anything\n\n")
+		} else {
+			fmt.Printf("Error: Empty screenshots for: %s (--require-all-screenshots)\n\n", strings.Join(emptyDevices, ", "))
+		}
+		s.printDiagnostics(target)
 		fmt.Printf("\nSolutions:\n")
-		fmt.Printf("  1. Verify the target URL is accessible: curl %s\n", targetURL)
+		fmt.Printf("  1. Verify the target URL is accessible: curl %s\n", target)
 		fmt.Printf("  2. Check that Firefox binaries are installed: npx playwright install --with-deps firefox\n")
-		fmt.Printf("  3. Try increasing timeout: viewport-cli scan --target %s --server-port 3002\n\n", targetURL)
-		
-		// Attempt to kill server on error if we started it
-		if serverManager != nil {
-			fmt.Printf("Cleaning up screenshot server on port %d...\n", serverPort)
-			if err := serverManager.Stop(); err != nil {
-				fmt.Printf("  ⚠️  Error stopping server: %v\n", err)
-			} else {
-				fmt.Printf("  ✅ Server stopped\n")
+		fmt.Printf("  3. Try increasing timeout: viewport-cli scan --target %s --server-port 3002\n\n", target)
+
+		err := withExitCode(exitScanFailed, fmt.Errorf("scan failed: all screenshots are empty"))
+		if !allEmpty {
+			err = withExitCode(exitScanFailed, fmt.Errorf("scan failed: %d of %d screenshots are empty", len(emptyDevices), len(resp.Results)))
+		}
+		if verboseErrors {
+			s.printVerboseErrors(target, err)
+			fmt.Println()
+		}
+		return resp, err
+	}
+
+	scanSucceeded = true
+	warnUnmatchedStyles(resp.Results)
+	if captureA11y {
+		warnMissingA11yTrees(resp.Results)
+	}
+
+	if verbose {
+		for _, result := range resp.Results {
+			if result.CaptureRetries > 0 {
+				fmt.Printf("ℹ️  %s needed %d capture retries\n", result.Device, result.CaptureRetries)
 			}
 		}
-		
-		fmt.Println()
-		return fmt.Errorf("scan failed: all screenshots are empty")
+	}
+
+	// A 404 or 500 page captures fine, so check what the page actually returned
+	if errorAsIssue {
+		addHTTPErrorIssues(resp.Results)
+	} else {
+		for _, result := range resp.Results {
+			if result.HTTPStatus >= 400 {
+				fmt.Printf("⚠️  Warning: %s returned HTTP %s (use --error-as-issue to report it as an issue)\n",
+					result.Device, httpStatusText(result.HTTPStatus))
+			}
+		}
+	}
+
+	if checkMixedContent {
+		addMixedContentIssues(resp.Results)
+	}
+	if len(zoomLevels) > 0 {
+		addZoomIssues(resp.Results)
+	}
+
+	if len(s.references) > 0 {
+		compareReferences(resp.Results, s.references)
 	}
 
 	// Display results
 	fmt.Printf("\n%s\n", lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("2")).Render("✅ Scan Complete!"))
-	fmt.Printf("Duration: %.2fs\n", elapsed.Seconds())
+	fmt.Printf("Duration: %s\n", describeDuration(elapsed))
 	fmt.Printf("Scan ID: %s\n", lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render(resp.ScanID))
-	fmt.Printf("Status: %s\n\n", resp.Status)
+	fmt.Printf("Status: %s\n", resp.Status)
+	printHTTPStatus(resp.Results)
+	if resp.NetworkProfile != "" || resp.CPUThrottle > 0 {
+		fmt.Printf("Throttling: %s\n", describeThrottling(resp.NetworkProfile, resp.CPUThrottle))
+	}
+	fmt.Println()
 
 	// Display results table with proper alignment
 	fmt.Println("Results:")
@@ -264,56 +1391,561 @@ func runScan(cmd *cobra.Command, args []string) error {
 	for _, result := range resp.Results {
 		// Format size with proper spacing (e.g., "1920×1080")
 		sizeStr := fmt.Sprintf("%d×%d", result.Dimensions.Width, result.Dimensions.Height)
-		fmt.Printf("│ %-8s │ %-10s │ %6d │\n",
+		issuesStr := strconv.Itoa(len(result.Issues))
+		if resp.AnalysisSkipped && len(result.Issues) == 0 {
+			issuesStr = "-"
+		}
+		fmt.Printf("│ %-8s │ %-10s │ %6s │\n",
 			result.Device,
 			sizeStr,
-			len(result.Issues),
+			issuesStr,
 		)
 	}
 	fmt.Println("└──────────┴────────────┴────────┘")
 
+	if resp.AnalysisSkipped && totalIssues(resp.Results) == 0 {
+		fmt.Println("ℹ️  Issue analysis skipped (--screenshot-only)")
+	}
+	if resp.DimensionsOnly {
+		fmt.Println("ℹ️  No screenshots captured (--dimensions-only)")
+	}
+
+	if dedupeIssuesFlag && !resp.AnalysisSkipped {
+		printDedupedIssues(resp.Results)
+	}
+
+	if captureNetwork {
+		printFailedResources(resp.Results)
+	}
+
+	if checkMixedContent {
+		printMixedContent(resp.Results, req.TargetURL)
+	}
+
+	if len(scrollAt) > 0 {
+		printScrollCaptures(resp.Results)
+	}
+
+	if len(zoomLevels) > 0 {
+		printZoomCaptures(resp.Results)
+	}
+
+	if len(s.references) > 0 {
+		printReferences(resp.Results)
+	}
+
 	// Save results
-	fmt.Printf("\n💾 Saving results to %s/\n", output)
-	if err := saveResults(resp, output); err != nil {
+	if noSave {
+		fmt.Println()
+		return resp, scanGates(emptyDevices, elapsed)
+	}
+
+	fmt.Printf("\n💾 Saving results to %s/\n", filepath.Join(s.store.Location(), s.targetDirs[target]))
+	s.mu.Lock()
+	if s.appendTo == "" {
+		resp.ScanID = s.claimScanID(resp.ScanID)
+	}
+	_, saveSpan := tracing.Start(ctx, "results.save", attribute.String("viewport.scan_id", resp.ScanID))
+	scanID := resp.ScanID
+	if s.appendTo != "" {
+		var merged *api.ScanResponse
+		if merged, err = appendResults(s.store, s.appendTo, resp); err == nil {
+			scanID = merged.ScanID
+		}
+	} else {
+		err = saveResults(s.store, resp, s.targetDirs[target])
+	}
+	tracing.End(saveSpan, err)
+	s.mu.Unlock()
+	if err != nil {
 		fmt.Printf("⚠️  Warning: Failed to save results: %v\n", err)
 	} else {
 		fmt.Println("✅ Results saved successfully!")
+		if annotate {
+			printAnnotated(resp.Results)
+		}
+		if _, local := s.store.(*results.FSStore); local && s.openResults {
+			scanDir := filepath.Join(output, s.targetDirs[target], scanID)
+			if err := openPath(scanDir); err != nil {
+				fmt.Printf("⚠️  Warning: Could not open results: %v\n", err)
+			}
+		}
 	}
 
 	fmt.Println()
-	return nil
+	return resp, scanGates(emptyDevices, elapsed)
 }
 
-func saveResults(resp *api.ScanResponse, outputDir string) error {
-	// Create scan directory
-	scanDir := fmt.Sprintf("%s/%s", outputDir, resp.ScanID)
-	if err := os.MkdirAll(scanDir, 0755); err != nil {
-		return fmt.Errorf("failed to create directory: %w", err)
+// printAnnotated lists the annotated screenshots written by --annotate
+func printAnnotated(results []api.ViewportResult) {
+	var files []string
+	for _, result := range results {
+		if result.AnnotatedFile != "" {
+			files = append(files, result.AnnotatedFile)
+		}
+	}
+	if len(files) == 0 {
+		fmt.Println("ℹ️  No issue locations reported by the server, nothing to annotate")
+		return
 	}
+	fmt.Printf("🖍️  Annotated: %s\n", strings.Join(files, ", "))
+}
 
-	// Save metadata
-	metadataFile := fmt.Sprintf("%s/metadata.json", scanDir)
-	metadataJSON, err := json.MarshalIndent(resp, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal metadata: %w", err)
+// printScrollCaptures lists the scroll offsets captured for each viewport
+func printScrollCaptures(results []api.ViewportResult) {
+	fmt.Printf("\n📜 Scroll captures:\n")
+	for _, result := range results {
+		offsets := make([]string, len(result.ScrollScreenshots))
+		for i, shot := range result.ScrollScreenshots {
+			offsets[i] = fmt.Sprintf("%dpx", shot.Offset)
+		}
+		if len(offsets) == 0 {
+			offsets = []string{"none (not supported by the server?)"}
+		}
+		fmt.Printf("  • %s: %s\n", result.Device, strings.Join(offsets, ", "))
+	}
+}
+
+// describeThrottling summarizes the network profile and CPU slowdown a scan emulates
+func describeThrottling(profile string, cpu int) string {
+	var parts []string
+	if profile != "" {
+		parts = append(parts, "network "+profile)
+	}
+	if cpu > 0 {
+		parts = append(parts, fmt.Sprintf("CPU %d× slower", cpu))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// scanGates applies the checks that fail a scan once its results are saved,
+// reporting every one that fails
+func scanGates(emptyDevices []string, elapsed time.Duration) error {
+	emptyErr := emptyViewportGate(emptyDevices)
+	durationErr := durationGate(elapsed)
+	if emptyErr != nil {
+		return emptyErr
+	}
+	return durationErr
+}
+
+// describeDuration formats how long a capture took, against --max-duration when set
+func describeDuration(elapsed time.Duration) string {
+	desc := fmt.Sprintf("%.2fs", elapsed.Seconds())
+	if maxDuration <= 0 {
+		return desc
+	}
+	style := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("2"))
+	if elapsed > maxDuration {
+		style = style.Foreground(lipgloss.Color("1"))
+	}
+	return style.Render(desc) + fmt.Sprintf(" (budget %s)", maxDuration)
+}
+
+// durationGate fails a saved scan whose capture took longer than --max-duration
+func durationGate(elapsed time.Duration) error {
+	if maxDuration <= 0 || elapsed <= maxDuration {
+		return nil
+	}
+	over := (elapsed - maxDuration).Round(10 * time.Millisecond)
+	fmt.Printf("%s\n\n", lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("1")).Render(
+		fmt.Sprintf("❌ Capture took %.2fs, %s over the %s budget (--max-duration)", elapsed.Seconds(), over, maxDuration)))
+	return withExitCode(exitTooSlow, fmt.Errorf("capture took %.2fs, longer than --max-duration %s", elapsed.Seconds(), maxDuration))
+}
+
+// emptyViewportGate fails a saved scan that had any empty screenshots when
+// --fail-on-empty-viewport is set
+func emptyViewportGate(emptyDevices []string) error {
+	if !failOnEmptyViewport || len(emptyDevices) == 0 {
+		return nil
+	}
+	fmt.Printf("%s\n\n", lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("1")).Render(
+		fmt.Sprintf("❌ Empty screenshots for: %s (--fail-on-empty-viewport)", strings.Join(emptyDevices, ", "))))
+	return withExitCode(exitEmptyViewport, fmt.Errorf("empty screenshots for: %s", strings.Join(emptyDevices, ", ")))
+}
+
+// printDiagnostics prints the settings used for a failed scan
+func (s *scanSession) printDiagnostics(target string) {
+	fmt.Printf("Diagnostics:\n")
+	fmt.Printf("  • Target URL: %s\n", target)
+	fmt.Printf("  • API Server: %s\n", apiURL)
+	fmt.Printf("  • Viewports: %v\n", viewports)
+	fmt.Printf("  • Output Dir: %s\n", output)
+	if len(s.headers) > 0 {
+		fmt.Printf("  • Headers: %s\n", formatHeaders(s.headers))
+	}
+}
+
+// runScanWizard prompts for the scan settings and reports whether to open the results afterwards
+func runScanWizard() bool {
+	p := prompt.NewPrompter(os.Stdin, os.Stdout)
+
+	fmt.Printf("\n%s\n\n", lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("6")).Render("🧭 ViewPort-CLI Scan Wizard"))
+	fmt.Println("Press [Enter] to accept the default values in brackets.")
+	fmt.Println()
+
+	targetURL = p.String("Target URL", fmt.Sprintf("http://localhost:%d", port))
+
+	fmt.Printf("Available viewports: %s\n",
+		lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render("mobile (375×667), tablet (768×1024), desktop (1920×1080)"))
+	viewportsInput := p.String("Viewports (comma separated)", strings.Join(viewports, ","))
+	viewports = prompt.ParseCSV(viewportsInput)
+
+	output = p.String("Output Directory", output)
+	openResults := p.Bool("Open results when done?", false)
+	fmt.Println()
+
+	return openResults
+}
+
+// isTerminal reports whether f is attached to a terminal
+func isTerminal(f *os.File) bool {
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+}
+
+// openPath opens a file or directory with the platform's default application
+func openPath(path string) error {
+	var c *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		c = exec.Command("open", path)
+	case "windows":
+		c = exec.Command("explorer", path)
+	default:
+		c = exec.Command("xdg-open", path)
 	}
+	return c.Start()
+}
+
+// targetMetrics is what --metrics-file reports about one scanned target
+type targetMetrics struct {
+	target    string
+	issues    map[string]int // Issues by lowercased severity
+	elapsed   time.Duration
+	succeeded bool
+}
+
+// recordMetrics records a finished scan of target for --metrics-file. A
+// target scanned again, e.g. retried, keeps only its last scan.
+func (s *scanSession) recordMetrics(target string, resp *api.ScanResponse, elapsed time.Duration, succeeded bool) {
+	m := targetMetrics{target: target, issues: make(map[string]int), elapsed: elapsed, succeeded: succeeded}
+	if resp != nil {
+		for _, result := range resp.Results {
+			for _, issue := range result.Issues {
+				m.issues[strings.ToLower(issue.Severity)]++
+			}
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.targetMetrics {
+		if s.targetMetrics[i].target == target {
+			s.targetMetrics[i] = m
+			return
+		}
+	}
+	s.targetMetrics = append(s.targetMetrics, m)
+}
+
+// scanMetrics builds the Prometheus metrics describing the scanned targets,
+// each sample labelled with its target
+func scanMetrics(targets []targetMetrics) []metrics.Metric {
+	issues := metrics.Metric{
+		Name: "viewport_scan_issues",
+		Help: "Number of issues detected by the last scan of a target, by severity.",
+		Type: "gauge",
+	}
+	duration := metrics.Metric{
+		Name: "viewport_scan_duration_seconds",
+		Help: "Duration of the last scan of a target in seconds.",
+		Type: "gauge",
+	}
+	success := metrics.Metric{
+		Name: "viewport_scan_success",
+		Help: "Whether the last scan of a target succeeded (1) or failed (0).",
+		Type: "gauge",
+	}
+
+	for _, target := range targets {
+		// Always report the canonical severities so series don't disappear between runs
+		counts := make(map[string]int, len(api.Severities))
+		for _, severity := range api.Severities {
+			counts[severity] = 0
+		}
+		for severity, n := range target.issues {
+			counts[severity] += n
+		}
+		severities := make([]string, 0, len(counts))
+		for severity := range counts {
+			severities = append(severities, severity)
+		}
+		sort.Strings(severities)
+		for _, severity := range severities {
+			issues.Samples = append(issues.Samples, metrics.Sample{
+				Labels: map[string]string{"target": target.target, "severity": severity},
+				Value:  float64(counts[severity]),
+			})
+		}
 
-	if err := os.WriteFile(metadataFile, metadataJSON, 0644); err != nil {
-		return fmt.Errorf("failed to write metadata: %w", err)
+		duration.Samples = append(duration.Samples, metrics.Sample{
+			Labels: map[string]string{"target": target.target},
+			Value:  target.elapsed.Seconds(),
+		})
+		succeeded := 0.0
+		if target.succeeded {
+			succeeded = 1
+		}
+		success.Samples = append(success.Samples, metrics.Sample{
+			Labels: map[string]string{"target": target.target},
+			Value:  succeeded,
+		})
 	}
+	return []metrics.Metric{issues, duration, success}
+}
 
-	// Decode and save screenshots
+// emptyScreenshotDevices returns the devices whose screenshot came back
+// empty. A --dimensions-only scan asked for none, so it has none empty.
+func emptyScreenshotDevices(resp *api.ScanResponse) []string {
+	if resp.DimensionsOnly {
+		return nil
+	}
+	var empty []string
 	for _, result := range resp.Results {
-		screenshotFile := fmt.Sprintf("%s/%s.png", scanDir, result.Device)
+		if len(result.ScreenshotBase64) == 0 {
+			empty = append(empty, result.Device)
+		}
+	}
+	return empty
+}
+
+// emptyScreenshotPolicy decides what empty screenshots, empty of total, do to
+// a scan: by default only all of them empty fails it, --allow-empty tolerates
+// that and --require-all-screenshots fails on any. Tolerated empty
+// screenshots return the status to save, EMPTY or PARTIAL.
+func emptyScreenshotPolicy(empty, total int, allowEmpty, requireAll bool) (fail bool, status string) {
+	allEmpty := empty == total
+	if (allEmpty && !allowEmpty) || (empty > 0 && requireAll) {
+		return true, ""
+	}
+	if empty == 0 {
+		return false, ""
+	}
+	if allEmpty {
+		return false, "EMPTY"
+	}
+	return false, "PARTIAL"
+}
+
+// screenshotFlagsSet lists the options in use that need screenshots, which
+// --dimensions-only doesn't capture
+func screenshotFlagsSet() []string {
+	needs := []struct {
+		set  bool
+		flag string
+	}{
+		{screenshotOnly, "--screenshot-only"},
+		{outputStdout, "--output-stdout"},
+		{pdfPath != "", "--pdf"},
+		{annotate, "--annotate"},
+		{len(referenceFlags) > 0, "--reference"},
+		{pixelDiff, "--pixel-diff"},
+		{len(scrollAt) > 0, "--scroll-at"},
+		{len(zoomLevels) > 0, "--zoom"},
+		{maxWidth > 0 || maxHeight > 0, "--max-width/--max-height"},
+		{allowEmpty, "--allow-empty"},
+		{requireAllShots, "--require-all-screenshots"},
+		{failOnEmptyViewport, "--fail-on-empty-viewport"},
+		{appendResultsTo != "", "--append-results"},
+		{selftest, "--selftest"},
+	}
+	var flags []string
+	for _, need := range needs {
+		if need.set {
+			flags = append(flags, need.flag)
+		}
+	}
+	return flags
+}
+
+// restrictViewports narrows available to the devices named by --only, keeping
+// the order of available
+func restrictViewports(available, only []string) ([]string, error) {
+	for _, device := range only {
+		found := false
+		for _, vp := range available {
+			if strings.EqualFold(vp, device) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("--only %s: not one of the selected viewports (%s)", device, strings.Join(available, ", "))
+		}
+	}
+
+	var selected []string
+	for _, vp := range available {
+		for _, device := range only {
+			if strings.EqualFold(vp, device) {
+				selected = append(selected, vp)
+				break
+			}
+		}
+	}
+	return selected, nil
+}
+
+// defaultRouteMap is the route map --only-changed reads unless configured otherwise
+const defaultRouteMap = ".viewport-routes"
+
+// lockFileName is the advisory lock taken in the output directory during a scan
+const lockFileName = ".viewport.lock"
+
+// maxCaptureRetries is the most --capture-retries the screenshot server accepts
+const maxCaptureRetries = 10
+
+// maxConcurrency is the most batch targets --concurrency scans at once; the
+// server captures only a few pages at a time anyway
+const maxConcurrency = 16
+
+// maxCaptureDelay is the longest --delay, in milliseconds, the screenshot server waits
+const maxCaptureDelay = 30000
+
+// staleLockAge is how old a lock must be before it is assumed abandoned
+const staleLockAge = 2 * time.Hour
+
+// ensureWritableDir creates dir if needed and verifies files can be written to it
+func ensureWritableDir(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("output directory %s is not usable: %w", dir, err)
+	}
+
+	probe, err := os.CreateTemp(dir, ".viewport-write-test-*")
+	if err != nil {
+		return fmt.Errorf("output directory %s is not writable: %w", dir, err)
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+
+	return nil
+}
+
+// saveResults writes the scan's metadata and screenshots to the results
+// store, in the subdirectory dir if it isn't empty
+func saveResults(store results.Store, resp *api.ScanResponse, dir string) error {
+	scan, err := encodeScan(resp)
+	if err != nil {
+		return err
+	}
+	scan.Dir = dir
+	return store.SaveScan(scan)
+}
+
+// encodeScan names resp's screenshots and builds the metadata and files to
+// save for it
+func encodeScan(resp *api.ScanResponse) (*results.ScanFiles, error) {
+	if resp.DimensionsOnly {
+		metadataJSON, err := json.MarshalIndent(resp, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal metadata: %w", err)
+		}
+		return &results.ScanFiles{ScanID: resp.ScanID, Metadata: metadataJSON, Files: map[string][]byte{}}, nil
+	}
+
+	// Name screenshots up front so metadata records where each one lives
+	names := screenshotFileNames(screenshotName, resp.Results)
+	for i := range resp.Results {
+		resp.Results[i].ScreenshotFile = names[i]
+		for j := range resp.Results[i].ScrollScreenshots {
+			shot := &resp.Results[i].ScrollScreenshots[j]
+			shot.ScreenshotFile = scrollScreenshotName(names[i], shot.Offset)
+		}
+		for j := range resp.Results[i].ZoomCaptures {
+			capture := &resp.Results[i].ZoomCaptures[j]
+			capture.ScreenshotFile = zoomScreenshotName(names[i], capture.Zoom)
+		}
+		if ref := resp.Results[i].Reference; ref != nil && ref.DiffPNG != nil {
+			ref.DiffFile = referenceDiffFileName(names[i])
+		}
+	}
+
+	// Decode screenshots, downscaling any that exceed the size limits
+	screenshots := make([][]byte, len(resp.Results))
+	for i, result := range resp.Results {
 		screenshotData, err := base64.StdEncoding.DecodeString(result.ScreenshotBase64)
 		if err != nil {
-			return fmt.Errorf("failed to decode screenshot: %w", err)
+			return nil, fmt.Errorf("failed to decode screenshot: %w", err)
 		}
-		if err := os.WriteFile(screenshotFile, screenshotData, 0644); err != nil {
-			return fmt.Errorf("failed to write screenshot: %w", err)
+		if (maxWidth > 0 || maxHeight > 0) && len(screenshotData) > 0 {
+			data, original, saved, err := downscaleScreenshot(screenshotData, maxWidth, maxHeight)
+			if err != nil {
+				return nil, err
+			}
+			if saved != original {
+				resp.Results[i].ScreenshotBase64 = base64.StdEncoding.EncodeToString(data)
+			}
+			screenshotData = data
+			resp.Results[i].OriginalSize = &original
+			resp.Results[i].SavedSize = &saved
 		}
+		screenshots[i] = screenshotData
 	}
 
-	return nil
+	// Annotated copies, for servers that locate their issues
+	annotated := make(map[int][]byte)
+	if annotate {
+		for i, result := range resp.Results {
+			scale := 1.0
+			if result.OriginalSize != nil && result.SavedSize != nil && result.OriginalSize.Width > 0 {
+				scale = float64(result.SavedSize.Width) / float64(result.OriginalSize.Width)
+			}
+			data, ok, err := annotateScreenshot(screenshots[i], result.Issues, scale)
+			if err != nil {
+				fmt.Printf("⚠️  Warning: Could not annotate %s: %v\n", result.Device, err)
+				continue
+			}
+			if ok {
+				resp.Results[i].AnnotatedFile = annotatedFileName(result.ScreenshotFile)
+				annotated[i] = data
+			}
+		}
+	}
+
+	metadataJSON, err := json.MarshalIndent(resp, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+
+	files := make(map[string][]byte, len(resp.Results))
+	for i, result := range resp.Results {
+		files[result.ScreenshotFile] = screenshots[i]
+		if data, ok := annotated[i]; ok {
+			files[result.AnnotatedFile] = data
+		}
+		if ref := result.Reference; ref != nil && ref.DiffPNG != nil {
+			files[ref.DiffFile] = ref.DiffPNG
+		}
+		for _, shot := range result.ScrollScreenshots {
+			data, err := base64.StdEncoding.DecodeString(shot.ScreenshotBase64)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode screenshot: %w", err)
+			}
+			files[shot.ScreenshotFile] = data
+		}
+		for _, capture := range result.ZoomCaptures {
+			data, err := base64.StdEncoding.DecodeString(capture.ScreenshotBase64)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode screenshot: %w", err)
+			}
+			files[capture.ScreenshotFile] = data
+		}
+	}
+
+	return &results.ScanFiles{
+		ScanID:   resp.ScanID,
+		Metadata: metadataJSON,
+		Files:    files,
+	}, nil
 }
 
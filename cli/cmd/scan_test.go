@@ -0,0 +1,300 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/law-makers/viewport-cli/pkg/api"
+	"github.com/law-makers/viewport-cli/pkg/metrics"
+	"github.com/law-makers/viewport-cli/pkg/results"
+)
+
+func TestEnsureWritableDirCreatesDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "results", "nested")
+	if err := ensureWritableDir(dir); err != nil {
+		t.Fatalf("ensureWritableDir: %v", err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("left %d files behind, want the write probe removed", len(entries))
+	}
+}
+
+func TestEnsureWritableDirReadOnly(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root can write to read-only directories")
+	}
+	dir := filepath.Join(t.TempDir(), "results")
+	if err := os.Mkdir(dir, 0555); err != nil {
+		t.Fatal(err)
+	}
+	err := ensureWritableDir(dir)
+	if err == nil || !strings.Contains(err.Error(), "is not writable") {
+		t.Errorf("ensureWritableDir = %v, want a not writable error", err)
+	}
+}
+
+func TestEnsureWritableDirUnderFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "results")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	err := ensureWritableDir(filepath.Join(file, "scans"))
+	if err == nil || !strings.Contains(err.Error(), "is not usable") {
+		t.Errorf("ensureWritableDir = %v, want a not usable error", err)
+	}
+}
+
+func TestEmptyScreenshotPolicy(t *testing.T) {
+	tests := []struct {
+		name       string
+		empty      int
+		allowEmpty bool
+		requireAll bool
+		wantFail   bool
+		wantStatus string
+	}{
+		{"none empty", 0, false, false, false, ""},
+		{"some empty", 1, false, false, false, "PARTIAL"},
+		{"all empty", 3, false, false, true, ""},
+		{"all empty, --allow-empty", 3, true, false, false, "EMPTY"},
+		{"some empty, --allow-empty", 1, true, false, false, "PARTIAL"},
+		{"none empty, --require-all-screenshots", 0, false, true, false, ""},
+		{"some empty, --require-all-screenshots", 1, false, true, true, ""},
+		{"all empty, --require-all-screenshots", 3, false, true, true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fail, status := emptyScreenshotPolicy(tt.empty, 3, tt.allowEmpty, tt.requireAll)
+			if fail != tt.wantFail || status != tt.wantStatus {
+				t.Errorf("emptyScreenshotPolicy(%d of 3) = %v, %q, want %v, %q", tt.empty, fail, status, tt.wantFail, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestScanMetricsLabelsEachTarget(t *testing.T) {
+	s := &scanSession{}
+	found := &api.ScanResponse{Results: []api.ViewportResult{
+		{Device: "mobile", Issues: []api.DetectedIssue{{Severity: "High"}, {Severity: "low"}}},
+		{Device: "desktop", Issues: []api.DetectedIssue{{Severity: "high"}}},
+	}}
+	s.recordMetrics("https://example.com/a", nil, time.Second, false)
+	s.recordMetrics("https://example.com/b", found, 2*time.Second, true)
+	// A retried target keeps only its last scan
+	s.recordMetrics("https://example.com/a", &api.ScanResponse{}, 3*time.Second, true)
+
+	var out strings.Builder
+	if err := metrics.Format(&out, scanMetrics(s.targetMetrics)); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`viewport_scan_issues{severity="high",target="https://example.com/a"} 0`,
+		`viewport_scan_issues{severity="high",target="https://example.com/b"} 2`,
+		`viewport_scan_issues{severity="low",target="https://example.com/b"} 1`,
+		`viewport_scan_issues{severity="critical",target="https://example.com/b"} 0`,
+		`viewport_scan_duration_seconds{target="https://example.com/a"} 3`,
+		`viewport_scan_duration_seconds{target="https://example.com/b"} 2`,
+		`viewport_scan_success{target="https://example.com/a"} 1`,
+		`viewport_scan_success{target="https://example.com/b"} 1`,
+	} {
+		if !strings.Contains(out.String(), want+"\n") {
+			t.Errorf("metrics lack %s:\n%s", want, out.String())
+		}
+	}
+	if n := strings.Count(out.String(), "# TYPE "); n != 3 {
+		t.Errorf("got %d metric families, want 3, each listed once", n)
+	}
+	if n := strings.Count(out.String(), "viewport_scan_success{"); n != 2 {
+		t.Errorf("got %d success samples, want one per target", n)
+	}
+}
+
+// mixedEmptyResponse is a scan where only the tablet screenshot came back empty
+func mixedEmptyResponse() *api.ScanResponse {
+	return &api.ScanResponse{Results: []api.ViewportResult{
+		{Device: "mobile", ScreenshotBase64: "iVBORw0KGgo="},
+		{Device: "tablet"},
+		{Device: "desktop", ScreenshotBase64: "iVBORw0KGgo="},
+	}}
+}
+
+func TestEmptyViewportGateMixedResponse(t *testing.T) {
+	defer func(prev bool) { failOnEmptyViewport = prev }(failOnEmptyViewport)
+	resp := mixedEmptyResponse()
+
+	empty := emptyScreenshotDevices(resp)
+	if !reflect.DeepEqual(empty, []string{"tablet"}) {
+		t.Fatalf("empty devices = %q, want [tablet]", empty)
+	}
+	// The scan itself is saved as partial, not failed
+	if fail, status := emptyScreenshotPolicy(len(empty), len(resp.Results), false, false); fail || status != "PARTIAL" {
+		t.Errorf("policy = %v, %q, want a tolerated PARTIAL scan", fail, status)
+	}
+
+	failOnEmptyViewport = false
+	if err := emptyViewportGate(empty); err != nil {
+		t.Errorf("gate without --fail-on-empty-viewport = %v, want nil", err)
+	}
+
+	failOnEmptyViewport = true
+	err := emptyViewportGate(empty)
+	if code := exitCodeFor(err); code != exitEmptyViewport {
+		t.Errorf("gate exit code = %d (%v), want %d", code, err, exitEmptyViewport)
+	}
+	if err == nil || !strings.Contains(err.Error(), "tablet") {
+		t.Errorf("gate error = %v, want it to name the empty viewport", err)
+	}
+	if err := emptyViewportGate(nil); err != nil {
+		t.Errorf("gate with no empty screenshots = %v, want nil", err)
+	}
+}
+
+func TestEmptyScreenshotDevicesDimensionsOnly(t *testing.T) {
+	resp := mixedEmptyResponse()
+	resp.DimensionsOnly = true
+	if empty := emptyScreenshotDevices(resp); empty != nil {
+		t.Errorf("empty devices of a --dimensions-only scan = %q, want none", empty)
+	}
+}
+
+func TestCLIUserAgent(t *testing.T) {
+	defer func(prev string) { userAgent = prev }(userAgent)
+
+	userAgent = ""
+	if got, want := cliUserAgent(), "viewport-cli/"+version; got != want {
+		t.Errorf("default User-Agent = %q, want %q", got, want)
+	}
+	userAgent = "my-ci-bot/2.0"
+	if got := cliUserAgent(); got != "my-ci-bot/2.0" {
+		t.Errorf("--user-agent User-Agent = %q, want my-ci-bot/2.0", got)
+	}
+}
+
+func TestDurationGate(t *testing.T) {
+	defer func(prev time.Duration) { maxDuration = prev }(maxDuration)
+	tests := []struct {
+		budget   time.Duration
+		elapsed  time.Duration
+		wantFail bool
+	}{
+		{0, time.Hour, false},
+		{45 * time.Second, 30 * time.Second, false},
+		{45 * time.Second, 45 * time.Second, false},
+		{45 * time.Second, 45*time.Second + time.Millisecond, true},
+		{time.Second, time.Minute, true},
+	}
+	for _, tt := range tests {
+		maxDuration = tt.budget
+		err := durationGate(tt.elapsed)
+		if (err != nil) != tt.wantFail {
+			t.Errorf("durationGate(%s) with a %s budget = %v, want failure %t", tt.elapsed, tt.budget, err, tt.wantFail)
+		}
+		if err != nil && exitCodeFor(err) != exitTooSlow {
+			t.Errorf("durationGate(%s) exit code = %d, want %d", tt.elapsed, exitCodeFor(err), exitTooSlow)
+		}
+	}
+}
+
+func TestScanGatesEmptyBeforeSlow(t *testing.T) {
+	defer func(budget time.Duration, fail bool) { maxDuration, failOnEmptyViewport = budget, fail }(maxDuration, failOnEmptyViewport)
+	maxDuration, failOnEmptyViewport = time.Second, true
+
+	if code := exitCodeFor(scanGates([]string{"tablet"}, time.Minute)); code != exitEmptyViewport {
+		t.Errorf("empty and slow scan exits %d, want %d", code, exitEmptyViewport)
+	}
+	if code := exitCodeFor(scanGates(nil, time.Minute)); code != exitTooSlow {
+		t.Errorf("slow scan exits %d, want %d", code, exitTooSlow)
+	}
+	if err := scanGates(nil, time.Millisecond); err != nil {
+		t.Errorf("fast scan = %v, want nil", err)
+	}
+}
+
+func TestSlowScanSavedThenFails(t *testing.T) {
+	defer func(prev time.Duration) { maxDuration = prev }(maxDuration)
+	maxDuration = 20 * time.Millisecond
+
+	const delay = 100 * time.Millisecond
+	client := scannerFunc(func(ctx context.Context, req *api.ScanRequest) (*api.ScanResponse, error) {
+		time.Sleep(delay)
+		return &api.ScanResponse{ScanID: "scan-1", Status: "completed", Results: []api.ViewportResult{
+			{Device: "mobile", ScreenshotBase64: "iVBORw0KGgo="},
+		}}, nil
+	})
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer target.Close()
+	s := &scanSession{client: client, store: results.NewFSStore(t.TempDir()), transport: &http.Transport{}}
+
+	_, err := s.scanTarget(context.Background(), target.URL)
+	if code := exitCodeFor(err); code != exitTooSlow {
+		t.Fatalf("scan over budget = %v (exit %d), want exit %d", err, code, exitTooSlow)
+	}
+
+	// The results are saved before the gate fails the scan, with the duration trend charts
+	data, err := s.store.ReadFile("scan-1", results.MetadataFile)
+	if err != nil {
+		t.Fatalf("slow scan not saved: %v", err)
+	}
+	var saved api.ScanResponse
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+	if saved.DurationMs < delay.Milliseconds() {
+		t.Errorf("saved duration = %dms, want at least %dms", saved.DurationMs, delay.Milliseconds())
+	}
+
+	maxDuration = time.Minute
+	if _, err := s.scanTarget(context.Background(), target.URL); err != nil {
+		t.Errorf("scan within budget = %v, want nil", err)
+	}
+}
+
+func TestEmptyScreenshotsExitScanFailed(t *testing.T) {
+	defer func(allow, requireAll bool) { allowEmpty, requireAllShots = allow, requireAll }(allowEmpty, requireAllShots)
+	allowEmpty = false
+
+	tests := []struct {
+		name       string
+		shots      []string
+		requireAll bool
+		want       string
+	}{
+		{"all empty", []string{"", ""}, false, "all screenshots are empty"},
+		{"partly empty", []string{"iVBORw0KGgo=", ""}, true, "1 of 2 screenshots are empty"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requireAllShots = tt.requireAll
+			client := scannerFunc(func(ctx context.Context, req *api.ScanRequest) (*api.ScanResponse, error) {
+				resp := &api.ScanResponse{ScanID: "scan-1", Status: "completed"}
+				for i, shot := range tt.shots {
+					resp.Results = append(resp.Results, api.ViewportResult{Device: []string{"mobile", "desktop"}[i], ScreenshotBase64: shot})
+				}
+				return resp, nil
+			})
+			target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			defer target.Close()
+			s := &scanSession{client: client, store: results.NewFSStore(t.TempDir()), transport: &http.Transport{}}
+
+			_, err := s.scanTarget(context.Background(), target.URL)
+			if code := exitCodeFor(err); code != exitScanFailed {
+				t.Errorf("scan = %v (exit %d), want exit %d", err, code, exitScanFailed)
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("scan error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/law-makers/viewport-cli/pkg/api"
+)
+
+// printJSONSchema prints the JSON Schema of the scan output in format: the
+// saved metadata.json of a scan for text, or one line for jsonl
+func printJSONSchema(format string) error {
+	switch format {
+	case "text":
+		return printSchema(api.ScanResponse{}, "viewport-cli scan metadata.json")
+	case "jsonl":
+		return printSchema(jsonlLine{}, "viewport-cli scan --output-format jsonl line")
+	case "ndjson":
+		return printSchema(issueLine{}, "viewport-cli scan --output-format ndjson line")
+	default:
+		return withExitCode(exitConfigError, fmt.Errorf("invalid --output-format %q (expected text, jsonl or ndjson)", format))
+	}
+}
+
+// printSchema prints the JSON Schema of the type of v
+func printSchema(v any, title string) error {
+	schema := api.Schema(v, title)
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal schema: %w", err)
+	}
+	fmt.Fprintln(os.Stdout, string(data))
+	return nil
+}
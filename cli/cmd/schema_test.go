@@ -0,0 +1,239 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/law-makers/viewport-cli/pkg/api"
+)
+
+// fillValue sets every field reachable from v to a non-zero value, so its
+// encoding has every property. depth stops recursive types.
+func fillValue(v reflect.Value, depth int) {
+	if depth > 4 {
+		return
+	}
+	if v.Type() == reflect.TypeOf(time.Time{}) {
+		v.Set(reflect.ValueOf(time.Date(2026, 10, 14, 8, 0, 0, 0, time.UTC)))
+		return
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				fillValue(v.Field(i), depth+1)
+			}
+		}
+	case reflect.Pointer:
+		v.Set(reflect.New(v.Type().Elem()))
+		fillValue(v.Elem(), depth+1)
+	case reflect.Slice:
+		v.Set(reflect.MakeSlice(v.Type(), 1, 1))
+		fillValue(v.Index(0), depth+1)
+	case reflect.Map:
+		v.Set(reflect.MakeMap(v.Type()))
+		elem := reflect.New(v.Type().Elem()).Elem()
+		fillValue(elem, depth+1)
+		v.SetMapIndex(reflect.ValueOf("key").Convert(v.Type().Key()), elem)
+	case reflect.String:
+		v.SetString("value")
+	case reflect.Bool:
+		v.SetBool(true)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(2)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(2)
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(1.5)
+	case reflect.Interface:
+		v.Set(reflect.ValueOf("value"))
+	}
+}
+
+// schemaValidator checks decoded JSON against the subset of JSON Schema that
+// api.Schema generates. Objects with properties are closed: a property the
+// schema doesn't list is drift.
+type schemaValidator struct {
+	root map[string]any
+	errs []string
+}
+
+func (s *schemaValidator) errorf(path, format string, args ...any) {
+	s.errs = append(s.errs, path+": "+fmt.Sprintf(format, args...))
+}
+
+func (s *schemaValidator) resolve(schema map[string]any) map[string]any {
+	ref, ok := schema["$ref"].(string)
+	if !ok {
+		return schema
+	}
+	if ref == "#" {
+		return s.root
+	}
+	defs, _ := s.root["$defs"].(map[string]any)
+	def, _ := defs[strings.TrimPrefix(ref, "#/$defs/")].(map[string]any)
+	return def
+}
+
+func (s *schemaValidator) validate(path string, schema map[string]any, value any) {
+	schema = s.resolve(schema)
+	if schema == nil {
+		s.errorf(path, "unresolvable schema")
+		return
+	}
+	if anyOf, ok := schema["anyOf"].([]any); ok {
+		for _, option := range anyOf {
+			inner := &schemaValidator{root: s.root}
+			inner.validate(path, option.(map[string]any), value)
+			if len(inner.errs) == 0 {
+				return
+			}
+		}
+		s.errorf(path, "%v matches none of the anyOf schemas", value)
+		return
+	}
+
+	switch schema["type"] {
+	case nil:
+		// Anything goes
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			s.errorf(path, "%T is not an object", value)
+			return
+		}
+		properties, _ := schema["properties"].(map[string]any)
+		for _, name := range schemaRequired(schema) {
+			if _, ok := object[name]; !ok {
+				s.errorf(path, "required property %q is missing", name)
+			}
+		}
+		for name, v := range object {
+			if property, ok := properties[name].(map[string]any); ok {
+				s.validate(path+"."+name, property, v)
+			} else if additional, ok := schema["additionalProperties"].(map[string]any); ok {
+				s.validate(path+"."+name, additional, v)
+			} else {
+				s.errorf(path, "property %q is not in the schema", name)
+			}
+		}
+	case "array":
+		items, ok := value.([]any)
+		if !ok {
+			s.errorf(path, "%T is not an array", value)
+			return
+		}
+		for i, item := range items {
+			s.validate(fmt.Sprintf("%s[%d]", path, i), schema["items"].(map[string]any), item)
+		}
+	case "string":
+		if _, ok := value.(string); !ok {
+			s.errorf(path, "%T is not a string", value)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			s.errorf(path, "%T is not a boolean", value)
+		}
+	case "integer":
+		if n, ok := value.(float64); !ok || n != float64(int64(n)) {
+			s.errorf(path, "%v is not an integer", value)
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			s.errorf(path, "%T is not a number", value)
+		}
+	case "null":
+		if value != nil {
+			s.errorf(path, "%v is not null", value)
+		}
+	default:
+		s.errorf(path, "unknown type %v", schema["type"])
+	}
+}
+
+func schemaRequired(schema map[string]any) []string {
+	switch required := schema["required"].(type) {
+	case []string:
+		return required
+	case []any:
+		names := make([]string, len(required))
+		for i, name := range required {
+			names[i] = name.(string)
+		}
+		return names
+	}
+	return nil
+}
+
+// checkSchema validates the encoding of v against the schema generated for its type
+func checkSchema(t *testing.T, v any) {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSchemaJSON(t, v, data)
+}
+
+// checkSchemaJSON validates data against the schema generated for the type of v
+func checkSchemaJSON(t *testing.T, v any, data []byte) {
+	t.Helper()
+	var decoded any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	s := &schemaValidator{root: api.Schema(v, "test")}
+	s.validate("$", s.root, decoded)
+	sort.Strings(s.errs)
+	for _, e := range s.errs {
+		t.Error(e)
+	}
+}
+
+func TestJSONSchemaMatchesOutput(t *testing.T) {
+	for _, v := range []any{&api.ScanResponse{}, &jsonlLine{}, &issueLine{}} {
+		name := reflect.TypeOf(v).Elem().Name()
+		t.Run(name, func(t *testing.T) {
+			// Zero values leave out omitempty fields and encode nil as null
+			zero := reflect.New(reflect.TypeOf(v).Elem())
+			checkSchema(t, zero.Interface())
+
+			full := reflect.New(reflect.TypeOf(v).Elem())
+			fillValue(full.Elem(), 0)
+			checkSchema(t, full.Interface())
+		})
+	}
+}
+
+func TestJSONSchemaMatchesScanOutput(t *testing.T) {
+	resp := testScanResponse()
+	checkSchema(t, resp)
+
+	var buf strings.Builder
+	w := newJSONLWriter(&buf)
+	if err := w.emit(batchResult{Target: "https://example.com", ScanID: resp.ScanID, Issues: 3, Duration: time.Second}, resp); err != nil {
+		t.Fatal(err)
+	}
+	checkSchemaJSON(t, jsonlLine{}, []byte(buf.String()))
+
+	buf.Reset()
+	w = newIssueWriter(&buf)
+	if err := w.emit(batchResult{Target: "https://example.com", ScanID: resp.ScanID, Issues: 3, Duration: time.Second}, resp); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+		checkSchemaJSON(t, issueLine{}, []byte(line))
+	}
+}
+
+func TestPrintJSONSchemaUnknownFormat(t *testing.T) {
+	err := printJSONSchema("xml")
+	if err == nil || exitCodeFor(err) != exitConfigError {
+		t.Errorf("printJSONSchema(xml) = %v, want a config error", err)
+	}
+}
@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image/png"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/law-makers/viewport-cli/pkg/api"
+)
+
+// selftestPageHeight is the height of the self-test page, taller than every
+// default viewport so a working full-page capture is longer than the viewport
+const selftestPageHeight = 2000
+
+// selftestPage is the known-good page scanned by --selftest
+var selftestPage = fmt.Sprintf(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>viewport-cli self-test</title>
+<style>
+  html, body { margin: 0; padding: 0; }
+  body { height: %dpx; font-family: sans-serif; background: linear-gradient(#2563eb, #16a34a); color: #fff; }
+  h1 { margin: 0; padding: 24px; }
+</style>
+</head>
+<body><h1>viewport-cli self-test</h1></body>
+</html>
+`, selftestPageHeight)
+
+// startSelftestServer serves the self-test page on an ephemeral local port,
+// returning its URL and a function that shuts the server down
+func startSelftestServer() (string, func(), error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to start self-test page server: %w", err)
+	}
+
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, selftestPage)
+	})}
+	go srv.Serve(listener)
+
+	return "http://" + listener.Addr().String() + "/", func() { srv.Close() }, nil
+}
+
+// selftestCheck is one assertion of the self-test
+type selftestCheck struct {
+	name string
+	err  error
+}
+
+// checkSelftest verifies the self-test scan: every viewport captured, each
+// screenshot a PNG as wide as its viewport and as tall as the whole page,
+// and the results saved. It prints a pass/fail report and returns an error
+// with exit code exitScanFailed if any check failed.
+func (s *scanSession) checkSelftest(target string, resp *api.ScanResponse, scanErr error) error {
+	var checks []selftestCheck
+	check := func(name string, err error) {
+		checks = append(checks, selftestCheck{name: name, err: err})
+	}
+
+	if resp == nil {
+		if scanErr == nil {
+			scanErr = fmt.Errorf("no response")
+		}
+		check("scan", scanErr)
+	} else {
+		check("scan", scanErr)
+
+		byDevice := make(map[string]api.ViewportResult, len(resp.Results))
+		for _, result := range resp.Results {
+			byDevice[result.Device] = result
+		}
+		for _, device := range viewports {
+			result, ok := byDevice[device]
+			if !ok {
+				check(device+" captured", fmt.Errorf("missing from the response"))
+				continue
+			}
+			check(device+" screenshot", checkSelftestScreenshot(result))
+		}
+
+		if s.store != nil {
+			check("results saved", checkSelftestSaved(s.store.Location(), resp))
+		}
+	}
+
+	failed := 0
+	fmt.Printf("%s\n", lipgloss.NewStyle().Bold(true).Render("🧪 Self-test"))
+	for _, c := range checks {
+		if c.err != nil {
+			failed++
+			fmt.Printf("  %s %s: %v\n", lipgloss.NewStyle().Foreground(lipgloss.Color("1")).Render("✗"), c.name, c.err)
+		} else {
+			fmt.Printf("  %s %s\n", lipgloss.NewStyle().Foreground(lipgloss.Color("2")).Render("✓"), c.name)
+		}
+	}
+	fmt.Println()
+
+	if failed > 0 {
+		fmt.Printf("%s\n\n", lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("1")).Render(fmt.Sprintf("❌ Self-test failed (%d of %d checks)", failed, len(checks))))
+		s.printDiagnostics(target)
+		fmt.Println()
+		return withExitCode(exitScanFailed, fmt.Errorf("self-test failed"))
+	}
+	fmt.Printf("%s\n\n", lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("2")).Render("✅ Self-test passed"))
+	return nil
+}
+
+// checkSelftestScreenshot checks a self-test screenshot is a PNG matching its viewport's width
+// (or a multiple of it, for high-DPI captures) and covering the whole page
+func checkSelftestScreenshot(result api.ViewportResult) error {
+	if result.ScreenshotBase64 == "" {
+		return fmt.Errorf("empty screenshot")
+	}
+	data, err := base64.StdEncoding.DecodeString(result.ScreenshotBase64)
+	if err != nil {
+		return fmt.Errorf("screenshot is not valid base64: %w", err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("screenshot is not a PNG: %w", err)
+	}
+
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	want := result.Dimensions.Width
+	if want <= 0 {
+		return fmt.Errorf("server reported no viewport width")
+	}
+	if width < want || width%want != 0 {
+		return fmt.Errorf("screenshot is %dpx wide, expected %dpx", width, want)
+	}
+	scale := width / want
+	if height < selftestPageHeight*scale {
+		return fmt.Errorf("screenshot is %dpx tall, expected the full %dpx page", height, selftestPageHeight*scale)
+	}
+	return nil
+}
+
+// checkSelftestSaved checks the scan's metadata and screenshots were written under dir
+func checkSelftestSaved(dir string, resp *api.ScanResponse) error {
+	files := []string{"metadata.json"}
+	for _, result := range resp.Results {
+		files = append(files, result.ScreenshotFile)
+	}
+	for _, name := range files {
+		info, err := os.Stat(filepath.Join(dir, resp.ScanID, name))
+		if err != nil {
+			return fmt.Errorf("%s not written: %w", name, err)
+		}
+		if info.Size() == 0 {
+			return fmt.Errorf("%s is empty", name)
+		}
+	}
+	return nil
+}
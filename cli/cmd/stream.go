@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/law-makers/viewport-cli/pkg/api"
+)
+
+// scan sends req, split into requests of at most s.viewportChunk viewports.
+// If the server refuses a request for having too many viewports, the chunk
+// size is lowered to the server's limit (from the error or its health
+// endpoint, else by halving) and the scan retried; the smaller size is kept
+// for the rest of the session.
+func (s *scanSession) scan(ctx context.Context, req *api.ScanRequest) (*api.ScanResponse, error) {
+	for {
+		s.mu.Lock()
+		chunk := s.viewportChunk
+		s.mu.Unlock()
+
+		resp, err := s.scanChunks(ctx, req, chunk)
+		var limitErr *api.TooManyViewportsError
+		if !errors.As(err, &limitErr) {
+			return resp, err
+		}
+
+		current := len(req.Viewports)
+		if chunk > 0 && chunk < current {
+			current = chunk
+		}
+		size := s.viewportLimit(ctx, limitErr, current)
+		if size <= 0 {
+			return nil, err
+		}
+		if verbose {
+			fmt.Printf("ℹ️  %v; retrying with up to %d viewports per request\n", err, size)
+		}
+		s.mu.Lock()
+		if s.viewportChunk == 0 || size < s.viewportChunk {
+			s.viewportChunk = size
+		}
+		s.mu.Unlock()
+	}
+}
+
+// viewportLimit picks a chunk size below current after a too-many-viewports
+// error, or 0 if the request can't be split any further
+func (s *scanSession) viewportLimit(ctx context.Context, limitErr *api.TooManyViewportsError, current int) int {
+	limit := limitErr.Max
+	if limit <= 0 {
+		if client, ok := s.client.(*api.Client); ok {
+			if info, err := client.Info(ctx); err == nil {
+				limit = info.MaxViewports
+			}
+		}
+	}
+	if limit <= 0 || limit >= current {
+		limit = current / 2
+	}
+	return limit
+}
+
+// scanChunks sends req as one request per chunk of up to size viewports and
+// merges the responses
+func (s *scanSession) scanChunks(ctx context.Context, req *api.ScanRequest, size int) (*api.ScanResponse, error) {
+	chunks := api.ChunkViewports(req.Viewports, size)
+	if len(chunks) == 1 {
+		return s.scanOnce(ctx, req)
+	}
+
+	if verbose {
+		fmt.Printf("ℹ️  Scanning %d viewports in %d requests of up to %d\n", len(req.Viewports), len(chunks), size)
+	}
+	responses := make([]*api.ScanResponse, 0, len(chunks))
+	for _, chunk := range chunks {
+		chunkReq := *req
+		chunkReq.Viewports = chunk
+		resp, err := s.scanOnce(ctx, &chunkReq)
+		if err != nil {
+			return nil, err
+		}
+		responses = append(responses, resp)
+	}
+	return api.MergeResponses(responses), nil
+}
+
+// scanOnce sends req with scanAttempt, retrying a request that timed out with
+// a longer timeout for --timeout-retry-escalation
+func (s *scanSession) scanOnce(ctx context.Context, req *api.ScanRequest) (*api.ScanResponse, error) {
+	timeouts := scanAttemptTimeouts()
+	if len(timeouts) == 1 {
+		return s.scanAttempt(ctx, req)
+	}
+
+	for i := 0; ; i++ {
+		attemptCtx, cancel := context.WithTimeout(ctx, timeouts[i])
+		resp, err := s.scanAttempt(attemptCtx, req)
+		// Only this attempt's own deadline counts, not the scan being cancelled
+		timedOut := err != nil && errors.Is(attemptCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil
+		cancel()
+		if !timedOut {
+			return resp, err
+		}
+		if i == len(timeouts)-1 {
+			return nil, fmt.Errorf("scan request timed out %d times, finally after %s: %w", i+1, timeouts[i], err)
+		}
+		fmt.Printf("⏱️  Scan request timed out after %s; retrying with a %s timeout\n", timeouts[i], timeouts[i+1])
+	}
+}
+
+// firstAttemptTimeout is the timeout of the first attempt at a scan request
+var firstAttemptTimeout = api.DefaultTimeout
+
+// scanAttemptTimeouts returns the timeout of each attempt at a scan request:
+// only firstAttemptTimeout, or with --timeout-retry-escalation one more for
+// each --max-retries, each the previous one times the factor, up to
+// --timeout-retry-max
+func scanAttemptTimeouts() []time.Duration {
+	timeouts := []time.Duration{firstAttemptTimeout}
+	if timeoutEscalation <= 1 {
+		return timeouts
+	}
+	limit := time.Duration(timeoutRetryMax) * time.Second
+	for len(timeouts) <= maxRetries {
+		next := time.Duration(float64(timeouts[len(timeouts)-1]) * timeoutEscalation).Round(time.Second)
+		if next > limit {
+			next = limit
+		}
+		if next <= timeouts[len(timeouts)-1] {
+			break
+		}
+		timeouts = append(timeouts, next)
+	}
+	return timeouts
+}
+
+// scanDeadline is how long scanning one target may take in all, leaving a
+// minute beyond the timeouts of its attempts for connection retries
+func scanDeadline() time.Duration {
+	total := time.Minute
+	for _, timeout := range scanAttemptTimeouts() {
+		total += timeout
+	}
+	return total
+}
+
+// scanAttempt sends req, streaming live progress when the server supports it
+// and falling back to a regular request when it doesn't
+func (s *scanSession) scanAttempt(ctx context.Context, req *api.ScanRequest) (*api.ScanResponse, error) {
+	client, ok := s.client.(*api.Client)
+	if !ok || noStream {
+		return s.client.Scan(ctx, req)
+	}
+
+	resp, err := client.ScanStream(ctx, req, printProgress)
+	if errors.Is(err, api.ErrStreamingUnsupported) {
+		if verbose {
+			fmt.Printf("ℹ️  %v; waiting for the full response instead\n", err)
+		}
+		// Servers that stream NDJSON results still show them as they arrive
+		return client.ScanNDJSON(ctx, req, printProgress)
+	}
+	return resp, err
+}
+
+// printProgress renders one streaming progress event
+func printProgress(event api.ProgressEvent) {
+	switch event.Type {
+	case api.EventViewportStarted:
+		fmt.Printf("  ⏳ %s: capturing...\n", event.Device)
+	case api.EventScreenshotCaptured:
+		fmt.Printf("  📷 %s: screenshot captured\n", event.Device)
+	case api.EventAnalysisDone:
+		if event.Device != "" {
+			fmt.Printf("  🔎 %s: analysis done\n", event.Device)
+		} else {
+			fmt.Println("  🔎 Analysis done")
+		}
+	case api.EventViewportResult:
+		if event.Viewport == nil {
+			return
+		}
+		fmt.Printf("  ✅ %s: done (%d issues)\n", event.Device, len(event.Viewport.Issues))
+	case api.EventReconnecting:
+		fmt.Printf("  ⚠️  Progress stream dropped (%s), reconnecting...\n", event.Message)
+	case api.EventComplete, api.EventError:
+		// Reported by the caller with the result
+	default:
+		if event.Message != "" && verbose {
+			fmt.Printf("  • %s\n", event.Message)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/law-makers/viewport-cli/pkg/api"
+)
+
+// setTimeoutEscalation sets the --timeout-retry-escalation flags for a test
+func setTimeoutEscalation(t *testing.T, factor float64, maxSeconds, retries int) {
+	t.Helper()
+	oldFactor, oldMax, oldRetries := timeoutEscalation, timeoutRetryMax, maxRetries
+	t.Cleanup(func() { timeoutEscalation, timeoutRetryMax, maxRetries = oldFactor, oldMax, oldRetries })
+	timeoutEscalation, timeoutRetryMax, maxRetries = factor, maxSeconds, retries
+}
+
+func TestScanAttemptTimeouts(t *testing.T) {
+	s := time.Second
+	tests := []struct {
+		name       string
+		factor     float64
+		maxSeconds int
+		retries    int
+		want       []time.Duration
+	}{
+		{"off", 0, 600, 2, []time.Duration{120 * s}},
+		{"one per retry", 1.5, 600, 2, []time.Duration{120 * s, 180 * s, 270 * s}},
+		{"capped", 1.5, 200, 3, []time.Duration{120 * s, 180 * s, 200 * s}},
+		{"cap at the default", 2, 120, 2, []time.Duration{120 * s}},
+		{"no retries", 2, 600, 0, []time.Duration{120 * s}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setTimeoutEscalation(t, tt.factor, tt.maxSeconds, tt.retries)
+			if got := scanAttemptTimeouts(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("scanAttemptTimeouts = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// slowScanner times out the first slow requests and answers the rest. It
+// records the timeout each request got, 0 for one without a deadline.
+func slowScanner(slow int, timeouts *[]time.Duration) api.Scanner {
+	return scannerFunc(func(ctx context.Context, req *api.ScanRequest) (*api.ScanResponse, error) {
+		var timeout time.Duration
+		if deadline, ok := ctx.Deadline(); ok {
+			timeout = time.Until(deadline)
+		}
+		*timeouts = append(*timeouts, timeout)
+		if len(*timeouts) <= slow {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return &api.ScanResponse{ScanID: "scan-1", Status: "completed"}, nil
+	})
+}
+
+func TestScanOnceTimeoutThenSuccess(t *testing.T) {
+	defer func(d time.Duration) { firstAttemptTimeout = d }(firstAttemptTimeout)
+	firstAttemptTimeout = time.Second
+	setTimeoutEscalation(t, 2, 600, 2)
+
+	var attempts []time.Duration
+	s := &scanSession{client: slowScanner(1, &attempts)}
+	resp, err := s.scanOnce(context.Background(), &api.ScanRequest{TargetURL: "https://example.com"})
+	if err != nil {
+		t.Fatalf("scanOnce: %v", err)
+	}
+	if resp.ScanID != "scan-1" {
+		t.Errorf("scan ID = %q, want the retry's scan-1", resp.ScanID)
+	}
+	if len(attempts) != 2 {
+		t.Fatalf("%d attempts, want a timed out one and its retry", len(attempts))
+	}
+	// The retry gets twice the timeout that ran out
+	for i, want := range []time.Duration{time.Second, 2 * time.Second} {
+		if got := attempts[i]; got > want || got < want-100*time.Millisecond {
+			t.Errorf("attempt %d had a %s timeout, want %s", i+1, got, want)
+		}
+	}
+}
+
+func TestScanOnceOnlyRetriesTimeouts(t *testing.T) {
+	setTimeoutEscalation(t, 2, 600, 2)
+
+	calls := 0
+	refused := errors.New("connection refused")
+	s := &scanSession{client: scannerFunc(func(ctx context.Context, req *api.ScanRequest) (*api.ScanResponse, error) {
+		calls++
+		return nil, refused
+	})}
+	if _, err := s.scanOnce(context.Background(), &api.ScanRequest{}); !errors.Is(err, refused) {
+		t.Errorf("scanOnce = %v, want the server's error", err)
+	}
+	if calls != 1 {
+		t.Errorf("a failure other than a timeout was sent %d times", calls)
+	}
+}
+
+func TestScanOnceCancelledIsNotRetried(t *testing.T) {
+	setTimeoutEscalation(t, 2, 600, 2)
+
+	var attempts []time.Duration
+	s := &scanSession{client: slowScanner(3, &attempts)}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := s.scanOnce(ctx, &api.ScanRequest{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("scanOnce = %v, want the scan's own deadline", err)
+	}
+	if len(attempts) != 1 {
+		t.Errorf("a cancelled scan was attempted %d times", len(attempts))
+	}
+}
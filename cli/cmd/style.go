@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/law-makers/viewport-cli/pkg/config"
+	"github.com/muesli/termenv"
+)
+
+// severityColors maps lower-case severity names to lipgloss colors
+var severityColors = config.DefaultSeverityColors()
+
+// applyDisplayConfig applies the display settings shared by every command
+func applyDisplayConfig(cfg *config.Config) {
+	if cfg != nil {
+		for severity, color := range cfg.Display.SeverityColors {
+			// Invalid colors are reported by 'config validate'; keep the default here
+			if config.ValidColor(color) {
+				severityColors[strings.ToLower(severity)] = color
+			}
+		}
+	}
+
+	if noColor || (cfg != nil && cfg.Display.NoColor) {
+		lipgloss.SetColorProfile(termenv.Ascii)
+	}
+}
+
+// renderSeverity renders a severity label in its configured color
+func renderSeverity(severity string) string {
+	style := lipgloss.NewStyle().Bold(true)
+	if color, ok := severityColors[strings.ToLower(severity)]; ok {
+		style = style.Foreground(lipgloss.Color(color))
+	}
+	return style.Render("[" + severity + "]")
+}
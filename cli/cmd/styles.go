@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/law-makers/viewport-cli/pkg/api"
+)
+
+// cssProperty matches CSS property names, including custom properties (--name)
+var cssProperty = regexp.MustCompile(`^(--[A-Za-z0-9_-]+|-?[a-z][a-z0-9-]*)$`)
+
+// parseStyleCaptures parses --capture-style values of the form
+// "<selector>:<property>,<property>...". The selector ends at the last colon, so
+// pseudo-classes like "li:first-child:color" work. Properties of repeated
+// selectors are merged, in the order given.
+func parseStyleCaptures(specs []string) ([]api.StyleCapture, error) {
+	var captures []api.StyleCapture
+	index := make(map[string]int)
+	for _, spec := range specs {
+		i := strings.LastIndex(spec, ":")
+		if i < 0 {
+			return nil, fmt.Errorf("invalid --capture-style %q: expected <selector>:<property>,... e.g. '.header:display,width'", spec)
+		}
+		selector := strings.TrimSpace(spec[:i])
+		if selector == "" {
+			return nil, fmt.Errorf("invalid --capture-style %q: the selector is empty", spec)
+		}
+
+		var properties []string
+		for _, property := range strings.Split(spec[i+1:], ",") {
+			property = strings.TrimSpace(property)
+			if !strings.HasPrefix(property, "--") {
+				property = strings.ToLower(property)
+			}
+			if !cssProperty.MatchString(property) {
+				return nil, fmt.Errorf("invalid --capture-style %q: %q is not a CSS property name", spec, property)
+			}
+			properties = append(properties, property)
+		}
+
+		j, ok := index[selector]
+		if !ok {
+			index[selector] = len(captures)
+			captures = append(captures, api.StyleCapture{Selector: selector})
+			j = len(captures) - 1
+		}
+		for _, property := range properties {
+			if !containsString(captures[j].Properties, property) {
+				captures[j].Properties = append(captures[j].Properties, property)
+			}
+		}
+	}
+	return captures, nil
+}
+
+// warnUnmatchedStyles warns once per selector that matched nothing, or that
+// the server couldn't evaluate, listing the devices it happened on
+func warnUnmatchedStyles(results []api.ViewportResult) {
+	var selectors []string
+	devices := make(map[string][]string)
+	errs := make(map[string]string)
+	for _, result := range results {
+		for _, style := range result.Styles {
+			if style.Matched {
+				continue
+			}
+			if _, seen := devices[style.Selector]; !seen {
+				selectors = append(selectors, style.Selector)
+			}
+			devices[style.Selector] = append(devices[style.Selector], result.Device)
+			if style.Error != "" {
+				errs[style.Selector] = style.Error
+			}
+		}
+	}
+
+	for _, selector := range selectors {
+		if err, ok := errs[selector]; ok {
+			fmt.Printf("⚠️  Warning: --capture-style selector %q failed: %s\n", selector, err)
+			continue
+		}
+		fmt.Printf("⚠️  Warning: --capture-style selector %q matched nothing on %s\n", selector, strings.Join(devices[selector], ", "))
+	}
+}
+
+// formatStyle renders an element style as "display: flex, width: 375px" in property name order
+func formatStyle(properties map[string]string) string {
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = name + ": " + properties[name]
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/law-makers/viewport-cli/pkg/api"
+)
+
+// summaryWriter prints the --summary-only line of each scan, safe for concurrent use
+type summaryWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// emit writes the summary line of a finished scan of target
+func (s *summaryWriter) emit(target string, resp *api.ScanResponse, err error, elapsed time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fmt.Fprintln(s.w, summaryLine(target, resp, err, elapsed))
+}
+
+// summaryLine formats the one-line result of a scan, e.g.
+// "example.com: FAIL (3 high, 5 medium) in 4.2s". A scan that found no
+// issues passes; one that couldn't complete is an ERROR with its reason.
+func summaryLine(target string, resp *api.ScanResponse, err error, elapsed time.Duration) string {
+	name := summaryTarget(target)
+	duration := fmt.Sprintf("in %.1fs", elapsed.Seconds())
+	if err != nil {
+		reason, _, _ := strings.Cut(err.Error(), "\n")
+		return fmt.Sprintf("%s: ERROR (%s) %s", name, reason, duration)
+	}
+
+	var details []string
+	issues := 0
+	if resp != nil {
+		counts := make(map[string]int)
+		for _, result := range resp.Results {
+			for _, issue := range result.Issues {
+				counts[issue.Severity]++
+				issues++
+			}
+		}
+		details = severityCounts(counts)
+		if empty := len(emptyScreenshotDevices(resp)); empty > 0 {
+			details = append(details, fmt.Sprintf("%d empty", empty))
+		}
+		if resp.AnalysisSkipped {
+			details = append(details, "screenshots only")
+		}
+		if resp.DimensionsOnly {
+			details = append(details, "dimensions only")
+		}
+	}
+
+	status := "PASS"
+	if issues > 0 {
+		status = "FAIL"
+	}
+	if len(details) == 0 {
+		return fmt.Sprintf("%s: %s %s", name, status, duration)
+	}
+	return fmt.Sprintf("%s: %s (%s) %s", name, status, strings.Join(details, ", "), duration)
+}
+
+// severityCounts lists issue counts most severe first, e.g. "3 high",
+// followed by severities outside the canonical set in name order
+func severityCounts(counts map[string]int) []string {
+	var parts []string
+	for _, severity := range api.Severities {
+		if counts[severity] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[severity], severity))
+		}
+	}
+	var other []string
+	for severity := range counts {
+		if !api.IsSeverity(severity) {
+			other = append(other, severity)
+		}
+	}
+	sort.Strings(other)
+	for _, severity := range other {
+		name := severity
+		if name == "" {
+			name = "unrated"
+		}
+		parts = append(parts, fmt.Sprintf("%d %s", counts[severity], name))
+	}
+	return parts
+}
+
+// summaryTarget shortens a target URL for the summary line by dropping the
+// scheme and a trailing slash
+func summaryTarget(target string) string {
+	if _, rest, ok := strings.Cut(target, "://"); ok {
+		target = rest
+	}
+	return strings.TrimSuffix(target, "/")
+}
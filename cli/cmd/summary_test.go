@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/law-makers/viewport-cli/pkg/api"
+)
+
+// issuesOf returns one issue per severity given
+func issuesOf(severities ...string) []api.DetectedIssue {
+	issues := make([]api.DetectedIssue, len(severities))
+	for i, severity := range severities {
+		issues[i] = api.DetectedIssue{Severity: severity, Type: "overlap"}
+	}
+	return issues
+}
+
+func TestSummaryLine(t *testing.T) {
+	png := "iVBORw0KGgo="
+	tests := []struct {
+		name   string
+		target string
+		resp   *api.ScanResponse
+		err    error
+		want   string
+	}{
+		{
+			name:   "no issues",
+			target: "https://example.com/",
+			resp:   &api.ScanResponse{Results: []api.ViewportResult{{Device: "mobile", ScreenshotBase64: png}}},
+			want:   "example.com: PASS in 4.2s",
+		},
+		{
+			name:   "most severe first",
+			target: "https://example.com",
+			resp: &api.ScanResponse{Results: []api.ViewportResult{
+				{Device: "mobile", ScreenshotBase64: png, Issues: issuesOf("medium", "high", "medium")},
+				{Device: "desktop", ScreenshotBase64: png, Issues: issuesOf("high", "medium", "high", "medium", "medium")},
+			}},
+			want: "example.com: FAIL (3 high, 5 medium) in 4.2s",
+		},
+		{
+			name:   "unknown and unrated severities last",
+			target: "http://localhost:3000/app",
+			resp: &api.ScanResponse{Results: []api.ViewportResult{
+				{Device: "mobile", ScreenshotBase64: png, Issues: issuesOf("", "warning", "critical", "low", "")},
+			}},
+			want: "localhost:3000/app: FAIL (1 critical, 1 low, 2 unrated, 1 warning) in 4.2s",
+		},
+		{
+			name:   "empty screenshots",
+			target: "https://example.com",
+			resp: &api.ScanResponse{Results: []api.ViewportResult{
+				{Device: "mobile", ScreenshotBase64: png, Issues: issuesOf("low")},
+				{Device: "tablet"},
+			}},
+			want: "example.com: FAIL (1 low, 1 empty) in 4.2s",
+		},
+		{
+			name:   "screenshots only",
+			target: "https://example.com",
+			resp:   &api.ScanResponse{AnalysisSkipped: true, Results: []api.ViewportResult{{Device: "mobile", ScreenshotBase64: png}}},
+			want:   "example.com: PASS (screenshots only) in 4.2s",
+		},
+		{
+			name:   "dimensions only",
+			target: "https://example.com",
+			resp:   &api.ScanResponse{DimensionsOnly: true, Results: []api.ViewportResult{{Device: "mobile"}}},
+			want:   "example.com: PASS (dimensions only) in 4.2s",
+		},
+		{
+			name:   "error keeps the first line",
+			target: "https://example.com",
+			err:    errors.New("connection refused\nis the server running?"),
+			want:   "example.com: ERROR (connection refused) in 4.2s",
+		},
+		{
+			name:   "no response",
+			target: "example.com",
+			want:   "example.com: PASS in 4.2s",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := summaryLine(tt.target, tt.resp, tt.err, 4200*time.Millisecond); got != tt.want {
+				t.Errorf("summaryLine = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSummaryWriterOneLinePerScan(t *testing.T) {
+	var buf strings.Builder
+	s := &summaryWriter{w: &buf}
+
+	const scans = 20
+	var wg sync.WaitGroup
+	for i := 0; i < scans; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.emit("https://example.com", &api.ScanResponse{}, nil, time.Second)
+		}()
+	}
+	wg.Wait()
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != scans {
+		t.Fatalf("got %d lines for %d scans", len(lines), scans)
+	}
+	for _, line := range lines {
+		if line != "example.com: PASS in 1.0s" {
+			t.Errorf("line = %q", line)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// normalizeTarget turns a target as typed by a user into an absolute http or
+// https URL, assuming http:// when no scheme is given (e.g. "localhost:3000"
+// or "example.com/about")
+func normalizeTarget(raw string) (string, error) {
+	target := strings.TrimSpace(raw)
+	if target == "" {
+		return "", fmt.Errorf("target URL is empty")
+	}
+	if !strings.Contains(target, "://") {
+		target = "http://" + strings.TrimPrefix(target, "//")
+	}
+
+	u, err := url.Parse(target)
+	if err != nil {
+		return "", fmt.Errorf("invalid target URL %q: %w", raw, err)
+	}
+	switch u.Scheme {
+	case "http", "https":
+	default:
+		return "", fmt.Errorf("unsupported scheme %q in target URL %q (use http or https)", u.Scheme, raw)
+	}
+	if u.Hostname() == "" {
+		return "", fmt.Errorf("target URL %q has no host", raw)
+	}
+	return u.String(), nil
+}
+
+// normalizeTargets normalizes every target, warning once per public host
+// scanned over plain http, where https was probably meant
+func normalizeTargets(targets []string) ([]string, error) {
+	normalized := make([]string, len(targets))
+	warned := make(map[string]bool)
+	for i, raw := range targets {
+		target, err := normalizeTarget(raw)
+		if err != nil {
+			return nil, err
+		}
+		normalized[i] = target
+
+		u, _ := url.Parse(target)
+		if u.Scheme == "http" && isPublicHost(u.Hostname()) && !warned[u.Host] {
+			warned[u.Host] = true
+			fmt.Printf("⚠️  Warning: %s is scanned over plain http; did you mean https://%s?\n", u.Host, u.Host)
+		}
+	}
+	return normalized, nil
+}
+
+// localSuffixes are domain suffixes that never resolve on the public internet
+var localSuffixes = []string{".localhost", ".local", ".test", ".internal", ".lan", ".home.arpa"}
+
+// isPublicHost reports whether host looks like a public internet host rather
+// than a local, private or development one
+func isPublicHost(host string) bool {
+	if ip := net.ParseIP(host); ip != nil {
+		return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsUnspecified())
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "localhost" || !strings.Contains(host, ".") {
+		return false
+	}
+	for _, suffix := range localSuffixes {
+		if strings.HasSuffix(host, suffix) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeTarget(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{"https://example.com", "https://example.com"},
+		{"http://localhost:3000/about", "http://localhost:3000/about"},
+		{"  https://example.com/path?q=1#top  ", "https://example.com/path?q=1#top"},
+		{"localhost:3000", "http://localhost:3000"},
+		{"example.com/about", "http://example.com/about"},
+		{"//example.com", "http://example.com"},
+		{"127.0.0.1:8080", "http://127.0.0.1:8080"},
+		{"[::1]:8080/", "http://[::1]:8080/"},
+		{"HTTPS://Example.com", "https://Example.com"},
+	}
+	for _, tt := range tests {
+		got, err := normalizeTarget(tt.raw)
+		if err != nil || got != tt.want {
+			t.Errorf("normalizeTarget(%q) = %q, %v, want %q", tt.raw, got, err, tt.want)
+		}
+	}
+}
+
+func TestNormalizeTargetInvalid(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string // Part of the error
+	}{
+		{"", "empty"},
+		{"   ", "empty"},
+		{"ftp://example.com/file", `unsupported scheme "ftp"`},
+		{"file:///etc/passwd", `unsupported scheme "file"`},
+		{"http://", "has no host"},
+		{"https:///path", "has no host"},
+		{"http://exa mple.com", "invalid target URL"},
+	}
+	for _, tt := range tests {
+		_, err := normalizeTarget(tt.raw)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("normalizeTarget(%q) = %v, want an error containing %q", tt.raw, err, tt.want)
+		}
+	}
+}
+
+func TestIsPublicHost(t *testing.T) {
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{"example.com", true},
+		{"www.example.co.uk", true},
+		{"93.184.216.34", true},
+		{"localhost", false},
+		{"intranet", false},
+		{"app.localhost", false},
+		{"printer.local", false},
+		{"shop.test", false},
+		{"api.internal", false},
+		{"nas.home.arpa", false},
+		{"Example.LOCAL.", false},
+		{"127.0.0.1", false},
+		{"10.0.0.5", false},
+		{"192.168.1.20", false},
+		{"169.254.1.1", false},
+		{"::1", false},
+		{"0.0.0.0", false},
+	}
+	for _, tt := range tests {
+		if got := isPublicHost(tt.host); got != tt.want {
+			t.Errorf("isPublicHost(%q) = %v, want %v", tt.host, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/law-makers/viewport-cli/pkg/results"
+)
+
+// maxTargetDirName caps --output-dir-per-target directory names, so deep
+// URLs still leave room for the scan directories inside them
+const maxTargetDirName = 100
+
+// unsafeDirChars matches the runs of characters replaced in directory names
+var unsafeDirChars = regexp.MustCompile(`[^a-z0-9._-]+`)
+
+// targetDirName derives the --output-dir-per-target directory of a target
+// from its host and path, e.g. https://Example.com:8080/docs/intro ->
+// example.com-8080_docs_intro. The scheme, query and fragment are left out.
+func targetDirName(target string) string {
+	host, path := target, ""
+	if u, err := url.Parse(target); err == nil && u.Host != "" {
+		host, path = u.Host, u.Path
+	}
+
+	var parts []string
+	for _, part := range append([]string{host}, strings.Split(path, "/")...) {
+		part = unsafeDirChars.ReplaceAllString(strings.ToLower(part), "-")
+		if part = strings.Trim(part, ".-"); part != "" {
+			parts = append(parts, part)
+		}
+	}
+
+	name := strings.Join(parts, "_")
+	if len(name) > maxTargetDirName {
+		name = strings.TrimRight(name[:maxTargetDirName], ".-_")
+	}
+	if name == "" {
+		name = "target"
+	}
+	return name
+}
+
+// targetDirNames assigns each target its directory, in order. Targets whose
+// names collide (e.g. URLs differing only in scheme or query) are numbered
+// -2, -3 and so on, so the same batch always lays out the same way.
+func targetDirNames(targets []string) map[string]string {
+	dirs := make(map[string]string, len(targets))
+	used := map[string]bool{results.LatestLink: true}
+	for _, target := range targets {
+		if _, ok := dirs[target]; ok {
+			continue
+		}
+		base := targetDirName(target)
+		name := base
+		for n := 2; used[name]; n++ {
+			name = fmt.Sprintf("%s-%d", base, n)
+		}
+		used[name] = true
+		dirs[target] = name
+	}
+	return dirs
+}
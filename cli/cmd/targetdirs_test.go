@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/law-makers/viewport-cli/pkg/results"
+)
+
+func TestTargetDirName(t *testing.T) {
+	tests := []struct {
+		target string
+		want   string
+	}{
+		{"https://example.com", "example.com"},
+		{"https://example.com/", "example.com"},
+		{"https://Example.com:8080/docs/intro", "example.com-8080_docs_intro"},
+		{"http://example.com/docs/intro?page=2#top", "example.com_docs_intro"},
+		{"https://example.com/a%20b/../c", "example.com_a-b_c"},
+		{"https://例え.jp/ページ", "jp"},
+		{"http://[::1]:3000/app", "1-3000_app"},
+		{"example.com/ignored", "example.com-ignored"},
+		{"https://example.com/" + strings.Repeat("segment/", 20), strings.TrimRight(("example.com_" + strings.Repeat("segment_", 20))[:maxTargetDirName], "_")},
+		{"https://.../", "target"},
+	}
+	for _, tt := range tests {
+		if got := targetDirName(tt.target); got != tt.want {
+			t.Errorf("targetDirName(%q) = %q, want %q", tt.target, got, tt.want)
+		}
+	}
+}
+
+func TestTargetDirNamesCollisions(t *testing.T) {
+	targets := []string{
+		"https://example.com/docs",
+		"https://example.com/docs-2",     // Takes the name the next collision would get
+		"http://example.com/docs",        // Differs only in scheme
+		"https://example.com/docs?v=2",   // Differs only in query
+		"https://example.com/docs",       // The same target twice
+		"https://latest",                 // The latest scan's link
+		"https://example.com/docs#intro", // Differs only in fragment
+	}
+	got := targetDirNames(targets)
+	want := map[string]string{
+		"https://example.com/docs":       "example.com_docs",
+		"https://example.com/docs-2":     "example.com_docs-2",
+		"http://example.com/docs":        "example.com_docs-3",
+		"https://example.com/docs?v=2":   "example.com_docs-4",
+		"https://latest":                 "latest-2",
+		"https://example.com/docs#intro": "example.com_docs-5",
+	}
+	if len(got) != len(want) {
+		t.Errorf("got %d directories, want %d: %v", len(got), len(want), got)
+	}
+	for target, dir := range want {
+		if got[target] != dir {
+			t.Errorf("%s -> %q, want %q", target, got[target], dir)
+		}
+	}
+
+	// The same batch always lays out the same way
+	again := targetDirNames(targets)
+	for target, dir := range got {
+		if again[target] != dir {
+			t.Errorf("%s -> %q on the second run, %q on the first", target, again[target], dir)
+		}
+	}
+}
+
+func TestTargetDirsListed(t *testing.T) {
+	store := results.NewFSStore(t.TempDir())
+	dirs := targetDirNames([]string{"https://example.com/a", "https://example.com/b"})
+	for i, target := range []string{"https://example.com/a", "https://example.com/b"} {
+		scan := results.ScanMetadata{ScanID: []string{"scan-a", "scan-b"}[i], Status: "completed"}
+		metadata, err := json.Marshal(scan)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := store.SaveScan(&results.ScanFiles{ScanID: scan.ScanID, Dir: dirs[target], Metadata: metadata}); err != nil {
+			t.Fatalf("saving into %s: %v", dirs[target], err)
+		}
+	}
+
+	scans, err := store.ListScans()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, scan := range scans {
+		ids = append(ids, scan.ScanID)
+	}
+	sort.Strings(ids)
+	if strings.Join(ids, ",") != "scan-a,scan-b" {
+		t.Errorf("listed scans %q, want the scans in both target directories", ids)
+	}
+}
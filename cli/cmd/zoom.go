@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/law-makers/viewport-cli/pkg/api"
+)
+
+// minZoom and maxZoom bound --zoom factors, the range browsers zoom pages in
+const (
+	minZoom = 0.25
+	maxZoom = 5.0
+)
+
+// validateZoomLevels checks --zoom factors are within minZoom..maxZoom and
+// name distinct files
+func validateZoomLevels(levels []float64) error {
+	seen := make(map[int]bool, len(levels))
+	for _, zoom := range levels {
+		if math.IsNaN(zoom) || zoom < minZoom || zoom > maxZoom {
+			return fmt.Errorf("--zoom factors must be between %g and %g (e.g. 2 for 200%%), got %g", minZoom, maxZoom, zoom)
+		}
+		if seen[zoomPercent(zoom)] {
+			return fmt.Errorf("--zoom lists %d%% more than once", zoomPercent(zoom))
+		}
+		seen[zoomPercent(zoom)] = true
+	}
+	return nil
+}
+
+// zoomPercent is a zoom factor as a whole percentage, e.g. 1.5 -> 150
+func zoomPercent(zoom float64) int {
+	return int(math.Round(zoom * 100))
+}
+
+// zoomScreenshotName names the capture of a viewport at a zoom factor,
+// e.g. mobile.png -> mobile-zoom200.png
+func zoomScreenshotName(name string, zoom float64) string {
+	return fmt.Sprintf("%s-zoom%d.png", strings.TrimSuffix(name, ".png"), zoomPercent(zoom))
+}
+
+// addZoomIssues adds the issues found at each zoom level to its viewport's
+// issues, so thresholds, baselines and reports count them, e.g. "At 200%
+// zoom: Page scrolls horizontally"
+func addZoomIssues(results []api.ViewportResult) {
+	for i := range results {
+		result := &results[i]
+		for _, capture := range result.ZoomCaptures {
+			for _, issue := range capture.Issues {
+				issue.Description = fmt.Sprintf("At %d%% zoom: %s", zoomPercent(capture.Zoom), issue.Description)
+				result.Issues = append(result.Issues, issue)
+			}
+		}
+	}
+}
+
+// printZoomCaptures lists each viewport's zoom captures with the issues found
+// at each zoom level
+func printZoomCaptures(results []api.ViewportResult) {
+	fmt.Printf("\n🔍 Zoom captures:\n")
+	for _, result := range results {
+		if len(result.ZoomCaptures) == 0 {
+			fmt.Printf("  • %s: none (not supported by the server?)\n", result.Device)
+			continue
+		}
+		fmt.Printf("  • %s\n", result.Device)
+		for _, capture := range result.ZoomCaptures {
+			fmt.Printf("      %d%% (%d×%d): %s\n", zoomPercent(capture.Zoom),
+				capture.Dimensions.Width, capture.Dimensions.Height, describeZoomIssues(capture.Issues))
+		}
+	}
+}
+
+// describeZoomIssues summarizes the issues of one zoom capture, e.g.
+// "2 issues (horizontal-scroll, text-clipped)"
+func describeZoomIssues(issues []api.DetectedIssue) string {
+	if len(issues) == 0 {
+		return countIssues(0)
+	}
+	var types []string
+	for _, issue := range issues {
+		if !containsString(types, issue.Type) {
+			types = append(types, issue.Type)
+		}
+	}
+	return fmt.Sprintf("%s (%s)", countIssues(len(issues)), strings.Join(types, ", "))
+}
+
+// countIssues says how many issues there are, e.g. "no issues" or "1 issue"
+func countIssues(n int) string {
+	switch n {
+	case 0:
+		return "no issues"
+	case 1:
+		return "1 issue"
+	}
+	return fmt.Sprintf("%d issues", n)
+}
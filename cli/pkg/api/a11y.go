@@ -0,0 +1,13 @@
+package api
+
+// A11yNode is a node of the accessibility tree captured with
+// ScanOptions.CaptureA11yTree, as the browser exposes it to assistive
+// technology. Children are in reading order.
+type A11yNode struct {
+	Role string `json:"role"`
+	// Name is the accessible name, e.g. a button's label or an image's alt text
+	Name string `json:"name,omitempty"`
+	// Level is the heading level for headings, 0 otherwise
+	Level    int        `json:"level,omitempty"`
+	Children []A11yNode `json:"children,omitempty"`
+}
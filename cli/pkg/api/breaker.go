@@ -0,0 +1,61 @@
+package api
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without contacting the server once too many
+// consecutive requests have failed to reach it
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// breakerCooldown is how long an open breaker fails requests before letting
+// one through to check whether the server is back
+const breakerCooldown = 30 * time.Second
+
+// circuitBreaker counts consecutive requests that could not reach the server
+// and trips once the count reaches threshold. A threshold of 0 never trips.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	failures  int
+	openedAt  time.Time // When the breaker tripped, or last let a trial request through
+}
+
+// allow reports whether a request may be sent. An open breaker lets one
+// trial request through per breakerCooldown.
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.threshold <= 0 || b.failures < b.threshold {
+		return true
+	}
+	if time.Since(b.openedAt) < breakerCooldown {
+		return false
+	}
+	b.openedAt = time.Now()
+	return true
+}
+
+// record updates the breaker with the outcome of a request. Any response from
+// the server, even an error status, counts as reaching it and resets the breaker.
+func (b *circuitBreaker) record(reached bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if reached {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.threshold > 0 && b.failures >= b.threshold {
+		b.openedAt = time.Now()
+	}
+}
+
+// consecutiveFailures returns the current failure count
+func (b *circuitBreaker) consecutiveFailures() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.failures
+}
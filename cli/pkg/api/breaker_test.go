@@ -0,0 +1,103 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	b := &circuitBreaker{threshold: 2}
+	b.record(false)
+	if !b.allow() {
+		t.Fatal("open after 1 of 2 failures")
+	}
+	b.record(true)
+	b.record(false)
+	if !b.allow() {
+		t.Fatal("a success didn't reset the failure count")
+	}
+	b.record(false)
+	if b.allow() {
+		t.Fatal("closed after 2 consecutive failures")
+	}
+
+	// After the cooldown one trial request goes through, and only one
+	b.openedAt = time.Now().Add(-breakerCooldown)
+	if !b.allow() {
+		t.Fatal("no trial request after the cooldown")
+	}
+	if b.allow() {
+		t.Fatal("a second trial request went through")
+	}
+	b.record(false)
+	if b.allow() {
+		t.Fatal("closed after the trial request failed")
+	}
+
+	b.openedAt = time.Now().Add(-breakerCooldown)
+	if !b.allow() {
+		t.Fatal("no trial request after the second cooldown")
+	}
+	b.record(true)
+	if !b.allow() || b.consecutiveFailures() != 0 {
+		t.Fatal("still open after the trial request succeeded")
+	}
+}
+
+func TestCircuitBreakerDisabled(t *testing.T) {
+	b := &circuitBreaker{}
+	for i := 0; i < 10; i++ {
+		b.record(false)
+	}
+	if !b.allow() {
+		t.Error("a breaker with threshold 0 tripped")
+	}
+}
+
+func TestScanCircuitBreakerFailingThenRecovering(t *testing.T) {
+	var down atomic.Bool
+	var requests atomic.Int32
+	down.Store(true)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if down.Load() {
+			// Drop the connection, as a crashed server would
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err == nil {
+				conn.Close()
+			}
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, scanOK)
+	}))
+	defer srv.Close()
+
+	client := NewClient(srv.URL).SetCircuitBreaker(2).SetRetryCount(0)
+	for i := 0; i < 2; i++ {
+		if _, err := client.Scan(context.Background(), testScanRequest()); !errors.Is(err, ErrRequestFailed) {
+			t.Fatalf("Scan %d against a failing server = %v, want ErrRequestFailed", i+1, err)
+		}
+	}
+	if _, err := client.Scan(context.Background(), testScanRequest()); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Scan after 2 failures = %v, want ErrCircuitOpen", err)
+	}
+	if n := requests.Load(); n != 2 {
+		t.Errorf("server got %d requests, want 2: the open breaker must not send any", n)
+	}
+
+	// The server recovers; after the cooldown the trial request reaches it
+	down.Store(false)
+	client.breaker.openedAt = time.Now().Add(-breakerCooldown)
+	for i := 0; i < 2; i++ {
+		if _, err := client.Scan(context.Background(), testScanRequest()); err != nil {
+			t.Fatalf("Scan %d after the server recovered: %v", i+1, err)
+		}
+	}
+}
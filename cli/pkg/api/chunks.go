@@ -0,0 +1,112 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrTooManyViewports is matched by errors from servers that refuse a request
+// for more viewports than they capture at once
+var ErrTooManyViewports = errors.New("too many viewports in one request")
+
+// TooManyViewportsError reports a server's per-request viewport limit.
+// Max is 0 when the server didn't say what the limit is.
+type TooManyViewportsError struct {
+	Max     int
+	Message string
+}
+
+func (e *TooManyViewportsError) Error() string {
+	if e.Max > 0 {
+		return fmt.Sprintf("%s (server limit: %d viewports per request)", e.Message, e.Max)
+	}
+	return e.Message
+}
+
+// Is makes errors.Is(err, ErrTooManyViewports) match
+func (e *TooManyViewportsError) Is(target error) bool {
+	return target == ErrTooManyViewports
+}
+
+// tooManyViewportsCode is the error code servers send with a viewport limit
+const tooManyViewportsCode = "too_many_viewports"
+
+// viewportLimitError returns a TooManyViewportsError if a server error means
+// the request had too many viewports: it carries the too_many_viewports code,
+// or, from servers that only send a message, says so in words
+func viewportLimitError(message, code string, max int) error {
+	if code == tooManyViewportsCode || strings.Contains(strings.ToLower(message), "too many viewports") {
+		if message == "" {
+			message = ErrTooManyViewports.Error()
+		}
+		return &TooManyViewportsError{Max: max, Message: message}
+	}
+	return nil
+}
+
+// ServerInfo is what a screenshot server reports about itself on its health endpoint
+type ServerInfo struct {
+	Status       string   `json:"status"`
+	Devices      []string `json:"devices"`
+	BrowserReady bool     `json:"browserReady"`
+	// MaxViewports is how many viewports the server captures per request (0 = no limit)
+	MaxViewports int `json:"maxViewports,omitempty"`
+	// MaxConcurrentCaptures is how many pages the server renders at once (0 = not advertised)
+	MaxConcurrentCaptures int `json:"maxConcurrentCaptures,omitempty"`
+}
+
+// Info fetches the server's health response. Servers that predate a field
+// leave it zero.
+func (c *Client) Info(ctx context.Context) (*ServerInfo, error) {
+	resp, err := c.httpClient.R().
+		SetContext(ctx).
+		Get(fmt.Sprintf("%s/", c.baseURL))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get server info: %w", err)
+	}
+
+	var info ServerInfo
+	if err := json.Unmarshal(resp.Body(), &info); err != nil {
+		return nil, fmt.Errorf("failed to parse server info: %w", err)
+	}
+	return &info, nil
+}
+
+// ChunkViewports splits viewports into groups of at most size, in order
+func ChunkViewports(viewports []string, size int) [][]string {
+	if size <= 0 || len(viewports) <= size {
+		return [][]string{viewports}
+	}
+	var chunks [][]string
+	for start := 0; start < len(viewports); start += size {
+		end := min(start+size, len(viewports))
+		chunks = append(chunks, viewports[start:end])
+	}
+	return chunks
+}
+
+// MergeResponses joins the responses to the chunks of one split scan. The
+// first response provides the scan ID, timestamp and status, unless a later
+// chunk reports a different status (e.g. partial); results keep chunk order.
+func MergeResponses(responses []*ScanResponse) *ScanResponse {
+	merged := *responses[0]
+	merged.Results = nil
+	var analyses []string
+	for _, resp := range responses {
+		merged.Results = append(merged.Results, resp.Results...)
+		if resp.GlobalAnalysis != "" {
+			analyses = append(analyses, resp.GlobalAnalysis)
+		}
+		if resp.Status != responses[0].Status {
+			merged.Status = resp.Status
+		}
+		if resp.APIVersion < merged.APIVersion {
+			merged.APIVersion = resp.APIVersion
+		}
+	}
+	merged.GlobalAnalysis = strings.Join(analyses, "\n\n")
+	return &merged
+}
@@ -2,17 +2,51 @@ package api
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-resty/resty/v2"
+	"github.com/law-makers/viewport-cli/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// APIVersion is the version of the scan API this client understands
+const APIVersion = 1
+
+// ErrRequestFailed is returned when the screenshot server could not be reached
+var ErrRequestFailed = errors.New("request failed")
+
+const (
+	// ClientVersionHeader tells the server which API version the client expects
+	ClientVersionHeader = "X-Viewport-Client-Version"
+	// ServerVersionHeader is how the server reports the API version it speaks
+	ServerVersionHeader = "X-Viewport-Api-Version"
 )
 
 // Client handles communication with the backend API
 type Client struct {
 	baseURL    string
 	httpClient *resty.Client
+	breaker    *circuitBreaker
+	// streamUnsupported is set once the server refuses /scan/stream
+	streamUnsupported atomic.Bool
+	// limiter paces requests, see SetRateLimit
+	limiter *rateLimiter
+	// onRetryAfter is told about retries waiting on Retry-After, see OnRetryAfter
+	onRetryAfter func(status int, wait time.Duration)
+	// tlsConfig and tlsFiles are set by SetTLSFiles
+	tlsConfig *tls.Config
+	tlsFiles  TLSFiles
 }
 
 // ScanRequest is the request sent to the backend API
@@ -24,8 +58,78 @@ type ScanRequest struct {
 
 // ScanOptions configures screenshot capture options
 type ScanOptions struct {
-	FullPage   bool   `json:"fullPage,omitempty"`
-	AuthHeader string `json:"authHeader,omitempty"`
+	FullPage   bool              `json:"fullPage"`
+	AuthHeader string            `json:"authHeader,omitempty"`
+	Headers    map[string]string `json:"headers,omitempty"`
+	// CaptureNetwork asks the server to record failed/blocked resource loads
+	CaptureNetwork bool `json:"captureNetwork,omitempty"`
+	// CaptureMixedContent asks for the http:// resources an https:// page
+	// requests, returned as ViewportResult.MixedContent
+	CaptureMixedContent bool `json:"captureMixedContent,omitempty"`
+	// NetworkProfile emulates a slower connection (one of NetworkProfiles)
+	NetworkProfile string `json:"networkProfile,omitempty"`
+	// CPUThrottle slows the page's CPU down by this factor (e.g. 4 = 4× slower)
+	CPUThrottle int `json:"cpuThrottle,omitempty"`
+	// ScrollPositions asks for an extra viewport-sized capture at each vertical offset in pixels
+	ScrollPositions []int `json:"scrollPositions,omitempty"`
+	// ZoomLevels asks for an extra capture of each viewport at each page
+	// zoom factor (e.g. 2 = 200%), returned as ViewportResult.ZoomCaptures
+	ZoomLevels []float64 `json:"zoomLevels,omitempty"`
+	// SkipAnalysis asks for screenshots only, without issue analysis
+	SkipAnalysis bool `json:"skipAnalysis,omitempty"`
+	// DimensionsOnly asks for dimensions and issues without screenshots, so
+	// results leave ScreenshotBase64 empty
+	DimensionsOnly bool `json:"dimensionsOnly,omitempty"`
+	// Selector clips the capture to the first element matching this CSS selector
+	Selector string `json:"selector,omitempty"`
+	// HostHeader is sent as the Host of the navigation request, e.g. to reach a
+	// virtual host by IP before DNS points at it
+	HostHeader string `json:"hostHeader,omitempty"`
+	// WaitForFonts delays the capture until document.fonts.ready, so web fonts
+	// have replaced their fallbacks
+	WaitForFonts bool `json:"waitForFonts,omitempty"`
+	// WaitTimeout caps the WaitForFonts wait in milliseconds; the page is
+	// captured anyway when it runs out
+	WaitTimeout int `json:"waitTimeout,omitempty"`
+	// CaptureDelayMs pauses this many milliseconds after the page has loaded
+	// (and fonts, with WaitForFonts) before capturing, e.g. for entrance animations
+	CaptureDelayMs int `json:"captureDelayMs,omitempty"`
+	// Devices describes viewports the server may not have built in, or
+	// overrides the built-in profile of the same name
+	Devices []DeviceProfile `json:"devices,omitempty"`
+	// CaptureRetries is how many times a capture that fails or comes back
+	// empty is retried, with a growing wait in between
+	CaptureRetries int `json:"captureRetries,omitempty"`
+	// CaptureStyles asks for computed CSS values of selected elements, returned as ViewportResult.Styles
+	CaptureStyles []StyleCapture `json:"captureStyles,omitempty"`
+	// CaptureA11yTree asks for each viewport's accessibility tree, returned as
+	// ViewportResult.A11yTree. Trees are large, so this is off by default.
+	CaptureA11yTree bool `json:"captureA11yTree,omitempty"`
+}
+
+// DeviceProfile fully describes a device to emulate, for custom viewports
+type DeviceProfile struct {
+	Name   string `json:"name"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	// DeviceScaleFactor is the device pixel ratio (0 = the browser default of 1)
+	DeviceScaleFactor float64 `json:"deviceScaleFactor,omitempty"`
+	UserAgent         string  `json:"userAgent,omitempty"`
+	// Mobile emulates a touch device
+	Mobile bool `json:"isMobile,omitempty"`
+}
+
+// NetworkProfiles are the connection profiles ScanOptions.NetworkProfile accepts
+var NetworkProfiles = []string{"slow-3g", "3g", "4g", "offline"}
+
+// ValidateNetworkProfile checks that name is one of NetworkProfiles
+func ValidateNetworkProfile(name string) error {
+	for _, profile := range NetworkProfiles {
+		if name == profile {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown network profile %q (expected one of: %s)", name, strings.Join(NetworkProfiles, ", "))
 }
 
 // ScanResponse is the response from the backend API
@@ -35,6 +139,30 @@ type ScanResponse struct {
 	Status         string            `json:"status"`
 	Results        []ViewportResult  `json:"results"`
 	GlobalAnalysis string            `json:"globalAnalysis"`
+	APIVersion     int               `json:"apiVersion,omitempty"`
+	// RequestedURL and FinalURL are filled in by the CLI: the target as given and where its redirects end up
+	RequestedURL string `json:"requestedUrl,omitempty"`
+	FinalURL     string `json:"finalUrl,omitempty"`
+	// GitCommit is the commit checked out where the CLI ran, if it ran in a git repository
+	GitCommit string `json:"gitCommit,omitempty"`
+	GitDirty  bool   `json:"gitDirty,omitempty"`
+	// NetworkProfile and CPUThrottle record the emulation the scan was requested with
+	NetworkProfile string `json:"networkProfile,omitempty"`
+	CPUThrottle    int    `json:"cpuThrottle,omitempty"`
+	// AnalysisSkipped records that the scan was a --screenshot-only capture
+	AnalysisSkipped bool `json:"analysisSkipped,omitempty"`
+	// DimensionsOnly records that the scan was a --dimensions-only check, saved without screenshots
+	DimensionsOnly bool `json:"dimensionsOnly,omitempty"`
+	// HostHeader records the --host-header the target was requested with
+	HostHeader string `json:"hostHeader,omitempty"`
+	// CaptureDelayMs records the --delay each viewport waited before its capture
+	CaptureDelayMs int `json:"captureDelayMs,omitempty"`
+	// DurationMs records how long the capture took, as timed by the CLI
+	DurationMs int64 `json:"durationMs,omitempty"`
+	// Label is the results label of a saved scan, kept when its metadata is rewritten
+	Label string `json:"label,omitempty"`
+	// Tags are the results tags of a saved scan, kept like Label
+	Tags []string `json:"tags,omitempty"`
 }
 
 // ViewportResult contains screenshot and metadata for a single viewport
@@ -43,6 +171,73 @@ type ViewportResult struct {
 	Dimensions        Dimensions      `json:"dimensions"`
 	ScreenshotBase64  string          `json:"screenshotBase64"`
 	Issues            []DetectedIssue `json:"issues"`
+	FailedResources   []NetworkEntry  `json:"failedResources,omitempty"`
+	// MixedContent is requested with ScanOptions.CaptureMixedContent
+	MixedContent []MixedContent `json:"mixedContent,omitempty"`
+	// HTTPStatus is the status code of the page's navigation response, when the server reports it
+	HTTPStatus int `json:"httpStatus,omitempty"`
+	// CaptureRetries is how many retries the capture needed (see ScanOptions.CaptureRetries)
+	CaptureRetries int `json:"captureRetries,omitempty"`
+	// Styles holds the computed styles requested with ScanOptions.CaptureStyles, in request order
+	Styles []ElementStyle `json:"styles,omitempty"`
+	// A11yTree is the accessibility tree requested with ScanOptions.CaptureA11yTree
+	A11yTree *A11yNode `json:"a11yTree,omitempty"`
+	// ScreenshotFile is the saved file name, filled in by the CLI when results are written
+	ScreenshotFile string `json:"screenshotFile,omitempty"`
+	// OriginalSize and SavedSize are the captured and written image sizes, recorded when a size limit is set
+	OriginalSize *Dimensions `json:"originalSize,omitempty"`
+	SavedSize    *Dimensions `json:"savedSize,omitempty"`
+	// ScrollScreenshots are the captures requested with ScanOptions.ScrollPositions
+	ScrollScreenshots []ScrollScreenshot `json:"scrollScreenshots,omitempty"`
+	// ZoomCaptures are the captures requested with ScanOptions.ZoomLevels
+	ZoomCaptures []ZoomCapture `json:"zoomCaptures,omitempty"`
+	// AnnotatedFile is the saved --annotate copy of the screenshot, if one was written
+	AnnotatedFile string `json:"annotatedFile,omitempty"`
+	// Reference is the comparison against a --reference design image, if one was given
+	Reference *ReferenceComparison `json:"reference,omitempty"`
+}
+
+// ReferenceComparison records how closely a screenshot matches a design reference image
+type ReferenceComparison struct {
+	Image      string  `json:"image"`
+	Similarity float64 `json:"similarity"`
+	// Scale is the factor the reference was resized by to match the screenshot width
+	Scale float64 `json:"scale"`
+	// ComparedHeight is the height both images cover; taller parts of either are ignored
+	ComparedHeight int    `json:"comparedHeight"`
+	HeightMismatch bool   `json:"heightMismatch,omitempty"`
+	DiffFile       string `json:"diffFile,omitempty"`
+	// DiffPNG is the diff overlay, written to DiffFile when results are saved
+	DiffPNG []byte `json:"-"`
+}
+
+// ScrollScreenshot is a viewport-sized capture taken with the page scrolled to Offset
+type ScrollScreenshot struct {
+	Offset           int    `json:"offset"`
+	ScreenshotBase64 string `json:"screenshotBase64"`
+	// ScreenshotFile is the saved file name, filled in by the CLI when results are written
+	ScreenshotFile string `json:"screenshotFile,omitempty"`
+}
+
+// ZoomCapture is a viewport captured at a page zoom factor, with the issues
+// found at that zoom
+type ZoomCapture struct {
+	Zoom float64 `json:"zoom"`
+	// Dimensions is the viewport in CSS pixels at this zoom, e.g. 195×422 for mobile at 200%
+	Dimensions       Dimensions      `json:"dimensions"`
+	ScreenshotBase64 string          `json:"screenshotBase64"`
+	Issues           []DetectedIssue `json:"issues"`
+	// ScreenshotFile is the saved file name, filled in by the CLI when results are written
+	ScreenshotFile string `json:"screenshotFile,omitempty"`
+}
+
+// NetworkEntry describes a resource request that failed or was blocked
+type NetworkEntry struct {
+	URL          string `json:"url"`
+	Method       string `json:"method,omitempty"`
+	ResourceType string `json:"resourceType,omitempty"`
+	Status       int    `json:"status,omitempty"`
+	Error        string `json:"error,omitempty"`
 }
 
 // Dimensions contains width and height information
@@ -57,37 +252,236 @@ type DetectedIssue struct {
 	Type        string `json:"type"`
 	Description string `json:"description"`
 	Suggestion  string `json:"suggestion"`
+	// BoundingBox locates the issue on the screenshot, when the server reports it
+	BoundingBox *BoundingBox `json:"boundingBox,omitempty"`
 }
 
+// BoundingBox is a rectangle on a screenshot, in captured-page pixels
+type BoundingBox struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// DefaultTimeout is how long a request to the server may take by default
+const DefaultTimeout = 120 * time.Second
+
 // NewClient creates a new API client
 func NewClient(baseURL string) *Client {
-	return &Client{
+	c := &Client{
 		baseURL: baseURL,
+		breaker: &circuitBreaker{},
 		httpClient: resty.New().
-			SetTimeout(120 * time.Second).
+			SetTimeout(DefaultTimeout).
+			SetHeader(ClientVersionHeader, strconv.Itoa(APIVersion)).
+			SetHeader("Accept-Encoding", "gzip").
+			SetHeader("User-Agent", DefaultUserAgent).
 			SetRetryCount(2).
-			SetRetryWaitTime(2 * time.Second),
+			SetRetryWaitTime(2 * time.Second).
+			SetRetryMaxWaitTime(MaxRetryAfter),
+	}
+	c.httpClient.SetRetryAfter(c.retryAfter)
+	// Every attempt, retries included, goes through the rate limiter
+	c.httpClient.OnBeforeRequest(func(_ *resty.Client, r *resty.Request) error {
+		withRetryWait(r)
+		if c.limiter == nil {
+			return nil
+		}
+		return c.limiter.wait(r.Context())
+	})
+	// A busy server's Retry-After is honored with or without a rate limit
+	c.httpClient.AddRetryCondition(func(r *resty.Response, err error) bool {
+		if r == nil || r.RawResponse == nil {
+			return false
+		}
+		retry := c.limiter != nil && c.rateLimited(r.RawResponse)
+		if _, busy := serverBusy(r.RawResponse); busy {
+			retry = true
+		}
+		return retry
+	})
+	return c.SetTransportOptions(TransportOptions{})
+}
+
+// SetRateLimit paces requests to the server at perSecond on average, so a
+// large batch doesn't trip the limits of a shared backend. A 429 response is
+// then retried, after holding back every request for its Retry-After. 0
+// disables the limit.
+func (c *Client) SetRateLimit(perSecond float64) *Client {
+	if perSecond <= 0 {
+		c.limiter = nil
+		return c
+	}
+	c.limiter = newRateLimiter(perSecond, 1)
+	return c
+}
+
+// rateLimited reports whether resp is a 429, pausing the rate limiter for its
+// Retry-After if it is
+func (c *Client) rateLimited(resp *http.Response) bool {
+	if resp == nil || resp.StatusCode != http.StatusTooManyRequests {
+		return false
+	}
+	if d, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok && c.limiter != nil {
+		c.limiter.pause(d)
+	}
+	return true
+}
+
+// DefaultMaxIdleConns is how many idle connections to the server are kept for reuse by default
+const DefaultMaxIdleConns = 10
+
+// DefaultIdleTimeout is how long an idle connection is kept open by default
+const DefaultIdleTimeout = 90 * time.Second
+
+// TransportOptions tunes how connections to the screenshot server are reused.
+// Zero values select the defaults.
+type TransportOptions struct {
+	// MaxIdleConns is how many idle connections are kept open between requests
+	MaxIdleConns int
+	// IdleTimeout is how long an idle connection is kept before it is closed
+	IdleTimeout time.Duration
+	// DisableKeepAlives opens a new connection for every request
+	DisableKeepAlives bool
+}
+
+// SetTransportOptions configures connection reuse. Every request goes to the
+// same server, so the idle limit applies to that host rather than being split
+// across hosts; a batch reusing one Client then keeps its connections warm.
+func (c *Client) SetTransportOptions(opts TransportOptions) *Client {
+	transport, err := c.httpClient.Transport()
+	if err != nil {
+		// A custom transport was installed; leave it as configured
+		return c
+	}
+	if opts.MaxIdleConns <= 0 {
+		opts.MaxIdleConns = DefaultMaxIdleConns
+	}
+	if opts.IdleTimeout <= 0 {
+		opts.IdleTimeout = DefaultIdleTimeout
+	}
+	transport.MaxIdleConns = opts.MaxIdleConns
+	transport.MaxIdleConnsPerHost = opts.MaxIdleConns
+	transport.IdleConnTimeout = opts.IdleTimeout
+	transport.DisableKeepAlives = opts.DisableKeepAlives
+	return c
+}
+
+// DefaultUserAgent identifies the CLI until SetUserAgent gives a more specific one
+const DefaultUserAgent = "viewport-cli"
+
+// SetUserAgent sets the User-Agent sent with every request to the server
+func (c *Client) SetUserAgent(userAgent string) *Client {
+	c.httpClient.SetHeader("User-Agent", userAgent)
+	return c
+}
+
+// SetLogOutput sends the HTTP client's own warnings and errors, e.g. about
+// retried requests, to w instead of stderr
+func (c *Client) SetLogOutput(w io.Writer) *Client {
+	c.httpClient.SetLogger(&restyLogger{log.New(w, "", log.LstdFlags)})
+	return c
+}
+
+// restyLogger logs resty's messages the way its default logger does
+type restyLogger struct {
+	l *log.Logger
+}
+
+func (l *restyLogger) Errorf(format string, v ...interface{}) {
+	l.l.Printf("ERROR RESTY "+format, v...)
+}
+
+func (l *restyLogger) Warnf(format string, v ...interface{}) {
+	l.l.Printf("WARN RESTY "+format, v...)
+}
+
+func (l *restyLogger) Debugf(format string, v ...interface{}) {
+	l.l.Printf("DEBUG RESTY "+format, v...)
+}
+
+// SetCompression enables or disables gzip-compressed responses (enabled by default)
+func (c *Client) SetCompression(enabled bool) *Client {
+	if enabled {
+		c.httpClient.SetHeader("Accept-Encoding", "gzip")
+	} else {
+		c.httpClient.SetHeader("Accept-Encoding", "identity")
 	}
+	return c
 }
 
-// Scan sends a scan request to the backend API
+// SetTimeout sets how long a request to the server may take, DefaultTimeout
+// by default. Scans can be cut shorter by their context.
+func (c *Client) SetTimeout(timeout time.Duration) *Client {
+	c.httpClient.SetTimeout(timeout)
+	return c
+}
+
+// SetRetryCount sets how many times a request that fails to reach the server is retried
+func (c *Client) SetRetryCount(count int) *Client {
+	c.httpClient.SetRetryCount(count)
+	return c
+}
+
+// SetCircuitBreaker makes Scan fail immediately with ErrCircuitOpen after
+// threshold consecutive requests have failed to reach the server, until a
+// request succeeds again. Every 30s one request is let through to find out.
+// A threshold of 0 disables the breaker.
+func (c *Client) SetCircuitBreaker(threshold int) *Client {
+	c.breaker = &circuitBreaker{threshold: threshold}
+	return c
+}
+
+// Scan sends a scan request to the backend API. A server that streams its
+// results as NDJSON instead of one JSON response is read to the end too.
 func (c *Client) Scan(ctx context.Context, req *ScanRequest) (*ScanResponse, error) {
+	return c.scan(ctx, req, nil)
+}
+
+// scan sends req and reads the response in the format the server chose by
+// its Content-Type, passing NDJSON progress to onEvent if it isn't nil
+func (c *Client) scan(ctx context.Context, req *ScanRequest, onEvent func(ProgressEvent)) (result *ScanResponse, err error) {
+	ctx, span := tracing.Start(ctx, "api.scan",
+		attribute.String("viewport.target_url", req.TargetURL),
+		attribute.StringSlice("viewport.viewports", req.Viewports))
+	defer func() { tracing.End(span, err) }()
+
+	if !c.breaker.allow() {
+		return nil, fmt.Errorf("%w: %d consecutive requests to %s failed; check that the screenshot server is running",
+			ErrCircuitOpen, c.breaker.consecutiveFailures(), c.baseURL)
+	}
+
 	endpoint := fmt.Sprintf("%s/scan", c.baseURL)
 
+	// The body is read here, so NDJSON lines can be handled as they arrive
 	resp, err := c.httpClient.R().
 		SetContext(ctx).
 		SetHeader("Content-Type", "application/json").
+		SetHeader("Accept", "application/json, "+ndjsonContentType).
+		SetHeaders(tracing.Headers(ctx)).
 		SetBody(req).
-		SetResult(&ScanResponse{}).
+		SetDoNotParseResponse(true).
 		Post(endpoint)
 
+	// Cancellation says nothing about the server's health
+	if ctx.Err() != context.Canceled {
+		c.breaker.record(err == nil)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrRequestFailed, err)
+	}
+	body, err := responseBody(resp)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
+	defer body.Close()
 
 	if !resp.IsSuccess() {
 		// Try to parse error details from server response
-		respBody := resp.String()
+		data, _ := io.ReadAll(body)
+		respBody := string(data)
 		
 		// Parse JSON error response to extract human-readable message
 		var errResp struct {
@@ -95,27 +489,108 @@ func (c *Client) Scan(ctx context.Context, req *ScanRequest) (*ScanResponse, err
 			Message string `json:"message"`
 			Help    string `json:"help"`
 			Details string `json:"details"`
+			Code    string `json:"code"`
+			// MaxViewports is sent with the too_many_viewports code
+			MaxViewports int `json:"maxViewports"`
 		}
 		
 		// Attempt to unmarshal the error response
 		if err := json.Unmarshal([]byte(respBody), &errResp); err == nil && errResp.Error != "" {
+			if limitErr := viewportLimitError(errResp.Error, errResp.Code, errResp.MaxViewports); limitErr != nil {
+				return nil, limitErr
+			}
 			// Return only the error message, without help text
 			// Help text will be shown separately in the CLI if needed
 			return nil, fmt.Errorf("%s", errResp.Error)
 		}
 		
+		if resp.StatusCode() == http.StatusTooManyRequests {
+			return nil, fmt.Errorf("screenshot server is rate limiting requests (HTTP 429); lower --rate-limit")
+		}
+
 		// Fallback to generic error
 		return nil, fmt.Errorf("scan failed: HTTP %d\n%s", resp.StatusCode(), respBody)
 	}
 
-	result, ok := resp.Result().(*ScanResponse)
-	if !ok {
+	if isNDJSON(resp.Header().Get("Content-Type")) {
+		result, err = readNDJSON(ctx, body, len(req.Viewports), onEvent)
+		if err != nil {
+			return nil, err
+		}
+	} else if err := json.NewDecoder(body).Decode(&result); err != nil || result == nil {
 		return nil, fmt.Errorf("failed to parse response")
 	}
 
+	// Older servers only report their version in a header, if at all
+	if result.APIVersion == 0 {
+		if v, err := strconv.Atoi(resp.Header().Get(ServerVersionHeader)); err == nil {
+			result.APIVersion = v
+		}
+	}
+
 	return result, nil
 }
 
+// CurlCommand returns a curl command line equivalent to the request Scan would send for req
+func (c *Client) CurlCommand(req *ScanRequest) (string, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	// One option per line, so the command stays readable when pasted into a bug report
+	lines := []string{"curl -X POST " + shellQuote(fmt.Sprintf("%s/scan", c.baseURL))}
+
+	names := make([]string, 0, len(c.httpClient.Header))
+	for name := range c.httpClient.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	compressed := false
+	for _, name := range names {
+		value := c.httpClient.Header.Get(name)
+		// Let curl negotiate and decode compression itself
+		if name == "Accept-Encoding" && value == "gzip" {
+			compressed = true
+			continue
+		}
+		lines = append(lines, "-H "+shellQuote(name+": "+value))
+	}
+	lines = append(lines, "-H "+shellQuote("Content-Type: application/json"))
+	lines = append(lines, "-H "+shellQuote("Accept: application/json, "+ndjsonContentType))
+	if c.tlsFiles.CACert != "" {
+		lines = append(lines, "--cacert "+shellQuote(c.tlsFiles.CACert))
+	}
+	if c.tlsFiles.ClientCert != "" {
+		lines = append(lines, "--cert "+shellQuote(c.tlsFiles.ClientCert), "--key "+shellQuote(c.tlsFiles.ClientKey))
+	}
+	if compressed {
+		lines = append(lines, "--compressed")
+	}
+	lines = append(lines, "--data-raw "+shellQuote(string(body)))
+
+	return strings.Join(lines, " \\\n  "), nil
+}
+
+// shellQuote quotes s for a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// CheckCompatibility returns an error describing any known incompatibility
+// between this client and a server speaking the given API version
+func CheckCompatibility(serverVersion int) error {
+	switch {
+	case serverVersion == 0:
+		return fmt.Errorf("server did not report an API version; it may predate version %d and omit newer fields", APIVersion)
+	case serverVersion < APIVersion:
+		return fmt.Errorf("server speaks API version %d but this CLI expects %d; update viewport-server", serverVersion, APIVersion)
+	case serverVersion > APIVersion:
+		return fmt.Errorf("server speaks API version %d but this CLI only understands %d; newer fields will be ignored, update viewport-cli", serverVersion, APIVersion)
+	}
+	return nil
+}
+
 // Health checks if the backend API is available
 func (c *Client) Health(ctx context.Context) error {
 	endpoint := fmt.Sprintf("%s/", c.baseURL)
@@ -0,0 +1,174 @@
+package api
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testScanRequest is a minimal scan request
+func testScanRequest() *ScanRequest {
+	return &ScanRequest{TargetURL: "https://example.com", Viewports: []string{"mobile"}}
+}
+
+// writeBody writes body, gzip-compressed when the request accepts it
+func writeBody(w http.ResponseWriter, r *http.Request, status int, contentType, body string) {
+	w.Header().Set("Content-Type", contentType)
+	if r.Header.Get("Accept-Encoding") != "gzip" {
+		w.WriteHeader(status)
+		io.WriteString(w, body)
+		return
+	}
+	w.Header().Set("Content-Encoding", "gzip")
+	w.WriteHeader(status)
+	gz := gzip.NewWriter(w)
+	io.WriteString(gz, body)
+	gz.Close()
+}
+
+func TestScanCompression(t *testing.T) {
+	tests := []struct {
+		name         string
+		compression  bool
+		contentType  string
+		body         string
+		wantEncoding string
+	}{
+		{"gzip JSON", true, "application/json", scanOK, "gzip"},
+		{"gzip NDJSON", true, ndjsonContentType,
+			`{"device":"mobile","dimensions":{"width":375,"height":667},"issues":[]}` + "\n" +
+				`{"scanId":"scan-1","status":"completed"}` + "\n", "gzip"},
+		{"--no-compression", false, "application/json", scanOK, "identity"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if got := r.Header.Get("Accept-Encoding"); got != tt.wantEncoding {
+					t.Errorf("Accept-Encoding = %q, want %q", got, tt.wantEncoding)
+				}
+				writeBody(w, r, http.StatusOK, tt.contentType, tt.body)
+			}))
+			defer srv.Close()
+
+			resp, err := NewClient(srv.URL).SetCompression(tt.compression).Scan(context.Background(), testScanRequest())
+			if err != nil {
+				t.Fatalf("Scan: %v", err)
+			}
+			if resp.ScanID != "scan-1" {
+				t.Errorf("scan ID = %q, want scan-1", resp.ScanID)
+			}
+		})
+	}
+}
+
+func TestScanCompressedError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeBody(w, r, http.StatusBadRequest, "application/json", `{"error":"Invalid target URL"}`)
+	}))
+	defer srv.Close()
+
+	_, err := NewClient(srv.URL).Scan(context.Background(), testScanRequest())
+	if err == nil || !strings.Contains(err.Error(), "Invalid target URL") {
+		t.Errorf("Scan = %v, want the server's error decoded from gzip", err)
+	}
+}
+
+func TestScanUserAgent(t *testing.T) {
+	tests := []struct {
+		name      string
+		userAgent string
+		want      string
+	}{
+		{"default", "", DefaultUserAgent},
+		{"custom", "viewport-cli/1.4.0 (ci)", "viewport-cli/1.4.0 (ci)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.Header.Get("User-Agent")
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprint(w, scanOK)
+			}))
+			defer srv.Close()
+
+			client := NewClient(srv.URL)
+			if tt.userAgent != "" {
+				client.SetUserAgent(tt.userAgent)
+			}
+			if _, err := client.Scan(context.Background(), testScanRequest()); err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("User-Agent = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// BenchmarkBatchIdleConns scans a 100-URL batch with 16 workers, as
+// scan --targets-file --concurrency 16 does, against a server that takes
+// 2ms a request. With fewer idle connections than workers, those returned
+// over the limit are closed and the next requests dial again; conns/op
+// counts the connections each batch opened.
+func BenchmarkBatchIdleConns(b *testing.B) {
+	const urls, workers = 100, 16
+	benchmarks := []struct {
+		name string
+		opts TransportOptions
+	}{
+		{"no keep-alive", TransportOptions{DisableKeepAlives: true}},
+		{"default", TransportOptions{}},
+		{"raised", TransportOptions{MaxIdleConns: workers}},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			var conns atomic.Int64
+			srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				time.Sleep(2 * time.Millisecond)
+				w.Header().Set("Content-Type", "application/json")
+				io.WriteString(w, scanOK)
+			}))
+			srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+				if state == http.StateNew {
+					conns.Add(1)
+				}
+			}
+			srv.Start()
+			defer srv.Close()
+			client := NewClient(srv.URL).SetRetryCount(0).SetTransportOptions(bm.opts)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				var wg sync.WaitGroup
+				queue := make(chan int, urls)
+				for j := 0; j < urls; j++ {
+					queue <- j
+				}
+				close(queue)
+				for w := 0; w < workers; w++ {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						for range queue {
+							if _, err := client.Scan(context.Background(), testScanRequest()); err != nil {
+								b.Error(err)
+							}
+						}
+					}()
+				}
+				wg.Wait()
+			}
+			b.ReportMetric(float64(conns.Load())/float64(b.N), "conns/op")
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Scanner sends scan requests. Client talks to a live screenshot server;
+// Recorder and Replayer save and serve fixtures for offline use.
+type Scanner interface {
+	Scan(ctx context.Context, req *ScanRequest) (*ScanResponse, error)
+}
+
+// fixture is the file format written by Recorder
+type fixture struct {
+	TargetURL string        `json:"targetUrl"`
+	Viewports []string      `json:"viewports"`
+	Response  *ScanResponse `json:"response"`
+}
+
+// fixtureName returns the file name a request's fixture is stored under.
+// Only the target and viewports identify a request, so headers (which may
+// hold credentials) never affect or end up in fixtures.
+func fixtureName(req *ScanRequest) string {
+	key, _ := json.Marshal(struct {
+		TargetURL string   `json:"targetUrl"`
+		Viewports []string `json:"viewports"`
+	}{req.TargetURL, req.Viewports})
+	sum := sha256.Sum256(key)
+	return "scan-" + hex.EncodeToString(sum[:])[:16] + ".json"
+}
+
+// Recorder passes requests to another Scanner and saves each successful
+// response in a fixture directory
+type Recorder struct {
+	next Scanner
+	dir  string
+}
+
+// NewRecorder creates a Recorder saving next's responses in dir
+func NewRecorder(next Scanner, dir string) *Recorder {
+	return &Recorder{next: next, dir: dir}
+}
+
+// Scan sends req and records the response
+func (r *Recorder) Scan(ctx context.Context, req *ScanRequest) (*ScanResponse, error) {
+	resp, err := r.next.Scan(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(r.dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create fixture directory: %w", err)
+	}
+	data, err := json.MarshalIndent(fixture{
+		TargetURL: req.TargetURL,
+		Viewports: req.Viewports,
+		Response:  resp,
+	}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal fixture: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(r.dir, fixtureName(req)), data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write fixture: %w", err)
+	}
+
+	return resp, nil
+}
+
+// Replayer serves responses saved by a Recorder without contacting a server
+type Replayer struct {
+	dir string
+}
+
+// NewReplayer creates a Replayer reading fixtures from dir
+func NewReplayer(dir string) *Replayer {
+	return &Replayer{dir: dir}
+}
+
+// Scan returns the recorded response for req
+func (r *Replayer) Scan(ctx context.Context, req *ScanRequest) (*ScanResponse, error) {
+	path := filepath.Join(r.dir, fixtureName(req))
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no recorded response for %s with viewports %v in %s", req.TargetURL, req.Viewports, r.dir)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixture: %w", err)
+	}
+
+	var f fixture
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse fixture %s: %w", path, err)
+	}
+	if f.Response == nil {
+		return nil, fmt.Errorf("fixture %s has no response", path)
+	}
+	return f.Response, nil
+}
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeScanner answers every scan with resp, or err, counting calls
+type fakeScanner struct {
+	resp  *ScanResponse
+	err   error
+	calls int
+}
+
+func (f *fakeScanner) Scan(ctx context.Context, req *ScanRequest) (*ScanResponse, error) {
+	f.calls++
+	return f.resp, f.err
+}
+
+func TestRecordReplayRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	want := &ScanResponse{
+		ScanID: "scan-1",
+		Status: "completed",
+		Results: []ViewportResult{{
+			Device:           "mobile",
+			Dimensions:       Dimensions{Width: 375, Height: 1200},
+			ScreenshotBase64: "iVBORw0KGgo=",
+			Issues:           []DetectedIssue{{Severity: "high", Type: "horizontal-scroll", Description: "Page scrolls horizontally"}},
+		}},
+	}
+	server := &fakeScanner{resp: want}
+	req := &ScanRequest{
+		TargetURL: "https://example.com",
+		Viewports: []string{"mobile"},
+		Options:   &ScanOptions{Headers: map[string]string{"Authorization": "Bearer secret"}},
+	}
+
+	got, err := NewRecorder(server, dir).Scan(context.Background(), req)
+	if err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	if got != want || server.calls != 1 {
+		t.Fatalf("Recorder didn't pass the request through")
+	}
+
+	// Headers don't identify a request, so a replay without them matches
+	replayed, err := NewReplayer(dir).Scan(context.Background(), &ScanRequest{TargetURL: "https://example.com", Viewports: []string{"mobile"}})
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if !reflect.DeepEqual(replayed, want) {
+		t.Errorf("replayed %+v, want %+v", replayed, want)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("fixture directory has %d entries (%v), want 1", len(entries), err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, entries[0].Name()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "secret") {
+		t.Error("fixture contains a request header")
+	}
+}
+
+func TestReplayUnrecordedRequest(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := NewRecorder(&fakeScanner{resp: &ScanResponse{ScanID: "scan-1"}}, dir).Scan(context.Background(),
+		&ScanRequest{TargetURL: "https://example.com", Viewports: []string{"mobile"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := NewReplayer(dir).Scan(context.Background(), &ScanRequest{TargetURL: "https://example.com", Viewports: []string{"mobile", "desktop"}})
+	if err == nil || !strings.Contains(err.Error(), "no recorded response") {
+		t.Errorf("replay with other viewports = %v, want no recorded response", err)
+	}
+}
+
+func TestRecorderSkipsFailedScans(t *testing.T) {
+	dir := t.TempDir()
+	failure := errors.New("connection refused")
+	_, err := NewRecorder(&fakeScanner{err: failure}, dir).Scan(context.Background(),
+		&ScanRequest{TargetURL: "https://example.com", Viewports: []string{"mobile"}})
+	if !errors.Is(err, failure) {
+		t.Errorf("Scan = %v, want the server's error", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("recorded %d fixtures of a failed scan", len(entries))
+	}
+}
@@ -0,0 +1,12 @@
+package api
+
+// MixedContent is an insecure http:// resource requested by an https:// page,
+// reported with ScanOptions.CaptureMixedContent
+type MixedContent struct {
+	URL string `json:"url"`
+	// ResourceType is the kind of resource, e.g. "script", "stylesheet" or "image"
+	ResourceType string `json:"resourceType,omitempty"`
+	// Blocked is true when the browser refused to load it, as it does for
+	// active content such as scripts; passive content like images loads
+	Blocked bool `json:"blocked,omitempty"`
+}
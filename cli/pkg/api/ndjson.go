@@ -0,0 +1,159 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// ndjsonContentType is the Content-Type of scan responses streamed as NDJSON
+const ndjsonContentType = "application/x-ndjson"
+
+// isNDJSON reports whether contentType is one of the NDJSON media types
+func isNDJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch mediaType {
+	case ndjsonContentType, "application/ndjson", "application/jsonl", "application/x-jsonlines":
+		return true
+	}
+	return false
+}
+
+// ScanNDJSON is Scan for servers that stream their results as NDJSON, one
+// JSON object per line, calling onEvent for each line as it arrives:
+// EventViewportResult with a viewport's result, and any progress events
+// the server sends between them. A server answering with a single JSON
+// response is read as by Scan, with no events.
+//
+// Lines are progress events as sent over /scan/stream. A viewport_result
+// line carries one ViewportResult in "viewport", and the final complete
+// line the scan's other fields in "result" (its results may be left out,
+// having already been sent). Bare ViewportResult and ScanResponse lines,
+// with no "type", are read the same way.
+func (c *Client) ScanNDJSON(ctx context.Context, req *ScanRequest, onEvent func(ProgressEvent)) (*ScanResponse, error) {
+	return c.scan(ctx, req, onEvent)
+}
+
+// responseBody returns the body of a response resty left unread, decoding
+// gzip as resty would have
+func responseBody(resp *resty.Response) (io.ReadCloser, error) {
+	body := resp.RawBody()
+	if resp.Header().Get("Content-Encoding") != "gzip" || resp.RawResponse.ContentLength == 0 {
+		return body, nil
+	}
+	gz, err := gzip.NewReader(body)
+	if err != nil {
+		body.Close()
+		return nil, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{gz, body}, nil
+}
+
+// readNDJSON assembles the response of a scan of expected viewports from
+// NDJSON lines read from body, passing each to onEvent if it isn't nil
+func readNDJSON(ctx context.Context, body io.Reader, expected int, onEvent func(ProgressEvent)) (*ScanResponse, error) {
+	if onEvent == nil {
+		onEvent = func(ProgressEvent) {}
+	}
+
+	// Lines carry base64 screenshots, too long for a bufio.Scanner's default buffer
+	reader := bufio.NewReader(body)
+	var results []ViewportResult
+	for n := 1; ; n++ {
+		line, readErr := reader.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			event, err := parseNDJSONLine(line)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse response line %d: %w", n, err)
+			}
+
+			switch event.Type {
+			case EventViewportResult:
+				results = append(results, *event.Viewport)
+				onEvent(event)
+			case EventComplete:
+				onEvent(event)
+				if len(event.Result.Results) == 0 {
+					event.Result.Results = results
+				}
+				return event.Result, nil
+			case EventError:
+				onEvent(event)
+				if limitErr := viewportLimitError(event.Error, "", 0); limitErr != nil {
+					return nil, limitErr
+				}
+				return nil, fmt.Errorf("%s", event.Error)
+			default:
+				onEvent(event)
+			}
+		}
+
+		if readErr != nil {
+			if ctx.Err() != nil {
+				return nil, fmt.Errorf("%w: %w", ErrRequestFailed, ctx.Err())
+			}
+			if errors.Is(readErr, io.EOF) {
+				return nil, fmt.Errorf("response ended before the scan completed (%d of %d viewports received)", len(results), expected)
+			}
+			return nil, fmt.Errorf("failed to read response: %w", readErr)
+		}
+	}
+}
+
+// parseNDJSONLine decodes one line of an NDJSON scan response into an event
+func parseNDJSONLine(line []byte) (ProgressEvent, error) {
+	var event ProgressEvent
+	if err := json.Unmarshal(line, &event); err != nil {
+		return event, err
+	}
+
+	if event.Type == "" {
+		// A bare result: a viewport's if it names a device, else the scan's
+		var bare struct {
+			Device string `json:"device"`
+			ScanID string `json:"scanId"`
+		}
+		json.Unmarshal(line, &bare)
+		switch {
+		case bare.Device != "":
+			event.Type = EventViewportResult
+			event.Viewport = &ViewportResult{}
+			if err := json.Unmarshal(line, event.Viewport); err != nil {
+				return event, err
+			}
+		case bare.ScanID != "":
+			event.Type = EventComplete
+			event.Result = &ScanResponse{}
+			if err := json.Unmarshal(line, event.Result); err != nil {
+				return event, err
+			}
+		default:
+			return event, fmt.Errorf("line has no type and is neither a viewport nor a scan result")
+		}
+	}
+
+	switch {
+	case event.Type == EventViewportResult && event.Viewport == nil:
+		return event, fmt.Errorf("%s line has no viewport", event.Type)
+	case event.Type == EventComplete && event.Result == nil:
+		return event, fmt.Errorf("%s line has no result", event.Type)
+	}
+	if event.Type == EventViewportResult && event.Device == "" {
+		event.Device = event.Viewport.Device
+	}
+	return event, nil
+}
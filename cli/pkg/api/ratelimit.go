@@ -0,0 +1,103 @@
+package api
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MaxRetryAfter caps how long a Retry-After header can hold requests back
+const MaxRetryAfter = 5 * time.Minute
+
+// rateLimiter paces requests with a token bucket: tokens are earned at rate
+// per second up to burst, and each request spends one, waiting for it if the
+// bucket is empty. A pause (after a 429) stops tokens being earned until it ends.
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time // When tokens were last earned; a pause moves it into the future
+}
+
+// newRateLimiter creates a limiter allowing perSecond requests on average,
+// and burst at once after an idle spell
+func newRateLimiter(perSecond float64, burst int) *rateLimiter {
+	return &rateLimiter{rate: perSecond, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// reserve spends a token and returns how long to wait before using it
+func (l *rateLimiter) reserve(now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.After(l.last) {
+		l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+		l.last = now
+	}
+	l.tokens--
+
+	at := l.last
+	if l.tokens < 0 {
+		at = at.Add(time.Duration(-l.tokens / l.rate * float64(time.Second)))
+	}
+	if at.Before(now) {
+		return 0
+	}
+	return at.Sub(now)
+}
+
+// wait blocks until the next request may be sent, or ctx is done
+func (l *rateLimiter) wait(ctx context.Context) error {
+	delay := l.reserve(time.Now())
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// pause holds back requests not yet reserved for d, as a server asked with Retry-After
+func (l *rateLimiter) pause(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	until := time.Now().Add(d)
+	if until.After(l.last) {
+		l.last = until
+		l.tokens = math.Min(l.tokens, 0)
+	}
+}
+
+// parseRetryAfter reads a Retry-After header, either delay seconds or an HTTP
+// date, capped at MaxRetryAfter. ok is false if the header is absent or invalid.
+func parseRetryAfter(value string, now time.Time) (d time.Duration, ok bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		d = time.Duration(seconds) * time.Second
+	} else if at, err := http.ParseTime(value); err == nil {
+		d = at.Sub(now)
+		if d < 0 {
+			d = 0
+		}
+	} else {
+		return 0, false
+	}
+	return min(d, MaxRetryAfter), true
+}
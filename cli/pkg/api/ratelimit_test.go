@@ -0,0 +1,127 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterPacesRequests(t *testing.T) {
+	l := newRateLimiter(2, 1)
+	now := l.last
+
+	// The burst of 1 goes at once, then one request every 500ms
+	for i, want := range []time.Duration{0, 500 * time.Millisecond, time.Second} {
+		if got := l.reserve(now); got != want {
+			t.Errorf("request %d waits %s, want %s", i+1, got, want)
+		}
+	}
+	// An idle spell refills the bucket, but only up to the burst
+	now = now.Add(10 * time.Second)
+	for i, want := range []time.Duration{0, 500 * time.Millisecond} {
+		if got := l.reserve(now); got != want {
+			t.Errorf("request %d after idling waits %s, want %s", i+1, got, want)
+		}
+	}
+}
+
+func TestRateLimiterPause(t *testing.T) {
+	l := newRateLimiter(10, 1)
+	l.pause(2 * time.Second)
+
+	// The pause empties the bucket, so pacing resumes from its end
+	now := time.Now()
+	for i, want := range []time.Duration{2100 * time.Millisecond, 2200 * time.Millisecond} {
+		if got := l.reserve(now); got < want-50*time.Millisecond || got > want {
+			t.Errorf("request %d after a 2s pause waits %s, want %s", i+1, got, want)
+		}
+	}
+}
+
+func TestRateLimiterWaitCancelled(t *testing.T) {
+	l := newRateLimiter(1, 1)
+	l.pause(time.Minute)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.wait(ctx); err != context.DeadlineExceeded {
+		t.Errorf("wait = %v, want the context's error", err)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 10, 14, 8, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{"3", 3 * time.Second, true},
+		{" 0 ", 0, true},
+		{"3600", MaxRetryAfter, true},
+		{now.Add(10 * time.Second).Format(http.TimeFormat), 10 * time.Second, true},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0, true},
+		{"", 0, false},
+		{"-1", 0, false},
+		{"soon", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseRetryAfter(tt.value, now)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("parseRetryAfter(%q) = %s, %t, want %s, %t", tt.value, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestScanRateLimit(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests []time.Time
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, time.Now())
+		n := len(requests)
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		// The second request is over the backend's limit
+		if n == 2 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprint(w, `{"error":"rate limited"}`)
+			return
+		}
+		fmt.Fprint(w, scanOK)
+	}))
+	defer srv.Close()
+
+	const perSecond = 10
+	client := NewClient(srv.URL).SetRateLimit(perSecond)
+	client.httpClient.SetRetryWaitTime(time.Millisecond)
+	for i := 0; i < 4; i++ {
+		if _, err := client.Scan(context.Background(), &ScanRequest{TargetURL: "https://example.com", Viewports: []string{"mobile"}}); err != nil {
+			t.Fatalf("scan %d: %v", i+1, err)
+		}
+	}
+
+	if len(requests) != 5 {
+		t.Fatalf("server got %d requests, want 4 scans and 1 retry", len(requests))
+	}
+	// Allow for timer granularity
+	const slack = 10 * time.Millisecond
+	for i := 1; i < len(requests); i++ {
+		gap := requests[i].Sub(requests[i-1])
+		want := time.Second / perSecond
+		if i == 2 {
+			// The retry waits out the 429's Retry-After
+			want = time.Second
+		}
+		if gap < want-slack {
+			t.Errorf("request %d sent %s after the one before, want at least %s", i+1, gap, want)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// retryWaitKey keys a request's retryWait in its context
+type retryWaitKey struct{}
+
+// retryWait is how long one request has waited on Retry-After, over all its attempts
+type retryWait struct {
+	total time.Duration
+}
+
+// withRetryWait gives a request's context a retryWait on its first attempt
+func withRetryWait(r *resty.Request) {
+	if _, ok := r.Context().Value(retryWaitKey{}).(*retryWait); !ok {
+		r.SetContext(context.WithValue(r.Context(), retryWaitKey{}, &retryWait{}))
+	}
+}
+
+// serverBusy returns how long a 429 or 503 response asks to wait before
+// retrying. ok is false for other responses and without a valid Retry-After.
+func serverBusy(resp *http.Response) (time.Duration, bool) {
+	if resp == nil || (resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable) {
+		return 0, false
+	}
+	return parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+}
+
+// OnRetryAfter calls notify before each retry that waits on a server's
+// Retry-After, with the response status and the wait, e.g. to report it
+func (c *Client) OnRetryAfter(notify func(status int, wait time.Duration)) *Client {
+	c.onRetryAfter = notify
+	return c
+}
+
+// retryAfter is the client's resty RetryAfter: a retry of a 429 or 503 waits
+// as long as its Retry-After asks, other retries the usual retry wait. A
+// request waits at most MaxRetryAfter in all on Retry-After, then fails.
+//
+// resty only asks when a retry is due, so the retried response's body is
+// discarded here; the body of the last attempt is left for the caller to
+// read the server's error from.
+func (c *Client) retryAfter(client *resty.Client, resp *resty.Response) (time.Duration, error) {
+	if resp == nil {
+		return client.RetryWaitTime, nil
+	}
+	discardBody(resp.RawResponse)
+	d, ok := serverBusy(resp.RawResponse)
+	if !ok {
+		return client.RetryWaitTime, nil
+	}
+	d = max(d, client.RetryWaitTime)
+
+	if wait, ok := resp.Request.Context().Value(retryWaitKey{}).(*retryWait); ok {
+		if wait.total+d > MaxRetryAfter {
+			return 0, fmt.Errorf("screenshot server is busy (HTTP %d) and asked to retry in %s, past the %s the CLI waits at most",
+				resp.StatusCode(), d, MaxRetryAfter)
+		}
+		wait.total += d
+	}
+	if c.onRetryAfter != nil {
+		c.onRetryAfter(resp.StatusCode(), d)
+	}
+	return d, nil
+}
+
+// discardBody drains and closes a response body that won't be read, so its
+// connection can be reused
+func discardBody(resp *http.Response) {
+	if resp == nil || resp.Body == nil {
+		return
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// scanOK is the body of a successful scan response
+const scanOK = `{"scanId":"scan-1","status":"completed","results":[]}`
+
+func TestScanRetriesBusyServer(t *testing.T) {
+	for _, status := range []int{http.StatusTooManyRequests, http.StatusServiceUnavailable} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			var requests atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				if requests.Add(1) == 1 {
+					w.Header().Set("Retry-After", "1")
+					w.WriteHeader(status)
+					fmt.Fprint(w, `{"error":"busy"}`)
+					return
+				}
+				fmt.Fprint(w, scanOK)
+			}))
+			defer srv.Close()
+
+			var notified []time.Duration
+			client := NewClient(srv.URL).OnRetryAfter(func(got int, wait time.Duration) {
+				if got != status {
+					t.Errorf("notified of HTTP %d, want %d", got, status)
+				}
+				notified = append(notified, wait)
+			})
+			client.httpClient.SetRetryWaitTime(time.Millisecond)
+
+			start := time.Now()
+			resp, err := client.Scan(context.Background(), &ScanRequest{TargetURL: "https://example.com", Viewports: []string{"mobile"}})
+			if err != nil {
+				t.Fatalf("Scan: %v", err)
+			}
+			if resp.ScanID != "scan-1" {
+				t.Errorf("scan ID = %q, want scan-1", resp.ScanID)
+			}
+			if n := requests.Load(); n != 2 {
+				t.Errorf("server got %d requests, want 2", n)
+			}
+			if len(notified) != 1 || notified[0] != time.Second {
+				t.Errorf("retry waits = %v, want [1s] from Retry-After", notified)
+			}
+			if elapsed := time.Since(start); elapsed < time.Second {
+				t.Errorf("retried after %s, before the Retry-After of 1s", elapsed)
+			}
+		})
+	}
+}
+
+func TestScanKeepsErrorOfLastBusyResponse(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprint(w, `{"error":"all browsers are busy"}`)
+	}))
+	defer srv.Close()
+
+	client := NewClient(srv.URL)
+	client.httpClient.SetRetryWaitTime(time.Millisecond)
+	_, err := client.Scan(context.Background(), &ScanRequest{TargetURL: "https://example.com", Viewports: []string{"mobile"}})
+	if err == nil {
+		t.Fatal("Scan succeeded against a server that is always busy")
+	}
+	if !strings.Contains(err.Error(), "all browsers are busy") {
+		t.Errorf("error = %q, want the server's message from the last response", err)
+	}
+	if n := requests.Load(); n != 3 {
+		t.Errorf("server got %d requests, want 3 (2 retries)", n)
+	}
+}
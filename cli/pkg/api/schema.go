@@ -0,0 +1,134 @@
+package api
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// SchemaDialect is the JSON Schema version Schema generates
+const SchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema generates the JSON Schema of v's JSON encoding from its Go type, so
+// it can't drift from the structs. Named structs other than v's own go in
+// $defs. Fields without omitempty are required, and those that can encode as
+// null (nil slices, maps and pointers) also allow null.
+func Schema(v any, title string) map[string]any {
+	g := &schemaGenerator{defs: make(map[string]any)}
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	g.root = t
+
+	schema := map[string]any{"$schema": SchemaDialect, "title": title}
+	for key, value := range g.structSchema(t) {
+		schema[key] = value
+	}
+	if len(g.defs) > 0 {
+		schema["$defs"] = g.defs
+	}
+	return schema
+}
+
+// schemaGenerator collects the $defs of one Schema call
+type schemaGenerator struct {
+	root reflect.Type
+	defs map[string]any
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// typeSchema returns the schema of t, referring to named structs through $defs
+func (g *schemaGenerator) typeSchema(t reflect.Type) map[string]any {
+	switch {
+	case t == timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.Pointer:
+		return g.typeSchema(t.Elem())
+	case t.Kind() == reflect.Struct:
+		if t == g.root {
+			return map[string]any{"$ref": "#"}
+		}
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		if _, ok := g.defs[t.Name()]; !ok {
+			g.defs[t.Name()] = nil // Placeholder, so recursive types terminate
+			g.defs[t.Name()] = g.structSchema(t)
+		}
+		return map[string]any{"$ref": "#/$defs/" + t.Name()}
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8:
+		return map[string]any{"type": "string", "contentEncoding": "base64"}
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		return map[string]any{"type": "array", "items": g.typeSchema(t.Elem())}
+	case t.Kind() == reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": g.typeSchema(t.Elem())}
+	case t.Kind() == reflect.String:
+		return map[string]any{"type": "string"}
+	case t.Kind() == reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		return map[string]any{"type": "number"}
+	default:
+		// Interfaces can hold anything
+		return map[string]any{}
+	}
+}
+
+// structSchema returns the object schema of struct type t, following
+// encoding/json: json:"-" and unexported fields are skipped, and embedded
+// structs without a name of their own contribute their fields
+func (g *schemaGenerator) structSchema(t reflect.Type) map[string]any {
+	properties := make(map[string]any)
+	required := []string{}
+	g.addFields(t, properties, &required)
+
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// addFields adds the properties of struct type t
+func (g *schemaGenerator) addFields(t reflect.Type, properties map[string]any, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		omitempty := strings.Contains(","+opts+",", ",omitempty,")
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				g.addFields(embedded, properties, required)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		schema := g.typeSchema(field.Type)
+		if !omitempty {
+			*required = append(*required, name)
+			switch field.Type.Kind() {
+			case reflect.Pointer, reflect.Slice, reflect.Map:
+				schema = map[string]any{"anyOf": []any{schema, map[string]any{"type": "null"}}}
+			}
+		}
+		properties[name] = schema
+	}
+}
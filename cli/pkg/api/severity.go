@@ -0,0 +1,52 @@
+package api
+
+import "strings"
+
+// Severities are the canonical issue severities, most severe first. Colors,
+// metrics and issue gating work on these.
+var Severities = []string{"critical", "high", "medium", "low"}
+
+// IsSeverity reports whether s is one of Severities
+func IsSeverity(s string) bool {
+	for _, severity := range Severities {
+		if s == severity {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeSeverities maps the issue severities of resp to the canonical set,
+// for backends with their own vocabulary (e.g. error/warn/info). mapping is
+// keyed by backend severity, matched case-insensitively; canonical severities
+// only have their case normalized. Severities that are neither canonical nor
+// mapped are left as they are and returned, each once, so they can be reported.
+func NormalizeSeverities(resp *ScanResponse, mapping map[string]string) []string {
+	lower := make(map[string]string, len(mapping))
+	for from, to := range mapping {
+		lower[strings.ToLower(from)] = strings.ToLower(to)
+	}
+
+	var unmapped []string
+	seen := make(map[string]bool)
+	normalize := func(issues []DetectedIssue) {
+		for j := range issues {
+			severity := strings.ToLower(issues[j].Severity)
+			if to, ok := lower[severity]; ok {
+				issues[j].Severity = to
+			} else if IsSeverity(severity) {
+				issues[j].Severity = severity
+			} else if !seen[severity] {
+				seen[severity] = true
+				unmapped = append(unmapped, issues[j].Severity)
+			}
+		}
+	}
+	for i := range resp.Results {
+		normalize(resp.Results[i].Issues)
+		for _, zoom := range resp.Results[i].ZoomCaptures {
+			normalize(zoom.Issues)
+		}
+	}
+	return unmapped
+}
@@ -0,0 +1,176 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/law-makers/viewport-cli/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Progress event types. All but EventReconnecting and EventViewportResult are
+// sent by servers that support /scan/stream; EventReconnecting is reported by
+// the client itself, and EventViewportResult by servers streaming NDJSON (see
+// ScanNDJSON).
+const (
+	EventViewportStarted    = "viewport_started"
+	EventScreenshotCaptured = "screenshot_captured"
+	EventAnalysisDone       = "analysis_done"
+	EventComplete           = "complete"
+	EventError              = "error"
+	EventReconnecting       = "reconnecting"
+	EventViewportResult     = "viewport_result"
+)
+
+// ProgressEvent is one message from a streaming scan. The final event is
+// either EventComplete carrying the result or EventError.
+type ProgressEvent struct {
+	Type    string        `json:"type"`
+	Device  string        `json:"device,omitempty"`
+	Message string        `json:"message,omitempty"`
+	Result  *ScanResponse `json:"result,omitempty"`
+	Error   string        `json:"error,omitempty"`
+	// Viewport is the result carried by an EventViewportResult
+	Viewport *ViewportResult `json:"viewport,omitempty"`
+}
+
+// ErrStreamingUnsupported is returned by ScanStream when the server does not
+// offer /scan/stream; callers should fall back to Scan
+var ErrStreamingUnsupported = errors.New("server does not support streaming scans")
+
+// streamReconnects is how many times a dropped stream is reopened
+const streamReconnects = 2
+
+// errStreamDropped marks a connection lost mid-scan, which is worth a reconnect
+var errStreamDropped = errors.New("stream connection lost")
+
+// ScanStream runs a scan over a WebSocket to /scan/stream, calling onEvent for
+// each progress event as it arrives. If the connection drops before the scan
+// completes it is reopened and the scan requested again. Once a server has
+// rejected the stream, later calls return ErrStreamingUnsupported immediately.
+func (c *Client) ScanStream(ctx context.Context, req *ScanRequest, onEvent func(ProgressEvent)) (resp *ScanResponse, err error) {
+	if c.streamUnsupported.Load() {
+		return nil, ErrStreamingUnsupported
+	}
+
+	ctx, span := tracing.Start(ctx, "api.scan_stream",
+		attribute.String("viewport.target_url", req.TargetURL),
+		attribute.StringSlice("viewport.viewports", req.Viewports))
+	defer func() {
+		// Falling back to a plain scan is routine, not a failure
+		spanErr := err
+		if errors.Is(err, ErrStreamingUnsupported) {
+			spanErr = nil
+		}
+		tracing.End(span, spanErr)
+	}()
+
+	for attempt := 0; ; attempt++ {
+		resp, err := c.scanStreamOnce(ctx, req, onEvent)
+		if !errors.Is(err, errStreamDropped) || attempt >= streamReconnects || ctx.Err() != nil {
+			return resp, err
+		}
+
+		onEvent(ProgressEvent{Type: EventReconnecting, Message: err.Error()})
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(time.Duration(attempt+1) * time.Second):
+		}
+	}
+}
+
+// scanStreamOnce runs one streaming attempt
+func (c *Client) scanStreamOnce(ctx context.Context, req *ScanRequest, onEvent func(ProgressEvent)) (*ScanResponse, error) {
+	header := http.Header{}
+	header.Set(ClientVersionHeader, strconv.Itoa(APIVersion))
+	header.Set("User-Agent", c.httpClient.Header.Get("User-Agent"))
+	for name, value := range tracing.Headers(ctx) {
+		header.Set(name, value)
+	}
+
+	if c.limiter != nil {
+		if err := c.limiter.wait(ctx); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrRequestFailed, err)
+		}
+	}
+	dialer := websocket.DefaultDialer
+	if c.tlsConfig != nil {
+		withTLS := *websocket.DefaultDialer
+		withTLS.TLSClientConfig = c.tlsConfig
+		dialer = &withTLS
+	}
+	conn, httpResp, err := dialer.DialContext(ctx, streamURL(c.baseURL), header)
+	if err != nil {
+		if c.rateLimited(httpResp) {
+			// Not a refusal to stream; the plain request waits out the pause
+			return nil, fmt.Errorf("%w: server is rate limiting", ErrStreamingUnsupported)
+		}
+		if httpResp != nil {
+			// The server answered but refused the upgrade: it doesn't stream
+			c.streamUnsupported.Store(true)
+		}
+		return nil, fmt.Errorf("%w: %v", ErrStreamingUnsupported, err)
+	}
+	defer conn.Close()
+	c.breaker.record(true)
+
+	// Unblock reads when the scan is cancelled
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	if err := conn.WriteJSON(req); err != nil {
+		return nil, fmt.Errorf("%w: %v", errStreamDropped, err)
+	}
+
+	for {
+		var event ProgressEvent
+		if err := conn.ReadJSON(&event); err != nil {
+			if ctx.Err() != nil {
+				return nil, fmt.Errorf("%w: %w", ErrRequestFailed, ctx.Err())
+			}
+			return nil, fmt.Errorf("%w: %v", errStreamDropped, err)
+		}
+
+		switch event.Type {
+		case EventComplete:
+			if event.Result == nil {
+				return nil, fmt.Errorf("failed to parse response")
+			}
+			onEvent(event)
+			return event.Result, nil
+		case EventError:
+			onEvent(event)
+			if limitErr := viewportLimitError(event.Error, "", 0); limitErr != nil {
+				return nil, limitErr
+			}
+			return nil, fmt.Errorf("%s", event.Error)
+		default:
+			onEvent(event)
+		}
+	}
+}
+
+// streamURL turns an http(s) base URL into the ws(s) URL of the streaming endpoint
+func streamURL(baseURL string) string {
+	switch {
+	case strings.HasPrefix(baseURL, "https://"):
+		baseURL = "wss://" + strings.TrimPrefix(baseURL, "https://")
+	case strings.HasPrefix(baseURL, "http://"):
+		baseURL = "ws://" + strings.TrimPrefix(baseURL, "http://")
+	}
+	return strings.TrimSuffix(baseURL, "/") + "/scan/stream"
+}
@@ -0,0 +1,19 @@
+package api
+
+// StyleCapture asks for the computed values of CSS properties on the first
+// element matching Selector, in every viewport
+type StyleCapture struct {
+	Selector   string   `json:"selector"`
+	Properties []string `json:"properties"`
+}
+
+// ElementStyle is the computed style captured for one StyleCapture
+type ElementStyle struct {
+	Selector string `json:"selector"`
+	// Matched is false when no element matched the selector; Properties is then empty
+	Matched bool `json:"matched"`
+	// Properties maps each requested property to its computed value
+	Properties map[string]string `json:"properties,omitempty"`
+	// Error is set when the server couldn't evaluate the selector, e.g. invalid syntax
+	Error string `json:"error,omitempty"`
+}
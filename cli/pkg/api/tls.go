@@ -0,0 +1,71 @@
+package api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"time"
+)
+
+// TLSFiles are the PEM files used for HTTPS connections to the screenshot server
+type TLSFiles struct {
+	// CACert holds CA certificates trusted on top of the system roots, e.g. a
+	// private CA that signed the server's certificate
+	CACert string
+	// ClientCert and ClientKey are presented to servers that require mutual TLS
+	ClientCert string
+	ClientKey  string
+}
+
+// LoadTLSConfig builds the TLS configuration for files. A client certificate
+// needs its key, and must match it.
+func LoadTLSConfig(files TLSFiles) (*tls.Config, error) {
+	if (files.ClientCert == "") != (files.ClientKey == "") {
+		return nil, fmt.Errorf("a client certificate and its key must be given together")
+	}
+
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+	if files.ClientCert != "" {
+		cert, err := tls.LoadX509KeyPair(files.ClientCert, files.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate %s with key %s: %w", files.ClientCert, files.ClientKey, err)
+		}
+		if cert.Leaf != nil && time.Now().After(cert.Leaf.NotAfter) {
+			return nil, fmt.Errorf("client certificate %s expired on %s", files.ClientCert, cert.Leaf.NotAfter.Format(time.DateOnly))
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	if files.CACert != "" {
+		data, err := os.ReadFile(files.CACert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA certificate: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no PEM certificates found in %s", files.CACert)
+		}
+		config.RootCAs = pool
+	}
+	return config, nil
+}
+
+// SetTLSFiles loads files (see LoadTLSConfig) and uses them for every
+// connection to the server, streaming included. No files keeps the defaults.
+func (c *Client) SetTLSFiles(files TLSFiles) (*Client, error) {
+	if files == (TLSFiles{}) {
+		return c, nil
+	}
+	config, err := LoadTLSConfig(files)
+	if err != nil {
+		return c, err
+	}
+	c.httpClient.SetTLSClientConfig(config)
+	c.tlsConfig = config
+	c.tlsFiles = files
+	return c, nil
+}
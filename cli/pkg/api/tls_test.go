@@ -0,0 +1,189 @@
+package api
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testCA signs the certificates of a test
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "viewport test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue signs a certificate valid until notAfter, returning it and its key as PEM
+func (ca *testCA) issue(t *testing.T, name string, usage x509.ExtKeyUsage, notAfter time.Time) (certPEM, keyPEM []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-2 * time.Hour),
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// writeFile writes data to name in dir and returns its path
+func writeFile(t *testing.T, dir, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// mTLSServer starts an HTTPS scan server that requires a client certificate signed by ca
+func mTLSServer(t *testing.T, ca *testCA) *httptest.Server {
+	t.Helper()
+	certPEM, keyPEM := ca.issue(t, "127.0.0.1", x509.ExtKeyUsageServerAuth, time.Now().Add(time.Hour))
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clients := x509.NewCertPool()
+	clients.AddCert(ca.cert)
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, scanOK)
+	}))
+	srv.TLS = &tls.Config{Certificates: []tls.Certificate{cert}, ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clients}
+	// Handshake failures are expected, keep them out of the test output
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestScanMutualTLS(t *testing.T) {
+	ca := newTestCA(t)
+	srv := mTLSServer(t, ca)
+	dir := t.TempDir()
+	caFile := writeFile(t, dir, "ca.pem", ca.pem)
+	certPEM, keyPEM := ca.issue(t, "viewport-cli", x509.ExtKeyUsageClientAuth, time.Now().Add(time.Hour))
+	certFile := writeFile(t, dir, "client.pem", certPEM)
+	keyFile := writeFile(t, dir, "client-key.pem", keyPEM)
+
+	tests := []struct {
+		name   string
+		files  TLSFiles
+		wantOK bool
+	}{
+		{"client certificate", TLSFiles{CACert: caFile, ClientCert: certFile, ClientKey: keyFile}, true},
+		{"no client certificate", TLSFiles{CACert: caFile}, false},
+		{"untrusted server", TLSFiles{ClientCert: certFile, ClientKey: keyFile}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewClient(srv.URL).SetRetryCount(0).SetTLSFiles(tt.files)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = client.Scan(context.Background(), &ScanRequest{TargetURL: "https://example.com", Viewports: []string{"mobile"}})
+			if tt.wantOK && err != nil {
+				t.Errorf("Scan failed with a trusted client certificate: %v", err)
+			}
+			if !tt.wantOK && err == nil {
+				t.Error("Scan succeeded, want a failed handshake")
+			}
+		})
+	}
+}
+
+func TestLoadTLSConfigErrors(t *testing.T) {
+	ca := newTestCA(t)
+	dir := t.TempDir()
+	certPEM, keyPEM := ca.issue(t, "viewport-cli", x509.ExtKeyUsageClientAuth, time.Now().Add(time.Hour))
+	certFile := writeFile(t, dir, "client.pem", certPEM)
+	keyFile := writeFile(t, dir, "client-key.pem", keyPEM)
+	_, otherKey := ca.issue(t, "other", x509.ExtKeyUsageClientAuth, time.Now().Add(time.Hour))
+	otherKeyFile := writeFile(t, dir, "other-key.pem", otherKey)
+	expiredPEM, expiredKey := ca.issue(t, "expired", x509.ExtKeyUsageClientAuth, time.Now().Add(-time.Hour))
+	expiredFile := writeFile(t, dir, "expired.pem", expiredPEM)
+	expiredKeyFile := writeFile(t, dir, "expired-key.pem", expiredKey)
+	notPEM := writeFile(t, dir, "ca.txt", []byte("not a certificate"))
+
+	tests := []struct {
+		name  string
+		files TLSFiles
+		want  string
+	}{
+		{"certificate without key", TLSFiles{ClientCert: certFile}, "must be given together"},
+		{"key without certificate", TLSFiles{ClientKey: keyFile}, "must be given together"},
+		{"key of another certificate", TLSFiles{ClientCert: certFile, ClientKey: otherKeyFile}, "failed to load client certificate"},
+		{"missing certificate", TLSFiles{ClientCert: filepath.Join(dir, "missing.pem"), ClientKey: keyFile}, "failed to load client certificate"},
+		{"expired certificate", TLSFiles{ClientCert: expiredFile, ClientKey: expiredKeyFile}, "expired on"},
+		{"CA file without certificates", TLSFiles{CACert: notPEM}, "no PEM certificates"},
+		{"missing CA file", TLSFiles{CACert: filepath.Join(dir, "missing-ca.pem")}, "failed to read CA certificate"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadTLSConfig(tt.files)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("LoadTLSConfig = %v, want an error containing %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package baseline
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/law-makers/viewport-cli/pkg/api"
+)
+
+// IndexFile is the name of the index mapping targets to their baselines
+const IndexFile = "index.json"
+
+// indexVersion is bumped when the index format changes; older indexes are ignored
+const indexVersion = 1
+
+// Entry is a target's baseline in the index
+type Entry struct {
+	URL    string `json:"url"`    // Target the baseline was promoted for, as scanned
+	ScanID string `json:"scanId"` // Scan promoted to the baseline
+	File   string `json:"file"`   // Baseline file in the store directory
+	// Promoted is when the scan became the baseline
+	Promoted time.Time `json:"promoted"`
+}
+
+type index struct {
+	Version int              `json:"version"`
+	Entries map[string]Entry `json:"entries"` // Keyed by Key of the target
+}
+
+// Store keeps one baseline scan per target in a directory, e.g.
+// <work dir>/baselines. Baselines are saved without screenshots: they are
+// compared by issues.
+type Store struct {
+	dir string
+}
+
+// NewStore returns the baseline store in dir, which is created when a
+// baseline is first saved
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// Dir returns the directory holding the baselines
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Key normalizes a target URL into its baseline key, so the same page gets
+// the same baseline however its URL is written: the scheme and host are
+// lowercased, default ports and the fragment dropped, an empty path becomes
+// "/" and query parameters are sorted.
+func Key(target string) (string, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return "", fmt.Errorf("invalid target URL %q: %w", target, err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("invalid target URL %q: missing scheme or host", target)
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	host, port := strings.ToLower(u.Hostname()), u.Port()
+	if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		port = ""
+	}
+	u.Host = host
+	if port != "" {
+		u.Host = net.JoinHostPort(host, port)
+	} else if strings.Contains(host, ":") {
+		u.Host = "[" + host + "]"
+	}
+	u.User = nil
+	u.Fragment, u.RawFragment = "", ""
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	u.RawQuery = u.Query().Encode()
+	return u.String(), nil
+}
+
+// fileName is the baseline file of a key
+func fileName(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:8]) + ".json"
+}
+
+// Get returns the baseline of target, or nil if it has none
+func (s *Store) Get(target string) (*api.ScanResponse, *Entry, error) {
+	key, err := Key(target)
+	if err != nil {
+		return nil, nil, err
+	}
+	idx, err := s.readIndex()
+	if err != nil {
+		return nil, nil, err
+	}
+	entry, ok := idx.Entries[key]
+	if !ok {
+		return nil, nil, nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(s.dir, entry.File))
+	if errors.Is(err, os.ErrNotExist) {
+		// The index outlived its file; the next scan establishes a new baseline
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read baseline: %w", err)
+	}
+	var scan api.ScanResponse
+	if err := json.Unmarshal(data, &scan); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse baseline %s: %w", entry.File, err)
+	}
+	return &scan, &entry, nil
+}
+
+// Put promotes scan to the baseline of target, replacing any previous one
+func (s *Store) Put(target string, scan *api.ScanResponse) (*Entry, error) {
+	key, err := Key(target)
+	if err != nil {
+		return nil, err
+	}
+	idx, err := s.readIndex()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create baseline directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(withoutScreenshots(scan), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal baseline: %w", err)
+	}
+	entry := Entry{URL: target, ScanID: scan.ScanID, File: fileName(key), Promoted: time.Now().UTC()}
+	if err := writeFileAtomic(filepath.Join(s.dir, entry.File), data); err != nil {
+		return nil, fmt.Errorf("failed to write baseline: %w", err)
+	}
+
+	idx.Entries[key] = entry
+	data, err = json.MarshalIndent(idx, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal baseline index: %w", err)
+	}
+	if err := writeFileAtomic(filepath.Join(s.dir, IndexFile), data); err != nil {
+		return nil, fmt.Errorf("failed to write baseline index: %w", err)
+	}
+	return &entry, nil
+}
+
+// readIndex loads the index, which is empty if there is none yet
+func (s *Store) readIndex() (index, error) {
+	idx := index{Version: indexVersion, Entries: map[string]Entry{}}
+	data, err := os.ReadFile(filepath.Join(s.dir, IndexFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return idx, nil
+	}
+	if err != nil {
+		return idx, fmt.Errorf("failed to read baseline index: %w", err)
+	}
+
+	var stored index
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return idx, fmt.Errorf("failed to parse baseline index %s: %w", filepath.Join(s.dir, IndexFile), err)
+	}
+	if stored.Version != indexVersion || stored.Entries == nil {
+		return idx, nil
+	}
+	return stored, nil
+}
+
+// withoutScreenshots copies scan with its screenshot data left out
+func withoutScreenshots(scan *api.ScanResponse) *api.ScanResponse {
+	stripped := *scan
+	stripped.Results = make([]api.ViewportResult, len(scan.Results))
+	for i, result := range scan.Results {
+		result.ScreenshotBase64 = ""
+		result.ScrollScreenshots = nil
+		result.ZoomCaptures = nil
+		result.Reference = nil
+		stripped.Results[i] = result
+	}
+	return &stripped
+}
+
+// writeFileAtomic replaces path with data via a temporary file, so a
+// concurrent reader never sees a partial baseline
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,157 @@
+package compare
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/law-makers/viewport-cli/pkg/api"
+)
+
+// Kinds of A11yChange
+const (
+	A11yAdded   = "added"
+	A11yRemoved = "removed"
+	A11yMoved   = "moved" // In both trees, at a different place in reading order
+)
+
+// maxA11yCells bounds the reading-order alignment table; larger trees are
+// compared without detecting moves
+const maxA11yCells = 4_000_000
+
+// A11yChange is a node of the accessibility tree added, removed or moved
+// between two captures of a viewport
+type A11yChange struct {
+	Kind  string `json:"kind"`
+	Role  string `json:"role"`
+	Name  string `json:"name,omitempty"`
+	Level int    `json:"level,omitempty"`
+}
+
+// String describes the node, e.g. `heading 2 "Pricing"`
+func (c A11yChange) String() string {
+	desc := c.Role
+	if c.Level > 0 {
+		desc += fmt.Sprintf(" %d", c.Level)
+	}
+	if c.Name != "" {
+		desc += fmt.Sprintf(" %q", c.Name)
+	}
+	return desc
+}
+
+// DiffA11yTree compares two accessibility trees by their nodes in reading
+// order (depth first). Nodes are matched by role, name and level; those in
+// both trees but out of order relative to the rest are moved. Either tree
+// may be nil, in which case there is nothing to compare.
+func DiffA11yTree(a, b *api.A11yNode) []A11yChange {
+	if a == nil || b == nil {
+		return nil
+	}
+	nodesA, nodesB := flattenA11y(a, nil), flattenA11y(b, nil)
+
+	// Most captures differ in a few places, so align only what lies between
+	// the common start and end
+	start := 0
+	for start < len(nodesA) && start < len(nodesB) && nodesA[start] == nodesB[start] {
+		start++
+	}
+	endA, endB := len(nodesA), len(nodesB)
+	for endA > start && endB > start && nodesA[endA-1] == nodesB[endB-1] {
+		endA--
+		endB--
+	}
+	removed, added := alignA11y(nodesA[start:endA], nodesB[start:endB])
+
+	// A node removed in one place and added in another has moved
+	var changes, moved []A11yChange
+	pending := make(map[A11yChange]int)
+	for _, node := range added {
+		pending[node]++
+	}
+	for _, node := range removed {
+		if pending[node] > 0 {
+			pending[node]--
+			node.Kind = A11yMoved
+			moved = append(moved, node)
+			continue
+		}
+		node.Kind = A11yRemoved
+		changes = append(changes, node)
+	}
+	for _, node := range added {
+		if pending[node] > 0 {
+			pending[node]--
+			node.Kind = A11yAdded
+			changes = append(changes, node)
+		}
+	}
+	return append(changes, moved...)
+}
+
+// flattenA11y appends node and its descendants to nodes in reading order,
+// without Kind
+func flattenA11y(node *api.A11yNode, nodes []A11yChange) []A11yChange {
+	nodes = append(nodes, A11yChange{Role: node.Role, Name: strings.TrimSpace(node.Name), Level: node.Level})
+	for i := range node.Children {
+		nodes = flattenA11y(&node.Children[i], nodes)
+	}
+	return nodes
+}
+
+// alignA11y returns the nodes of a and b outside their longest common
+// subsequence. Past maxA11yCells it falls back to comparing counts, so
+// nodes in both are never reported.
+func alignA11y(a, b []A11yChange) (onlyA, onlyB []A11yChange) {
+	if len(a) == 0 || len(b) == 0 {
+		return a, b
+	}
+	if len(a)*len(b) > maxA11yCells {
+		count := make(map[A11yChange]int)
+		for _, node := range b {
+			count[node]++
+		}
+		for _, node := range a {
+			if count[node] > 0 {
+				count[node]--
+				continue
+			}
+			onlyA = append(onlyA, node)
+		}
+		for _, node := range b {
+			if count[node] > 0 {
+				count[node]--
+				onlyB = append(onlyB, node)
+			}
+		}
+		return onlyA, onlyB
+	}
+
+	// lcs[i][j] is the longest common subsequence of a[i:] and b[j:]
+	cols := len(b) + 1
+	lcs := make([]int32, (len(a)+1)*cols)
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i*cols+j] = lcs[(i+1)*cols+j+1] + 1
+			} else {
+				lcs[i*cols+j] = max(lcs[(i+1)*cols+j], lcs[i*cols+j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			i++
+			j++
+		case lcs[(i+1)*cols+j] >= lcs[i*cols+j+1]:
+			onlyA = append(onlyA, a[i])
+			i++
+		default:
+			onlyB = append(onlyB, b[j])
+			j++
+		}
+	}
+	return append(onlyA, a[i:]...), append(onlyB, b[j:]...)
+}
@@ -0,0 +1,144 @@
+package compare
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+
+	"github.com/law-makers/viewport-cli/pkg/api"
+	"golang.org/x/image/draw"
+)
+
+// IssueKey identifies an issue by type and description, ignoring case and surrounding whitespace
+func IssueKey(issue api.DetectedIssue) string {
+	return strings.ToLower(issue.Type) + "\x00" + strings.ToLower(strings.TrimSpace(issue.Description))
+}
+
+// DiffIssues splits two issue lists into issues only in a, only in b, and in both
+func DiffIssues(a, b []api.DetectedIssue) (onlyA, onlyB, common []api.DetectedIssue) {
+	onlyA, onlyB, common = []api.DetectedIssue{}, []api.DetectedIssue{}, []api.DetectedIssue{}
+
+	inB := make(map[string]bool, len(b))
+	for _, issue := range b {
+		inB[IssueKey(issue)] = true
+	}
+
+	inA := make(map[string]bool, len(a))
+	for _, issue := range a {
+		key := IssueKey(issue)
+		inA[key] = true
+		if inB[key] {
+			common = append(common, issue)
+		} else {
+			onlyA = append(onlyA, issue)
+		}
+	}
+
+	for _, issue := range b {
+		if !inA[IssueKey(issue)] {
+			onlyB = append(onlyB, issue)
+		}
+	}
+
+	return onlyA, onlyB, common
+}
+
+// PixelDiff compares two PNG images and returns the percentage of differing
+// pixels along with an image highlighting them in red. Areas covered by only
+// one image (when sizes differ) count as different.
+func PixelDiff(a, b []byte) (float64, image.Image, error) {
+	imgA, err := png.Decode(bytes.NewReader(a))
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to decode first image: %w", err)
+	}
+	imgB, err := png.Decode(bytes.NewReader(b))
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to decode second image: %w", err)
+	}
+
+	percent, diff := PixelDiffImages(imgA, imgB, 0)
+	return percent, diff, nil
+}
+
+// PixelDiffImages is PixelDiff for decoded images. Pixels whose channels all
+// differ by at most tolerance (0-255) count as the same.
+func PixelDiffImages(imgA, imgB image.Image, tolerance uint8) (float64, image.Image) {
+	boundsA, boundsB := imgA.Bounds(), imgB.Bounds()
+	width := max(boundsA.Dx(), boundsB.Dx())
+	height := max(boundsA.Dy(), boundsB.Dy())
+	if width == 0 || height == 0 {
+		return 0, image.NewRGBA(image.Rect(0, 0, 0, 0))
+	}
+
+	diff := image.NewRGBA(image.Rect(0, 0, width, height))
+	highlight := color.RGBA{R: 255, A: 255}
+	differing := 0
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			pa := image.Pt(boundsA.Min.X+x, boundsA.Min.Y+y)
+			pb := image.Pt(boundsB.Min.X+x, boundsB.Min.Y+y)
+			inA, inB := pa.In(boundsA), pb.In(boundsB)
+
+			if inA && inB && similarColor(imgA.At(pa.X, pa.Y), imgB.At(pb.X, pb.Y), tolerance) {
+				// Unchanged pixels are shown faded so differences stand out
+				diff.Set(x, y, fade(imgA.At(pa.X, pa.Y)))
+				continue
+			}
+
+			differing++
+			diff.Set(x, y, highlight)
+		}
+	}
+
+	return float64(differing) * 100 / float64(width*height), diff
+}
+
+// ScaleToWidth resizes img to the given width, keeping its aspect ratio
+func ScaleToWidth(img image.Image, width int) image.Image {
+	bounds := img.Bounds()
+	if bounds.Dx() == width || bounds.Dx() == 0 {
+		return img
+	}
+	height := int(float64(bounds.Dy())*float64(width)/float64(bounds.Dx()) + 0.5)
+	if height < 1 {
+		height = 1
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Src, nil)
+	return dst
+}
+
+// Crop returns the part of img within width×height from its top-left corner
+func Crop(img image.Image, width, height int) image.Image {
+	bounds := img.Bounds()
+	if bounds.Dx() <= width && bounds.Dy() <= height {
+		return img
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, min(width, bounds.Dx()), min(height, bounds.Dy())))
+	draw.Draw(dst, dst.Bounds(), img, bounds.Min, draw.Src)
+	return dst
+}
+
+// similarColor compares two colors channel by channel, allowing tolerance (0-255) per channel
+func similarColor(a, b color.Color, tolerance uint8) bool {
+	r1, g1, b1, a1 := a.RGBA()
+	r2, g2, b2, a2 := b.RGBA()
+	limit := uint32(tolerance) * 0x101
+	within := func(x, y uint32) bool {
+		if x > y {
+			return x-y <= limit
+		}
+		return y-x <= limit
+	}
+	return within(r1, r2) && within(g1, g2) && within(b1, b2) && within(a1, a2)
+}
+
+// fade returns a light grayscale version of c
+func fade(c color.Color) color.Color {
+	gray := color.GrayModel.Convert(c).(color.Gray)
+	return color.Gray{Y: 192 + gray.Y/4}
+}
@@ -0,0 +1,61 @@
+package compare
+
+import (
+	"sort"
+
+	"github.com/law-makers/viewport-cli/pkg/api"
+)
+
+// NoMatch stands in for the values of an element style whose selector matched nothing
+const NoMatch = "(no match)"
+
+// StyleChange is a computed style value that differs between two scans
+type StyleChange struct {
+	Selector string `json:"selector"`
+	// Property is empty when the selector matched in only one of the scans
+	Property string `json:"property,omitempty"`
+	Before   string `json:"before"`
+	After    string `json:"after"`
+}
+
+// DiffStyles compares the element styles of two captures of a viewport. Only
+// selectors and properties captured by both are compared; a selector that
+// matched an element in one capture but not the other is one change.
+func DiffStyles(a, b []api.ElementStyle) []StyleChange {
+	inB := make(map[string]api.ElementStyle, len(b))
+	for _, style := range b {
+		inB[style.Selector] = style
+	}
+
+	var changes []StyleChange
+	for _, sa := range a {
+		sb, ok := inB[sa.Selector]
+		if !ok || sa.Error != "" || sb.Error != "" {
+			continue
+		}
+		switch {
+		case !sa.Matched && !sb.Matched:
+			continue
+		case !sa.Matched:
+			changes = append(changes, StyleChange{Selector: sa.Selector, Before: NoMatch, After: "matched"})
+			continue
+		case !sb.Matched:
+			changes = append(changes, StyleChange{Selector: sa.Selector, Before: "matched", After: NoMatch})
+			continue
+		}
+
+		properties := make([]string, 0, len(sa.Properties))
+		for property := range sa.Properties {
+			if _, ok := sb.Properties[property]; ok {
+				properties = append(properties, property)
+			}
+		}
+		sort.Strings(properties)
+		for _, property := range properties {
+			if before, after := sa.Properties[property], sb.Properties[property]; before != after {
+				changes = append(changes, StyleChange{Selector: sa.Selector, Property: property, Before: before, After: after})
+			}
+		}
+	}
+	return changes
+}
@@ -1,18 +1,33 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/law-makers/viewport-cli/pkg/api"
 	"github.com/spf13/viper"
 )
 
 // Config holds all application configuration
 type Config struct {
+	// Fail on unknown keys instead of ignoring them (also --strict-config)
+	StrictConfig bool `mapstructure:"strict_config"`
+
 	// API Configuration
 	API struct {
 		URL string `mapstructure:"url"`
+		// PEM files for HTTPS servers: a CA to trust, and a client certificate and key for mutual TLS
+		CACert     string `mapstructure:"ca_cert"`
+		ClientCert string `mapstructure:"client_cert"`
+		ClientKey  string `mapstructure:"client_key"`
 	} `mapstructure:"api"`
 
 	// Scan Configuration
@@ -23,6 +38,18 @@ type Config struct {
 		Output string `mapstructure:"output"`
 		// Default timeout in seconds
 		Timeout int `mapstructure:"timeout"`
+		// Prime the browser with a throwaway scan after auto-starting the server
+		Warmup bool `mapstructure:"warmup"`
+		// User-Agent for the CLI's own HTTP requests (empty = viewport-cli/<version>)
+		UserAgent string `mapstructure:"user_agent"`
+		// Route map used by --only-changed (empty = .viewport-routes)
+		RouteMap string `mapstructure:"route_map"`
+		// Capture options for URLs whose path matches a glob; the first match wins
+		Routes []RouteOptions `mapstructure:"routes"`
+		// Backend issue severities mapped to critical, high, medium or low
+		SeverityMap map[string]string `mapstructure:"severity_map"`
+		// YAML file of device profiles that viewports resolve against, on top of the built-ins
+		DeviceListFile string `mapstructure:"device_list_file"`
 	} `mapstructure:"scan"`
 
 	// CLI Display Configuration
@@ -33,7 +60,32 @@ type Config struct {
 		NoColor bool `mapstructure:"no_color"`
 		// Disable table formatting
 		NoTable bool `mapstructure:"no_table"`
+		// Color used for each issue severity (ANSI 0-255 or hex like #ff8800)
+		SeverityColors map[string]string `mapstructure:"severity_colors"`
 	} `mapstructure:"display"`
+
+	// Results Storage Configuration
+	Results struct {
+		// Where scans are stored: filesystem (under scan.output) or s3
+		Backend string `mapstructure:"backend"`
+		S3      struct {
+			Bucket string `mapstructure:"bucket"`
+			Prefix string `mapstructure:"prefix"`
+			Region string `mapstructure:"region"`
+			// Optional S3-compatible endpoint, e.g. MinIO
+			Endpoint string `mapstructure:"endpoint"`
+		} `mapstructure:"s3"`
+	} `mapstructure:"results"`
+}
+
+// RouteOptions overrides capture options for URLs whose path matches Match
+type RouteOptions struct {
+	// URL path glob, e.g. /products/** ("*" stays within a segment, "**" spans segments)
+	Match string `mapstructure:"match" yaml:"match"`
+	// Capture the whole page (the default) or only the viewport
+	FullPage *bool `mapstructure:"full_page" yaml:"full_page,omitempty"`
+	// Clip the capture to the first element matching this CSS selector
+	Selector string `mapstructure:"selector" yaml:"selector,omitempty"`
 }
 
 // DefaultConfig returns a Config with sensible defaults
@@ -43,12 +95,39 @@ func DefaultConfig() *Config {
 	cfg.Scan.Viewports = []string{"mobile", "tablet", "desktop"}
 	cfg.Scan.Output = "./viewport-results"
 	cfg.Scan.Timeout = 60
+	cfg.Scan.Warmup = false
+	cfg.Scan.SeverityMap = map[string]string{}
 	cfg.Display.Verbose = false
 	cfg.Display.NoColor = false
 	cfg.Display.NoTable = false
+	cfg.Display.SeverityColors = DefaultSeverityColors()
+	cfg.Results.Backend = "filesystem"
+	cfg.Results.S3.Prefix = "viewport-results"
 	return cfg
 }
 
+// DefaultSeverityColors returns the colors used for severities not set in config
+func DefaultSeverityColors() map[string]string {
+	return map[string]string{
+		"critical": "1",
+		"high":     "3",
+		"medium":   "4",
+		"low":      "8",
+	}
+}
+
+// hexColorPattern matches the #RGB and #RRGGBB forms lipgloss accepts
+var hexColorPattern = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// ValidColor reports whether s is a lipgloss color: an ANSI code (0-255) or a hex color
+func ValidColor(s string) bool {
+	if hexColorPattern.MatchString(s) {
+		return true
+	}
+	n, err := strconv.Atoi(s)
+	return err == nil && n >= 0 && n <= 255
+}
+
 // LoadConfig loads configuration from files and environment
 func LoadConfig(configPath string) (*Config, error) {
 	v := viper.New()
@@ -58,65 +137,242 @@ func LoadConfig(configPath string) (*Config, error) {
 	setDefaults(v, defaults)
 
 	// Look for config file
+	if configPath == "" {
+		// The same search GetConfigPath uses, so saved config is what gets loaded
+		configPath = findConfigFile()
+	}
 	if configPath != "" {
-		// Use provided path
 		v.SetConfigFile(configPath)
-	} else {
-		// Look in home directory
-		home, err := os.UserHomeDir()
-		if err == nil {
-			v.AddConfigPath(home)
-		}
-
-		// Look in current directory
-		v.AddConfigPath(".")
-
-		// Look in .config/viewport-cli
-		if home != "" {
-			v.AddConfigPath(filepath.Join(home, ".config", "viewport-cli"))
-		}
-
-		v.SetConfigName(".viewport")
-		v.SetConfigType("yaml")
 	}
 
 	// Read environment variables with prefix VIEWPORT_
 	v.SetEnvPrefix("VIEWPORT")
 	v.AutomaticEnv()
 
-	// Try to read the file, but don't fail if it doesn't exist
-	if err := v.ReadInConfig(); err != nil {
-		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
-			// If there's an error other than file not found, return it
-			return nil, fmt.Errorf("error reading config file: %w", err)
+	// Without a config file we use defaults
+	if configPath != "" {
+		if err := v.ReadInConfig(); err != nil {
+			return nil, describeConfigError(configPath, err)
 		}
-		// File not found is OK - we'll use defaults
 	}
 
-	// Unmarshal into Config struct
+	// Unmarshal into Config struct, noting keys that don't map to a field
 	cfg := DefaultConfig()
-	if err := v.Unmarshal(cfg); err != nil {
+	var md mapstructure.Metadata
+	if err := v.Unmarshal(cfg, func(dc *mapstructure.DecoderConfig) { dc.Metadata = &md }); err != nil {
 		return nil, fmt.Errorf("error parsing config: %w", err)
 	}
 
+	if (strict || cfg.StrictConfig) && len(md.Unused) > 0 {
+		sort.Strings(md.Unused)
+		return nil, &UnknownKeysError{Keys: md.Unused}
+	}
+
 	return cfg, nil
 }
 
-// GetConfigPath returns the path where config file should be created
-func GetConfigPath() (string, error) {
+// UnknownKeysError is returned by LoadConfig in strict mode when the config
+// has keys that don't match any setting, usually typos
+type UnknownKeysError struct {
+	Keys []string
+}
+
+func (e *UnknownKeysError) Error() string {
+	return fmt.Sprintf("unknown config keys: %s", strings.Join(e.Keys, ", "))
+}
+
+// Validate checks that the configuration values are usable
+func Validate(cfg *Config) error {
+	var errs []error
+
+	if cfg.API.URL != "" {
+		u, err := url.Parse(cfg.API.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("api.url %q must be an http(s) URL", cfg.API.URL))
+		}
+	}
+	if (cfg.API.ClientCert == "") != (cfg.API.ClientKey == "") {
+		errs = append(errs, fmt.Errorf("api.client_cert and api.client_key must be set together"))
+	}
+
+	if len(cfg.Scan.Viewports) == 0 {
+		errs = append(errs, fmt.Errorf("scan.viewports must list at least one viewport"))
+	}
+	for _, vp := range cfg.Scan.Viewports {
+		if vp == "" {
+			errs = append(errs, fmt.Errorf("scan.viewports contains an empty viewport name"))
+		}
+	}
+
+	if cfg.Scan.Output == "" {
+		errs = append(errs, fmt.Errorf("scan.output must not be empty"))
+	}
+
+	if cfg.Scan.Timeout <= 0 {
+		errs = append(errs, fmt.Errorf("scan.timeout must be a positive number of seconds, got %d", cfg.Scan.Timeout))
+	}
+
+	for i, route := range cfg.Scan.Routes {
+		if route.Match == "" {
+			errs = append(errs, fmt.Errorf("scan.routes[%d] needs a match glob", i))
+		} else if !strings.HasPrefix(route.Match, "/") && !strings.HasPrefix(route.Match, "*") {
+			errs = append(errs, fmt.Errorf("scan.routes[%d] match %q must be a URL path glob starting with / (or *)", i, route.Match))
+		}
+		if route.FullPage == nil && route.Selector == "" {
+			errs = append(errs, fmt.Errorf("scan.routes[%d] (%s) sets neither full_page nor selector", i, route.Match))
+		}
+	}
+
+	for from, to := range cfg.Scan.SeverityMap {
+		if !api.IsSeverity(strings.ToLower(to)) {
+			errs = append(errs, fmt.Errorf("scan.severity_map.%s %q must be one of: %s", from, to, strings.Join(api.Severities, ", ")))
+		}
+	}
+
+	for severity, color := range cfg.Display.SeverityColors {
+		if !ValidColor(color) {
+			errs = append(errs, fmt.Errorf("display.severity_colors.%s %q must be an ANSI color (0-255) or hex color (#rgb or #rrggbb)", severity, color))
+		}
+	}
+
+	switch cfg.Results.Backend {
+	case "", "filesystem":
+	case "s3":
+		if cfg.Results.S3.Bucket == "" {
+			errs = append(errs, fmt.Errorf("results.s3.bucket must be set when results.backend is s3"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("results.backend %q must be filesystem or s3", cfg.Results.Backend))
+	}
+
+	return errors.Join(errs...)
+}
+
+// strict makes LoadConfig fail on unknown keys, see SetStrict
+var strict bool
+
+// SetStrict makes LoadConfig fail on unknown keys (the --strict-config flag),
+// as the strict_config setting does. Loading is lenient by default.
+func SetStrict(enabled bool) {
+	strict = enabled
+}
+
+// workDir overrides where config and state files are written, see SetWorkDir
+var workDir string
+
+// SetWorkDir sets the directory for config and state files (the --work-dir flag).
+// An empty dir falls back to VIEWPORT_WORK_DIR, then to ~/.config/viewport-cli.
+func SetWorkDir(dir string) {
+	workDir = dir
+}
+
+// WorkDir returns the work dir override, or "" if none is set
+func WorkDir() string {
+	if workDir != "" {
+		return workDir
+	}
+	return os.Getenv("VIEWPORT_WORK_DIR")
+}
+
+// stateDir returns the directory for config and state files
+func stateDir() (string, error) {
+	if dir := WorkDir(); dir != "" {
+		return dir, nil
+	}
+
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return "", err
 	}
+	return filepath.Join(home, ".config", "viewport-cli"), nil
+}
 
-	// Try to create .config/viewport-cli directory
-	configDir := filepath.Join(home, ".config", "viewport-cli")
-	if err := os.MkdirAll(configDir, 0755); err != nil {
-		// Fallback to current directory if home doesn't work
-		return ".viewport.yaml", nil
+// GetStatePath returns the path of a state file (PID files, caches and
+// the like), creating the directory that holds it
+func GetStatePath(name string) (string, error) {
+	dir, err := stateDir()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create state directory: %w", err)
 	}
+	return filepath.Join(dir, name), nil
+}
+
+// configFileName is the name new config files are created with
+const configFileName = ".viewport.yaml"
 
-	return filepath.Join(configDir, ".viewport.yaml"), nil
+// configSearchDirs lists the directories searched for a config file, in order:
+// the work dir, the home directory, the current directory, then ~/.config/viewport-cli
+func configSearchDirs() []string {
+	var dirs []string
+	if dir := WorkDir(); dir != "" {
+		dirs = append(dirs, dir)
+	}
+	home, err := os.UserHomeDir()
+	if err == nil {
+		dirs = append(dirs, home)
+	}
+	dirs = append(dirs, ".")
+	if err == nil {
+		dirs = append(dirs, filepath.Join(home, ".config", "viewport-cli"))
+	}
+	return dirs
+}
+
+// findConfigFile returns the first existing .viewport.<ext> in configSearchDirs, or ""
+func findConfigFile() string {
+	for _, dir := range configSearchDirs() {
+		for _, ext := range viper.SupportedExts {
+			path := filepath.Join(dir, ".viewport."+ext)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				return path
+			}
+		}
+	}
+	return ""
+}
+
+// GetConfigPath returns the config file that LoadConfig reads if one exists,
+// otherwise where a new one should be created: the state directory, or the
+// current directory if that can't be created. It fails if neither is writable.
+func GetConfigPath() (string, error) {
+	if path := findConfigFile(); path != "" {
+		return path, nil
+	}
+
+	var tried []string
+	configDir, err := stateDir()
+	if err == nil {
+		if err = os.MkdirAll(configDir, 0755); err == nil {
+			if err = checkWritable(configDir); err == nil {
+				return filepath.Join(configDir, configFileName), nil
+			}
+		}
+		tried = append(tried, fmt.Sprintf("%s (%v)", configDir, err))
+	} else {
+		tried = append(tried, fmt.Sprintf("home directory (%v)", err))
+	}
+
+	// Fall back to the current directory, which LoadConfig also searches
+	if err := checkWritable("."); err != nil {
+		tried = append(tried, fmt.Sprintf("current directory (%v)", err))
+		return "", fmt.Errorf("no writable location for the config file, tried %s; set --work-dir or VIEWPORT_WORK_DIR to a writable directory",
+			strings.Join(tried, ", "))
+	}
+	return configFileName, nil
+}
+
+// checkWritable verifies that files can be created in dir
+func checkWritable(dir string) error {
+	probe, err := os.CreateTemp(dir, ".viewport-write-test-*")
+	if err != nil {
+		return err
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+	return nil
 }
 
 // SaveConfig saves the configuration to a file
@@ -141,11 +397,27 @@ func SaveConfig(cfg *Config, path string) error {
 
 // setDefaults sets all default values in viper
 func setDefaults(v *viper.Viper, cfg *Config) {
+	v.SetDefault("strict_config", cfg.StrictConfig)
 	v.SetDefault("api.url", cfg.API.URL)
+	v.SetDefault("api.ca_cert", cfg.API.CACert)
+	v.SetDefault("api.client_cert", cfg.API.ClientCert)
+	v.SetDefault("api.client_key", cfg.API.ClientKey)
 	v.SetDefault("scan.viewports", cfg.Scan.Viewports)
 	v.SetDefault("scan.output", cfg.Scan.Output)
 	v.SetDefault("scan.timeout", cfg.Scan.Timeout)
+	v.SetDefault("scan.warmup", cfg.Scan.Warmup)
+	v.SetDefault("scan.user_agent", cfg.Scan.UserAgent)
+	v.SetDefault("scan.route_map", cfg.Scan.RouteMap)
+	v.SetDefault("scan.routes", cfg.Scan.Routes)
+	v.SetDefault("scan.severity_map", cfg.Scan.SeverityMap)
+	v.SetDefault("scan.device_list_file", cfg.Scan.DeviceListFile)
 	v.SetDefault("display.verbose", cfg.Display.Verbose)
 	v.SetDefault("display.no_color", cfg.Display.NoColor)
 	v.SetDefault("display.no_table", cfg.Display.NoTable)
+	v.SetDefault("display.severity_colors", cfg.Display.SeverityColors)
+	v.SetDefault("results.backend", cfg.Results.Backend)
+	v.SetDefault("results.s3.bucket", cfg.Results.S3.Bucket)
+	v.SetDefault("results.s3.prefix", cfg.Results.S3.Prefix)
+	v.SetDefault("results.s3.region", cfg.Results.S3.Region)
+	v.SetDefault("results.s3.endpoint", cfg.Results.S3.Endpoint)
 }
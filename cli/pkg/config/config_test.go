@@ -0,0 +1,146 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// isolateConfigSearch points the config search at empty temporary
+// directories: home, the work dir override and the current directory
+func isolateConfigSearch(t *testing.T) (home, cwd string) {
+	t.Helper()
+	home, cwd = t.TempDir(), t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("VIEWPORT_WORK_DIR", "")
+	SetWorkDir("")
+	t.Chdir(cwd)
+	return home, cwd
+}
+
+func TestGetConfigPathDefault(t *testing.T) {
+	home, _ := isolateConfigSearch(t)
+	path, err := GetConfigPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(home, ".config", "viewport-cli", configFileName); path != want {
+		t.Errorf("GetConfigPath = %q, want %q", path, want)
+	}
+}
+
+func TestGetConfigPathExisting(t *testing.T) {
+	home, _ := isolateConfigSearch(t)
+	existing := filepath.Join(home, ".viewport.yaml")
+	if err := os.WriteFile(existing, []byte("api:\n  url: http://localhost:3001\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if path, err := GetConfigPath(); err != nil || path != existing {
+		t.Errorf("GetConfigPath = %q, %v, want the existing %q", path, err, existing)
+	}
+}
+
+func TestGetConfigPathUnwritableHome(t *testing.T) {
+	home, _ := isolateConfigSearch(t)
+	// ~/.config is a file, so the state directory can't be created, even by root
+	if err := os.WriteFile(filepath.Join(home, ".config"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	path, err := GetConfigPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != configFileName {
+		t.Errorf("GetConfigPath = %q, want the current directory's %s", path, configFileName)
+	}
+}
+
+func TestGetConfigPathNoHome(t *testing.T) {
+	isolateConfigSearch(t)
+	t.Setenv("HOME", "")
+	path, err := GetConfigPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != configFileName {
+		t.Errorf("GetConfigPath = %q, want the current directory's %s", path, configFileName)
+	}
+}
+
+func TestGetConfigPathNowhereWritable(t *testing.T) {
+	home, cwd := isolateConfigSearch(t)
+	if err := os.WriteFile(filepath.Join(home, ".config"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	// A removed working directory can't be written to, even by root
+	if err := os.Remove(cwd); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := GetConfigPath()
+	if err == nil {
+		t.Fatal("GetConfigPath succeeded with nowhere writable")
+	}
+	for _, want := range []string{"no writable location", "current directory", "--work-dir"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q doesn't mention %q", err, want)
+		}
+	}
+}
+
+func TestGetConfigPathWorkDir(t *testing.T) {
+	isolateConfigSearch(t)
+	dir := filepath.Join(t.TempDir(), "work")
+	t.Setenv("VIEWPORT_WORK_DIR", dir)
+	path, err := GetConfigPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, configFileName); path != want {
+		t.Errorf("GetConfigPath = %q, want %q", path, want)
+	}
+}
+
+func TestLoadConfigUnknownKeys(t *testing.T) {
+	defer SetStrict(false)
+	const fixture = "testdata/unknown-keys.yaml"
+
+	SetStrict(false)
+	cfg, err := LoadConfig(fixture)
+	if err != nil {
+		t.Fatalf("lenient LoadConfig: %v", err)
+	}
+	if cfg.Scan.Timeout != 45 {
+		t.Errorf("timeout = %d, want the known keys loaded", cfg.Scan.Timeout)
+	}
+
+	SetStrict(true)
+	_, err = LoadConfig(fixture)
+	var unknown *UnknownKeysError
+	if !errors.As(err, &unknown) {
+		t.Fatalf("strict LoadConfig = %v, want an UnknownKeysError", err)
+	}
+	if want := []string{"api.urll", "colour", "scan.viewport"}; !reflect.DeepEqual(unknown.Keys, want) {
+		t.Errorf("unknown keys = %q, want %q, sorted", unknown.Keys, want)
+	}
+
+	if _, err := LoadConfig("testdata/valid.yaml"); err != nil {
+		t.Errorf("strict LoadConfig of a valid config: %v", err)
+	}
+}
+
+func TestLoadConfigStrictSetting(t *testing.T) {
+	SetStrict(false)
+	path := filepath.Join(t.TempDir(), ".viewport.yaml")
+	if err := os.WriteFile(path, []byte("strict_config: true\nscan:\n  timout: 30\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := LoadConfig(path)
+	var unknown *UnknownKeysError
+	if !errors.As(err, &unknown) || !reflect.DeepEqual(unknown.Keys, []string{"scan.timout"}) {
+		t.Errorf("LoadConfig with strict_config = %v, want unknown key scan.timout", err)
+	}
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/spf13/viper"
+)
+
+// maxContextLine is how much of a line parse errors quote
+const maxContextLine = 80
+
+// yamlErrorLine finds the line number in YAML parser errors like "yaml: line 3: ..."
+var yamlErrorLine = regexp.MustCompile(`\bline (\d+)\b`)
+
+// describeConfigError adds the config file's path to an error from reading
+// it and, when the file failed to parse, the line it failed at
+func describeConfigError(path string, err error) error {
+	var parseErr viper.ConfigParseError
+	if !errors.As(err, &parseErr) {
+		return fmt.Errorf("error reading config file %s: %w", path, err)
+	}
+	data, readErr := os.ReadFile(path)
+	if readErr != nil {
+		return fmt.Errorf("error parsing config file %s: %w", path, parseErr.Unwrap())
+	}
+	return fmt.Errorf("error parsing config file %s: %w%s", path, parseErr.Unwrap(), parseContext(data, parseErr.Unwrap()))
+}
+
+// parseContext quotes the line of data that err, a YAML or JSON parse error,
+// points at, prefixed with a newline, or returns "" if it can't tell. Data
+// that isn't UTF-8 is reported as such, since the parser's own message
+// ("invalid leading UTF-8 octet") doesn't say where.
+func parseContext(data []byte, err error) string {
+	if !utf8.Valid(data) {
+		offset := 0
+		for offset < len(data) {
+			r, size := utf8.DecodeRune(data[offset:])
+			if r == utf8.RuneError && size <= 1 {
+				break
+			}
+			offset += size
+		}
+		line := bytes.Count(data[:offset], []byte("\n")) + 1
+		return fmt.Sprintf("\n  line %d isn't UTF-8 text, the file looks binary or corrupted: %q", line, contextLine(data, line))
+	}
+
+	line := 0
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		line = bytes.Count(data[:min(int(syntaxErr.Offset), len(data))], []byte("\n")) + 1
+	} else if m := yamlErrorLine.FindStringSubmatch(err.Error()); m != nil {
+		line, _ = strconv.Atoi(m[1])
+	}
+	if line < 1 || line > bytes.Count(data, []byte("\n"))+1 {
+		return ""
+	}
+	return fmt.Sprintf("\n  %4d | %s", line, contextLine(data, line))
+}
+
+// contextLine returns the 1-based line of data, cut to maxContextLine bytes
+func contextLine(data []byte, line int) []byte {
+	text := bytes.Split(data, []byte("\n"))[line-1]
+	text = bytes.TrimRight(text, "\r")
+	if len(text) > maxContextLine {
+		text = append(text[:maxContextLine:maxContextLine], "..."...)
+	}
+	return text
+}
@@ -0,0 +1,158 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+	"go.yaml.in/yaml/v3"
+)
+
+// DefaultValue returns the built-in default for a dotted config key such as
+// "scan.timeout". Sections like "scan" are keys too, returning their defaults
+// as a map, and so are entries of map settings like
+// display.severity_colors.high, whose default is nil if the entry is not built in.
+func DefaultValue(key string) (interface{}, bool) {
+	v := viper.New()
+	setDefaults(v, DefaultConfig())
+
+	key = strings.ToLower(key)
+	for _, k := range v.AllKeys() {
+		if k == key || strings.HasPrefix(k, key+".") {
+			return v.Get(key), true
+		}
+		if parent, entry, ok := cutLast(key); ok && k == parent {
+			if m, isMap := v.Get(k).(map[string]string); isMap {
+				if def, ok := m[entry]; ok {
+					return def, true
+				}
+				return nil, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// cutLast splits a dotted key into its parent and last segment
+func cutLast(key string) (string, string, bool) {
+	i := strings.LastIndex(key, ".")
+	if i < 0 {
+		return "", "", false
+	}
+	return key[:i], key[i+1:], true
+}
+
+// UnsetKey removes a dotted key from the YAML config file at path so its
+// default applies again. The file is edited as YAML rather than round-tripped
+// through Config, which would write every default back; comments and the
+// order of other keys are kept. Sections left empty are removed as well.
+// It reports whether the key was present.
+func UnsetKey(path, key string) (bool, error) {
+	if ext := strings.ToLower(filepath.Ext(path)); ext != ".yaml" && ext != ".yml" {
+		return false, fmt.Errorf("only YAML config files can be edited, not %s", path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return false, fmt.Errorf("failed to parse config file %s: %w%s", path, err, parseContext(data, err))
+	}
+	if len(doc.Content) == 0 {
+		return false, nil
+	}
+
+	if !removeKey(doc.Content[0], strings.Split(strings.ToLower(key), ".")) {
+		return false, nil
+	}
+
+	// A file left with no keys is written empty
+	var buf bytes.Buffer
+	if len(doc.Content[0].Content) > 0 {
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(&doc); err != nil {
+			return false, fmt.Errorf("failed to encode config file: %w", err)
+		}
+		if err := enc.Close(); err != nil {
+			return false, fmt.Errorf("failed to encode config file: %w", err)
+		}
+	}
+
+	// Write through a temp file so an interrupted write can't truncate the config
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+		return false, fmt.Errorf("failed to write config file: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return false, fmt.Errorf("failed to write config file: %w", err)
+	}
+	return true, nil
+}
+
+// removeKey deletes the mapping entry at path below node, dropping mappings
+// it leaves empty. Keys match case-insensitively, as viper reads them.
+func removeKey(node *yaml.Node, path []string) bool {
+	if node.Kind != yaml.MappingNode {
+		return false
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if strings.ToLower(node.Content[i].Value) != path[0] {
+			continue
+		}
+		value := node.Content[i+1]
+		if len(path) > 1 {
+			if !removeKey(value, path[1:]) {
+				return false
+			}
+			if len(value.Content) > 0 {
+				return true
+			}
+		}
+		node.Content = append(node.Content[:i], node.Content[i+2:]...)
+		return true
+	}
+	return false
+}
+
+// FormatValue renders a config value the way it reads in the config file
+func FormatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "(none)"
+	case []string:
+		return "[" + strings.Join(v, ", ") + "]"
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		parts := make([]string, len(keys))
+		for i, k := range keys {
+			parts[i] = k + ": " + FormatValue(v[k])
+		}
+		return "{" + strings.Join(parts, ", ") + "}"
+	case map[string]string:
+		m := make(map[string]interface{}, len(v))
+		for k, s := range v {
+			m[k] = s
+		}
+		return FormatValue(m)
+	case string:
+		if v == "" {
+			return `""`
+		}
+		return v
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
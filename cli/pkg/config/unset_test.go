@@ -0,0 +1,136 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// unsetFixture sets a nested section, a list and keys that must survive an unset
+const unsetFixture = `# Team defaults
+
+api:
+  url: http://localhost:4000 # staging server
+scan:
+  viewports:
+    - mobile
+    - wide
+  timeout: 45
+results:
+  backend: s3
+  s3:
+    bucket: team-bucket
+    region: eu-west-1
+`
+
+// writeConfig writes data to a config file in a temporary directory
+func writeConfig(t *testing.T, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), ".viewport.yaml")
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// unsetAndLoad unsets key in the config file at path and loads the result
+func unsetAndLoad(t *testing.T, path, key string) *Config {
+	t.Helper()
+	found, err := UnsetKey(path, key)
+	if err != nil {
+		t.Fatalf("UnsetKey(%s): %v", key, err)
+	}
+	if !found {
+		t.Fatalf("UnsetKey(%s) found no key", key)
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig after unsetting %s: %v", key, err)
+	}
+	return cfg
+}
+
+func TestUnsetKeyNested(t *testing.T) {
+	SetStrict(false)
+	path := writeConfig(t, unsetFixture)
+
+	cfg := unsetAndLoad(t, path, "results.s3.bucket")
+	if cfg.Results.S3.Bucket != "" {
+		t.Errorf("bucket = %q after unset, want the empty default", cfg.Results.S3.Bucket)
+	}
+	if cfg.Results.S3.Region != "eu-west-1" || cfg.Results.Backend != "s3" {
+		t.Errorf("results = %+v, want the bucket's neighbours kept", cfg.Results)
+	}
+
+	// Unsetting the last key of a section removes the section too
+	unsetAndLoad(t, path, "results.s3.region")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "s3:") {
+		t.Errorf("config still has an empty s3 section:\n%s", data)
+	}
+}
+
+func TestUnsetKeyList(t *testing.T) {
+	SetStrict(false)
+	path := writeConfig(t, unsetFixture)
+
+	cfg := unsetAndLoad(t, path, "Scan.Viewports")
+	if want := DefaultConfig().Scan.Viewports; !reflect.DeepEqual(cfg.Scan.Viewports, want) {
+		t.Errorf("viewports = %q after unset, want the default %q", cfg.Scan.Viewports, want)
+	}
+	if cfg.Scan.Timeout != 45 {
+		t.Errorf("timeout = %d, want the list's neighbour kept", cfg.Scan.Timeout)
+	}
+}
+
+func TestUnsetKeyMissing(t *testing.T) {
+	path := writeConfig(t, unsetFixture)
+
+	for _, key := range []string{"scan.warmup", "results.s3.endpoint", "api.url.host", "nope"} {
+		found, err := UnsetKey(path, key)
+		if err != nil || found {
+			t.Errorf("UnsetKey(%s) = %v, %v, want not found", key, found, err)
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != unsetFixture {
+		t.Errorf("config rewritten without a key to remove:\n%s", data)
+	}
+}
+
+func TestUnsetKeyRewriteKeepsOtherKeys(t *testing.T) {
+	SetStrict(false)
+	path := writeConfig(t, unsetFixture)
+
+	unsetAndLoad(t, path, "scan.timeout")
+	cfg := unsetAndLoad(t, path, "api.url")
+
+	// The second rewrite starts from the first one's file, not the loaded config
+	if cfg.Scan.Timeout != DefaultConfig().Scan.Timeout || cfg.API.URL != DefaultConfig().API.URL {
+		t.Errorf("timeout = %d and url = %q, want both back at their defaults", cfg.Scan.Timeout, cfg.API.URL)
+	}
+	if !reflect.DeepEqual(cfg.Scan.Viewports, []string{"mobile", "wide"}) || cfg.Results.S3.Bucket != "team-bucket" {
+		t.Errorf("config = %+v, want the keys not unset kept", cfg)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, removed := range []string{"timeout", "url", "prefix", "output"} {
+		if strings.Contains(string(data), removed+":") {
+			t.Errorf("config has %s after the rewrite:\n%s", removed, data)
+		}
+	}
+	if !strings.Contains(string(data), "# Team defaults") {
+		t.Errorf("config lost its comments:\n%s", data)
+	}
+}
@@ -0,0 +1,83 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// run executes git with args in the current directory and returns its trimmed output
+func run(args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", strings.Join(args, " "), msg)
+		}
+		return "", fmt.Errorf("git %s: %w", strings.Join(args, " "), err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// HeadCommit returns the full hash of the checked-out commit
+func HeadCommit() (string, error) {
+	return ResolveRevision("HEAD")
+}
+
+// ResolveRevision resolves a revision such as HEAD~1, a branch or an
+// abbreviated hash to a full commit hash
+func ResolveRevision(rev string) (string, error) {
+	return run("rev-parse", "--verify", "--quiet", "--end-of-options", rev+"^{commit}")
+}
+
+// IsDirty reports whether the working tree has uncommitted changes
+func IsDirty() (bool, error) {
+	out, err := run("status", "--porcelain", "--untracked-files=no")
+	if err != nil {
+		return false, err
+	}
+	return out != "", nil
+}
+
+// Short abbreviates a commit hash for display
+func Short(commit string) string {
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return commit
+}
+
+// ChangedFiles lists the files that differ from the merge base of base and
+// HEAD, including uncommitted and untracked files. Paths are relative to the
+// repository root.
+func ChangedFiles(base string) ([]string, error) {
+	baseCommit, err := ResolveRevision(base)
+	if err != nil {
+		return nil, fmt.Errorf("unknown base revision %q: %w", base, err)
+	}
+	mergeBase, err := run("merge-base", baseCommit, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+
+	diff, err := run("diff", "--name-only", mergeBase)
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := run("ls-files", "--others", "--exclude-standard", "--full-name", ":/")
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, line := range strings.Split(diff+"\n"+untracked, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			files = append(files, line)
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,150 @@
+package lock
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"syscall"
+	"time"
+)
+
+// Info is what a lockfile records about its holder
+type Info struct {
+	PID      int       `json:"pid"`
+	Host     string    `json:"host"`
+	Acquired time.Time `json:"acquired"`
+}
+
+// HeldError is returned by Acquire when another live process holds the lock
+type HeldError struct {
+	Path string
+	Info Info
+}
+
+func (e *HeldError) Error() string {
+	return fmt.Sprintf("%s is held by PID %d on %s since %s",
+		e.Path, e.Info.PID, e.Info.Host, e.Info.Acquired.Local().Format(time.RFC3339))
+}
+
+// writeGrace is how long a lockfile may stay empty or unparsable before it is
+// taken over: the process that created it may not have written it yet
+const writeGrace = 2 * time.Second
+
+// readRetryInterval is how often a lockfile still being written is re-read
+const readRetryInterval = 20 * time.Millisecond
+
+// Lock is an advisory lock held through a lockfile
+type Lock struct {
+	path string
+}
+
+// Acquire creates the lockfile at path. An existing lockfile is taken over if
+// it is older than staleAfter, left by a process on this host that has exited,
+// or still unreadable writeGrace after it was last written; otherwise a
+// *HeldError is returned.
+func Acquire(path string, staleAfter time.Duration) (*Lock, error) {
+	host, _ := os.Hostname()
+	data, err := json.Marshal(Info{PID: os.Getpid(), Host: host, Acquired: time.Now()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal lock info: %w", err)
+	}
+
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			_, err = f.Write(data)
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				os.Remove(path)
+				return nil, fmt.Errorf("failed to write lockfile: %w", err)
+			}
+			return &Lock{path: path}, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("failed to create lockfile: %w", err)
+		}
+
+		holder, err := waitForInfo(path)
+		if errors.Is(err, os.ErrNotExist) {
+			// Released meanwhile
+			continue
+		}
+		if err == nil && !isStale(holder, host, staleAfter) {
+			return nil, &HeldError{Path: path, Info: holder}
+		}
+		// Stale or unreadable: remove it and try once more
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to remove stale lockfile: %w", err)
+		}
+	}
+	return nil, fmt.Errorf("failed to acquire %s: another process keeps taking it", path)
+}
+
+// Release removes the lockfile
+func (l *Lock) Release() error {
+	if err := os.Remove(l.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove lockfile: %w", err)
+	}
+	return nil
+}
+
+// readInfo parses an existing lockfile
+func readInfo(path string) (Info, error) {
+	var info Info
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return info, err
+	}
+	if err := json.Unmarshal(data, &info); err != nil {
+		return info, err
+	}
+	return info, nil
+}
+
+// waitForInfo parses an existing lockfile, re-reading it while it is
+// unparsable but younger than writeGrace
+func waitForInfo(path string) (Info, error) {
+	for {
+		info, err := readInfo(path)
+		if err == nil || errors.Is(err, os.ErrNotExist) {
+			return info, err
+		}
+		stat, statErr := os.Stat(path)
+		if statErr != nil {
+			return info, statErr
+		}
+		if time.Since(stat.ModTime()) >= writeGrace {
+			return info, err
+		}
+		time.Sleep(readRetryInterval)
+	}
+}
+
+// isStale reports whether a lock can be taken over
+func isStale(info Info, host string, staleAfter time.Duration) bool {
+	if staleAfter > 0 && time.Since(info.Acquired) > staleAfter {
+		return true
+	}
+	return info.Host == host && !processAlive(info.PID)
+}
+
+// processAlive reports whether pid is a running process on this host.
+// Windows offers no cheap check, so processes there are assumed alive.
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		return true
+	}
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	err = p.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
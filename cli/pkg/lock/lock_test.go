@@ -0,0 +1,116 @@
+package lock
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAcquireContention(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scan.lock")
+
+	const contenders = 16
+	var (
+		wg      sync.WaitGroup
+		start   = make(chan struct{})
+		mu      sync.Mutex
+		held    []*Lock
+		refused int
+	)
+	for i := 0; i < contenders; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			l, err := Acquire(path, 0)
+			mu.Lock()
+			defer mu.Unlock()
+			var heldErr *HeldError
+			switch {
+			case err == nil:
+				held = append(held, l)
+			case errors.As(err, &heldErr):
+				refused++
+			default:
+				t.Errorf("Acquire: %v", err)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	if len(held) != 1 || refused != contenders-1 {
+		t.Fatalf("%d contenders got the lock and %d were refused, want 1 and %d", len(held), refused, contenders-1)
+	}
+	if err := held[0].Release(); err != nil {
+		t.Fatal(err)
+	}
+	l, err := Acquire(path, 0)
+	if err != nil {
+		t.Fatalf("Acquire after Release: %v", err)
+	}
+	l.Release()
+}
+
+func TestAcquireWaitsForLockfileBeingWritten(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scan.lock")
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	// The holder writes its info shortly after creating the lockfile
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		host, _ := os.Hostname()
+		data, _ := json.Marshal(Info{PID: os.Getpid(), Host: host, Acquired: time.Now()})
+		os.WriteFile(path, data, 0644)
+	}()
+
+	_, err := Acquire(path, 0)
+	var heldErr *HeldError
+	if !errors.As(err, &heldErr) {
+		t.Fatalf("Acquire = %v, want a HeldError once the holder's info is written", err)
+	}
+	if heldErr.Info.PID != os.Getpid() {
+		t.Errorf("held by PID %d, want %d", heldErr.Info.PID, os.Getpid())
+	}
+}
+
+func TestAcquireTakesOverUnparsableLockfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scan.lock")
+	if err := os.WriteFile(path, []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-time.Minute)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := Acquire(path, 0)
+	if err != nil {
+		t.Fatalf("Acquire over an unparsable lockfile older than writeGrace: %v", err)
+	}
+	defer l.Release()
+	if _, err := readInfo(path); err != nil {
+		t.Errorf("lockfile not rewritten: %v", err)
+	}
+}
+
+func TestAcquireTakesOverLockOfExitedProcess(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scan.lock")
+	host, _ := os.Hostname()
+	// PID 0 is never a live holder
+	data, _ := json.Marshal(Info{PID: 0, Host: host, Acquired: time.Now()})
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := Acquire(path, 0)
+	if err != nil {
+		t.Fatalf("Acquire over the lock of an exited process: %v", err)
+	}
+	l.Release()
+}
@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	metrics := []Metric{
+		{
+			Name: "viewport_scan_issues",
+			Help: "Number of issues.\nBy severity.",
+			Type: "gauge",
+			Samples: []Sample{
+				{Labels: map[string]string{"target": "https://example.com/?q=\"a\\b\"", "severity": "high"}, Value: 2},
+				{Labels: map[string]string{"target": "https://example.com", "severity": "low"}, Value: 0},
+			},
+		},
+		Gauge("viewport_scan_duration_seconds", `Duration in seconds, e.g. 1.5 or 1e-3 \ s.`, 12.25),
+		{Name: "untyped", Samples: []Sample{{Value: 1e21}}},
+	}
+
+	var out strings.Builder
+	if err := Format(&out, metrics); err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP viewport_scan_issues Number of issues.\nBy severity.
+# TYPE viewport_scan_issues gauge
+viewport_scan_issues{severity="high",target="https://example.com/?q=\"a\\b\""} 2
+viewport_scan_issues{severity="low",target="https://example.com"} 0
+# HELP viewport_scan_duration_seconds Duration in seconds, e.g. 1.5 or 1e-3 \\ s.
+# TYPE viewport_scan_duration_seconds gauge
+viewport_scan_duration_seconds 12.25
+untyped 1e+21
+`
+	if out.String() != want {
+		t.Errorf("Format wrote:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
+
+func TestWriteFileReplacesFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "viewport.prom")
+	if err := os.WriteFile(path, []byte("stale 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := WriteFile(path, []Metric{Gauge("viewport_scan_success", "", 1)}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "# TYPE viewport_scan_success gauge\nviewport_scan_success 1\n" {
+		t.Errorf("metrics file = %q", data)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0644 {
+		t.Errorf("metrics file mode = %v, want 0644 for collectors to read", info.Mode().Perm())
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("left %d files in the directory, want only the metrics file", len(entries))
+	}
+}
@@ -0,0 +1,98 @@
+package prompt
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Prompter asks questions on a writer and reads the answers from a reader
+type Prompter struct {
+	r *bufio.Reader
+	w io.Writer
+}
+
+// NewPrompter creates a new prompter reading from r and writing prompts to w
+func NewPrompter(r io.Reader, w io.Writer) *Prompter {
+	return &Prompter{
+		r: bufio.NewReader(r),
+		w: w,
+	}
+}
+
+// String asks a question and returns the string. Returns default if empty.
+func (p *Prompter) String(label string, def string) string {
+	fmt.Fprintf(p.w, "%s [%s]: ", label, lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render(def))
+
+	input := p.readLine()
+	if input == "" {
+		return def
+	}
+	return input
+}
+
+// Bool asks a yes/no question.
+func (p *Prompter) Bool(label string, def bool) bool {
+	// Visual indicator of capital letter shows default (e.g., [Y/n] or [y/N])
+	options := "[Y/n]"
+	if !def {
+		options = "[y/N]"
+	}
+
+	fmt.Fprintf(p.w, "%s %s: ", label, lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render(options))
+
+	input := strings.ToLower(p.readLine())
+	if input == "" {
+		return def
+	}
+
+	return input == "y" || input == "yes"
+}
+
+// Int asks for an integer, asking again until a valid number is entered.
+func (p *Prompter) Int(label string, def int) int {
+	for {
+		fmt.Fprintf(p.w, "%s [%s]: ", label, lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render(strconv.Itoa(def)))
+
+		input, err := p.r.ReadString('\n')
+		input = strings.TrimSpace(input)
+
+		if input == "" {
+			return def
+		}
+
+		val, convErr := strconv.Atoi(input)
+		if convErr == nil {
+			return val
+		}
+		fmt.Fprintln(p.w, lipgloss.NewStyle().Foreground(lipgloss.Color("1")).Render("Please enter a valid number."))
+
+		// Input is exhausted, so asking again would loop forever
+		if err != nil {
+			return def
+		}
+	}
+}
+
+// readLine reads a single trimmed line of input
+func (p *Prompter) readLine() string {
+	input, _ := p.r.ReadString('\n')
+	return strings.TrimSpace(input)
+}
+
+// ParseCSV splits a comma separated list, dropping empty entries
+func ParseCSV(input string) []string {
+	parts := strings.Split(input, ",")
+	var result []string
+	for _, p := range parts {
+		trimmed := strings.TrimSpace(p)
+		if trimmed != "" {
+			result = append(result, trimmed)
+		}
+	}
+	return result
+}
@@ -0,0 +1,107 @@
+package prompt
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// scripted returns a prompter reading the given input lines, and the
+// buffer its prompts are written to
+func scripted(lines ...string) (*Prompter, *strings.Builder) {
+	out := &strings.Builder{}
+	return NewPrompter(strings.NewReader(strings.Join(lines, "\n")), out), out
+}
+
+func TestStringDefault(t *testing.T) {
+	p, out := scripted("", "  https://example.com  ")
+	if got := p.String("API URL", "http://localhost:3001"); got != "http://localhost:3001" {
+		t.Errorf("empty answer = %q, want the default", got)
+	}
+	if got := p.String("API URL", "http://localhost:3001"); got != "https://example.com" {
+		t.Errorf("answer = %q, want it trimmed", got)
+	}
+	if !strings.Contains(out.String(), "API URL [") || !strings.Contains(out.String(), "http://localhost:3001") {
+		t.Errorf("prompt = %q, want the label and default", out.String())
+	}
+}
+
+func TestIntReprompts(t *testing.T) {
+	p, out := scripted("abc", "2.5", "45")
+	if got := p.Int("Timeout", 30); got != 45 {
+		t.Errorf("Int = %d, want 45", got)
+	}
+	if n := strings.Count(out.String(), "Please enter a valid number."); n != 2 {
+		t.Errorf("reprompted %d times, want 2:\n%s", n, out.String())
+	}
+	if n := strings.Count(out.String(), "Timeout ["); n != 3 {
+		t.Errorf("asked %d times, want 3", n)
+	}
+}
+
+func TestIntDefault(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"empty answer", "\n"},
+		{"empty input", ""},
+		// Input ending on an invalid answer must not loop forever
+		{"invalid last answer", "abc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := scripted(tt.input)
+			if got := p.Int("Timeout", 30); got != 30 {
+				t.Errorf("Int = %d, want the default 30", got)
+			}
+		})
+	}
+}
+
+func TestBool(t *testing.T) {
+	tests := []struct {
+		input string
+		def   bool
+		want  bool
+	}{
+		{"", true, true},
+		{"", false, false},
+		{"y", false, true},
+		{"Yes", false, true},
+		{" YES ", false, true},
+		{"n", true, false},
+		{"no", true, false},
+		{"maybe", true, false},
+	}
+	for _, tt := range tests {
+		p, _ := scripted(tt.input)
+		if got := p.Bool("Save results?", tt.def); got != tt.want {
+			t.Errorf("Bool(%q, default %v) = %v, want %v", tt.input, tt.def, got, tt.want)
+		}
+	}
+}
+
+func TestBoolShowsDefault(t *testing.T) {
+	p, out := scripted("")
+	p.Bool("Save results?", true)
+	if !strings.Contains(out.String(), "[Y/n]") {
+		t.Errorf("prompt = %q, want [Y/n]", out.String())
+	}
+	p, out = scripted("")
+	p.Bool("Save results?", false)
+	if !strings.Contains(out.String(), "[y/N]") {
+		t.Errorf("prompt = %q, want [y/N]", out.String())
+	}
+}
+
+func TestParseCSV(t *testing.T) {
+	got := ParseCSV(" mobile, ,tablet,desktop ,")
+	want := []string{"mobile", "tablet", "desktop"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseCSV = %q, want %q", got, want)
+	}
+	if got := ParseCSV(""); got != nil {
+		t.Errorf("ParseCSV(\"\") = %q, want nil", got)
+	}
+}
@@ -0,0 +1,264 @@
+package results
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// FSStore keeps each scan in its own directory under a local results directory
+type FSStore struct {
+	dir string
+}
+
+// NewFSStore creates a store for the results directory dir
+func NewFSStore(dir string) *FSStore {
+	return &FSStore{dir: dir}
+}
+
+// Location returns the results directory
+func (s *FSStore) Location() string {
+	return s.dir
+}
+
+// SaveScan writes the scan's metadata and files to <dir>/<scan-id>/, or
+// <dir>/<scan.Dir>/<scan-id>/. Files are written to a hidden temporary directory that is renamed into place only
+// once everything is written, so an interrupted save never leaves a
+// half-written scan behind.
+func (s *FSStore) SaveScan(scan *ScanFiles) (err error) {
+	if err := checkScanID(scan.ScanID); err != nil {
+		return err
+	}
+	if scan.Dir != "" {
+		if err := checkTargetDir(scan.Dir); err != nil {
+			return err
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(s.dir, scan.Dir), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	tmpDir, err := os.MkdirTemp(s.dir, ".tmp-"+scan.ScanID+"-")
+	if err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			os.RemoveAll(tmpDir)
+		}
+	}()
+	if err := os.Chmod(tmpDir, 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	for name, data := range scan.Files {
+		if err := writeFileSync(filepath.Join(tmpDir, name), data); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+	if err := writeFileSync(filepath.Join(tmpDir, MetadataFile), scan.Metadata); err != nil {
+		return fmt.Errorf("failed to write metadata: %w", err)
+	}
+
+	// Move any previous save of this scan aside rather than deleting it first,
+	// so it survives if the rename fails
+	existingDir := s.scanDir(scan.ScanID)
+	scanDir := filepath.Join(s.dir, scan.Dir, scan.ScanID)
+	var oldDir string
+	if _, err := os.Stat(existingDir); err == nil {
+		if scan.Dir == "" {
+			scanDir = existingDir
+		}
+		oldDir = tmpDir + ".old"
+		if err := os.Rename(existingDir, oldDir); err != nil {
+			return fmt.Errorf("failed to replace existing scan: %w", err)
+		}
+	}
+	if err := os.Rename(tmpDir, scanDir); err != nil {
+		if oldDir != "" {
+			os.Rename(oldDir, existingDir)
+		}
+		return fmt.Errorf("failed to move scan into place: %w", err)
+	}
+	if oldDir != "" {
+		os.RemoveAll(oldDir)
+	}
+
+	// Keep the index current for the next listing; it is rebuilt as needed anyway
+	s.refreshIndex()
+
+	rel, err := filepath.Rel(s.dir, scanDir)
+	if err != nil {
+		rel = scan.ScanID
+	}
+	if err := s.writeLatest(scan, rel); err != nil {
+		return fmt.Errorf("scan saved, but %w", err)
+	}
+	return nil
+}
+
+// writeFileAtomic replaces path with data via a temporary file, so readers
+// see either the old or the new contents
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := writeFileSync(tmp, data); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// writeFileSync writes data to path and flushes it to disk
+func writeFileSync(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ListScans returns all scans found in the results directory. Summaries come
+// from the index, which is refreshed for any scans that changed.
+func (s *FSStore) ListScans() ([]ScanSummary, error) {
+	// Check if directory exists
+	if _, err := os.Stat(s.dir); os.IsNotExist(err) {
+		return []ScanSummary{}, nil // Return empty list if directory doesn't exist
+	}
+
+	index, err := s.refreshIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	var scans []ScanSummary
+	for _, e := range index.Entries {
+		// Skip directories without valid metadata
+		if e.Scan != nil {
+			scans = append(scans, *e.Scan)
+		}
+	}
+
+	sortScans(scans)
+	return scans, nil
+}
+
+// SkippedScans returns the scan directories whose metadata ListScans couldn't use
+func (s *FSStore) SkippedScans() ([]SkippedScan, error) {
+	if _, err := os.Stat(s.dir); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	index, err := s.refreshIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	var skipped []SkippedScan
+	for id, e := range index.Entries {
+		if e.Scan == nil {
+			skipped = append(skipped, SkippedScan{ScanID: id, Reason: e.Error})
+		}
+	}
+	sort.Slice(skipped, func(i, j int) bool { return skipped[i].ScanID < skipped[j].ScanID })
+	return skipped, nil
+}
+
+// GetScan retrieves a specific scan by ID
+func (s *FSStore) GetScan(scanID string) (*ScanMetadata, error) {
+	return readScanMetadata(filepath.Join(s.scanDir(scanID), MetadataFile))
+}
+
+// readScanMetadata reads and parses the metadata file at path
+func readScanMetadata(path string) (*ScanMetadata, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metadata: %w", err)
+	}
+	metadata, err := parseMetadata(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return metadata, nil
+}
+
+// ReadFile returns one file of a saved scan
+func (s *FSStore) ReadFile(scanID, name string) ([]byte, error) {
+	if err := checkScanID(scanID); err != nil {
+		return nil, err
+	}
+	if err := checkFileName(name); err != nil {
+		return nil, err
+	}
+	return os.ReadFile(filepath.Join(s.scanDir(scanID), name))
+}
+
+// SetLabel attaches a label to a scan, rejecting labels already used by another scan
+func (s *FSStore) SetLabel(scanID, label string) error {
+	if err := checkLabel(s, scanID, label); err != nil {
+		return err
+	}
+
+	metadataPath := filepath.Join(s.scanDir(scanID), MetadataFile)
+	data, err := os.ReadFile(metadataPath)
+	if err != nil {
+		return fmt.Errorf("failed to read metadata: %w", err)
+	}
+
+	updated, err := relabel(data, label)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(metadataPath, updated); err != nil {
+		return fmt.Errorf("failed to write metadata: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateTags adds and removes normalized tags on a scan, rewriting its metadata atomically
+func (s *FSStore) UpdateTags(scanID string, add, remove []string) ([]string, error) {
+	if err := checkScanID(scanID); err != nil {
+		return nil, err
+	}
+
+	metadataPath := filepath.Join(s.scanDir(scanID), MetadataFile)
+	data, err := os.ReadFile(metadataPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metadata: %w", err)
+	}
+
+	updated, tags, err := retag(data, add, remove)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeFileAtomic(metadataPath, updated); err != nil {
+		return nil, fmt.Errorf("failed to write metadata: %w", err)
+	}
+	return tags, nil
+}
+
+// DeleteScan removes a scan directory, and its per-target directory if that
+// is left empty
+func (s *FSStore) DeleteScan(scanID string) error {
+	if err := checkScanID(scanID); err != nil {
+		return err
+	}
+	scanDir := s.scanDir(scanID)
+	if err := os.RemoveAll(scanDir); err != nil {
+		return err
+	}
+	if parent := filepath.Dir(scanDir); parent != filepath.Clean(s.dir) {
+		os.Remove(parent)
+	}
+	s.clearLatest(scanID)
+	return nil
+}
@@ -0,0 +1,135 @@
+package results
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// IndexFile caches scan summaries in the results directory so listing scans
+// doesn't have to read every metadata file
+const IndexFile = ".index.json"
+
+// indexVersion is bumped whenever the cached summary format changes
+const indexVersion = 3
+
+// Indexer is implemented by stores that keep an index of their scans
+type Indexer interface {
+	// Reindex rebuilds the index from scratch and returns how many scans it holds
+	Reindex() (int, error)
+}
+
+// scanIndex is the format of IndexFile
+type scanIndex struct {
+	Version int                   `json:"version"`
+	Entries map[string]indexEntry `json:"entries"`
+}
+
+// indexEntry caches one scan directory's summary along with the metadata file
+// state it was read from, so a changed file is noticed and re-read
+type indexEntry struct {
+	ModTime time.Time    `json:"modTime"`
+	Size    int64        `json:"size"`
+	Scan    *ScanSummary `json:"scan,omitempty"` // nil if the metadata is invalid
+	// Error is why the metadata is invalid, kept so listings can report skipped scans
+	Error string `json:"error,omitempty"`
+	// Dir is the per-target directory the scan is in, if it isn't at the top
+	Dir string `json:"dir,omitempty"`
+}
+
+// readIndex loads the index, returning an empty one if it is missing, unreadable or outdated
+func (s *FSStore) readIndex() scanIndex {
+	index := scanIndex{Version: indexVersion, Entries: map[string]indexEntry{}}
+
+	data, err := os.ReadFile(filepath.Join(s.dir, IndexFile))
+	if err != nil {
+		return index
+	}
+	var cached scanIndex
+	if err := json.Unmarshal(data, &cached); err != nil || cached.Version != indexVersion || cached.Entries == nil {
+		return index
+	}
+	return cached
+}
+
+// writeIndex saves the index, replacing the old one atomically
+func (s *FSStore) writeIndex(index scanIndex) error {
+	data, err := json.Marshal(index)
+	if err != nil {
+		return fmt.Errorf("failed to marshal index: %w", err)
+	}
+	return writeFileAtomic(filepath.Join(s.dir, IndexFile), data)
+}
+
+// refreshIndex brings the index up to date with the results directory,
+// including per-target directories, re-reading only metadata files that were
+// added or changed since it was written
+func (s *FSStore) refreshIndex() (scanIndex, error) {
+	locations, err := s.findScanDirs()
+	if err != nil {
+		return scanIndex{}, err
+	}
+
+	cached := s.readIndex()
+	index := scanIndex{Version: indexVersion, Entries: make(map[string]indexEntry, len(locations))}
+	changed := false
+
+	for _, loc := range locations {
+		// A scan ID found twice is listed where it was found first
+		if _, dup := index.Entries[loc.ID]; dup {
+			continue
+		}
+
+		// Directories without metadata (e.g. comparison reports) aren't scans
+		path := filepath.Join(s.dir, loc.path(), MetadataFile)
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+
+		if old, ok := cached.Entries[loc.ID]; ok && old.Dir == loc.Dir && old.ModTime.Equal(info.ModTime()) && old.Size == info.Size() {
+			index.Entries[loc.ID] = old
+			continue
+		}
+
+		changed = true
+		e := indexEntry{ModTime: info.ModTime(), Size: info.Size(), Dir: loc.Dir}
+		if metadata, err := readScanMetadata(path); err == nil {
+			summary := summarize(metadata)
+			e.Scan = &summary
+		} else {
+			e.Error = err.Error()
+		}
+		index.Entries[loc.ID] = e
+	}
+
+	if changed || len(index.Entries) != len(cached.Entries) {
+		// The index is only a cache; a read-only results directory still lists fine
+		s.writeIndex(index)
+	}
+	return index, nil
+}
+
+// Reindex discards the index and rebuilds it from every scan's metadata
+func (s *FSStore) Reindex() (int, error) {
+	if _, err := os.Stat(s.dir); os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err := os.Remove(filepath.Join(s.dir, IndexFile)); err != nil && !os.IsNotExist(err) {
+		return 0, fmt.Errorf("failed to remove index: %w", err)
+	}
+
+	index, err := s.refreshIndex()
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, e := range index.Entries {
+		if e.Scan != nil {
+			count++
+		}
+	}
+	return count, nil
+}
@@ -0,0 +1,92 @@
+package results
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// LatestFile points at the most recently saved scan, from the top of the store
+const LatestFile = "latest.json"
+
+// LatestLink is a symlink to the most recently saved scan's directory, on
+// filesystems that support symlinks
+const LatestLink = "latest"
+
+// Latest is the content of LatestFile: a summary of the most recently saved scan
+type Latest struct {
+	ScanSummary
+	RequestedURL string `json:"requestedUrl,omitempty"`
+	// Path is where the scan's files are: a directory relative to the results
+	// directory, or the object key prefix in S3
+	Path    string    `json:"path"`
+	SavedAt time.Time `json:"savedAt"`
+}
+
+// newLatest builds the LatestFile document for a scan saved under path
+func newLatest(metadata []byte, path string) ([]byte, error) {
+	scan, err := parseMetadata(metadata)
+	if err != nil {
+		return nil, err
+	}
+	latest := Latest{
+		ScanSummary:  summarize(scan),
+		RequestedURL: scan.RequestedURL,
+		Path:         path,
+		SavedAt:      time.Now().UTC(),
+	}
+	return json.MarshalIndent(latest, "", "  ")
+}
+
+// parseLatest decodes a LatestFile document
+func parseLatest(data []byte) (*Latest, error) {
+	var latest Latest
+	if err := json.Unmarshal(data, &latest); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", LatestFile, err)
+	}
+	return &latest, nil
+}
+
+// writeLatest points latest.json, and the latest symlink where possible, at a
+// scan just saved to path, relative to the results directory. Both are
+// replaced atomically, so readers never see a half-written pointer.
+func (s *FSStore) writeLatest(scan *ScanFiles, path string) error {
+	data, err := newLatest(scan.Metadata, path)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(filepath.Join(s.dir, LatestFile), data); err != nil {
+		return fmt.Errorf("failed to write %s: %w", LatestFile, err)
+	}
+
+	// The JSON pointer is enough where symlinks aren't available (e.g. Windows
+	// without developer mode), so link failures are ignored
+	tmp := filepath.Join(s.dir, ".latest.tmp")
+	os.Remove(tmp)
+	if err := os.Symlink(path, tmp); err == nil {
+		if err := os.Rename(tmp, filepath.Join(s.dir, LatestLink)); err != nil {
+			os.Remove(tmp)
+		}
+	}
+	return nil
+}
+
+// Latest reads the latest.json pointer; the error matches fs.ErrNotExist if
+// no scan has been saved since pointers were introduced
+func (s *FSStore) Latest() (*Latest, error) {
+	data, err := os.ReadFile(filepath.Join(s.dir, LatestFile))
+	if err != nil {
+		return nil, err
+	}
+	return parseLatest(data)
+}
+
+// clearLatest removes the latest pointers if they point at scanID
+func (s *FSStore) clearLatest(scanID string) {
+	if latest, err := s.Latest(); err == nil && latest.ScanID == scanID {
+		os.Remove(filepath.Join(s.dir, LatestFile))
+		os.Remove(filepath.Join(s.dir, LatestLink))
+	}
+}
@@ -0,0 +1,91 @@
+package results
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+// snippetWidth is how many bytes of a document are quoted on each side of
+// the point where it failed to parse
+const snippetWidth = 30
+
+// parseError is a metadata document that couldn't be parsed, located as
+// precisely as the JSON decoder allows
+type parseError struct {
+	// Line and Column are 1-based, or 0 when the decoder reported no position
+	Line, Column int
+	// Snippet quotes the document around the failure
+	Snippet string
+	// Binary is set when the document isn't UTF-8 text, e.g. a truncated
+	// write or a file overwritten by a screenshot
+	Binary bool
+	Err    error
+}
+
+func (e *parseError) Error() string {
+	switch {
+	case e.Binary:
+		return fmt.Sprintf("not UTF-8 text at line %d, column %d, the file looks binary or corrupted: %s", e.Line, e.Column, e.Snippet)
+	case e.Line > 0:
+		return fmt.Sprintf("%v at line %d, column %d: %s", e.Err, e.Line, e.Column, e.Snippet)
+	default:
+		return e.Err.Error()
+	}
+}
+
+func (e *parseError) Unwrap() error {
+	return e.Err
+}
+
+// describeParseError locates err, returned by json.Unmarshal for data, in
+// data. An empty document, invalid UTF-8 and the decoder's byte offsets are
+// all turned into something a user can find in the file.
+func describeParseError(data []byte, err error) error {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return &parseError{Err: fmt.Errorf("the file is empty")}
+	}
+
+	if !utf8.Valid(data) {
+		offset := 0
+		for offset < len(data) {
+			r, size := utf8.DecodeRune(data[offset:])
+			if r == utf8.RuneError && size <= 1 {
+				break
+			}
+			offset += size
+		}
+		return newParseError(data, offset, true, err)
+	}
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		return newParseError(data, int(syntaxErr.Offset), false, err)
+	case errors.As(err, &typeErr):
+		return newParseError(data, int(typeErr.Offset), false, err)
+	}
+	return &parseError{Err: err}
+}
+
+// newParseError builds the parseError for a failure at byte offset of data
+func newParseError(data []byte, offset int, binary bool, err error) *parseError {
+	offset = min(max(offset, 0), len(data))
+	line := bytes.Count(data[:offset], []byte("\n")) + 1
+	lineStart := bytes.LastIndexByte(data[:offset], '\n') + 1
+
+	start := max(lineStart, offset-snippetWidth)
+	end := min(len(data), offset+snippetWidth)
+	if i := bytes.IndexByte(data[offset:end], '\n'); i >= 0 {
+		end = offset + i
+	}
+	snippet := fmt.Sprintf("%q", data[start:end])
+	if end == len(data) && !binary {
+		snippet += " (end of file)"
+	}
+
+	return &parseError{Line: line, Column: offset - lineStart + 1, Snippet: snippet, Binary: binary, Err: err}
+}
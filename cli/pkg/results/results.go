@@ -2,9 +2,9 @@ package results
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
+	"regexp"
 	"sort"
 	"strings"
 	"time"
@@ -13,9 +13,22 @@ import (
 // ScanMetadata represents the metadata stored in metadata.json
 type ScanMetadata struct {
 	ScanID    string    `json:"scanId"`
+	Label     string    `json:"label,omitempty"`
+	Tags      []string  `json:"tags,omitempty"`
 	Timestamp string    `json:"timestamp"`
 	Status    string    `json:"status"`
 	Results   []Result  `json:"results"`
+	RequestedURL string `json:"requestedUrl,omitempty"`
+	FinalURL     string `json:"finalUrl,omitempty"`
+	GitCommit    string `json:"gitCommit,omitempty"`
+	GitDirty     bool   `json:"gitDirty,omitempty"`
+	NetworkProfile string `json:"networkProfile,omitempty"`
+	CPUThrottle    int    `json:"cpuThrottle,omitempty"`
+	AnalysisSkipped bool  `json:"analysisSkipped,omitempty"`
+	DimensionsOnly bool `json:"dimensionsOnly,omitempty"`
+	HostHeader string `json:"hostHeader,omitempty"`
+	CaptureDelayMs int `json:"captureDelayMs,omitempty"`
+	DurationMs int64 `json:"durationMs,omitempty"`
 }
 
 // Result represents a single viewport result
@@ -25,105 +38,197 @@ type Result struct {
 		Width  int `json:"width"`
 		Height int `json:"height"`
 	} `json:"dimensions"`
-	Issues []interface{} `json:"issues"`
+	Issues []Issue `json:"issues"`
+	ScreenshotFile string `json:"screenshotFile,omitempty"`
+	ScrollScreenshots []ScrollScreenshot `json:"scrollScreenshots,omitempty"`
+	ZoomCaptures []ZoomCapture `json:"zoomCaptures,omitempty"`
+	AnnotatedFile string `json:"annotatedFile,omitempty"`
+	HTTPStatus int `json:"httpStatus,omitempty"`
+	Styles []ElementStyle `json:"styles,omitempty"`
+	A11yTree *A11yNode `json:"a11yTree,omitempty"`
+}
+
+// ElementStyle is the computed style captured for a CSS selector
+type ElementStyle struct {
+	Selector   string            `json:"selector"`
+	Matched    bool              `json:"matched"`
+	Properties map[string]string `json:"properties,omitempty"`
+	Error      string            `json:"error,omitempty"`
+}
+
+// A11yNode is a node of a viewport's accessibility tree
+type A11yNode struct {
+	Role     string     `json:"role"`
+	Name     string     `json:"name,omitempty"`
+	Level    int        `json:"level,omitempty"`
+	Children []A11yNode `json:"children,omitempty"`
+}
+
+// ScrollScreenshot is a capture of a viewport scrolled to Offset
+type ScrollScreenshot struct {
+	Offset         int    `json:"offset"`
+	ScreenshotFile string `json:"screenshotFile"`
+}
+
+// ZoomCapture is a saved capture of a viewport at a page zoom factor
+type ZoomCapture struct {
+	Zoom           float64 `json:"zoom"`
+	ScreenshotFile string  `json:"screenshotFile"`
+	Issues         []Issue `json:"issues"`
+}
+
+// Issue represents a single detected issue
+type Issue struct {
+	Severity    string `json:"severity"`
+	Type        string `json:"type"`
+	Description string `json:"description"`
+	Suggestion  string `json:"suggestion"`
 }
 
 // ScanSummary represents a summary of a scan
 type ScanSummary struct {
-	ScanID      string
-	Timestamp   time.Time
-	Viewports   []string
-	IssueCount  int
-	Status      string
+	ScanID      string    `json:"scanId"`
+	Label       string    `json:"label,omitempty"`
+	Tags        []string  `json:"tags,omitempty"`
+	Timestamp   time.Time `json:"timestamp"`
+	Viewports   []string  `json:"viewports"`
+	IssueCount  int       `json:"issueCount"`
+	Status      string    `json:"status"`
+	GitCommit   string    `json:"gitCommit,omitempty"`
 }
 
-// ListScans returns all scans found in the results directory
-func ListScans(resultsDir string) ([]ScanSummary, error) {
-	// Check if directory exists
-	if _, err := os.Stat(resultsDir); os.IsNotExist(err) {
-		return []ScanSummary{}, nil // Return empty list if directory doesn't exist
+// summarize builds the list entry for a scan
+func summarize(metadata *ScanMetadata) ScanSummary {
+	// Parse timestamp
+	timestamp, err := time.Parse(time.RFC3339, metadata.Timestamp)
+	if err != nil {
+		// Use current time if parsing fails
+		timestamp = time.Now()
 	}
 
-	entries, err := os.ReadDir(resultsDir)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read results directory: %w", err)
+	// Extract viewports and count issues
+	viewports := make([]string, 0)
+	issueCount := 0
+
+	for _, result := range metadata.Results {
+		viewports = append(viewports, strings.ToLower(result.Device))
+		issueCount += len(result.Issues)
 	}
 
-	var scans []ScanSummary
+	return ScanSummary{
+		ScanID:     metadata.ScanID,
+		Label:      metadata.Label,
+		Tags:       metadata.Tags,
+		Timestamp:  timestamp,
+		Viewports:  viewports,
+		IssueCount: issueCount,
+		Status:     metadata.Status,
+		GitCommit:  metadata.GitCommit,
+	}
+}
 
-	for _, entry := range entries {
-		if !entry.IsDir() {
-			continue
-		}
+// sortScans sorts scans by timestamp, newest first
+func sortScans(scans []ScanSummary) {
+	sort.Slice(scans, func(i, j int) bool {
+		return scans[i].Timestamp.After(scans[j].Timestamp)
+	})
+}
 
-		// Try to read metadata.json from this directory
-		metadataPath := filepath.Join(resultsDir, entry.Name(), "metadata.json")
-		metadata, err := readMetadata(metadataPath)
-		if err != nil {
-			// Skip directories without valid metadata
-			continue
-		}
+// ResolveScan retrieves a scan by ID, falling back to looking it up by label
+func ResolveScan(store Store, ref string) (*ScanMetadata, error) {
+	metadata, getErr := store.GetScan(ref)
+	if getErr == nil {
+		return metadata, nil
+	}
 
-		// Parse timestamp
-		timestamp, err := time.Parse(time.RFC3339, metadata.Timestamp)
-		if err != nil {
-			// Use current time if parsing fails
-			timestamp = time.Now()
+	scans, err := store.ListScans()
+	if err != nil {
+		return nil, err
+	}
+	for _, scan := range scans {
+		if scan.Label != "" && scan.Label == ref {
+			return store.GetScan(scan.ScanID)
 		}
+	}
 
-		// Extract viewports and count issues
-		viewports := make([]string, 0)
-		issueCount := 0
+	// A scan with this ID exists but its metadata is broken; say why
+	var parseErr *parseError
+	if errors.As(getErr, &parseErr) {
+		return nil, getErr
+	}
+	return nil, fmt.Errorf("no scan found with ID or label %q", ref)
+}
 
-		for _, result := range metadata.Results {
-			viewports = append(viewports, strings.ToLower(result.Device))
-			issueCount += len(result.Issues)
+// FindByCommit returns the newest scan taken at the given full commit hash
+func FindByCommit(store Store, commit string) (*ScanMetadata, error) {
+	scans, err := store.ListScans()
+	if err != nil {
+		return nil, err
+	}
+	// Scans are sorted newest first
+	for _, scan := range scans {
+		if scan.GitCommit != "" && scan.GitCommit == commit {
+			return store.GetScan(scan.ScanID)
 		}
-
-		scans = append(scans, ScanSummary{
-			ScanID:     metadata.ScanID,
-			Timestamp:  timestamp,
-			Viewports:  viewports,
-			IssueCount: issueCount,
-			Status:     metadata.Status,
-		})
 	}
+	return nil, fmt.Errorf("no scan found for commit %s", commit)
+}
 
-	// Sort by timestamp, newest first
-	sort.Slice(scans, func(i, j int) bool {
-		return scans[i].Timestamp.After(scans[j].Timestamp)
-	})
+// labelPattern restricts labels to characters that are safe in paths and flags
+var labelPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
 
-	return scans, nil
+// ValidateLabel checks that a label is usable as a human-friendly scan name
+func ValidateLabel(label string) error {
+	if !labelPattern.MatchString(label) {
+		return fmt.Errorf("invalid label %q: use up to 64 letters, digits, '.', '_' or '-', starting with a letter or digit", label)
+	}
+	return nil
 }
 
-// GetScan retrieves a specific scan by ID
-func GetScan(resultsDir, scanID string) (*ScanMetadata, error) {
-	metadataPath := filepath.Join(resultsDir, scanID, "metadata.json")
-	return readMetadata(metadataPath)
+// checkLabel validates label and rejects it if another scan in store already uses it
+func checkLabel(store Store, scanID, label string) error {
+	if err := ValidateLabel(label); err != nil {
+		return err
+	}
+
+	scans, err := store.ListScans()
+	if err != nil {
+		return err
+	}
+	for _, scan := range scans {
+		if scan.Label == label && scan.ScanID != scanID {
+			return fmt.Errorf("label %q is already used by scan %s", label, scan.ScanID)
+		}
+	}
+	return nil
 }
 
-// readMetadata reads and parses a metadata.json file
-func readMetadata(path string) (*ScanMetadata, error) {
-	data, err := os.ReadFile(path)
+// relabel sets the label in a raw metadata document. The raw document is
+// edited so fields this package doesn't model are preserved.
+func relabel(data []byte, label string) ([]byte, error) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse metadata: %w", err)
+	}
+	doc["label"] = label
+
+	updated, err := json.MarshalIndent(doc, "", "  ")
 	if err != nil {
-		return nil, fmt.Errorf("failed to read metadata: %w", err)
+		return nil, fmt.Errorf("failed to marshal metadata: %w", err)
 	}
+	return updated, nil
+}
 
+// parseMetadata parses a metadata.json document
+func parseMetadata(data []byte) (*ScanMetadata, error) {
 	var metadata ScanMetadata
 	if err := json.Unmarshal(data, &metadata); err != nil {
-		return nil, fmt.Errorf("failed to parse metadata: %w", err)
+		return nil, fmt.Errorf("failed to parse metadata: %w", describeParseError(data, err))
 	}
 
 	return &metadata, nil
 }
 
-// DeleteScan removes a scan directory
-func DeleteScan(resultsDir, scanID string) error {
-	scanPath := filepath.Join(resultsDir, scanID)
-	return os.RemoveAll(scanPath)
-}
-
 // FilterByDateRange filters scans within a date range
 func FilterByDateRange(scans []ScanSummary, after, before time.Time) []ScanSummary {
 	var filtered []ScanSummary
@@ -150,3 +255,60 @@ func FilterByStatus(scans []ScanSummary, status string) []ScanSummary {
 
 	return filtered
 }
+
+// SearchOptions narrows SearchIssues
+type SearchOptions struct {
+	// Query is matched case-insensitively against issue type, description and suggestion
+	Query    string
+	Severity string
+	Device   string
+	// Tags limits the search to scans with every one of these normalized tags
+	Tags []string
+}
+
+// SearchMatch is one viewport of a scan with the issues that matched a search
+type SearchMatch struct {
+	Scan   *ScanMetadata
+	Device string
+	Issues []Issue
+}
+
+// SearchIssues returns the viewport results, newest scan first, with issues matching opts
+func SearchIssues(store Store, opts SearchOptions) ([]SearchMatch, error) {
+	scans, err := store.ListScans()
+	if err != nil {
+		return nil, err
+	}
+
+	query := strings.ToLower(opts.Query)
+	var matches []SearchMatch
+	for _, summary := range FilterByTags(scans, opts.Tags) {
+		scan, err := store.GetScan(summary.ScanID)
+		if err != nil {
+			continue
+		}
+
+		for _, result := range scan.Results {
+			if opts.Device != "" && !strings.EqualFold(result.Device, opts.Device) {
+				continue
+			}
+
+			var issues []Issue
+			for _, issue := range result.Issues {
+				if opts.Severity != "" && !strings.EqualFold(issue.Severity, opts.Severity) {
+					continue
+				}
+				if strings.Contains(strings.ToLower(issue.Type), query) ||
+					strings.Contains(strings.ToLower(issue.Description), query) ||
+					strings.Contains(strings.ToLower(issue.Suggestion), query) {
+					issues = append(issues, issue)
+				}
+			}
+			if len(issues) > 0 {
+				matches = append(matches, SearchMatch{Scan: scan, Device: result.Device, Issues: issues})
+			}
+		}
+	}
+
+	return matches, nil
+}
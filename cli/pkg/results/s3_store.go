@@ -0,0 +1,395 @@
+package results
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"path"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// S3Store keeps each scan under <prefix>/<scan-id>/ in an S3 bucket, so scan
+// history can be shared between machines. Credentials come from the standard
+// AWS sources (environment, shared config, instance role).
+type S3Store struct {
+	client *s3.Client
+	bucket string
+	prefix string
+}
+
+// NewS3Store creates a store for bucket. endpoint may point at an
+// S3-compatible service; region and endpoint may be empty.
+func NewS3Store(bucket, prefix, region, endpoint string) (*S3Store, error) {
+	if bucket == "" {
+		return nil, fmt.Errorf("results.s3.bucket must be set to use the s3 results backend")
+	}
+
+	var opts []func(*awsconfig.LoadOptions) error
+	if region != "" {
+		opts = append(opts, awsconfig.WithRegion(region))
+	}
+	cfg, err := awsconfig.LoadDefaultConfig(context.Background(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+
+	client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if endpoint != "" {
+			o.BaseEndpoint = aws.String(endpoint)
+			o.UsePathStyle = true
+		}
+	})
+
+	return &S3Store{
+		client: client,
+		bucket: bucket,
+		prefix: strings.Trim(prefix, "/"),
+	}, nil
+}
+
+// Location returns the bucket and prefix as an s3:// URL
+func (s *S3Store) Location() string {
+	return "s3://" + path.Join(s.bucket, s.prefix)
+}
+
+// key returns the object key for a file of a scan
+func (s *S3Store) key(scanID, name string) string {
+	return path.Join(s.prefix, scanID, name)
+}
+
+// scanPrefix returns the key prefix under which a scan's objects live
+func (s *S3Store) scanPrefix(scanID string) string {
+	return s.key(scanID, "") + "/"
+}
+
+// SaveScan uploads the scan's files, then its metadata, so a listed scan is
+// always complete. Saving a scan again (e.g. --append-results) then deletes
+// the objects of the earlier save that this one didn't write.
+func (s *S3Store) SaveScan(scan *ScanFiles) error {
+	if scan.Dir != "" {
+		return fmt.Errorf("per-target directories are not supported for %s", s.Location())
+	}
+	if err := checkScanID(scan.ScanID); err != nil {
+		return err
+	}
+
+	previous, err := s.listKeys(s.scanPrefix(scan.ScanID))
+	if err != nil {
+		return err
+	}
+
+	written := map[string]bool{s.key(scan.ScanID, MetadataFile): true}
+	for name, data := range scan.Files {
+		key := s.key(scan.ScanID, name)
+		if err := s.put(key, data, contentType(name)); err != nil {
+			return fmt.Errorf("failed to upload %s: %w", name, err)
+		}
+		written[key] = true
+	}
+	if err := s.put(s.key(scan.ScanID, MetadataFile), scan.Metadata, "application/json"); err != nil {
+		return fmt.Errorf("failed to upload metadata: %w", err)
+	}
+
+	// Only once the new metadata no longer refers to them
+	var stale []string
+	for _, key := range previous {
+		if !written[key] {
+			stale = append(stale, key)
+		}
+	}
+	if err := s.deleteKeys(stale); err != nil {
+		return fmt.Errorf("scan saved, but failed to delete the files of its earlier save: %w", err)
+	}
+
+	// PutObject replaces the pointer atomically, so readers see the old or the new one
+	latest, err := newLatest(scan.Metadata, s.scanPrefix(scan.ScanID))
+	if err != nil {
+		return err
+	}
+	if err := s.put(path.Join(s.prefix, LatestFile), latest, "application/json"); err != nil {
+		return fmt.Errorf("scan saved, but failed to upload %s: %w", LatestFile, err)
+	}
+	return nil
+}
+
+// Latest downloads the latest.json pointer at the top of the prefix
+func (s *S3Store) Latest() (*Latest, error) {
+	out, err := s.client.GetObject(context.Background(), &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(path.Join(s.prefix, LatestFile)),
+	})
+	if err != nil {
+		var noKey *types.NoSuchKey
+		if errors.As(err, &noKey) {
+			return nil, fmt.Errorf("no %s in %s: %w", LatestFile, s.Location(), fs.ErrNotExist)
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", LatestFile, err)
+	}
+	defer out.Body.Close()
+
+	data, err := io.ReadAll(out.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", LatestFile, err)
+	}
+	return parseLatest(data)
+}
+
+// contentType returns the Content-Type of a scan file from its extension,
+// e.g. image/png for screenshots and text/html for reports
+func contentType(name string) string {
+	if t := mime.TypeByExtension(path.Ext(name)); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
+
+// put uploads one object
+func (s *S3Store) put(key string, data []byte, contentType string) error {
+	_, err := s.client.PutObject(context.Background(), &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String(contentType),
+	})
+	return err
+}
+
+// readMetadata downloads a scan's raw metadata document
+func (s *S3Store) readMetadata(scanID string) ([]byte, error) {
+	out, err := s.client.GetObject(context.Background(), &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key(scanID, MetadataFile)),
+	})
+	if err != nil {
+		var noKey *types.NoSuchKey
+		if errors.As(err, &noKey) {
+			return nil, fmt.Errorf("scan %s not found in %s: %w", scanID, s.Location(), fs.ErrNotExist)
+		}
+		return nil, fmt.Errorf("failed to read metadata: %w", err)
+	}
+	defer out.Body.Close()
+
+	data, err := io.ReadAll(out.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metadata: %w", err)
+	}
+	return data, nil
+}
+
+// ReadFile downloads one file of a saved scan
+func (s *S3Store) ReadFile(scanID, name string) ([]byte, error) {
+	if err := checkScanID(scanID); err != nil {
+		return nil, err
+	}
+	if err := checkFileName(name); err != nil {
+		return nil, err
+	}
+	out, err := s.client.GetObject(context.Background(), &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key(scanID, name)),
+	})
+	if err != nil {
+		var noKey *types.NoSuchKey
+		if errors.As(err, &noKey) {
+			return nil, fmt.Errorf("%s of scan %s not found in %s: %w", name, scanID, s.Location(), fs.ErrNotExist)
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	defer out.Body.Close()
+
+	data, err := io.ReadAll(out.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	return data, nil
+}
+
+// GetScan retrieves a specific scan by ID
+func (s *S3Store) GetScan(scanID string) (*ScanMetadata, error) {
+	if err := checkScanID(scanID); err != nil {
+		return nil, err
+	}
+	data, err := s.readMetadata(scanID)
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := parseMetadata(data)
+	if err != nil {
+		return nil, fmt.Errorf("s3://%s/%s: %w", s.bucket, s.key(scanID, MetadataFile), err)
+	}
+	return metadata, nil
+}
+
+// ListScans returns all scans under the prefix
+func (s *S3Store) ListScans() ([]ScanSummary, error) {
+	scans, _, err := s.list()
+	return scans, err
+}
+
+// SkippedScans returns the scans under the prefix whose metadata ListScans couldn't use
+func (s *S3Store) SkippedScans() ([]SkippedScan, error) {
+	_, skipped, err := s.list()
+	return skipped, err
+}
+
+// list reads the metadata of every scan under the prefix, returning the
+// summaries of the valid ones and why the others were skipped
+func (s *S3Store) list() ([]ScanSummary, []SkippedScan, error) {
+	listPrefix := ""
+	if s.prefix != "" {
+		listPrefix = s.prefix + "/"
+	}
+
+	var scans []ScanSummary
+	var skipped []SkippedScan
+	paginator := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket:    aws.String(s.bucket),
+		Prefix:    aws.String(listPrefix),
+		Delimiter: aws.String("/"),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.Background())
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read %s: %w", s.Location(), err)
+		}
+
+		for _, p := range page.CommonPrefixes {
+			scanID := strings.TrimSuffix(strings.TrimPrefix(aws.ToString(p.Prefix), listPrefix), "/")
+			metadata, err := s.GetScan(scanID)
+			if errors.Is(err, fs.ErrNotExist) {
+				// Prefixes without metadata (e.g. comparison reports) aren't scans
+				continue
+			}
+			if err != nil {
+				skipped = append(skipped, SkippedScan{ScanID: scanID, Reason: err.Error()})
+				continue
+			}
+			scans = append(scans, summarize(metadata))
+		}
+	}
+
+	sortScans(scans)
+	return scans, skipped, nil
+}
+
+// SetLabel attaches a label to a scan, rejecting labels already used by another scan
+func (s *S3Store) SetLabel(scanID, label string) error {
+	if err := checkScanID(scanID); err != nil {
+		return err
+	}
+	if err := checkLabel(s, scanID, label); err != nil {
+		return err
+	}
+
+	data, err := s.readMetadata(scanID)
+	if err != nil {
+		return err
+	}
+	updated, err := relabel(data, label)
+	if err != nil {
+		return err
+	}
+	if err := s.put(s.key(scanID, MetadataFile), updated, "application/json"); err != nil {
+		return fmt.Errorf("failed to upload metadata: %w", err)
+	}
+	return nil
+}
+
+// UpdateTags adds and removes normalized tags on a scan. A single PUT
+// replaces the metadata object, so readers never see a partial document.
+func (s *S3Store) UpdateTags(scanID string, add, remove []string) ([]string, error) {
+	if err := checkScanID(scanID); err != nil {
+		return nil, err
+	}
+
+	data, err := s.readMetadata(scanID)
+	if err != nil {
+		return nil, err
+	}
+	updated, tags, err := retag(data, add, remove)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.put(s.key(scanID, MetadataFile), updated, "application/json"); err != nil {
+		return nil, fmt.Errorf("failed to upload metadata: %w", err)
+	}
+	return tags, nil
+}
+
+// DeleteScan removes every object of a scan
+func (s *S3Store) DeleteScan(scanID string) error {
+	if err := checkScanID(scanID); err != nil {
+		return err
+	}
+
+	keys, err := s.listKeys(s.scanPrefix(scanID))
+	if err != nil {
+		return err
+	}
+	if err := s.deleteKeys(keys); err != nil {
+		return err
+	}
+
+	if latest, err := s.Latest(); err == nil && latest.ScanID == scanID {
+		s.client.DeleteObject(context.Background(), &s3.DeleteObjectInput{
+			Bucket: aws.String(s.bucket),
+			Key:    aws.String(path.Join(s.prefix, LatestFile)),
+		})
+	}
+	return nil
+}
+
+// listKeys returns the key of every object under prefix
+func (s *S3Store) listKeys(prefix string) ([]string, error) {
+	var keys []string
+	paginator := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(prefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("failed to list scan objects: %w", err)
+		}
+		for _, obj := range page.Contents {
+			keys = append(keys, aws.ToString(obj.Key))
+		}
+	}
+	return keys, nil
+}
+
+// maxDeleteObjects is the most keys one DeleteObjects request may name
+const maxDeleteObjects = 1000
+
+// deleteKeys deletes the objects with keys
+func (s *S3Store) deleteKeys(keys []string) error {
+	for len(keys) > 0 {
+		batch := keys[:min(len(keys), maxDeleteObjects)]
+		keys = keys[len(batch):]
+
+		objects := make([]types.ObjectIdentifier, len(batch))
+		for i, key := range batch {
+			objects[i] = types.ObjectIdentifier{Key: aws.String(key)}
+		}
+		out, err := s.client.DeleteObjects(context.Background(), &s3.DeleteObjectsInput{
+			Bucket: aws.String(s.bucket),
+			Delete: &types.Delete{Objects: objects, Quiet: aws.Bool(true)},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to delete scan objects: %w", err)
+		}
+		if len(out.Errors) > 0 {
+			return fmt.Errorf("failed to delete scan object %s: %s", aws.ToString(out.Errors[0].Key), aws.ToString(out.Errors[0].Message))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package results
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MetadataFile is the name of the metadata document saved with every scan
+const MetadataFile = "metadata.json"
+
+// ScanFiles is everything saved for one scan: its metadata document and
+// screenshot files keyed by file name
+type ScanFiles struct {
+	ScanID   string
+	Metadata []byte
+	Files    map[string][]byte
+	// Dir saves the scan in this subdirectory of the results directory, e.g.
+	// one per target URL; only the filesystem store supports it. When empty,
+	// a scan saved before stays where it is.
+	Dir string
+}
+
+// SkippedScan is a stored scan that ListScans leaves out because its
+// metadata can't be read or parsed
+type SkippedScan struct {
+	ScanID string
+	Reason string
+}
+
+// Skipper is implemented by stores that can say which scans a listing skips, and why
+type Skipper interface {
+	// SkippedScans returns the scans ListScans leaves out, in ID order
+	SkippedScans() ([]SkippedScan, error)
+}
+
+// Store saves and retrieves scan results
+type Store interface {
+	// SaveScan writes a scan's metadata and files, replacing any existing scan with the same ID
+	SaveScan(scan *ScanFiles) error
+	// GetScan retrieves a scan's metadata by ID
+	GetScan(scanID string) (*ScanMetadata, error)
+	// ListScans returns all stored scans, newest first
+	ListScans() ([]ScanSummary, error)
+	// DeleteScan removes a scan and its files
+	DeleteScan(scanID string) error
+	// SetLabel attaches a label to a scan, rejecting labels already used by another scan
+	SetLabel(scanID, label string) error
+	// UpdateTags adds and removes normalized tags (see NormalizeTag) on a scan
+	// and returns its resulting tags
+	UpdateTags(scanID string, add, remove []string) ([]string, error)
+	// ReadFile returns one file of a saved scan, e.g. MetadataFile or a screenshot
+	ReadFile(scanID, name string) ([]byte, error)
+	// Latest returns the most recently saved scan, from the LatestFile pointer
+	// updated by SaveScan; the error matches fs.ErrNotExist if there is none
+	Latest() (*Latest, error)
+	// Location describes where scans are stored, for display
+	Location() string
+}
+
+// StoreOptions selects and configures a results store
+type StoreOptions struct {
+	Backend  string // "filesystem" (default) or "s3"
+	Dir      string // Results directory for the filesystem backend
+	Bucket   string
+	Prefix   string
+	Region   string
+	Endpoint string // Optional S3-compatible endpoint, e.g. MinIO
+}
+
+// NewStore creates the store selected by opts.Backend
+func NewStore(opts StoreOptions) (Store, error) {
+	switch opts.Backend {
+	case "", "filesystem":
+		return NewFSStore(opts.Dir), nil
+	case "s3":
+		return NewS3Store(opts.Bucket, opts.Prefix, opts.Region, opts.Endpoint)
+	default:
+		return nil, fmt.Errorf("unknown results backend %q (expected filesystem or s3)", opts.Backend)
+	}
+}
+
+// checkScanID rejects IDs that would escape or address the whole store
+func checkScanID(scanID string) error {
+	return checkName("scan ID", scanID)
+}
+
+// checkFileName rejects file names that would escape a scan's directory
+func checkFileName(name string) error {
+	return checkName("file name", name)
+}
+
+// checkName rejects empty names and names with path separators or dot segments
+func checkName(kind, name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("invalid %s %q", kind, name)
+	}
+	return nil
+}
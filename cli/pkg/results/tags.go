@@ -0,0 +1,108 @@
+package results
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// tagPattern restricts tags to characters that are safe in paths and flags
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,63}$`)
+
+// NormalizeTag trims and lowercases a tag, so "Release-2.1 " and "release-2.1"
+// are the same tag, and checks that what remains is a valid tag
+func NormalizeTag(tag string) (string, error) {
+	normalized := strings.ToLower(strings.TrimSpace(tag))
+	if !tagPattern.MatchString(normalized) {
+		return "", fmt.Errorf("invalid tag %q: use up to 64 letters, digits, '.', '_' or '-', starting with a letter or digit", tag)
+	}
+	return normalized, nil
+}
+
+// NormalizeTags normalizes every tag, dropping duplicates
+func NormalizeTags(tags []string) ([]string, error) {
+	seen := make(map[string]bool, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		t, err := NormalizeTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[t] {
+			seen[t] = true
+			normalized = append(normalized, t)
+		}
+	}
+	return normalized, nil
+}
+
+// HasTags reports whether a scan's tags include every one of want
+func HasTags(tags, want []string) bool {
+	for _, w := range want {
+		found := false
+		for _, tag := range tags {
+			if tag == w {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// FilterByTags keeps the scans tagged with every one of tags
+func FilterByTags(scans []ScanSummary, tags []string) []ScanSummary {
+	var filtered []ScanSummary
+	for _, scan := range scans {
+		if HasTags(scan.Tags, tags) {
+			filtered = append(filtered, scan)
+		}
+	}
+	return filtered
+}
+
+// retag adds and removes normalized tags in a raw metadata document, like
+// relabel, and returns the document with the scan's resulting tags in sorted order
+func retag(data []byte, add, remove []string) ([]byte, []string, error) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse metadata: %w", err)
+	}
+
+	set := make(map[string]bool)
+	if existing, ok := doc["tags"].([]interface{}); ok {
+		for _, tag := range existing {
+			if s, ok := tag.(string); ok {
+				set[s] = true
+			}
+		}
+	}
+	for _, tag := range add {
+		set[tag] = true
+	}
+	for _, tag := range remove {
+		delete(set, tag)
+	}
+
+	tags := make([]string, 0, len(set))
+	for tag := range set {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	if len(tags) == 0 {
+		delete(doc, "tags")
+	} else {
+		doc["tags"] = tags
+	}
+
+	updated, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+	return updated, tags, nil
+}
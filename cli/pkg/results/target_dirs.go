@@ -0,0 +1,112 @@
+package results
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// scanLocation is a directory in the results directory that may hold a scan
+type scanLocation struct {
+	ID string // Directory name, which is the scan ID
+	// Dir is the per-target directory it is in (see ScanFiles.Dir), or "" at the top
+	Dir string
+}
+
+// path returns the location relative to the results directory
+func (l scanLocation) path() string {
+	return filepath.Join(l.Dir, l.ID)
+}
+
+// findScanDirs lists the directories of the results directory that may hold
+// scans: its subdirectories, and those of per-target directories. Top-level
+// scans come first. Hidden directories are saves in progress.
+func (s *FSStore) findScanDirs() ([]scanLocation, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read results directory: %w", err)
+	}
+
+	var top, nested []scanLocation
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		subdirs, ok := s.targetDirScans(entry.Name())
+		if !ok {
+			top = append(top, scanLocation{ID: entry.Name()})
+			continue
+		}
+		for _, id := range subdirs {
+			nested = append(nested, scanLocation{ID: id, Dir: entry.Name()})
+		}
+	}
+	return append(top, nested...), nil
+}
+
+// targetDirScans returns the subdirectories of dir if it is a per-target
+// directory: one without metadata that holds only directories. A scan
+// directory always has files, even when its metadata is missing.
+func (s *FSStore) targetDirScans(dir string) ([]string, bool) {
+	entries, err := os.ReadDir(filepath.Join(s.dir, dir))
+	if err != nil {
+		return nil, false
+	}
+	var subdirs []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			return nil, false
+		}
+		if !strings.HasPrefix(entry.Name(), ".") {
+			subdirs = append(subdirs, entry.Name())
+		}
+	}
+	return subdirs, len(subdirs) > 0
+}
+
+// scanDir returns the directory a saved scan is in, which is under a
+// per-target directory if the index found it there. Scans that aren't saved
+// yet get their top-level directory.
+func (s *FSStore) scanDir(scanID string) string {
+	top := filepath.Join(s.dir, scanID)
+	if _, err := os.Stat(top); err == nil {
+		return top
+	}
+
+	if e, ok := s.readIndex().Entries[scanID]; ok && e.Dir != "" {
+		path := filepath.Join(s.dir, e.Dir, scanID)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	// Saved, or moved, since the index was last written
+	if index, err := s.refreshIndex(); err == nil {
+		if e, ok := index.Entries[scanID]; ok && e.Dir != "" {
+			return filepath.Join(s.dir, e.Dir, scanID)
+		}
+	}
+	return top
+}
+
+// TargetDir returns the per-target directory a saved scan is in (see
+// ScanFiles.Dir), or "" if it is at the top of the results directory
+func (s *FSStore) TargetDir(scanID string) string {
+	dir := filepath.Dir(s.scanDir(scanID))
+	if dir == filepath.Clean(s.dir) {
+		return ""
+	}
+	return filepath.Base(dir)
+}
+
+// checkTargetDir rejects per-target directory names that would escape the
+// results directory or be taken for a save in progress
+func checkTargetDir(dir string) error {
+	if err := checkName("directory", dir); err != nil {
+		return err
+	}
+	if strings.HasPrefix(dir, ".") || dir == LatestLink {
+		return fmt.Errorf("invalid directory %q", dir)
+	}
+	return nil
+}
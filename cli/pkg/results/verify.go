@@ -0,0 +1,224 @@
+package results
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"image/png"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ScanCheck is the verification result for one scan
+type ScanCheck struct {
+	ScanID   string
+	Problems []string
+	Repaired bool
+}
+
+// Healthy reports whether no problems were found
+func (c ScanCheck) Healthy() bool {
+	return len(c.Problems) == 0
+}
+
+// Verifier is implemented by stores that can check, and optionally repair, their scans
+type Verifier interface {
+	Verify(repair bool) ([]ScanCheck, error)
+}
+
+// rawResult is the part of a saved viewport result needed to check its screenshot
+type rawResult struct {
+	Device           string `json:"device"`
+	ScreenshotBase64 string `json:"screenshotBase64"`
+	ScreenshotFile   string `json:"screenshotFile"`
+}
+
+// fileName returns the screenshot's file name, including for scans saved
+// before metadata recorded it
+func (r rawResult) fileName() string {
+	if r.ScreenshotFile != "" {
+		return r.ScreenshotFile
+	}
+	return r.Device + ".png"
+}
+
+// Verify checks every scan directory for missing or invalid metadata and for
+// screenshots referenced by metadata that are missing. With repair, missing
+// screenshots are restored from the copy embedded in metadata, and
+// unreadable metadata is regenerated from the PNGs present (the old file is
+// kept as metadata.json.corrupt).
+func (s *FSStore) Verify(repair bool) ([]ScanCheck, error) {
+	if _, err := os.Stat(s.dir); os.IsNotExist(err) {
+		return []ScanCheck{}, nil
+	}
+
+	locations, err := s.findScanDirs()
+	if err != nil {
+		return nil, err
+	}
+
+	var checks []ScanCheck
+	for _, loc := range locations {
+		// Comparison reports share the results directory but aren't scans
+		if _, err := os.Stat(filepath.Join(s.dir, loc.path(), "comparison.json")); err == nil {
+			continue
+		}
+		checks = append(checks, s.verifyScan(loc, repair))
+	}
+	return checks, nil
+}
+
+// verifyScan checks a single scan directory
+func (s *FSStore) verifyScan(loc scanLocation, repair bool) ScanCheck {
+	scanID := loc.ID
+	check := ScanCheck{ScanID: scanID}
+	scanDir := filepath.Join(s.dir, loc.path())
+	metadataPath := filepath.Join(scanDir, MetadataFile)
+
+	var doc struct {
+		ScanID         string      `json:"scanId"`
+		Results        []rawResult `json:"results"`
+		DimensionsOnly bool        `json:"dimensionsOnly"`
+	}
+	data, err := os.ReadFile(metadataPath)
+	if err == nil {
+		if err = json.Unmarshal(data, &doc); err != nil {
+			err = describeParseError(data, err)
+		}
+	}
+	if err == nil && doc.ScanID == "" {
+		err = fmt.Errorf("no scan ID")
+	}
+	if err != nil {
+		if os.IsNotExist(err) {
+			check.Problems = append(check.Problems, "metadata.json is missing")
+		} else {
+			check.Problems = append(check.Problems, fmt.Sprintf("metadata.json is invalid: %v", err))
+		}
+		if repair {
+			if err := regenerateMetadata(scanDir, scanID); err != nil {
+				check.Problems = append(check.Problems, fmt.Sprintf("could not regenerate metadata: %v", err))
+			} else {
+				check.Repaired = true
+			}
+		}
+		return check
+	}
+
+	// --dimensions-only scans are saved without screenshots
+	shots := doc.Results
+	if doc.DimensionsOnly {
+		shots = nil
+	}
+	repairedAll := true
+	for _, result := range shots {
+		name := result.fileName()
+		if _, err := os.Stat(filepath.Join(scanDir, name)); err == nil {
+			continue
+		}
+		check.Problems = append(check.Problems, fmt.Sprintf("screenshot %s is missing", name))
+		if !repair {
+			continue
+		}
+
+		screenshot, err := base64.StdEncoding.DecodeString(result.ScreenshotBase64)
+		if err == nil && len(screenshot) > 0 {
+			err = os.WriteFile(filepath.Join(scanDir, name), screenshot, 0644)
+		} else if err == nil {
+			err = fmt.Errorf("metadata holds no copy")
+		}
+		if err != nil {
+			check.Problems = append(check.Problems, fmt.Sprintf("could not restore %s: %v", name, err))
+			repairedAll = false
+		}
+	}
+	check.Repaired = repair && !check.Healthy() && repairedAll
+
+	return check
+}
+
+// regenerateMetadata writes a new metadata.json describing the PNGs in scanDir.
+// Issues and analysis are lost, so the scan's status is set to "recovered".
+func regenerateMetadata(scanDir, scanID string) error {
+	pngs, err := filepath.Glob(filepath.Join(scanDir, "*.png"))
+	if err != nil {
+		return err
+	}
+	if len(pngs) == 0 {
+		return fmt.Errorf("no screenshots to rebuild it from")
+	}
+	sort.Strings(pngs)
+
+	type dimensions struct {
+		Width  int `json:"width"`
+		Height int `json:"height"`
+	}
+	type result struct {
+		Device           string        `json:"device"`
+		Dimensions       dimensions    `json:"dimensions"`
+		ScreenshotBase64 string        `json:"screenshotBase64"`
+		Issues           []interface{} `json:"issues"`
+		ScreenshotFile   string        `json:"screenshotFile"`
+	}
+
+	var results []result
+	var newest time.Time
+	for _, path := range pngs {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		cfg, err := png.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			// Not a usable screenshot, leave it out
+			continue
+		}
+		if info, err := os.Stat(path); err == nil && info.ModTime().After(newest) {
+			newest = info.ModTime()
+		}
+
+		name := filepath.Base(path)
+		results = append(results, result{
+			Device:           strings.TrimSuffix(name, ".png"),
+			Dimensions:       dimensions{Width: cfg.Width, Height: cfg.Height},
+			ScreenshotBase64: base64.StdEncoding.EncodeToString(data),
+			Issues:           []interface{}{},
+			ScreenshotFile:   name,
+		})
+	}
+	if len(results) == 0 {
+		return fmt.Errorf("no valid screenshots to rebuild it from")
+	}
+
+	metadata, err := json.MarshalIndent(map[string]interface{}{
+		"scanId":    scanID,
+		"timestamp": scanTime(scanID, newest).UTC().Format(time.RFC3339),
+		"status":    "recovered",
+		"results":   results,
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+
+	metadataPath := filepath.Join(scanDir, MetadataFile)
+	if _, err := os.Stat(metadataPath); err == nil {
+		if err := os.Rename(metadataPath, metadataPath+".corrupt"); err != nil {
+			return fmt.Errorf("failed to preserve old metadata: %w", err)
+		}
+	}
+	return writeFileAtomic(metadataPath, metadata)
+}
+
+// scanTime recovers when a scan ran from its "scan-<unix millis>" ID,
+// falling back to fallback
+func scanTime(scanID string, fallback time.Time) time.Time {
+	if ms, err := strconv.ParseInt(strings.TrimPrefix(scanID, "scan-"), 10, 64); err == nil && ms > 0 {
+		return time.UnixMilli(ms)
+	}
+	return fallback
+}
@@ -0,0 +1,37 @@
+package server
+
+import (
+	"strings"
+	"sync"
+)
+
+// tailBuffer is an io.Writer that keeps only the most recent bytes written to it
+type tailBuffer struct {
+	mu   sync.Mutex
+	buf  []byte
+	size int
+}
+
+// newTailBuffer creates a tail buffer holding at most size bytes
+func newTailBuffer(size int) *tailBuffer {
+	return &tailBuffer{size: size}
+}
+
+// Write appends p, discarding the oldest bytes once the buffer is full
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.buf = append(b.buf, p...)
+	if len(b.buf) > b.size {
+		b.buf = b.buf[len(b.buf)-b.size:]
+	}
+	return len(p), nil
+}
+
+// String returns the buffered output, trimmed of surrounding whitespace
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return strings.TrimSpace(string(b.buf))
+}
@@ -2,22 +2,45 @@ package server
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 )
 
 // Manager handles the lifecycle of the screenshot server
 type Manager struct {
-	port      int
-	serverURL string
-	cmd       *exec.Cmd
+	mu             sync.Mutex
+	port           int
+	serverURL      string
+	cmd            *exec.Cmd
+	logPath        string
+	logFile        *os.File
+	logEcho        io.Writer
+	recent         *tailBuffer
+	readyBody      map[string]interface{}
+	userAgent      string
+	stopWithParent bool
+
+	// Health check cache, see SetHealthCacheTTL
+	healthMu    sync.Mutex
+	healthTTL   time.Duration
+	lastHealthy time.Time
+	healthETag  string
 }
 
+// recentOutputSize is how much of the server's latest output is kept for error reports
+const recentOutputSize = 4096
+
 // NewManager creates a new server manager
 func NewManager(port int) *Manager {
 	return &Manager{
@@ -26,8 +49,60 @@ func NewManager(port int) *Manager {
 	}
 }
 
+// SetUserAgent sets the User-Agent sent with health checks
+func (m *Manager) SetUserAgent(userAgent string) {
+	m.userAgent = userAgent
+}
+
+// SetStopWithParent asks the OS to terminate a spawned server if this process
+// dies without stopping it, e.g. on a panic in a goroutine. Only Linux
+// supports this; elsewhere the server is only stopped by Stop.
+func (m *Manager) SetStopWithParent(enabled bool) {
+	m.stopWithParent = enabled
+}
+
+// SetLogFile redirects the spawned server's stdout/stderr to the given file
+func (m *Manager) SetLogFile(path string) {
+	m.logPath = path
+}
+
+// SetLogEcho mirrors the spawned server's stdout/stderr to w (e.g. os.Stderr)
+func (m *Manager) SetLogEcho(w io.Writer) {
+	m.logEcho = w
+}
+
+// SetReadyBody requires the health response body to contain these top-level
+// JSON fields and values before the server is considered running
+func (m *Manager) SetReadyBody(expected map[string]interface{}) {
+	m.readyBody = expected
+}
+
+// SetHealthCacheTTL makes IsRunning trust a successful health check for ttl
+// before probing again, so frequent callers don't hammer the server. Probes
+// after that are conditional on the last health response's ETag. A ttl of 0
+// (the default) disables the cache.
+func (m *Manager) SetHealthCacheTTL(ttl time.Duration) {
+	m.healthMu.Lock()
+	defer m.healthMu.Unlock()
+	m.healthTTL = ttl
+	m.forgetHealth()
+}
+
+// forgetHealth drops the cached health check result. Callers hold healthMu.
+func (m *Manager) forgetHealth() {
+	m.lastHealthy = time.Time{}
+	m.healthETag = ""
+}
+
 // IsRunning checks if the server is already running and healthy
 func (m *Manager) IsRunning(ctx context.Context, timeout time.Duration) bool {
+	m.healthMu.Lock()
+	defer m.healthMu.Unlock()
+
+	if m.healthTTL > 0 && !m.lastHealthy.IsZero() && time.Since(m.lastHealthy) < m.healthTTL {
+		return true
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
@@ -35,29 +110,92 @@ func (m *Manager) IsRunning(ctx context.Context, timeout time.Duration) bool {
 	if err != nil {
 		return false
 	}
+	if m.userAgent != "" {
+		req.Header.Set("User-Agent", m.userAgent)
+	}
+	if m.healthTTL > 0 && m.healthETag != "" {
+		req.Header.Set("If-None-Match", m.healthETag)
+	}
 
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
+		m.forgetHealth()
 		return false
 	}
 	defer resp.Body.Close()
 
+	// An unchanged health response was already found healthy
+	if resp.StatusCode == http.StatusNotModified && m.healthETag != "" {
+		m.lastHealthy = time.Now()
+		return true
+	}
+
+	healthy := m.checkHealthResponse(resp)
+	if healthy && m.healthTTL > 0 {
+		// Only a fully ready server is worth caching; a 503 may recover any moment
+		if resp.StatusCode == http.StatusOK {
+			m.lastHealthy = time.Now()
+			m.healthETag = resp.Header.Get("ETag")
+		}
+	} else {
+		m.forgetHealth()
+	}
+	return healthy
+}
+
+// checkHealthResponse reports whether a health check response means the server is running
+func (m *Manager) checkHealthResponse(resp *http.Response) bool {
 	// Accept 200 (OK) - server is ready
 	// Accept 503 (Service Unavailable) - server is responding but browser init failed
 	// This is still a successful health check - the server is running and accessible
-	return resp.StatusCode == 200 || resp.StatusCode == 503
+	if resp.StatusCode != 200 && resp.StatusCode != 503 {
+		return false
+	}
+
+	if m.readyBody == nil {
+		return true
+	}
+	return matchesReadyBody(resp.Body, m.readyBody)
+}
+
+// matchesReadyBody reports whether the JSON body contains every expected field and value
+func matchesReadyBody(body io.Reader, expected map[string]interface{}) bool {
+	var actual map[string]interface{}
+	if err := json.NewDecoder(body).Decode(&actual); err != nil {
+		return false
+	}
+
+	for key, want := range expected {
+		got, ok := actual[key]
+		if !ok || !reflect.DeepEqual(got, want) {
+			return false
+		}
+	}
+	return true
 }
 
-// findViewportServerExecutable tries multiple methods to find viewport-server
-func findViewportServerExecutable() string {
+// ServerPackage is the npm package that provides the viewport-server command
+const ServerPackage = "viewport-cli-server"
+
+// InstallCommand installs the screenshot server globally
+var InstallCommand = []string{"npm", "install", "-g", ServerPackage}
+
+// ErrNotInstalled is returned by Start when no viewport-server executable can be found
+var ErrNotInstalled = errors.New("viewport-server is not installed")
+
+// findViewportServerExecutable tries multiple methods to find viewport-server,
+// reporting false if none finds it
+func findViewportServerExecutable() (string, bool) {
 	// Method 1: Try 'viewport-server' directly (should be in PATH from npm)
-	if _, err := exec.LookPath("viewport-server"); err == nil {
-		return "viewport-server"
+	if path, err := exec.LookPath("viewport-server"); err == nil {
+		return path, true
 	}
 
-	// Method 2: Try 'npx viewport-server'
-	if _, err := exec.LookPath("npx"); err == nil {
-		return "npx"
+	// Method 2: A project-local install, which is what npx would run. npx
+	// itself would fetch whatever package is called viewport-server instead.
+	local := filepath.Join("node_modules", ".bin", "viewport-server")
+	if _, err := os.Stat(local); err == nil {
+		return local, true
 	}
 
 	// Method 3: Try to find it relative to the executable (development mode)
@@ -72,30 +210,51 @@ func findViewportServerExecutable() string {
 
 		for _, p := range possiblePaths {
 			if _, err := os.Stat(p); err == nil {
-				return p
+				return p, true
 			}
 		}
 	}
 
-	// Fallback
-	return "viewport-server"
+	return "", false
+}
+
+// Installed reports whether a viewport-server executable can be found
+func Installed() bool {
+	_, ok := findViewportServerExecutable()
+	return ok
 }
 
-// getViewportServerCommand creates the appropriate exec.Cmd for starting the server
-func getViewportServerCommand(ctx context.Context, port int) *exec.Cmd {
-	executable := findViewportServerExecutable()
+// Install runs InstallCommand, streaming its output to w, and checks that
+// viewport-server can be found afterwards
+func Install(ctx context.Context, w io.Writer) error {
+	if _, err := exec.LookPath(InstallCommand[0]); err != nil {
+		return fmt.Errorf("npm not found; install Node.js (https://nodejs.org), then run: %s", strings.Join(InstallCommand, " "))
+	}
+
+	cmd := exec.CommandContext(ctx, InstallCommand[0], InstallCommand[1:]...)
+	cmd.Stdout = w
+	cmd.Stderr = w
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s failed: %w", strings.Join(InstallCommand, " "), err)
+	}
 
-	// If we found npx, use it
-	if executable == "npx" {
-		return exec.CommandContext(ctx, "npx", "viewport-server", "--port", fmt.Sprintf("%d", port))
+	if !Installed() {
+		return fmt.Errorf("%s succeeded, but viewport-server is still not on PATH; add npm's global bin directory (see npm prefix -g) to PATH", ServerPackage)
 	}
+	return nil
+}
 
-	// Otherwise, use the executable directly
+// getViewportServerCommand creates the exec.Cmd for starting the server
+func getViewportServerCommand(ctx context.Context, executable string, port int) *exec.Cmd {
 	return exec.CommandContext(ctx, executable, "--port", fmt.Sprintf("%d", port))
 }
 
-// Start spawns the screenshot server
+// Start spawns the screenshot server. It is safe to call from several
+// goroutines; only the first caller spawns a process.
 func (m *Manager) Start(ctx context.Context, verbose bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	// Check if already running
 	if m.IsRunning(ctx, 2*time.Second) {
 		if verbose {
@@ -109,10 +268,24 @@ func (m *Manager) Start(ctx context.Context, verbose bool) error {
 	}
 
 	// Spawn viewport-server process with intelligent command resolution
-	m.cmd = getViewportServerCommand(ctx, m.port)
+	executable, ok := findViewportServerExecutable()
+	if !ok {
+		return fmt.Errorf("%w; install it with: %s", ErrNotInstalled, strings.Join(InstallCommand, " "))
+	}
+	m.cmd = getViewportServerCommand(ctx, executable, m.port)
+
+	if m.stopWithParent {
+		stopWithParent(m.cmd)
+	}
+
+	// Capture server output so startup failures can be diagnosed
+	if err := m.attachOutput(); err != nil {
+		return err
+	}
 
 	// Run detached from this process
 	if err := m.cmd.Start(); err != nil {
+		m.closeLog()
 		return fmt.Errorf("failed to start screenshot server: %w", err)
 	}
 
@@ -133,16 +306,80 @@ func (m *Manager) Start(ctx context.Context, verbose bool) error {
 	}
 
 	m.cmd.Process.Kill()
+	m.cmd.Wait()
+	m.closeLog()
+
+	if recent := m.recent.String(); recent != "" {
+		return fmt.Errorf("screenshot server failed to start after %d seconds\nRecent server output:\n%s", maxAttempts/2, recent)
+	}
 	return fmt.Errorf("screenshot server failed to start after %d seconds", maxAttempts/2)
 }
 
+// attachOutput wires the server's stdout/stderr to the recent-output buffer,
+// the log file and the echo writer, whichever are configured
+func (m *Manager) attachOutput() error {
+	m.recent = newTailBuffer(recentOutputSize)
+	writers := []io.Writer{m.recent}
+
+	if m.logPath != "" {
+		f, err := os.OpenFile(m.logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to open server log file: %w", err)
+		}
+		m.logFile = f
+		writers = append(writers, f)
+	}
+
+	if m.logEcho != nil {
+		writers = append(writers, m.logEcho)
+	}
+
+	// Using the same writer for both streams serializes writes to it
+	out := io.MultiWriter(writers...)
+	m.cmd.Stdout = out
+	m.cmd.Stderr = out
+	return nil
+}
+
+// closeLog flushes and closes the server log file if one is open
+func (m *Manager) closeLog() {
+	if m.logFile == nil {
+		return
+	}
+	m.logFile.Sync()
+	m.logFile.Close()
+	m.logFile = nil
+}
+
+// Spawned reports whether this manager started the server process itself,
+// as opposed to finding one already running
+func (m *Manager) Spawned() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.cmd != nil
+}
+
+// RecentOutput returns the latest stdout/stderr of the spawned server, or ""
+// if this manager didn't start it
+func (m *Manager) RecentOutput() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.recent == nil {
+		return ""
+	}
+	return m.recent.String()
+}
+
 // GetURL returns the server URL
 func (m *Manager) GetURL() string {
 	return m.serverURL
 }
 
-// Stop gracefully stops the server
+// Stop gracefully stops the server. Calling it again after the server has stopped is a no-op.
 func (m *Manager) Stop() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	if m.cmd == nil || m.cmd.Process == nil {
 		return nil
 	}
@@ -164,11 +401,20 @@ func (m *Manager) Stop() error {
 	case <-done:
 	}
 
+	m.closeLog()
+	m.cmd = nil
+
+	m.healthMu.Lock()
+	m.forgetHealth()
+	m.healthMu.Unlock()
 	return nil
 }
 
 // Kill forcefully kills the server
 func (m *Manager) Kill() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	if m.cmd == nil || m.cmd.Process == nil {
 		return nil
 	}
@@ -0,0 +1,122 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// healthServer answers health checks with an ETag, and 304 when the
+// request's If-None-Match matches it, recording each request's If-None-Match
+type healthServer struct {
+	*httptest.Server
+	mu          sync.Mutex
+	ifNoneMatch []string
+}
+
+func newHealthServer(t *testing.T) *healthServer {
+	hs := &healthServer{}
+	hs.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hs.mu.Lock()
+		hs.ifNoneMatch = append(hs.ifNoneMatch, r.Header.Get("If-None-Match"))
+		hs.mu.Unlock()
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write([]byte(`{"status":"ok"}`))
+	}))
+	t.Cleanup(hs.Close)
+	return hs
+}
+
+// requests returns the If-None-Match of each health check so far
+func (hs *healthServer) requests() []string {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	return append([]string(nil), hs.ifNoneMatch...)
+}
+
+func TestIsRunningHealthCache(t *testing.T) {
+	hs := newHealthServer(t)
+	m := &Manager{serverURL: hs.URL}
+	const ttl = 100 * time.Millisecond
+	m.SetHealthCacheTTL(ttl)
+	ctx := context.Background()
+
+	if !m.IsRunning(ctx, time.Second) {
+		t.Fatal("not running on the first check")
+	}
+	if !m.IsRunning(ctx, time.Second) {
+		t.Fatal("not running on a cached check")
+	}
+	if got := hs.requests(); len(got) != 1 {
+		t.Fatalf("%d health requests within the TTL, want 1", len(got))
+	}
+
+	// Once the TTL expires the check is made again, conditional on the ETag
+	time.Sleep(ttl + 20*time.Millisecond)
+	if !m.IsRunning(ctx, time.Second) {
+		t.Fatal("not running after a 304")
+	}
+	got := hs.requests()
+	if len(got) != 2 || got[1] != `"v1"` {
+		t.Fatalf("If-None-Match of the health requests = %q, want [\"\" \"v1\"]", got)
+	}
+
+	// The 304 renewed the cache
+	m.IsRunning(ctx, time.Second)
+	if len(hs.requests()) != 2 {
+		t.Errorf("%d health requests, want the 304 to be cached for the TTL", len(hs.requests()))
+	}
+
+	// A server that went away is noticed once the TTL expires
+	hs.Close()
+	if !m.IsRunning(ctx, time.Second) {
+		t.Error("cached check not trusted within the TTL")
+	}
+	time.Sleep(ttl + 20*time.Millisecond)
+	if m.IsRunning(ctx, time.Second) {
+		t.Error("running after the server stopped and the TTL expired")
+	}
+}
+
+func TestIsRunningWithoutCache(t *testing.T) {
+	hs := newHealthServer(t)
+	m := &Manager{serverURL: hs.URL}
+	for i := 0; i < 3; i++ {
+		if !m.IsRunning(context.Background(), time.Second) {
+			t.Fatalf("not running on check %d", i+1)
+		}
+	}
+	got := hs.requests()
+	if len(got) != 3 {
+		t.Fatalf("%d health requests, want one per check without a TTL", len(got))
+	}
+	for _, etag := range got {
+		if etag != "" {
+			t.Errorf("sent If-None-Match %q without a TTL", etag)
+		}
+	}
+}
+
+func TestIsRunningUserAgent(t *testing.T) {
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("User-Agent")
+	}))
+	defer srv.Close()
+
+	m := &Manager{serverURL: srv.URL}
+	m.SetUserAgent("viewport-cli/1.4.0")
+	if !m.IsRunning(context.Background(), time.Second) {
+		t.Fatal("not running")
+	}
+	if got != "viewport-cli/1.4.0" {
+		t.Errorf("health check User-Agent = %q, want viewport-cli/1.4.0", got)
+	}
+}
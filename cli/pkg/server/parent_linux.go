@@ -0,0 +1,18 @@
+//go:build linux
+
+package server
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// stopWithParent has the kernel send cmd SIGTERM when this process exits.
+// When the server is launched through npx, the node process it starts is a
+// grandchild and relies on npx forwarding the signal.
+func stopWithParent(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Pdeathsig = syscall.SIGTERM
+}
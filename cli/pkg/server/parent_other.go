@@ -0,0 +1,8 @@
+//go:build !linux
+
+package server
+
+import "os/exec"
+
+// stopWithParent is a no-op: only Linux can tie a child's lifetime to its parent
+func stopWithParent(cmd *exec.Cmd) {}
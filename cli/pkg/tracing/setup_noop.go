@@ -0,0 +1,17 @@
+//go:build !otel
+
+package tracing
+
+import (
+	"context"
+	"errors"
+)
+
+// Setup leaves the global no-op tracer in place: this build has no exporter.
+// A non-empty endpoint is an error, so --otel-endpoint isn't silently ignored.
+func Setup(endpoint, version string) (func(context.Context) error, error) {
+	if endpoint != "" {
+		return nil, errors.New("--otel-endpoint needs a build with OpenTelemetry export; rebuild with: go build -tags otel")
+	}
+	return func(context.Context) error { return nil }, nil
+}
@@ -0,0 +1,40 @@
+// Package tracing wraps the scan flow in OpenTelemetry spans. Only the OTel
+// API is linked by default, whose global no-op tracer makes every span free;
+// the SDK and the OTLP exporter Setup installs are compiled in with the otel
+// build tag (go build -tags otel).
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName identifies the CLI's instrumentation
+const tracerName = "github.com/law-makers/viewport-cli"
+
+// Start starts a span as a child of any span in ctx
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End marks span as failed if err is non-nil, then ends it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Headers returns the trace context headers to send with a request made in ctx.
+// It is empty when tracing is disabled.
+func Headers(ctx context.Context) map[string]string {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	return carrier
+}
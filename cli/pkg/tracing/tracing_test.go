@@ -0,0 +1,90 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// installRecorder installs a tracer provider exporting to a no-op exporter,
+// recording the spans it ends, until the test is done
+func installRecorder(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(tracetest.NewNoopExporter()),
+		sdktrace.WithSpanProcessor(recorder),
+	)
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		provider.Shutdown(context.Background())
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	})
+	return recorder
+}
+
+func TestSpansAreRecorded(t *testing.T) {
+	recorder := installRecorder(t)
+
+	ctx, scan := Start(context.Background(), "scan", attribute.String("viewport.target_url", "https://example.com"))
+	_, request := Start(ctx, "api.scan")
+	End(request, errors.New("connection refused"))
+	End(scan, nil)
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("recorded %d spans, want 2", len(spans))
+	}
+	req, root := spans[0], spans[1]
+	if root.Name() != "scan" || req.Name() != "api.scan" {
+		t.Fatalf("recorded spans %q and %q, want api.scan and scan", req.Name(), root.Name())
+	}
+	if req.Parent().SpanID() != root.SpanContext().SpanID() {
+		t.Error("api.scan is not a child of scan")
+	}
+	if got := root.Attributes(); len(got) != 1 || got[0].Value.AsString() != "https://example.com" {
+		t.Errorf("scan attributes = %v, want the target URL", got)
+	}
+	if root.Status().Code != codes.Unset {
+		t.Errorf("scan status = %v, want unset", root.Status())
+	}
+	if req.Status().Code != codes.Error || req.Status().Description != "connection refused" {
+		t.Errorf("api.scan status = %v, want the error", req.Status())
+	}
+	if len(req.Events()) != 1 || req.Events()[0].Name != "exception" {
+		t.Errorf("api.scan events = %v, want the recorded error", req.Events())
+	}
+}
+
+func TestHeadersPropagateTraceContext(t *testing.T) {
+	if got := Headers(context.Background()); len(got) != 0 {
+		t.Errorf("headers without a span = %v, want none", got)
+	}
+
+	installRecorder(t)
+	ctx, span := Start(context.Background(), "scan")
+	defer span.End()
+	if Headers(ctx)["traceparent"] == "" {
+		t.Errorf("headers = %v, want a traceparent", Headers(ctx))
+	}
+}
+
+func TestSetupWithoutEndpoint(t *testing.T) {
+	shutdown, err := Setup("", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Errorf("shutdown: %v", err)
+	}
+}
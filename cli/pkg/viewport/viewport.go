@@ -0,0 +1,234 @@
+// Package viewport scans a page for responsive layout issues from Go code,
+// for test suites and tools that embed the scanner instead of running the
+// CLI. Scan loads the same config as the CLI, can start the screenshot
+// server, and returns typed results. Nothing is printed and nothing is saved.
+//
+// # Stability
+//
+// Scan, Options, Result, Viewport and Issue are the stable API: within a
+// major version, fields and functions are only added, never removed,
+// renamed or given a different meaning, and the zero value of a new Options
+// field keeps the behavior from before it existed. Errors are stable only as
+// far as errors.Is against the sentinel errors named below; their messages
+// may change. Other packages under pkg/ are the CLI's internals and may
+// change in any release.
+package viewport
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/law-makers/viewport-cli/pkg/api"
+	"github.com/law-makers/viewport-cli/pkg/config"
+	"github.com/law-makers/viewport-cli/pkg/server"
+)
+
+// ErrServerNotInstalled is returned when Options.StartServer asks for the
+// screenshot server but it isn't installed
+var ErrServerNotInstalled = server.ErrNotInstalled
+
+// ErrServerUnreachable is returned when the screenshot server could not be reached
+var ErrServerUnreachable = api.ErrRequestFailed
+
+// Options configures one Scan. Only Target is required; the other fields
+// fall back to the config file the CLI reads, then to the CLI's defaults.
+type Options struct {
+	// Target is the URL to scan
+	Target string
+	// Viewports to capture, e.g. "mobile" or "desktop" (default: scan.viewports in config)
+	Viewports []string
+
+	// ConfigFile is the config to load (default: the one the CLI finds)
+	ConfigFile string
+	// ServerURL is the screenshot server's address (default: api.url in config)
+	ServerURL string
+	// StartServer starts a local screenshot server on ServerURL's port if none
+	// is running there, and stops it again before Scan returns
+	StartServer bool
+	// Timeout bounds the request to the server (default: scan.timeout in config)
+	Timeout time.Duration
+
+	// ViewportOnly captures only the visible viewport instead of the whole page
+	ViewportOnly bool
+	// Selector clips the capture to the first element matching this CSS selector
+	Selector string
+	// Headers are sent with the page's requests, e.g. for authentication
+	Headers map[string]string
+	// SkipAnalysis captures screenshots without looking for issues
+	SkipAnalysis bool
+}
+
+// Result is the outcome of a Scan
+type Result struct {
+	// ScanID is the server's ID for the scan
+	ScanID string
+	// Target is the scanned URL, as given in Options
+	Target string
+	// Viewports holds one entry per captured viewport, in request order
+	Viewports []Viewport
+	// Duration is how long the capture took
+	Duration time.Duration
+}
+
+// Viewport is what one viewport's capture found
+type Viewport struct {
+	// Device is the viewport name, e.g. "mobile"
+	Device string
+	// Width and Height are the captured page's size in pixels
+	Width  int
+	Height int
+	// Screenshot is the capture as a PNG
+	Screenshot []byte
+	// HTTPStatus is the page's response status, 0 if the server didn't report it
+	HTTPStatus int
+	Issues     []Issue
+}
+
+// Issue is one layout problem found on a viewport
+type Issue struct {
+	// Severity is one of critical, high, medium or low
+	Severity    string
+	Type        string
+	Description string
+	Suggestion  string
+}
+
+// IssueCount returns the number of issues over every viewport
+func (r *Result) IssueCount() int {
+	count := 0
+	for _, v := range r.Viewports {
+		count += len(v.Issues)
+	}
+	return count
+}
+
+// Scan captures opts.Target at each viewport and returns what was found. It
+// returns when the scan is done, or ctx is cancelled.
+func Scan(ctx context.Context, opts Options) (*Result, error) {
+	if opts.Target == "" {
+		return nil, errors.New("no target to scan")
+	}
+	cfg, err := config.LoadConfig(opts.ConfigFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := config.Validate(cfg); err != nil {
+		return nil, err
+	}
+
+	serverURL := opts.ServerURL
+	if serverURL == "" {
+		serverURL = cfg.API.URL
+	}
+	if opts.StartServer {
+		stop, err := startServer(ctx, serverURL)
+		if err != nil {
+			return nil, err
+		}
+		defer stop()
+	}
+
+	client := api.NewClient(serverURL).SetLogOutput(io.Discard)
+	timeout := opts.Timeout
+	if timeout <= 0 && cfg.Scan.Timeout > 0 {
+		timeout = time.Duration(cfg.Scan.Timeout) * time.Second
+	}
+	if timeout > 0 {
+		client.SetTimeout(timeout)
+	}
+	if cfg.Scan.UserAgent != "" {
+		client.SetUserAgent(cfg.Scan.UserAgent)
+	}
+	tlsFiles := api.TLSFiles{CACert: cfg.API.CACert, ClientCert: cfg.API.ClientCert, ClientKey: cfg.API.ClientKey}
+	if _, err := client.SetTLSFiles(tlsFiles); err != nil {
+		return nil, err
+	}
+
+	viewports := opts.Viewports
+	if len(viewports) == 0 {
+		viewports = cfg.Scan.Viewports
+	}
+	req := &api.ScanRequest{
+		TargetURL: opts.Target,
+		Viewports: viewports,
+		Options: &api.ScanOptions{
+			FullPage:     !opts.ViewportOnly,
+			Selector:     opts.Selector,
+			Headers:      opts.Headers,
+			SkipAnalysis: opts.SkipAnalysis,
+		},
+	}
+
+	start := time.Now()
+	resp, err := client.Scan(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	api.NormalizeSeverities(resp, cfg.Scan.SeverityMap)
+	return newResult(opts.Target, resp, time.Since(start))
+}
+
+// startServer starts a screenshot server for serverURL, which must be local,
+// unless one is running already. stop only stops a server this started.
+func startServer(ctx context.Context, serverURL string) (stop func(), err error) {
+	u, err := url.Parse(serverURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid server URL %q: %w", serverURL, err)
+	}
+	host := u.Hostname()
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return nil, fmt.Errorf("can only start a screenshot server on localhost, not %s", host)
+	}
+	port, err := strconv.Atoi(u.Port())
+	if err != nil {
+		return nil, fmt.Errorf("server URL %q has no port to start the screenshot server on", serverURL)
+	}
+
+	manager := server.NewManager(port)
+	manager.SetStopWithParent(true)
+	if err := manager.Start(ctx, false); err != nil {
+		return nil, err
+	}
+	return func() {
+		if manager.Spawned() {
+			manager.Stop()
+		}
+	}, nil
+}
+
+// newResult converts the server's response into a Result
+func newResult(target string, resp *api.ScanResponse, elapsed time.Duration) (*Result, error) {
+	result := &Result{ScanID: resp.ScanID, Target: target, Duration: elapsed}
+	for _, r := range resp.Results {
+		v := Viewport{
+			Device:     r.Device,
+			Width:      r.Dimensions.Width,
+			Height:     r.Dimensions.Height,
+			HTTPStatus: r.HTTPStatus,
+		}
+		if r.ScreenshotBase64 != "" {
+			png, err := base64.StdEncoding.DecodeString(r.ScreenshotBase64)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode %s screenshot: %w", r.Device, err)
+			}
+			v.Screenshot = png
+		}
+		for _, issue := range r.Issues {
+			v.Issues = append(v.Issues, Issue{
+				Severity:    issue.Severity,
+				Type:        issue.Type,
+				Description: issue.Description,
+				Suggestion:  issue.Suggestion,
+			})
+		}
+		result.Viewports = append(result.Viewports, v)
+	}
+	return result, nil
+}
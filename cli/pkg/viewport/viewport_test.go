@@ -0,0 +1,142 @@
+package viewport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/law-makers/viewport-cli/pkg/api"
+)
+
+// writeConfig writes a config file mapping the backend's "warning" severity
+// and returns its path, so tests don't read the user's config
+func writeConfig(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), ".viewport.yaml")
+	config := "scan:\n  viewports: [mobile, desktop]\n  severity_map:\n    warning: medium\n"
+	if err := os.WriteFile(path, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// captureStdout returns what fn writes to stdout
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	done := make(chan []byte)
+	go func() {
+		data, _ := io.ReadAll(r)
+		done <- data
+	}()
+	fn()
+	w.Close()
+	return string(<-done)
+}
+
+func TestScan(t *testing.T) {
+	var got api.ScanRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decoding scan request: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(api.ScanResponse{ScanID: "scan-1", Status: "completed", Results: []api.ViewportResult{
+			{
+				Device:           "mobile",
+				Dimensions:       api.Dimensions{Width: 375, Height: 2000},
+				ScreenshotBase64: "iVBORw0KGgo=",
+				HTTPStatus:       200,
+				Issues: []api.DetectedIssue{
+					{Severity: "HIGH", Type: "horizontal-scroll", Description: "Page scrolls horizontally", Suggestion: "Set max-width: 100%"},
+					{Severity: "warning", Type: "small-text", Description: "Text under 12px"},
+				},
+			},
+			{Device: "desktop", Dimensions: api.Dimensions{Width: 1440, Height: 1600}},
+		}})
+	}))
+	defer srv.Close()
+
+	var result *Result
+	var err error
+	out := captureStdout(t, func() {
+		result, err = Scan(context.Background(), Options{
+			Target:       "https://example.com",
+			ConfigFile:   writeConfig(t),
+			ServerURL:    srv.URL,
+			ViewportOnly: true,
+			Selector:     "main",
+		})
+	})
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if out != "" {
+		t.Errorf("Scan printed %q, want nothing on stdout", out)
+	}
+
+	if !reflect.DeepEqual(got.Viewports, []string{"mobile", "desktop"}) || got.Options.FullPage || got.Options.Selector != "main" {
+		t.Errorf("request = %+v with options %+v, want the config's viewports and the given options", got, got.Options)
+	}
+
+	want := &Result{ScanID: "scan-1", Target: "https://example.com", Viewports: []Viewport{
+		{
+			Device:     "mobile",
+			Width:      375,
+			Height:     2000,
+			Screenshot: []byte("\x89PNG\r\n\x1a\n"),
+			HTTPStatus: 200,
+			Issues: []Issue{
+				{Severity: "high", Type: "horizontal-scroll", Description: "Page scrolls horizontally", Suggestion: "Set max-width: 100%"},
+				{Severity: "medium", Type: "small-text", Description: "Text under 12px"},
+			},
+		},
+		{Device: "desktop", Width: 1440, Height: 1600},
+	}}
+	if result.Duration <= 0 {
+		t.Errorf("duration = %s, want the capture timed", result.Duration)
+	}
+	result.Duration = 0
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("result = %+v\nwant %+v", result, want)
+	}
+	if n := result.IssueCount(); n != 2 {
+		t.Errorf("IssueCount = %d, want 2", n)
+	}
+}
+
+func TestScanServerUnreachable(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.Close()
+
+	var err error
+	out := captureStdout(t, func() {
+		_, err = Scan(context.Background(), Options{Target: "https://example.com", ConfigFile: writeConfig(t), ServerURL: srv.URL})
+	})
+	if !errors.Is(err, ErrServerUnreachable) {
+		t.Errorf("Scan = %v, want ErrServerUnreachable", err)
+	}
+	if out != "" {
+		t.Errorf("Scan printed %q, want nothing on stdout", out)
+	}
+}
+
+func TestScanNoTarget(t *testing.T) {
+	if _, err := Scan(context.Background(), Options{}); err == nil {
+		t.Error("Scan without a target succeeded")
+	}
+}
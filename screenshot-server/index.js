@@ -17,6 +17,8 @@ const http = require('http');
 const url = require('url');
 const path = require('path');
 const fs = require('fs');
+const zlib = require('zlib');
+const crypto = require('crypto');
 const { firefox } = require('playwright');
 
 // Parse command line arguments
@@ -46,9 +48,57 @@ const DEVICE_VIEWPORTS = {
   desktop: { width: 1920, height: 1080, name: 'DESKTOP' },
 };
 
+/**
+ * Device profiles sent by the CLI (from a --device-list-file), keyed by
+ * name. Invalid entries are dropped, so the device falls back to the
+ * built-in of that name or fails as unknown.
+ */
+function customDevices(options) {
+  const devices = {};
+  if (!options || !Array.isArray(options.devices)) {
+    return devices;
+  }
+  for (const d of options.devices) {
+    if (!d || typeof d.name !== 'string' || !Number.isInteger(d.width) || !Number.isInteger(d.height) ||
+        d.width <= 0 || d.height <= 0) {
+      continue;
+    }
+    devices[d.name.toLowerCase()] = {
+      width: d.width,
+      height: d.height,
+      name: d.name.toUpperCase(),
+      deviceScaleFactor: typeof d.deviceScaleFactor === 'number' && d.deviceScaleFactor > 0 ? d.deviceScaleFactor : undefined,
+      userAgent: typeof d.userAgent === 'string' && d.userAgent ? d.userAgent : undefined,
+      isMobile: Boolean(d.isMobile),
+    };
+  }
+  return devices;
+}
+
+/**
+ * Look up a device by name, preferring profiles sent with the request
+ */
+function resolveDevice(device, capture = {}) {
+  return (capture.devices && capture.devices[device]) || DEVICE_VIEWPORTS[device];
+}
+
 let browser = null;
 let concurrentPages = 0;
-const MAX_CONCURRENT_PAGES = 3;
+// Most pages rendered at once, advertised on the health endpoint so clients
+// don't run more scans in parallel than are captured
+const MAX_CONCURRENT_PAGES = parseInt(process.env.MAX_CONCURRENT_PAGES, 10) || 3;
+// Most viewports accepted per scan request (0 = no limit), advertised on the health endpoint
+const MAX_VIEWPORTS = parseInt(process.env.MAX_VIEWPORTS, 10) || 0;
+// Most capture retries a request may ask for, and the wait before the first
+// (doubled for each further retry)
+const MAX_CAPTURE_RETRIES = 10;
+const CAPTURE_RETRY_DELAY = 1000;
+// Longest post-load capture delay a request may ask for, in milliseconds
+const MAX_CAPTURE_DELAY = 30000;
+// Page zoom factors a request may ask for, the range browsers zoom in
+const MIN_ZOOM = 0.25;
+const MAX_ZOOM = 5;
+const API_VERSION = 1; // Scan API version, reported via X-Viewport-Api-Version
 let browserInitError = null; // Track browser init errors
 let serverInstance = null; // Track HTTP server for graceful shutdown
 
@@ -165,24 +215,93 @@ async function initBrowser() {
  * Capture screenshot with Playwright
  */
 async function captureScreenshot(targetUrl, device) {
+  const { screenshotBase64 } = await capturePage(targetUrl, device, []);
+  return screenshotBase64;
+}
+
+/**
+ * Parse options.captureStyles: a list of { selector, properties } asking for
+ * computed CSS values
+ */
+function styleCaptures(options) {
+  if (!options || !Array.isArray(options.captureStyles)) {
+    return [];
+  }
+  return options.captureStyles
+    .filter((c) => c && typeof c.selector === 'string' && c.selector && Array.isArray(c.properties))
+    .map((c) => ({ selector: c.selector, properties: c.properties.filter((p) => typeof p === 'string' && p) }));
+}
+
+/**
+ * Read the computed style values of the first element matching each capture's
+ * selector. Selectors that match nothing come back unmatched, and invalid
+ * selectors with the browser's error.
+ */
+async function captureStyles(page, captures) {
+  return page.evaluate((captures) => captures.map(({ selector, properties }) => {
+    let element;
+    try {
+      element = document.querySelector(selector);
+    } catch (err) {
+      return { selector, matched: false, error: err.message };
+    }
+    if (!element) {
+      return { selector, matched: false };
+    }
+    const computed = window.getComputedStyle(element);
+    const values = {};
+    for (const property of properties) {
+      values[property] = computed.getPropertyValue(property).trim();
+    }
+    return { selector, matched: true, properties: values };
+  }), captures);
+}
+
+/**
+ * Capture a full-page screenshot, plus a viewport-sized screenshot with the
+ * page scrolled to each of scrollPositions (pixel offsets). capture.fullPage
+ * set to false captures only the viewport, and capture.selector clips the
+ * main screenshot to the first matching element. capture.waitForFonts
+ * delays the capture until document.fonts.ready, for at most
+ * capture.waitTimeout milliseconds. capture.delay then pauses that many
+ * milliseconds, e.g. for entrance animations to finish. capture.devices holds
+ * device profiles sent with the request, which may set a pixel ratio, user
+ * agent and touch.
+ * capture.styles lists elements whose computed styles are read before the
+ * capture (see captureStyles). capture.dimensionsOnly skips the screenshots
+ * and returns an empty screenshotBase64, for checks that don't need images.
+ * capture.zoomLevels adds a capture at each page zoom factor (see captureZoom).
+ */
+async function capturePage(targetUrl, device, scrollPositions, capture = {}) {
   // Rate limiting: wait if too many concurrent pages
   while (concurrentPages >= MAX_CONCURRENT_PAGES) {
     await new Promise(resolve => setTimeout(resolve, 100));
   }
   
   concurrentPages++;
+  let context = null;
   
   try {
     if (!browser) {
       throw new Error('Browser not initialized');
     }
 
-    const viewport = DEVICE_VIEWPORTS[device];
+    const viewport = resolveDevice(device, capture);
     if (!viewport) {
       throw new Error(`Unknown device: ${device}`);
     }
 
-    const page = await browser.newPage();
+    // Profiles with a pixel ratio, user agent or mobile flag need their own
+    // context. Firefox can't emulate isMobile, so mobile profiles get touch.
+    if (viewport.deviceScaleFactor || viewport.userAgent || viewport.isMobile) {
+      context = await browser.newContext({
+        viewport: { width: viewport.width, height: viewport.height },
+        deviceScaleFactor: viewport.deviceScaleFactor,
+        userAgent: viewport.userAgent,
+        hasTouch: viewport.isMobile,
+      });
+    }
+    const page = context ? await context.newPage() : await browser.newPage();
     
     // Set viewport
     await page.setViewportSize({
@@ -192,20 +311,62 @@ async function captureScreenshot(targetUrl, device) {
 
     // Navigate to target with timeout
     console.log(`[Screenshot] Navigating to ${targetUrl}...`);
-    await page.goto(targetUrl, {
+    const response = await page.goto(targetUrl, {
       waitUntil: 'load',
       timeout: 30000,
     });
+    // Error pages render fine, so report the status for the CLI to flag
+    const httpStatus = response ? response.status() : undefined;
+
+    if (capture.waitForFonts) {
+      // Web fonts load after 'load' fires; capture anyway if they never settle
+      const fontsLoaded = await Promise.race([
+        page.evaluate(() => document.fonts.ready.then(() => true)),
+        new Promise(resolve => setTimeout(() => resolve(false), capture.waitTimeout)),
+      ]);
+      if (!fontsLoaded) {
+        console.warn(`[Screenshot] Web fonts not ready after ${capture.waitTimeout}ms for ${device}, capturing anyway`);
+      }
+    }
+
+    if (capture.delay > 0) {
+      console.log(`[Screenshot] Waiting ${capture.delay}ms before capturing ${device}...`);
+      await page.waitForTimeout(capture.delay);
+    }
+
+    // Read styles before scrolling, at the state the main screenshot shows
+    const styles = capture.styles && capture.styles.length > 0
+      ? await captureStyles(page, capture.styles)
+      : undefined;
+
+    if (capture.dimensionsOnly) {
+      await page.close();
+      if (context) {
+        await context.close();
+      }
+      concurrentPages--;
+      console.log(`[Screenshot] Checked ${device} without a screenshot (dimensions only)`);
+      return { screenshotBase64: '', scrollScreenshots: [], zoomCaptures: [], httpStatus, styles };
+    }
 
     console.log(`[Screenshot] Taking screenshot for ${device}...`);
     // Take screenshot as base64 PNG
-    const screenshotBuffer = await page.screenshot({
-      fullPage: true,
-    });
+    let screenshotBuffer;
+    try {
+      screenshotBuffer = capture.selector
+        ? await page.locator(capture.selector).first().screenshot()
+        : await page.screenshot({ fullPage: capture.fullPage !== false });
+    } catch (err) {
+      // A page that hasn't rendered yet (e.g. zero height) fails here; worth another try
+      err.retryable = true;
+      throw err;
+    }
     
     // Validate screenshot was actually captured
     if (!screenshotBuffer || screenshotBuffer.length === 0) {
-      throw new Error(`Screenshot capture returned empty buffer for ${device} - page may not have loaded correctly`);
+      const err = new Error(`Screenshot capture returned empty buffer for ${device} - page may not have loaded correctly`);
+      err.retryable = true;
+      throw err;
     }
     
     const screenshotBase64 = screenshotBuffer.toString('base64');
@@ -216,17 +377,114 @@ async function captureScreenshot(targetUrl, device) {
     }
 
     console.log(`[Screenshot] Screenshot captured for ${device} (${screenshotBuffer.length} bytes)`);
+
+    // Scroll-reactive layouts (sticky headers etc.) only show up mid-page
+    const scrollScreenshots = [];
+    for (const offset of scrollPositions) {
+      await page.evaluate((y) => window.scrollTo(0, y), offset);
+      // Give scroll handlers and transitions a moment to settle
+      await page.waitForTimeout(250);
+      const buffer = await page.screenshot({ fullPage: false });
+      scrollScreenshots.push({ offset, screenshotBase64: buffer.toString('base64') });
+      console.log(`[Screenshot] Captured ${device} scrolled to ${offset}px`);
+    }
+
     await page.close();
+    if (context) {
+      await context.close();
+      context = null;
+    }
+
+    // Each zoom level is its own page load, in this capture's slot
+    const zoomCaptures = [];
+    for (const zoom of capture.zoomLevels || []) {
+      zoomCaptures.push(await captureZoom(targetUrl, viewport, zoom, capture));
+      console.log(`[Screenshot] Captured ${device} at ${Math.round(zoom * 100)}% zoom`);
+    }
 
     concurrentPages--;
-    return screenshotBase64;
+    return { screenshotBase64, scrollScreenshots, zoomCaptures, httpStatus, styles };
   } catch (err) {
+    if (context) {
+      await context.close().catch(() => {});
+    }
     concurrentPages--;
     console.error(`[Screenshot] Error capturing ${device}:`, err.message);
     throw err;
   }
 }
 
+/**
+ * Capture targetUrl on viewport as the browser shows it at a page zoom
+ * factor (e.g. 2 for 200%): the CSS viewport shrinks by the factor and each
+ * CSS pixel covers that many device pixels, so media queries and layout
+ * respond as they do to browser zoom. A page that then scrolls sideways is
+ * reported, as it fails WCAG's reflow criterion.
+ */
+async function captureZoom(targetUrl, viewport, zoom, capture) {
+  const width = Math.max(1, Math.round(viewport.width / zoom));
+  const height = Math.max(1, Math.round(viewport.height / zoom));
+  const context = await browser.newContext({
+    viewport: { width, height },
+    deviceScaleFactor: (viewport.deviceScaleFactor || 1) * zoom,
+    userAgent: viewport.userAgent,
+    hasTouch: viewport.isMobile,
+  });
+  try {
+    const page = await context.newPage();
+    await page.goto(targetUrl, { waitUntil: 'load', timeout: 30000 });
+    if (capture.delay > 0) {
+      await page.waitForTimeout(capture.delay);
+    }
+    const contentWidth = await page.evaluate(() => document.documentElement.scrollWidth);
+    const buffer = capture.selector
+      ? await page.locator(capture.selector).first().screenshot()
+      : await page.screenshot({ fullPage: capture.fullPage !== false });
+
+    const percent = Math.round(zoom * 100);
+    const issues = [];
+    if (contentWidth > width) {
+      issues.push({
+        severity: 'high',
+        type: 'zoom-horizontal-scroll',
+        description: `Page scrolls horizontally: ${contentWidth}px of content in a ${width}px viewport`,
+        suggestion: `Let content reflow at ${percent}% zoom, e.g. with relative widths and wrapping instead of fixed widths`,
+      });
+    }
+    return { zoom, dimensions: { width, height }, screenshotBase64: buffer.toString('base64'), issues };
+  } finally {
+    await context.close();
+  }
+}
+
+/**
+ * Capture a page like capturePage, retrying a capture that fails or comes
+ * back empty up to capture.retries times, waiting CAPTURE_RETRY_DELAY before
+ * the first retry and twice as long before each further one. Navigation
+ * errors aren't retried. The result, or the final error, carries the number
+ * of retries used.
+ */
+async function capturePageWithRetries(targetUrl, device, scrollPositions, capture = {}) {
+  const retries = capture.retries || 0;
+  for (let attempt = 0; ; attempt++) {
+    try {
+      const result = await capturePage(targetUrl, device, scrollPositions, capture);
+      if (attempt > 0) {
+        console.log(`[Screenshot] ${device} captured after ${attempt} ${attempt === 1 ? 'retry' : 'retries'}`);
+      }
+      return { ...result, retries: attempt };
+    } catch (err) {
+      if (!err.retryable || attempt >= retries) {
+        err.retries = attempt;
+        throw err;
+      }
+      const delay = CAPTURE_RETRY_DELAY * 2 ** attempt;
+      console.warn(`[Screenshot] Capture of ${device} failed (${err.message}), retrying in ${delay}ms (${attempt + 1}/${retries})`);
+      await new Promise(resolve => setTimeout(resolve, delay));
+    }
+  }
+}
+
 /**
  * HTTP request handler
  */
@@ -237,9 +495,10 @@ const server = http.createServer(async (req, res) => {
 
   // CORS headers
   res.setHeader('Access-Control-Allow-Origin', '*');
-  res.setHeader('Access-Control-Allow-Methods', 'GET, POST, OPTIONS');
-  res.setHeader('Access-Control-Allow-Headers', 'Content-Type');
+  res.setHeader('Access-Control-Allow-Methods', 'GET, HEAD, POST, OPTIONS');
+  res.setHeader('Access-Control-Allow-Headers', 'Content-Type, X-Viewport-Client-Version, If-None-Match');
   res.setHeader('Content-Type', 'application/json');
+  res.setHeader('X-Viewport-Api-Version', String(API_VERSION));
 
   // Handle CORS preflight
   if (req.method === 'OPTIONS') {
@@ -249,18 +508,32 @@ const server = http.createServer(async (req, res) => {
   }
 
   // Health check
-  if (pathname === '/' && req.method === 'GET') {
+  if (pathname === '/' && (req.method === 'GET' || req.method === 'HEAD')) {
     const healthStatus = {
       status: browser ? 'ok' : 'degraded',
       service: 'local-screenshot-server',
       devices: Object.keys(DEVICE_VIEWPORTS),
       browserReady: !!browser,
+      maxConcurrentCaptures: MAX_CONCURRENT_PAGES,
+      ...(MAX_VIEWPORTS > 0 && { maxViewports: MAX_VIEWPORTS }),
     };
-    
+    const body = JSON.stringify(healthStatus);
+
     // Return 200 if browser is ready, 503 if not (but server is running)
     const statusCode = browser ? 200 : 503;
+
+    // The body only changes with browser state, so pollers can send
+    // If-None-Match and get an empty 304 while nothing has changed
+    const etag = `"${crypto.createHash('sha1').update(body).digest('hex').slice(0, 16)}"`;
+    res.setHeader('ETag', etag);
+    if (statusCode === 200 && req.headers['if-none-match'] === etag) {
+      res.writeHead(304);
+      res.end();
+      return;
+    }
+
     res.writeHead(statusCode);
-    res.end(JSON.stringify(healthStatus));
+    res.end(req.method === 'HEAD' ? undefined : body);
     return;
   }
 
@@ -290,7 +563,30 @@ const server = http.createServer(async (req, res) => {
           return;
         }
 
-        const { targetUrl, viewports } = JSON.parse(body);
+        const { targetUrl, viewports, options } = JSON.parse(body);
+        const scrollPositions = (options && Array.isArray(options.scrollPositions))
+          ? options.scrollPositions.filter((y) => Number.isInteger(y) && y >= 0)
+          : [];
+        const capture = {
+          fullPage: !options || options.fullPage !== false,
+          selector: (options && typeof options.selector === 'string' && options.selector) || undefined,
+          waitForFonts: Boolean(options && options.waitForFonts),
+          waitTimeout: (options && Number.isInteger(options.waitTimeout) && options.waitTimeout > 0)
+            ? options.waitTimeout
+            : 5000,
+          devices: customDevices(options),
+          styles: styleCaptures(options),
+          dimensionsOnly: Boolean(options && options.dimensionsOnly),
+          delay: (options && Number.isInteger(options.captureDelayMs) && options.captureDelayMs > 0)
+            ? Math.min(options.captureDelayMs, MAX_CAPTURE_DELAY)
+            : 0,
+          retries: (options && Number.isInteger(options.captureRetries) && options.captureRetries > 0)
+            ? Math.min(options.captureRetries, MAX_CAPTURE_RETRIES)
+            : 0,
+          zoomLevels: (options && Array.isArray(options.zoomLevels))
+            ? options.zoomLevels.filter((z) => typeof z === 'number' && z >= MIN_ZOOM && z <= MAX_ZOOM)
+            : [],
+        };
         
         if (!targetUrl) {
           res.writeHead(400);
@@ -300,6 +596,17 @@ const server = http.createServer(async (req, res) => {
 
         // Use viewports as-is (lowercase) or default
         const devices = viewports || ['mobile', 'tablet', 'desktop'];
+
+        // The CLI splits the request when it sees this code
+        if (MAX_VIEWPORTS > 0 && devices.length > MAX_VIEWPORTS) {
+          res.writeHead(400);
+          res.end(JSON.stringify({
+            error: `Too many viewports: ${devices.length} requested, at most ${MAX_VIEWPORTS} per request`,
+            code: 'too_many_viewports',
+            maxViewports: MAX_VIEWPORTS,
+          }));
+          return;
+        }
         
         console.log(`[Request /scan] Capturing ${devices.join(', ')} for ${targetUrl}`);
         
@@ -307,20 +614,34 @@ const server = http.createServer(async (req, res) => {
         const results = await Promise.all(
           devices.map(async (device) => {
             try {
-              const screenshotBase64 = await captureScreenshot(targetUrl, device);
-              const viewport = DEVICE_VIEWPORTS[device];
-              return {
+              const { screenshotBase64, scrollScreenshots, zoomCaptures, httpStatus, retries, styles } = await capturePageWithRetries(targetUrl, device, scrollPositions, capture);
+              const viewport = resolveDevice(device, capture);
+              const result = {
                 device: device.toLowerCase(),
                 dimensions: {
                   width: viewport?.width || 0,
                   height: viewport?.height || 0,
                 },
                 screenshotBase64,
+                httpStatus,
                 issues: []
               };
+              if (retries > 0) {
+                result.captureRetries = retries;
+              }
+              if (styles) {
+                result.styles = styles;
+              }
+              if (scrollScreenshots.length > 0) {
+                result.scrollScreenshots = scrollScreenshots;
+              }
+              if (zoomCaptures.length > 0) {
+                result.zoomCaptures = zoomCaptures;
+              }
+              return result;
             } catch (err) {
               console.error(`[Error] Failed to capture ${device}:`, err);
-              const viewport = DEVICE_VIEWPORTS[device];
+              const viewport = resolveDevice(device, capture);
               return {
                 device: device.toLowerCase(),
                 dimensions: {
@@ -329,14 +650,17 @@ const server = http.createServer(async (req, res) => {
                 },
                 screenshotBase64: '',
                 issues: [],
+                captureRetries: err.retries || undefined,
                 error: err.message
               };
             }
           })
         );
         
-        // Check if any results have actual screenshots
-        const hasValidScreenshots = results.some(r => r.screenshotBase64 && r.screenshotBase64.length > 0);
+        // Check if any results have actual screenshots (none are taken for dimensions only)
+        const hasValidScreenshots = capture.dimensionsOnly
+          ? results.some(r => !r.error)
+          : results.some(r => r.screenshotBase64 && r.screenshotBase64.length > 0);
         const hasErrors = results.some(r => r.error);
         
         // If all screenshots failed or are empty, return 500 with errors
@@ -368,8 +692,15 @@ const server = http.createServer(async (req, res) => {
           globalAnalysis: ''
         };
         
-        res.writeHead(200, { 'Content-Type': 'application/json' });
-        res.end(JSON.stringify(response));
+        // Base64 screenshots compress well, so gzip when the client accepts it
+        const payload = JSON.stringify(response);
+        if (/\bgzip\b/.test(req.headers['accept-encoding'] || '')) {
+          res.writeHead(200, { 'Content-Type': 'application/json', 'Content-Encoding': 'gzip' });
+          res.end(zlib.gzipSync(payload));
+        } else {
+          res.writeHead(200, { 'Content-Type': 'application/json' });
+          res.end(payload);
+        }
       } catch (err) {
         console.error('[Error] /scan endpoint:', err);
         res.writeHead(500);